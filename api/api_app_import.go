@@ -0,0 +1,462 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// A task of the export of another todo app mapped onto an item. The item has no id, the source id is the id
+// of the task in the app
+type appTask struct {
+	sourceId string
+	item     entity.TodoItem
+	hasDue   bool
+}
+
+// Reads the tasks of the export of an app, location is the timezone of the due dates without one
+type appImporter func(file io.Reader, location *time.Location) ([]appTask, error)
+
+// Importers of the exports of other apps by the name of the source
+var appImporters = map[string]appImporter{
+	"todoist": readTodoistTasks,
+	"mstodo":  readMSTodoTasks,
+}
+
+// Id of a task or a project of an export, apps write them as strings or as numbers
+type appId string
+
+func (id *appId) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*id = appId(value)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("an id must be a string or a number, not %s", data)
+	}
+
+	*id = appId(number.String())
+	return nil
+}
+
+// Flag of an export, older exports write 0 and 1 instead of false and true
+type appFlag bool
+
+func (f *appFlag) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var value bool
+	if err := json.Unmarshal(data, &value); err == nil {
+		*f = appFlag(value)
+		return nil
+	}
+
+	var number int
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("a flag must be a boolean or a number, not %s", data)
+	}
+
+	*f = appFlag(number != 0)
+	return nil
+}
+
+// Label of a project or a list of an app, cut to the longest label an item can have
+func appLabel(name string) string {
+	label := strings.Join(strings.Fields(name), " ")
+	for utf8.RuneCountInString(label) > 50 {
+		_, size := utf8.DecodeLastRuneInString(label)
+		label = strings.TrimSpace(label[:len(label)-size])
+	}
+
+	return label
+}
+
+// Adds the labels that are not empty and not added yet
+func addAppLabels(labels []string, added ...string) []string {
+	for _, label := range added {
+		if label = appLabel(label); label != "" && !containsString(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+
+	return labels
+}
+
+// An item updated to its task, the changes are the columns of the item and its status and labels
+type appUpdate struct {
+	current entity.TodoItem
+	changes map[string]interface{}
+	status  string
+	add     []string
+	remove  []string
+}
+
+// Status of an item imported again from a task. Apps without a status of their own only know open and
+// completed tasks, so an item in progress or blocked stays so while its task is open and a cancelled item
+// stays cancelled when its task is completed
+func importedStatus(current, wanted string) string {
+	if wanted == entity.StatusTodo && (current == entity.StatusInProgress || current == entity.StatusBlocked) {
+		return current
+	}
+
+	if wanted == entity.StatusDone && current == entity.StatusCancelled {
+		return current
+	}
+
+	return wanted
+}
+
+func formatImportDate(date time.Time) string {
+	return date.UTC().Format("2006-01-02T15:04:05Z07:00")
+}
+
+// Fields of a new item, only the fields with a value are listed
+func createdFields(item entity.TodoItem) []entity.AppImportField {
+	fields := []entity.AppImportField{
+		{Field: "name", To: item.Item.Name},
+	}
+
+	if item.Item.Details.Description != "" {
+		fields = append(fields, entity.AppImportField{Field: "description", To: item.Item.Details.Description})
+	}
+
+	fields = append(fields,
+		entity.AppImportField{Field: "due_date", To: formatImportDate(item.Item.Details.DueDate)},
+		entity.AppImportField{Field: "priority", To: item.Item.Details.Priority},
+		entity.AppImportField{Field: "status", To: item.Status},
+	)
+
+	if len(item.Labels) > 0 {
+		fields = append(fields, entity.AppImportField{Field: "labels", To: strings.Join(item.Labels, ", ")})
+	}
+
+	return fields
+}
+
+// Fields of an item that change with an update to the wanted item, in the order of createdFields
+func updatedFields(current, wanted entity.TodoItem, update appUpdate) []entity.AppImportField {
+	var fields []entity.AppImportField
+
+	if _, ok := update.changes["name"]; ok {
+		fields = append(fields, entity.AppImportField{Field: "name", From: current.Item.Name, To: wanted.Item.Name})
+	}
+
+	if _, ok := update.changes["description"]; ok {
+		fields = append(fields, entity.AppImportField{Field: "description", From: current.Item.Details.Description, To: wanted.Item.Details.Description})
+	}
+
+	if _, ok := update.changes["due_date"]; ok {
+		fields = append(fields, entity.AppImportField{Field: "due_date", From: formatImportDate(current.Item.Details.DueDate), To: formatImportDate(wanted.Item.Details.DueDate)})
+	}
+
+	if _, ok := update.changes["priority"]; ok {
+		fields = append(fields, entity.AppImportField{Field: "priority", From: current.Item.Details.Priority, To: wanted.Item.Details.Priority})
+	}
+
+	if update.status != current.Status {
+		fields = append(fields, entity.AppImportField{Field: "status", From: current.Status, To: update.status})
+	}
+
+	if len(update.add) > 0 || len(update.remove) > 0 {
+		labels := []string{}
+		for _, label := range current.Labels {
+			if !containsString(update.remove, label) {
+				labels = append(labels, label)
+			}
+		}
+		labels = append(labels, update.add...)
+		sort.Strings(labels)
+
+		fields = append(fields, entity.AppImportField{Field: "labels", From: strings.Join(current.Labels, ", "), To: strings.Join(labels, ", ")})
+	}
+
+	return fields
+}
+
+// Plans the import of the tasks, existing holds the items imported before by the ids of their tasks. A task
+// is created when it was not imported before, its item is updated to it when they differ and left alone when
+// the item is in trash. A task without a due date is due at the end of today for a new item and keeps the due
+// date of an item it updates. Items are only created or updated when no task is invalid
+func (as ApiService) planAppImport(tasks []appTask, existing map[string]entity.TodoItem, location *time.Location) (result entity.AppImportResultDto, creates []entity.TodoItem, sources map[string]string, updates []appUpdate) {
+	result.Changes = make([]entity.AppImportChange, 0, len(tasks))
+	sources = map[string]string{}
+	seen := map[string]bool{}
+
+	for _, task := range tasks {
+		change := entity.AppImportChange{SourceId: task.sourceId, Name: task.item.Item.Name}
+		err := validateTextItem(task.item)
+		if seen[task.sourceId] {
+			err = fmt.Errorf("the task %s appears more than once", task.sourceId)
+		}
+		seen[task.sourceId] = true
+
+		current, imported := existing[task.sourceId]
+		wanted := task.item
+
+		switch {
+		case err != nil:
+		case imported && current.IsDeleted:
+			change.Action = entity.ImportActionSkip
+			change.ItemId = current.Id
+			change.Error = constants.ITEM_IN_TRASH
+			result.Skipped++
+			result.Changes = append(result.Changes, change)
+			continue
+		case imported:
+			change.ItemId = current.Id
+			if !task.hasDue {
+				wanted.Item.Details.DueDate = current.Item.Details.DueDate
+			}
+
+			wanted.Status = importedStatus(current.Status, wanted.Status)
+			if !as.statusTransitions().CanTransition(current.Status, wanted.Status) {
+				err = fmt.Errorf(constants.INVALID_STATUS_TRANSITION, current.Status, wanted.Status)
+			} else if wanted.Status != current.Status && waitsOnBlockers(current, wanted.Status) {
+				err = errors.New(constants.ITEM_BLOCKED)
+			}
+		default:
+			if !task.hasDue {
+				wanted.Item.Details.DueDate, _ = textDueDate("", location)
+			}
+
+			if !as.statusTransitions().CanTransition(entity.StatusTodo, wanted.Status) && wanted.Status != entity.StatusTodo {
+				err = fmt.Errorf(constants.INVALID_STATUS_TRANSITION, entity.StatusTodo, wanted.Status)
+			}
+		}
+
+		if err != nil {
+			change.Action = entity.ImportActionInvalid
+			change.Error = err.Error()
+			result.Invalid++
+			result.Changes = append(result.Changes, change)
+			continue
+		}
+
+		if !imported {
+			wanted.Id = uuid.New().String()
+			sort.Strings(wanted.Labels)
+
+			change.Action = entity.ImportActionCreate
+			change.ItemId = wanted.Id
+			change.Fields = createdFields(wanted)
+			result.Created++
+			result.Changes = append(result.Changes, change)
+
+			creates = append(creates, wanted)
+			sources[wanted.Id] = task.sourceId
+			continue
+		}
+
+		update := appUpdate{
+			current: current,
+			changes: changedItemColumns(current.Item, wanted.Item),
+			status:  wanted.Status,
+		}
+		update.add, update.remove = labelChanges(current.Labels, wanted.Labels)
+
+		change.Fields = updatedFields(current, wanted, update)
+		if len(change.Fields) == 0 {
+			change.Action = entity.ImportActionUnchanged
+			result.Unchanged++
+			result.Changes = append(result.Changes, change)
+			continue
+		}
+
+		change.Action = entity.ImportActionUpdate
+		result.Updated++
+		result.Changes = append(result.Changes, change)
+		updates = append(updates, update)
+	}
+
+	return result, creates, sources, updates
+}
+
+// Updates an item to its task and records the revision of the update
+func (u appUpdate) apply(r ApiRepository, userId string) (item entity.TodoItem, err error) {
+	item = u.current
+
+	if len(u.changes) > 0 {
+		if item, err = r.PatchTodoItem(item.Id, u.changes, item.Version); err != nil {
+			return item, err
+		}
+	}
+
+	if u.status != u.current.Status {
+		if item, err = r.SetItemStatus(item.Id, u.status, item.Version); err != nil {
+			return item, err
+		}
+	}
+
+	if len(u.add) > 0 || len(u.remove) > 0 {
+		if item, err = r.SetItemLabels(item.Id, u.add, u.remove); err != nil {
+			return item, err
+		}
+	}
+
+	action := entity.RevisionUpdated
+	if len(u.changes) == 0 && len(u.add) == 0 && len(u.remove) == 0 {
+		action = entity.RevisionStatusChanged
+	}
+
+	return item, r.CreateItemRevision(item, userId, action)
+}
+
+// Imports the tasks of an export of another app
+// @Summary imports the tasks of an export of another todo app
+// @Description todoist reads a Todoist backup with its projects and items. The project of a task becomes a label unless it is the inbox, its labels are kept, priority 4 is high, 3 medium and 2 and 1 low, and a checked task is done. A due date without a time is due at the end of the day. mstodo reads the lists of Microsoft To Do with their tasks, under lists or value. The list of a task becomes a label unless it is the default list, its categories are labels, its importance the priority and notStarted, inProgress, waitingOnOthers and completed are todo, in_progress, blocked and done. Each task is imported once, importing the same export again updates the items imported before to their tasks, except for items in trash. A task that is open keeps an item in progress or blocked and a completed task keeps a cancelled item. The changes list what is created, updated, unchanged, skipped or invalid, with the fields that change. Nothing is imported when any task is invalid. With dry_run the changes are only listed.
+// @Tags Import and export
+// @Accept multipart/form-data
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param source path string true "App of the export" Enums(todoist, mstodo)
+// @Param file formData file true "Export of the app"
+// @Param timezone formData string false "Timezone of the due dates without one" default(UTC)
+// @Param dry_run formData bool false "Only list the changes"
+// @Success 200 {object} entity.AppImportResultDto
+// @Success 201 {object} entity.AppImportResultDto
+// @Failure 400 {object} entity.AppImportResultDto
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_WORKSPACE_ROLE
+// @Failure 404 string constants.UNSUPPORTED_IMPORT_SOURCE
+// @Failure 409 string constants.ITEM_VERSION_MISMATCH
+// @Failure 500 string constants.IMPORT_ITEMS_ERROR
+// @Router /item/import/{source} [post]
+func (as ApiService) ImportAppItems(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	source := c.Param("source")
+	importer, ok := appImporters[source]
+	if !ok {
+		errMessage := fmt.Sprintf(constants.UNSUPPORTED_IMPORT_SOURCE, source)
+		return c.String(http.StatusNotFound, errMessage)
+	}
+
+	allowed, err := as.authorizeCreate(c)
+	if !allowed {
+		return err
+	}
+
+	timezone := c.FormValue("timezone")
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_TIMEZONE, timezone)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	dryRun := false
+	if value := c.FormValue("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			errMessage := fmt.Sprintf(constants.BAD_REQUEST, "dry_run must be true or false")
+			return c.String(http.StatusBadRequest, errMessage)
+		}
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.IMPORT_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+	defer file.Close()
+
+	tasks, err := importer(file, location)
+	if err == nil && len(tasks) > maxImportRows {
+		err = errTooManyRows
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_APP_EXPORT, source, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	sourceIds := make([]string, len(tasks))
+	for i, task := range tasks {
+		sourceIds[i] = task.sourceId
+	}
+
+	r := as.repo(c)
+	existing, err := r.FindItemSources(source, sourceIds, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.IMPORT_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	result, creates, sources, updates := as.planAppImport(tasks, existing, location)
+	result.Source = source
+	result.DryRun = dryRun
+
+	if result.Invalid > 0 {
+		return c.JSONPretty(http.StatusBadRequest, result, " ")
+	}
+
+	if dryRun {
+		return c.JSONPretty(http.StatusOK, result, " ")
+	}
+
+	err = r.WithTx(func(tx ApiRepository) error {
+		if _, err := tx.insertImportedItems(creates, userId); err != nil {
+			return err
+		}
+
+		if err := tx.CreateItemSources(source, sources, userId); err != nil {
+			return err
+		}
+
+		for _, update := range updates {
+			if _, err := update.apply(tx, userId); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return c.String(http.StatusConflict, constants.ITEM_VERSION_MISMATCH)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.IMPORT_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, result, " ")
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestAppImport(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	dueDate := time.Date(2023, 10, 15, 23, 59, 59, 0, time.UTC)
+
+	t.Run("Test Read a Todoist backup", func(t *testing.T) {
+		backup := `{
+			"projects": [
+				{"id": "220474322", "name": "Inbox", "inbox_project": true},
+				{"id": "220474323", "name": "Home"}
+			],
+			"items": [
+				{"id": "6Jf8VQXxpwv56VQ7", "project_id": "220474323", "content": "Pay rent", "description": "Flat, March", "priority": 4,
+					"due": {"date": "2023-10-15"}, "labels": ["bills", "Home"], "checked": false},
+				{"id": 2995104339, "project_id": 220474322, "content": "Call mom", "priority": 1,
+					"due": {"date": "2023-10-15T09:00:00", "timezone": "Europe/Berlin"}, "checked": 1},
+				{"id": "2995104340", "project_id": "220474322", "content": "Deleted item", "is_deleted": true},
+				{"id": "2995104341", "project_id": "220474322", "content": "Water the plants", "priority": 3, "due": null}
+			]
+		}`
+
+		tasks, err := readTodoistTasks(strings.NewReader(backup), time.UTC)
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 3)
+
+		assert.Equal(t, "6Jf8VQXxpwv56VQ7", tasks[0].sourceId)
+		assert.Equal(t, "Pay rent", tasks[0].item.Item.Name)
+		assert.Equal(t, "Flat, March", tasks[0].item.Item.Details.Description)
+		assert.Equal(t, "HIGH", tasks[0].item.Item.Details.Priority)
+		assert.Equal(t, dueDate, tasks[0].item.Item.Details.DueDate)
+		assert.True(t, tasks[0].hasDue)
+		assert.Equal(t, []string{"Home", "bills"}, tasks[0].item.Labels)
+		assert.Equal(t, entity.StatusTodo, tasks[0].item.Status)
+
+		assert.Equal(t, "2995104339", tasks[1].sourceId)
+		assert.Equal(t, "LOW", tasks[1].item.Item.Details.Priority)
+		assert.Equal(t, time.Date(2023, 10, 15, 7, 0, 0, 0, time.UTC), tasks[1].item.Item.Details.DueDate)
+		assert.Empty(t, tasks[1].item.Labels)
+		assert.Equal(t, entity.StatusDone, tasks[1].item.Status)
+
+		assert.Equal(t, "MEDIUM", tasks[2].item.Item.Details.Priority)
+		assert.False(t, tasks[2].hasDue)
+	})
+
+	t.Run("Test Read an invalid Todoist backup", func(t *testing.T) {
+		_, err := readTodoistTasks(strings.NewReader(`{"projects": []}`), time.UTC)
+		assert.EqualError(t, err, "the backup has no items")
+
+		_, err = readTodoistTasks(strings.NewReader(`{"items": [{"id": "1", "content": "Pay rent", "due": {"date": "tomorrow"}}]}`), time.UTC)
+		assert.Error(t, err)
+
+		_, err = readTodoistTasks(strings.NewReader(`{"items": [{"id": true}]}`), time.UTC)
+		assert.Error(t, err)
+	})
+
+	t.Run("Test Read a Microsoft To Do export", func(t *testing.T) {
+		export := `{
+			"value": [
+				{"id": "AAMkADIyAAAAABrJAAA=", "displayName": "Tasks", "wellknownListName": "defaultList", "tasks": [
+					{"id": "AlMKXwbQAAAJws6wcAAAA=", "title": "Pay rent", "importance": "high", "status": "notStarted",
+						"body": {"content": "<p>Flat, March</p><p>Ask the landlord &amp; the bank</p>", "contentType": "html"},
+						"dueDateTime": {"dateTime": "2023-10-15T00:00:00.0000000", "timeZone": "UTC"}, "categories": ["Bills"]}
+				]},
+				{"id": "AAMkADIyAAAAABrKAAA=", "displayName": "Home", "wellknownListName": "none", "tasks": [
+					{"id": "AlMKXwbQAAAJws6wcAAAB=", "title": "Call mom", "importance": "low", "status": "waitingOnOthers",
+						"body": {"content": "", "contentType": "text"},
+						"dueDateTime": {"dateTime": "2023-10-15T09:00:00.0000000", "timeZone": "Pacific Standard Time"}},
+					{"id": "AlMKXwbQAAAJws6wcAAAC=", "title": "Water the plants", "importance": "normal", "status": "completed"}
+				]}
+			]
+		}`
+
+		tasks, err := readMSTodoTasks(strings.NewReader(export), berlin)
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 3)
+
+		assert.Equal(t, "AlMKXwbQAAAJws6wcAAAA=", tasks[0].sourceId)
+		assert.Equal(t, "Flat, March\nAsk the landlord & the bank", tasks[0].item.Item.Details.Description)
+		assert.Equal(t, "HIGH", tasks[0].item.Item.Details.Priority)
+		assert.Equal(t, dueDate, tasks[0].item.Item.Details.DueDate)
+		assert.Equal(t, []string{"Bills"}, tasks[0].item.Labels)
+		assert.Equal(t, entity.StatusTodo, tasks[0].item.Status)
+
+		assert.Equal(t, "LOW", tasks[1].item.Item.Details.Priority)
+		assert.Equal(t, time.Date(2023, 10, 15, 7, 0, 0, 0, time.UTC), tasks[1].item.Item.Details.DueDate)
+		assert.Equal(t, []string{"Home"}, tasks[1].item.Labels)
+		assert.Equal(t, entity.StatusBlocked, tasks[1].item.Status)
+
+		assert.Equal(t, "MEDIUM", tasks[2].item.Item.Details.Priority)
+		assert.Equal(t, entity.StatusDone, tasks[2].item.Status)
+		assert.False(t, tasks[2].hasDue)
+	})
+
+	t.Run("Test Read an invalid Microsoft To Do export", func(t *testing.T) {
+		_, err := readMSTodoTasks(strings.NewReader(`{"lists": []}`), time.UTC)
+		assert.EqualError(t, err, "the export has no lists")
+
+		_, err = readMSTodoTasks(strings.NewReader(`{"lists": [{"displayName": "Home", "tasks": [{"title": "Pay rent"}]}]}`), time.UTC)
+		assert.Error(t, err)
+	})
+
+	t.Run("Test Labels of projects are cut", func(t *testing.T) {
+		assert.Equal(t, "Home chores", appLabel("  Home   chores "))
+		assert.Equal(t, strings.Repeat("ä", 50), appLabel(strings.Repeat("ä", 60)))
+		assert.Equal(t, []string{"Home"}, addAppLabels(nil, "", "Home", " Home "))
+	})
+
+	t.Run("Test Status of an item imported again", func(t *testing.T) {
+		assert.Equal(t, entity.StatusInProgress, importedStatus(entity.StatusInProgress, entity.StatusTodo))
+		assert.Equal(t, entity.StatusBlocked, importedStatus(entity.StatusBlocked, entity.StatusTodo))
+		assert.Equal(t, entity.StatusCancelled, importedStatus(entity.StatusCancelled, entity.StatusDone))
+		assert.Equal(t, entity.StatusDone, importedStatus(entity.StatusInProgress, entity.StatusDone))
+		assert.Equal(t, entity.StatusTodo, importedStatus(entity.StatusDone, entity.StatusTodo))
+	})
+
+	t.Run("Test Plan an import", func(t *testing.T) {
+		as := ApiService{}
+
+		task := func(sourceId, name string, hasDue bool, status string, labels ...string) appTask {
+			item := entity.TodoItem{Status: status, Labels: labels}
+			item.Item.Name = name
+			item.Item.Details.Priority = "HIGH"
+			if hasDue {
+				item.Item.Details.DueDate = dueDate
+			}
+
+			return appTask{sourceId: sourceId, item: item, hasDue: hasDue}
+		}
+
+		existing := map[string]entity.TodoItem{
+			"2": {Id: "item-2", Item: entity.TodoItemDto{Name: "Call mom", Details: entity.TodoItemDetailsDto{DueDate: dueDate, Priority: "HIGH"}}, Status: entity.StatusInProgress},
+			"3": {Id: "item-3", Item: entity.TodoItemDto{Name: "Water plants", Details: entity.TodoItemDetailsDto{DueDate: dueDate.AddDate(0, 0, -1), Priority: "LOW"}}, Status: entity.StatusTodo, Labels: []string{"home"}},
+			"4": {Id: "item-4", Item: entity.TodoItemDto{Name: "Old task"}, IsDeleted: true},
+		}
+
+		tasks := []appTask{
+			task("1", "Pay rent", false, entity.StatusDone, "bills"),
+			task("2", "Call mom", false, entity.StatusTodo),
+			task("3", "Water the plants", true, entity.StatusTodo, "garden"),
+			task("4", "Old task", true, entity.StatusTodo),
+		}
+
+		result, creates, sources, updates := as.planAppImport(tasks, existing, time.UTC)
+
+		assert.Equal(t, 1, result.Created)
+		assert.Equal(t, 1, result.Updated)
+		assert.Equal(t, 1, result.Unchanged)
+		assert.Equal(t, 1, result.Skipped)
+		assert.Equal(t, 0, result.Invalid)
+
+		assert.Len(t, creates, 1)
+		assert.Equal(t, map[string]string{creates[0].Id: "1"}, sources)
+		assert.Equal(t, creates[0].Id, result.Changes[0].ItemId)
+		assert.False(t, creates[0].Item.Details.DueDate.IsZero())
+		assert.Equal(t, entity.ImportActionCreate, result.Changes[0].Action)
+
+		assert.Equal(t, entity.ImportActionUnchanged, result.Changes[1].Action)
+
+		assert.Len(t, updates, 1)
+		assert.Equal(t, []string{"garden"}, updates[0].add)
+		assert.Equal(t, []string{"home"}, updates[0].remove)
+		assert.Equal(t, entity.ImportActionUpdate, result.Changes[2].Action)
+		assert.Equal(t, []entity.AppImportField{
+			{Field: "name", From: "Water plants", To: "Water the plants"},
+			{Field: "due_date", From: "2023-10-14T23:59:59Z", To: "2023-10-15T23:59:59Z"},
+			{Field: "priority", From: "LOW", To: "HIGH"},
+			{Field: "labels", From: "home", To: "garden"},
+		}, result.Changes[2].Fields)
+
+		assert.Equal(t, entity.ImportActionSkip, result.Changes[3].Action)
+		assert.Equal(t, "item-4", result.Changes[3].ItemId)
+	})
+
+	t.Run("Test Plan an import with invalid tasks", func(t *testing.T) {
+		as := ApiService{}
+		blocked := entity.TodoItem{Id: "item-1", Status: entity.StatusBlocked}
+
+		tasks := []appTask{
+			{sourceId: "1", item: entity.TodoItem{Item: entity.TodoItemDto{Name: "Pay rent"}, Status: entity.StatusDone}},
+			{sourceId: "2", item: entity.TodoItem{Item: entity.TodoItemDto{Name: "Go"}, Status: entity.StatusTodo}},
+			{sourceId: "2", item: entity.TodoItem{Item: entity.TodoItemDto{Name: "Call mom"}, Status: entity.StatusTodo}},
+		}
+
+		result, creates, _, updates := as.planAppImport(tasks, map[string]entity.TodoItem{"1": blocked}, time.UTC)
+
+		assert.Equal(t, 3, result.Invalid)
+		assert.Empty(t, creates)
+		assert.Empty(t, updates)
+		assert.Equal(t, "Cannot change the status from blocked to done", result.Changes[0].Error)
+		assert.Equal(t, "the name must be at least 3 characters", result.Changes[1].Error)
+		assert.Equal(t, "the task 2 appears more than once", result.Changes[2].Error)
+	})
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+	"todo-project/notification"
+)
+
+// Users following an item, its assignee and watchers
+func itemFollowers(item entity.TodoItem) []string {
+	followers := append([]string{}, item.Watchers...)
+	if item.AssigneeId != "" {
+		followers = append(followers, item.AssigneeId)
+	}
+
+	return followers
+}
+
+// Notifies each recipient once about an action on an item, the user who made the change is left out.
+// Failures are logged since the change itself already succeeded
+func (as ApiService) notifyUsers(action string, item entity.TodoItem, actorId string, recipients []string) {
+	if as.Notifier == nil {
+		return
+	}
+
+	notified := map[string]bool{actorId: true}
+	for _, recipient := range recipients {
+		if notified[recipient] {
+			continue
+		}
+		notified[recipient] = true
+
+		err := as.Notifier.Notify(notification.Notification{
+			Event:     "item." + action,
+			Recipient: recipient,
+			ActorId:   actorId,
+			Item:      item,
+		})
+		if err != nil {
+			log.Printf(constants.NOTIFY_ERROR, recipient, action, err)
+		}
+	}
+}
+
+// Assigns an item to a user who can edit it
+// @Summary assigns a todo item to a user by email
+// @Description The assignee must own the item or have accepted an editor share of it, and is notified of the assignment.
+// @Tags Assignment
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param dto body entity.AssigneeDto true "Assignee"
+// @Success 200 {object} entity.TodoItem
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_ROLE
+// @Failure 404 string constants.EMAIL_NOT_REGISTERED
+// @Failure 422 string constants.ASSIGNEE_WITHOUT_ACCESS
+// @Failure 500 string constants.ASSIGN_ITEM_ERROR
+// @Router /item/{id}/assignee [put]
+func (as ApiService) AssignItemById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	assignee := new(entity.AssigneeDto)
+	if err := c.Bind(assignee); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(assignee)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleEditor)
+	if !allowed {
+		return err
+	}
+
+	assigneeId, err := as.repo(c).FindUserIdByEmail(assignee.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.EMAIL_NOT_REGISTERED)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.ASSIGN_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	role, err := as.repo(c).GetItemRole(param, assigneeId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.ASSIGN_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if !roleAllows(role, entity.RoleEditor) {
+		return c.String(http.StatusUnprocessableEntity, constants.ASSIGNEE_WITHOUT_ACCESS)
+	}
+
+	item, err := as.withRevision(as.repo(c), userId, entity.RevisionAssigned, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.SetItemAssignee(param, assigneeId)
+	})
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.ASSIGN_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusOK, item, " ")
+}
+
+// Removes the assignee of an item, the former assignee is notified
+// @Summary unassigns a todo item
+// @Tags Assignment
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Success 200 {object} entity.TodoItem
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_ROLE
+// @Failure 500 string constants.ASSIGN_ITEM_ERROR
+// @Router /item/{id}/assignee [delete]
+func (as ApiService) UnassignItemById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleEditor)
+	if !allowed {
+		return err
+	}
+
+	current, err := as.repo(c).FindItemById(param)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.ASSIGN_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if current.AssigneeId == "" {
+		c.Response().Header().Set(HeaderETag, itemETag(current))
+		return c.JSONPretty(http.StatusOK, current, " ")
+	}
+
+	item, err := as.withRevision(as.repo(c), userId, entity.RevisionUnassigned, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.SetItemAssignee(param, "")
+	})
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.ASSIGN_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if !containsString(item.Watchers, current.AssigneeId) {
+		as.notifyUsers(entity.RevisionUnassigned, item, userId, []string{current.AssigneeId})
+	}
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusOK, item, " ")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Adds or removes the current user from the watchers of an item
+func (as ApiService) setWatching(c echo.Context, watch bool) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleViewer)
+	if !allowed {
+		return err
+	}
+
+	var item entity.TodoItem
+	if watch {
+		item, err = as.repo(c).AddItemWatcher(param, userId)
+	} else {
+		item, err = as.repo(c).RemoveItemWatcher(param, userId)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.WATCH_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusOK, item, " ")
+}
+
+// Starts watching an item, watchers are notified of its changes
+// @Summary watches a todo item
+// @Tags Assignment
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Success 200 {object} entity.TodoItem
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 500 string constants.WATCH_ITEM_ERROR
+// @Router /item/{id}/watchers [post]
+func (as ApiService) WatchItemById(c echo.Context) error {
+	return as.setWatching(c, true)
+}
+
+// Stops watching an item
+// @Summary stops watching a todo item
+// @Tags Assignment
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Success 200 {object} entity.TodoItem
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 500 string constants.WATCH_ITEM_ERROR
+// @Router /item/{id}/watchers [delete]
+func (as ApiService) UnwatchItemById(c echo.Context) error {
+	return as.setWatching(c, false)
+}
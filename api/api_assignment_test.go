@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+	"todo-project/notification"
+)
+
+func TestAssignment(t *testing.T) {
+	item := entity.TodoItem{
+		Id:         "3a35452e-957c-4588-8d40-c88f370067d2",
+		AssigneeId: "assignee",
+		Watchers:   []string{"actor", "assignee", "watcher"},
+	}
+
+	t.Run("Test Item followers", func(t *testing.T) {
+		assert.Equal(t, []string{"actor", "assignee", "watcher", "assignee"}, itemFollowers(item))
+		assert.Empty(t, itemFollowers(entity.TodoItem{}))
+	})
+
+	t.Run("Test Notify users", func(t *testing.T) {
+		var got []notification.Notification
+		as := ApiService{
+			Notifier: notification.NotifierFunc(func(n notification.Notification) error {
+				got = append(got, n)
+				return errors.New("unreachable")
+			}),
+		}
+
+		as.notifyUsers(entity.RevisionCompleted, item, "actor", itemFollowers(item))
+
+		assert.Len(t, got, 2)
+		assert.Equal(t, "assignee", got[0].Recipient)
+		assert.Equal(t, "watcher", got[1].Recipient)
+		assert.Equal(t, "item.completed", got[0].Event)
+		assert.Equal(t, "actor", got[0].ActorId)
+	})
+
+	t.Run("Test Notify users without a notifier", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			ApiService{}.notifyUsers(entity.RevisionCompleted, item, "actor", itemFollowers(item))
+		})
+	})
+}
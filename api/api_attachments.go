@@ -0,0 +1,359 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+	"todo-project/storage"
+)
+
+// Size and type limits of the uploaded attachments and the storage quota of each user, in bytes
+type AttachmentLimits struct {
+	MaxSize      int64
+	AllowedTypes []string
+	Quota        int64
+}
+
+// Room for the multipart boundaries and part headers around the file in an upload body
+const attachmentFormOverhead = 16 << 10
+
+var DefaultAttachmentLimits = AttachmentLimits{
+	MaxSize:      10 << 20,
+	AllowedTypes: []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"},
+	Quota:        100 << 20,
+}
+
+// Parses the limits from their configuration, an empty value keeps the default of that limit
+func ParseAttachmentLimits(maxSize, allowedTypes, quota string) (AttachmentLimits, error) {
+	limits := DefaultAttachmentLimits
+
+	if strings.TrimSpace(maxSize) != "" {
+		size, err := strconv.ParseInt(strings.TrimSpace(maxSize), 10, 64)
+		if err != nil || size <= 0 {
+			return limits, fmt.Errorf("invalid maximum size %q", maxSize)
+		}
+		limits.MaxSize = size
+	}
+
+	if strings.TrimSpace(quota) != "" {
+		size, err := strconv.ParseInt(strings.TrimSpace(quota), 10, 64)
+		if err != nil || size <= 0 {
+			return limits, fmt.Errorf("invalid quota %q", quota)
+		}
+		limits.Quota = size
+	}
+
+	if strings.TrimSpace(allowedTypes) != "" {
+		limits.AllowedTypes = nil
+		for _, contentType := range strings.Split(allowedTypes, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(contentType))
+			if err != nil {
+				return limits, fmt.Errorf("invalid type %q", contentType)
+			}
+			limits.AllowedTypes = append(limits.AllowedTypes, mediaType)
+		}
+	}
+
+	return limits, nil
+}
+
+func (l AttachmentLimits) allows(contentType string) bool {
+	for _, allowed := range l.AllowedTypes {
+		if allowed == contentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Attachment limits, the defaults are used when none are configured
+func (as ApiService) attachmentLimits() AttachmentLimits {
+	if as.Attachments.MaxSize == 0 {
+		return DefaultAttachmentLimits
+	}
+
+	return as.Attachments
+}
+
+// Deletes the blobs of purged attachments, a failure only leaves an orphaned blob behind so it is logged
+func deleteBlobs(blobs storage.BlobStore, keys []string) {
+	if blobs == nil {
+		return
+	}
+
+	for _, key := range keys {
+		if err := blobs.Delete(key); err != nil {
+			log.Printf(constants.DELETE_BLOB_ERROR, key, err)
+		}
+	}
+}
+
+// Checks the blob store is configured and the user has the required role on the item, responding when not
+func (as ApiService) attachmentsOfUser(c echo.Context, required string) (string, bool, error) {
+	if as.Blobs == nil {
+		return "", false, c.String(http.StatusServiceUnavailable, constants.ATTACHMENTS_NOT_CONFIGURED)
+	}
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return "", false, c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return "", false, c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeItem(c, c.Param("id"), userId, required)
+
+	return userId, allowed, err
+}
+
+// Uploads a file as an attachment of an item
+// @Summary uploads an attachment to a todo item
+// @Description The type is detected from the content of the file and must be one of the allowed types.
+// @Tags Attachment
+// @Accept multipart/form-data
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} entity.Attachment
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 409 string constants.ITEM_IN_TRASH
+// @Failure 413 string constants.ATTACHMENT_TOO_LARGE
+// @Failure 415 string constants.ATTACHMENT_TYPE_NOT_ALLOWED
+// @Failure 500 string constants.UPLOAD_ATTACHMENT_ERROR
+// @Failure 507 string constants.STORAGE_QUOTA_EXCEEDED
+// @Router /item/{id}/attachments [post]
+func (as ApiService) UploadAttachment(c echo.Context) error {
+	param := c.Param("id")
+	limits := as.attachmentLimits()
+
+	userId, ok, err := as.attachmentsOfUser(c, entity.RoleEditor)
+	if !ok {
+		return err
+	}
+
+	item, err := as.repo(c).FindItemById(param)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.FIND_ITEM_BY_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if item.IsDeleted {
+		return c.String(http.StatusConflict, constants.ITEM_IN_TRASH)
+	}
+
+	// The body is cut at the limit so that an oversized upload is not parsed and spooled to disk before the check
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, limits.MaxSize+attachmentFormOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		errMessage := fmt.Sprintf(constants.ATTACHMENT_TOO_LARGE, limits.MaxSize)
+		return c.String(http.StatusRequestEntityTooLarge, errMessage)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	if fileHeader.Size > limits.MaxSize {
+		errMessage := fmt.Sprintf(constants.ATTACHMENT_TOO_LARGE, limits.MaxSize)
+		return c.String(http.StatusRequestEntityTooLarge, errMessage)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.UPLOAD_ATTACHMENT_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		errMessage := fmt.Sprintf(constants.UPLOAD_ATTACHMENT_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+	head = head[:n]
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !limits.allows(contentType) {
+		errMessage := fmt.Sprintf(constants.ATTACHMENT_TYPE_NOT_ALLOWED, contentType)
+		return c.String(http.StatusUnsupportedMediaType, errMessage)
+	}
+
+	id := (uuid.New()).String()
+	attachment := entity.Attachment{
+		Id:          id,
+		ItemId:      param,
+		UserId:      userId,
+		FileName:    fileHeader.Filename,
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+		StorageKey:  "items/" + param + "/" + id,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+
+	err = as.Blobs.Put(attachment.StorageKey, io.MultiReader(bytes.NewReader(head), file), attachment.Size, contentType)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.UPLOAD_ATTACHMENT_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	// The quota is checked when the attachment is recorded, together with the uploads running at the same time
+	err = as.repo(c).CreateAttachment(attachment, limits.Quota)
+	if err != nil {
+		deleteBlobs(as.Blobs, []string{attachment.StorageKey})
+	}
+
+	if errors.Is(err, ErrAttachmentOverQuota) {
+		errMessage := fmt.Sprintf(constants.STORAGE_QUOTA_EXCEEDED, limits.Quota)
+		return c.String(http.StatusInsufficientStorage, errMessage)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.UPLOAD_ATTACHMENT_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, attachment, " ")
+}
+
+// Lists the attachments of an item
+// @Summary lists the attachments of a todo item
+// @Tags Attachment
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Success 200 {array} entity.Attachment
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 500 string constants.GET_ATTACHMENTS_ERROR
+// @Router /item/{id}/attachments [get]
+func (as ApiService) GetAttachments(c echo.Context) error {
+	if _, ok, err := as.attachmentsOfUser(c, entity.RoleViewer); !ok {
+		return err
+	}
+
+	attachments, err := as.repo(c).GetItemAttachments(c.Param("id"))
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_ATTACHMENTS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, attachments, " ")
+}
+
+// Streams the content of an attachment, byte ranges are supported
+// @Summary downloads an attachment of a todo item
+// @Tags Attachment
+// @Produce octet-stream
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param attachmentId path string true "attachment Id"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 404 string constants.ATTACHMENT_NOT_FOUND
+// @Failure 500 string constants.GET_ATTACHMENTS_ERROR
+// @Router /item/{id}/attachments/{attachmentId} [get]
+func (as ApiService) DownloadAttachment(c echo.Context) error {
+	if _, ok, err := as.attachmentsOfUser(c, entity.RoleViewer); !ok {
+		return err
+	}
+
+	attachment, err := as.repo(c).FindAttachmentById(c.Param("id"), c.Param("attachmentId"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.ATTACHMENT_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_ATTACHMENTS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	blob, err := as.Blobs.Open(attachment.StorageKey)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return c.String(http.StatusNotFound, constants.ATTACHMENT_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_ATTACHMENTS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+	defer blob.Close()
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, attachment.ContentType)
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(c.Response(), c.Request(), attachment.FileName, attachment.CreatedAt, blob)
+	return nil
+}
+
+// Deletes an attachment and its content
+// @Summary deletes an attachment of a todo item
+// @Tags Attachment
+// @Produce plain
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param attachmentId path string true "attachment Id"
+// @Success 200 string constants.DELETE_ATTACHMENT_SUCCESSFULL
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 404 string constants.ATTACHMENT_NOT_FOUND
+// @Failure 500 string constants.DELETE_ATTACHMENT_ERROR
+// @Router /item/{id}/attachments/{attachmentId} [delete]
+func (as ApiService) DeleteAttachmentById(c echo.Context) error {
+	if _, ok, err := as.attachmentsOfUser(c, entity.RoleEditor); !ok {
+		return err
+	}
+
+	attachment, err := as.repo(c).FindAttachmentById(c.Param("id"), c.Param("attachmentId"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.ATTACHMENT_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.DELETE_ATTACHMENT_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if err := as.repo(c).DeleteAttachment(attachment.Id); err != nil {
+		errMessage := fmt.Sprintf(constants.DELETE_ATTACHMENT_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	deleteBlobs(as.Blobs, []string{attachment.StorageKey})
+	return c.String(http.StatusOK, constants.DELETE_ATTACHMENT_SUCCESSFULL)
+}
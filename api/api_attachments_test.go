@@ -0,0 +1,42 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachments(t *testing.T) {
+	t.Run("Test Default attachment limits", func(t *testing.T) {
+		limits, err := ParseAttachmentLimits("", "", "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultAttachmentLimits, limits)
+		assert.True(t, limits.allows("application/pdf"))
+		assert.False(t, limits.allows("application/zip"))
+	})
+
+	t.Run("Test Configured attachment limits", func(t *testing.T) {
+		limits, err := ParseAttachmentLimits("1024", "image/png, text/plain; charset=utf-8", "4096")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1024), limits.MaxSize)
+		assert.Equal(t, int64(4096), limits.Quota)
+		assert.Equal(t, []string{"image/png", "text/plain"}, limits.AllowedTypes)
+	})
+
+	t.Run("Test Invalid attachment limits", func(t *testing.T) {
+		_, err := ParseAttachmentLimits("ten", "", "")
+		assert.Error(t, err)
+
+		_, err = ParseAttachmentLimits("", "", "-1")
+		assert.Error(t, err)
+
+		_, err = ParseAttachmentLimits("", "image/", "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Test Unconfigured limits use the defaults", func(t *testing.T) {
+		assert.Equal(t, DefaultAttachmentLimits, ApiService{}.attachmentLimits())
+	})
+}
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+const (
+	BoardGroupByStatus = "status"
+	BoardGroupByLabel  = "label"
+)
+
+// Parses the comma separated columns of a board, the status board defaults to every status
+func parseBoardColumns(groupBy, param string) ([]string, error) {
+	var columns []string
+	seen := map[string]bool{}
+
+	for _, column := range strings.Split(param, ",") {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+
+		if seen[column] || (groupBy == BoardGroupByStatus && !isStatus(column)) {
+			return nil, fmt.Errorf("invalid column %q", column)
+		}
+
+		seen[column] = true
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		if groupBy == BoardGroupByLabel {
+			return nil, errors.New("a label board needs columns")
+		}
+
+		return statuses, nil
+	}
+
+	return columns, nil
+}
+
+// Column of the board the item belongs to, an item with several labels goes to the first matching column
+func boardColumnOf(item entity.TodoItem, groupBy string, columns []string) (string, bool) {
+	for _, column := range columns {
+		if groupBy == BoardGroupByStatus && item.Status == column {
+			return column, true
+		}
+
+		if groupBy == BoardGroupByLabel && hasLabel(item, column) {
+			return column, true
+		}
+	}
+
+	return "", false
+}
+
+func hasLabel(item entity.TodoItem, label string) bool {
+	for _, l := range item.Labels {
+		if l == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Groups the ranked items into the columns of the board, keeping at most limit items per column
+func buildBoard(items []entity.TodoItem, groupBy string, columns []string, limit int) entity.Board {
+	board := entity.Board{GroupBy: groupBy, Columns: make([]entity.BoardColumn, len(columns))}
+
+	index := map[string]int{}
+	for i, column := range columns {
+		board.Columns[i] = entity.BoardColumn{Name: column, Items: []entity.TodoItem{}}
+		index[column] = i
+	}
+
+	for _, item := range items {
+		column, ok := boardColumnOf(item, groupBy, columns)
+		if !ok {
+			continue
+		}
+
+		boardColumn := &board.Columns[index[column]]
+		boardColumn.Total++
+		if len(boardColumn.Items) < limit {
+			boardColumn.Items = append(boardColumn.Items, item)
+		}
+	}
+
+	return board
+}
+
+// Whether the neighbour is listed with the item, on the personal list of the same user or in the same workspace
+func sameList(item, neighbour entity.TodoItem) bool {
+	if neighbour.IsDeleted || item.WorkspaceId != neighbour.WorkspaceId {
+		return false
+	}
+
+	return item.WorkspaceId != "" || item.UserId == neighbour.UserId
+}
+
+// Rank of a neighbour of the moved item, an empty id is the top or the bottom of the list
+func (as ApiService) neighbourRank(c echo.Context, item entity.TodoItem, id string) (string, error) {
+	if id == "" {
+		return "", nil
+	}
+
+	neighbour, err := as.repo(c).FindItemById(id)
+	if err == nil && !sameList(item, neighbour) {
+		err = sql.ErrNoRows
+	}
+
+	return neighbour.Rank, err
+}
+
+// Shows the items of the list as a board, each column ordered by the rank of the items
+// @Summary shows the todo items as a board
+// @Description The items are grouped by status, every status being a column unless columns are given, or by label, in which case the columns are required. An item with several labels shows in the first matching column.
+// @Tags Board
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param group_by query string false "Grouping of the columns" Enums(status, label) default(status)
+// @Param columns query string false "Comma separated columns"
+// @Param limit query int false "Maximum number of items per column" default(100)
+// @Success 200 {object} entity.Board
+// @Failure 400 string constants.INVALID_BOARD_COLUMNS
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.GET_BOARD_ERROR
+// @Router /item/board [get]
+func (as ApiService) GetBoard(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	groupBy := c.QueryParam("group_by")
+	if groupBy == "" {
+		groupBy = BoardGroupByStatus
+	}
+
+	if groupBy != BoardGroupByStatus && groupBy != BoardGroupByLabel {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, "group_by must be status or label")
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	columns, err := parseBoardColumns(groupBy, c.QueryParam("columns"))
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_BOARD_COLUMNS, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	limit := 100
+	if param := c.QueryParam("limit"); param != "" {
+		value, err := strconv.Atoi(param)
+		if err != nil || value <= 0 {
+			errMessage := fmt.Sprintf(constants.BAD_REQUEST, "limit must be a positive number")
+			return c.String(http.StatusBadRequest, errMessage)
+		}
+		limit = value
+	}
+
+	items, err := as.repo(c).GetBoardItems(userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_BOARD_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, buildBoard(items, groupBy, columns, limit), " ")
+}
+
+// Moves an item between two neighbours, and to another column of the board when a column is given
+// @Summary moves a todo item on the list or the board
+// @Description after_id is the item right above the new place and before_id the item right below it, leaving out after_id moves the item to the top and leaving out before_id to the bottom. Moving to another status column changes the status of the item, moving to another label column adds the label and removes the label of from_column.
+// @Tags Board
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param dto body entity.MoveItemDto true "New place of the item"
+// @Param If-Match header string false "ETag of the item being moved"
+// @Success 200 {object} entity.TodoItem
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_ROLE
+// @Failure 409 string constants.INVALID_STATUS_TRANSITION
+// @Failure 412 {object} entity.TodoItem
+// @Failure 428 string constants.IF_MATCH_REQUIRED
+// @Failure 500 string constants.MOVE_ITEM_ERROR
+// @Router /item/{id}/move [post]
+func (as ApiService) MoveItemById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	move := new(entity.MoveItemDto)
+	if err := c.Bind(move); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(move)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	if move.GroupBy == "" {
+		move.GroupBy = BoardGroupByStatus
+	}
+
+	if move.GroupBy == BoardGroupByStatus && move.Column != "" && !isStatus(move.Column) {
+		errMessage := fmt.Sprintf(constants.INVALID_BOARD_COLUMNS, move.Column)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	version, err := as.ifMatchVersion(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleEditor)
+	if !allowed {
+		return err
+	}
+
+	current, err := as.repo(c).FindItemById(param)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.FIND_ITEM_BY_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if version != 0 && version != current.Version {
+		c.Response().Header().Set(HeaderETag, itemETag(current))
+		return c.JSONPretty(http.StatusPreconditionFailed, current, " ")
+	}
+
+	ranks := make([]string, 2)
+	for spread := false; ; spread = true {
+		for i, id := range []string{move.AfterId, move.BeforeId} {
+			ranks[i], err = as.neighbourRank(c, current, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				errMessage := fmt.Sprintf(constants.NEIGHBOUR_NOT_FOUND, id)
+				return c.String(http.StatusBadRequest, errMessage)
+			}
+
+			if err != nil {
+				errMessage := fmt.Sprintf(constants.FIND_ITEM_BY_ITEM_ERROR, err)
+				return c.String(http.StatusInternalServerError, errMessage)
+			}
+		}
+
+		if spread || ranks[0] == "" || ranks[0] != ranks[1] {
+			break
+		}
+
+		// Neighbours sharing a rank get distinct ranks first, then the item goes between their new ranks
+		if err := as.repo(c).SpreadRankTie(current.UserId, ranks[0]); err != nil {
+			errMessage := fmt.Sprintf(constants.MOVE_ITEM_ERROR, err)
+			return c.String(http.StatusInternalServerError, errMessage)
+		}
+	}
+
+	rank, err := moveRank(ranks[0], ranks[1], time.Now())
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	var item entity.TodoItem
+
+	switch {
+	case move.GroupBy == BoardGroupByStatus && move.Column != "" && move.Column != current.Status:
+		if !as.statusTransitions().CanTransition(current.Status, move.Column) {
+			errMessage := fmt.Sprintf(constants.INVALID_STATUS_TRANSITION, current.Status, move.Column)
+			return c.String(http.StatusConflict, errMessage)
+		}
+
+		if waitsOnBlockers(current, move.Column) {
+			return c.String(http.StatusConflict, constants.ITEM_BLOCKED)
+		}
+
+		item, err = as.withRevision(as.repo(c), userId, entity.RevisionStatusChanged, func(r ApiRepository) (entity.TodoItem, error) {
+			if _, err := r.SetItemStatus(param, move.Column, current.Version); err != nil {
+				return entity.TodoItem{}, err
+			}
+
+			return r.SetItemRank(param, rank, 0)
+		})
+
+	case move.GroupBy == BoardGroupByLabel && move.Column != move.FromColumn:
+		var add, remove []string
+		if move.Column != "" {
+			add = []string{move.Column}
+		}
+		if move.FromColumn != "" {
+			remove = []string{move.FromColumn}
+		}
+
+		item, err = as.withRevision(as.repo(c), userId, entity.RevisionUpdated, func(r ApiRepository) (entity.TodoItem, error) {
+			if _, err := r.SetItemLabels(param, add, remove); err != nil {
+				return entity.TodoItem{}, err
+			}
+
+			return r.SetItemRank(param, rank, 0)
+		})
+
+	default:
+		// Reordering within a column is a single row update, it is not recorded in the history
+		item, err = as.repo(c).SetItemRank(param, rank, current.Version)
+	}
+
+	if errors.Is(err, ErrVersionMismatch) {
+		return as.versionMismatch(c, param)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.MOVE_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusOK, item, " ")
+}
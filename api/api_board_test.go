@@ -0,0 +1,76 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestBoard(t *testing.T) {
+	items := []entity.TodoItem{
+		{Id: "1", Status: entity.StatusTodo, Labels: []string{"home"}},
+		{Id: "2", Status: entity.StatusDone, Labels: []string{"finance", "home"}},
+		{Id: "3", Status: entity.StatusTodo},
+		{Id: "4", Status: entity.StatusTodo, Labels: []string{"finance"}},
+	}
+
+	t.Run("Test Parse board columns", func(t *testing.T) {
+		columns, err := parseBoardColumns(BoardGroupByStatus, "")
+		assert.NoError(t, err)
+		assert.Equal(t, statuses, columns)
+
+		columns, err = parseBoardColumns(BoardGroupByStatus, "todo, done")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"todo", "done"}, columns)
+
+		_, err = parseBoardColumns(BoardGroupByStatus, "todo,later")
+		assert.Error(t, err)
+
+		_, err = parseBoardColumns(BoardGroupByLabel, "home,home")
+		assert.Error(t, err)
+
+		_, err = parseBoardColumns(BoardGroupByLabel, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Test Build board by status", func(t *testing.T) {
+		board := buildBoard(items, BoardGroupByStatus, []string{entity.StatusTodo, entity.StatusInProgress, entity.StatusDone}, 2)
+
+		assert.Equal(t, BoardGroupByStatus, board.GroupBy)
+		assert.Len(t, board.Columns, 3)
+
+		assert.Equal(t, 3, board.Columns[0].Total)
+		assert.Equal(t, "1", board.Columns[0].Items[0].Id)
+		assert.Equal(t, "3", board.Columns[0].Items[1].Id)
+		assert.Len(t, board.Columns[0].Items, 2)
+
+		assert.Equal(t, 0, board.Columns[1].Total)
+		assert.Equal(t, []entity.TodoItem{}, board.Columns[1].Items)
+
+		assert.Equal(t, 1, board.Columns[2].Total)
+	})
+
+	t.Run("Test Build board by label", func(t *testing.T) {
+		board := buildBoard(items, BoardGroupByLabel, []string{"finance", "home"}, 100)
+
+		assert.Equal(t, "finance", board.Columns[0].Name)
+		assert.Equal(t, 2, board.Columns[0].Total)
+		assert.Equal(t, "2", board.Columns[0].Items[0].Id)
+		assert.Equal(t, "4", board.Columns[0].Items[1].Id)
+
+		assert.Equal(t, 1, board.Columns[1].Total)
+		assert.Equal(t, "1", board.Columns[1].Items[0].Id)
+	})
+
+	t.Run("Test Same list", func(t *testing.T) {
+		item := entity.TodoItem{UserId: "user"}
+
+		assert.True(t, sameList(item, entity.TodoItem{UserId: "user"}))
+		assert.False(t, sameList(item, entity.TodoItem{UserId: "other"}))
+		assert.False(t, sameList(item, entity.TodoItem{UserId: "user", IsDeleted: true}))
+		assert.False(t, sameList(item, entity.TodoItem{UserId: "user", WorkspaceId: "workspace"}))
+		assert.True(t, sameList(entity.TodoItem{UserId: "user", WorkspaceId: "workspace"}, entity.TodoItem{UserId: "other", WorkspaceId: "workspace"}))
+	})
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Returned from the transaction to roll back an all-or-nothing batch
+var errBulkAborted = errors.New(constants.BULK_OPERATION_NOT_EXECUTED)
+
+// Ids of the form $ref:<n> refer to the item created by the operation n of the batch
+const bulkReferencePrefix = "$ref:"
+
+func bulkError(result entity.BulkResultDto, status int, message string) entity.BulkResultDto {
+	result.Status = status
+	result.Error = message
+	return result
+}
+
+// Checks the fields each kind of operation needs, the field values are checked with the validator tags
+func validateBulkOperation(operation entity.BulkOperationDto) error {
+	switch operation.Op {
+	case "create":
+		if operation.Item == nil {
+			return fmt.Errorf(constants.INVALID_BULK_OPERATION, operation.Op, "item is required")
+		}
+		if operation.Id != "" {
+			return fmt.Errorf(constants.INVALID_BULK_OPERATION, operation.Op, "id is generated by the server")
+		}
+		return nil
+	case "update":
+		if operation.Details == nil {
+			return fmt.Errorf(constants.INVALID_BULK_OPERATION, operation.Op, "details are required")
+		}
+	case "move":
+		if !isStatus(operation.Status) {
+			return fmt.Errorf(constants.INVALID_BULK_OPERATION, operation.Op, "status is invalid")
+		}
+	case "label":
+		if len(operation.Add) == 0 && len(operation.Remove) == 0 {
+			return fmt.Errorf(constants.INVALID_BULK_OPERATION, operation.Op, "add or remove is required")
+		}
+	}
+
+	if operation.Id == "" {
+		return fmt.Errorf(constants.INVALID_BULK_OPERATION, operation.Op, "id is required")
+	}
+
+	return nil
+}
+
+// Resolves a $ref:<n> id to the id of the item created by the operation n, results holds the results of
+// the operations before this one. Other ids are returned unchanged
+func resolveBulkReference(operation entity.BulkOperationDto, results []entity.BulkResultDto) (string, error) {
+	if !strings.HasPrefix(operation.Id, bulkReferencePrefix) {
+		return operation.Id, nil
+	}
+
+	index, err := strconv.Atoi(strings.TrimPrefix(operation.Id, bulkReferencePrefix))
+	if err != nil || index < 0 || index >= len(results) || results[index].Op != "create" {
+		return "", fmt.Errorf(constants.INVALID_BULK_OPERATION, operation.Op, "id refers to no earlier create operation")
+	}
+
+	if results[index].Error != "" {
+		return "", errBulkAborted
+	}
+
+	return results[index].Id, nil
+}
+
+// Runs a single operation of a batch, items holds the current state of the items the user has a role on
+// and roles the role of the user on each of them
+func (as ApiService) runBulkOperation(r ApiRepository, index int, operation entity.BulkOperationDto, items map[string]entity.TodoItem, roles map[string]string, userId string) entity.BulkResultDto {
+	result := entity.BulkResultDto{
+		Index: index,
+		Op:    operation.Op,
+		Id:    operation.Id,
+	}
+
+	if err := validateBulkOperation(operation); err != nil {
+		return bulkError(result, http.StatusBadRequest, fmt.Sprintf(constants.BAD_REQUEST, err))
+	}
+
+	// The created item is added to items so that later operations of the batch can refer to it
+	if operation.Op == "create" {
+		item, err := r.CreateTodoItem(operation.Item, userId)
+		if err == nil {
+			err = as.recordRevision(r, item, userId, entity.RevisionCreated)
+		}
+
+		if err != nil {
+			return bulkError(result, http.StatusInternalServerError, fmt.Sprintf(constants.CREATE_ITEM_ERROR, err))
+		}
+
+		items[item.Id] = item
+		roles[item.Id] = entity.RoleOwner
+		result.Id = item.Id
+		result.Status = http.StatusCreated
+		result.Item = &item
+		return result
+	}
+
+	current, ok := items[operation.Id]
+	if !ok {
+		return bulkError(result, http.StatusForbidden, constants.DOES_NOT_BELONG_TO_USER)
+	}
+
+	// Same roles as the single item endpoints, deleting needs the owner role
+	required := entity.RoleEditor
+	if operation.Op == "delete" {
+		required = entity.RoleOwner
+	}
+
+	if !roleAllows(roles[operation.Id], required) {
+		return bulkError(result, http.StatusForbidden, fmt.Sprintf(constants.INSUFFICIENT_ROLE, required))
+	}
+
+	if operation.Version != 0 && operation.Version != current.Version {
+		result.Item = &current
+		return bulkError(result, http.StatusPreconditionFailed, constants.ITEM_VERSION_MISMATCH)
+	}
+
+	var item entity.TodoItem
+	var err error
+	errMessage := constants.UPDATE_ITEM_ERROR
+	action := entity.RevisionUpdated
+
+	switch operation.Op {
+	case "update":
+		item, err = r.UpdateTodoItem(operation.Details, operation.Id, current.Version)
+	case "complete", "move":
+		status := operation.Status
+		if operation.Op == "complete" {
+			status = entity.StatusDone
+		}
+
+		if !as.statusTransitions().CanTransition(current.Status, status) {
+			return bulkError(result, http.StatusConflict, fmt.Sprintf(constants.INVALID_STATUS_TRANSITION, current.Status, status))
+		}
+
+		if waitsOnBlockers(current, status) {
+			return bulkError(result, http.StatusConflict, constants.ITEM_BLOCKED)
+		}
+
+		errMessage = constants.CHANGE_STATUS_ITEM_ERROR
+		action = entity.RevisionStatusChanged
+		if operation.Op == "complete" {
+			action = entity.RevisionCompleted
+		}
+		item, err = r.SetItemStatus(operation.Id, status, current.Version)
+	case "delete":
+		errMessage = constants.DELETE_ITEM_ERROR
+		action = entity.RevisionDeleted
+		err = r.DeleteTodoItem(operation.Id, current.Version)
+		item = current
+		item.IsDeleted = true
+	case "label":
+		item, err = r.SetItemLabels(operation.Id, operation.Add, operation.Remove)
+	}
+
+	if err == nil {
+		err = as.recordRevision(r, item, userId, action)
+	}
+
+	if errors.Is(err, ErrVersionMismatch) {
+		return bulkError(result, http.StatusPreconditionFailed, constants.ITEM_VERSION_MISMATCH)
+	}
+
+	if err != nil {
+		return bulkError(result, http.StatusInternalServerError, fmt.Sprintf(errMessage, err))
+	}
+
+	if operation.Op != "delete" {
+		items[item.Id] = item
+		result.Item = &item
+	} else {
+		delete(items, item.Id)
+		delete(roles, item.Id)
+	}
+
+	result.Status = http.StatusOK
+	return result
+}
+
+// Runs the operations in one transaction, in the all-or-nothing mode the first failure rolls back the
+// whole batch, otherwise each operation runs in a savepoint so only the failed ones are rolled back
+func (as ApiService) runBulkOperations(r ApiRepository, request entity.BulkRequestDto, userId string) (entity.BulkResponseDto, error) {
+	response := entity.BulkResponseDto{
+		Results: make([]entity.BulkResultDto, len(request.Operations)),
+	}
+
+	ids := []string{}
+	for _, operation := range request.Operations {
+		if operation.Id != "" && !strings.HasPrefix(operation.Id, bulkReferencePrefix) {
+			ids = append(ids, operation.Id)
+		}
+	}
+
+	err := r.WithTx(func(tx ApiRepository) error {
+		items, roles, err := tx.FindItemsOfUser(ids, userId)
+		if err != nil {
+			return err
+		}
+
+		for i, operation := range request.Operations {
+			if !request.Atomic {
+				if _, err := tx.DB.Exec(SavepointQuery); err != nil {
+					return err
+				}
+			}
+
+			var result entity.BulkResultDto
+			id, err := resolveBulkReference(operation, response.Results[:i])
+
+			switch {
+			case errors.Is(err, errBulkAborted):
+				result = bulkError(entity.BulkResultDto{Index: i, Op: operation.Op, Id: operation.Id}, http.StatusFailedDependency, constants.BULK_OPERATION_NOT_EXECUTED)
+			case err != nil:
+				result = bulkError(entity.BulkResultDto{Index: i, Op: operation.Op, Id: operation.Id}, http.StatusBadRequest, fmt.Sprintf(constants.BAD_REQUEST, err))
+			default:
+				operation.Id = id
+				result = as.runBulkOperation(tx, i, operation, items, roles, userId)
+			}
+			response.Results[i] = result
+
+			if result.Error == "" {
+				if !request.Atomic {
+					if _, err := tx.DB.Exec(ReleaseSavepointQuery); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			if request.Atomic {
+				for j := i + 1; j < len(request.Operations); j++ {
+					response.Results[j] = entity.BulkResultDto{
+						Index:  j,
+						Op:     request.Operations[j].Op,
+						Id:     request.Operations[j].Id,
+						Status: http.StatusFailedDependency,
+						Error:  constants.BULK_OPERATION_NOT_EXECUTED,
+					}
+				}
+				return errBulkAborted
+			}
+
+			if _, err := tx.DB.Exec(RollbackToSavepointQuery); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errBulkAborted) {
+		return response, nil
+	}
+
+	if err != nil {
+		return response, err
+	}
+
+	response.Committed = true
+	return response, nil
+}
@@ -0,0 +1,864 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+const (
+	calDAVNamespace         = "urn:ietf:params:xml:ns:caldav"
+	calendarServerNamespace = "http://calendarserver.org/ns/"
+
+	// Path of the principal and the calendar home of every user, the calendars are below it
+	calDAVRoot = "/caldav/"
+
+	// Calendar of the personal list, the calendars of the workspaces are named by their ids
+	calDAVPersonal = "personal"
+
+	// Largest PROPFIND or REPORT body and largest task a client can PUT
+	maxDAVBodySize = 1 << 20
+
+	davStatusOK       = "HTTP/1.1 200 OK"
+	davStatusNotFound = "HTTP/1.1 404 Not Found"
+)
+
+// Methods of the principal, of a calendar and of a task resource
+var (
+	CalDAVHomeMethods     = []string{http.MethodOptions, echo.PROPFIND}
+	CalDAVCalendarMethods = []string{http.MethodOptions, echo.PROPFIND, echo.REPORT}
+	CalDAVObjectMethods   = []string{http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, echo.PROPFIND}
+)
+
+// Ids of the items a client can create, through CalDAV or a sync. The name of a task resource is its id followed by .ics
+var calDAVResourceId = regexp.MustCompile(`^[A-Za-z0-9@._-]{1,100}$`)
+
+var (
+	davResourceType            = xml.Name{Space: "DAV:", Local: "resourcetype"}
+	davDisplayName             = xml.Name{Space: "DAV:", Local: "displayname"}
+	davGetETag                 = xml.Name{Space: "DAV:", Local: "getetag"}
+	davGetContentType          = xml.Name{Space: "DAV:", Local: "getcontenttype"}
+	davCurrentUserPrincipal    = xml.Name{Space: "DAV:", Local: "current-user-principal"}
+	davPrincipalURL            = xml.Name{Space: "DAV:", Local: "principal-URL"}
+	davCurrentUserPrivilegeSet = xml.Name{Space: "DAV:", Local: "current-user-privilege-set"}
+	davSupportedReportSet      = xml.Name{Space: "DAV:", Local: "supported-report-set"}
+	calDAVCalendarHomeSet      = xml.Name{Space: calDAVNamespace, Local: "calendar-home-set"}
+	calDAVSupportedComponents  = xml.Name{Space: calDAVNamespace, Local: "supported-calendar-component-set"}
+	calDAVCalendarData         = xml.Name{Space: calDAVNamespace, Local: "calendar-data"}
+	calDAVCalendarQuery        = xml.Name{Space: calDAVNamespace, Local: "calendar-query"}
+	calDAVCalendarMultiget     = xml.Name{Space: calDAVNamespace, Local: "calendar-multiget"}
+	calendarServerCTag         = xml.Name{Space: calendarServerNamespace, Local: "getctag"}
+)
+
+// A property or a part of its value, written with the namespace of its name
+type davProperty struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr    `xml:",any,attr"`
+	Text     string        `xml:",chardata"`
+	Children []davProperty `xml:",any"`
+}
+
+func davElement(name xml.Name, children ...davProperty) davProperty {
+	return davProperty{XMLName: name, Children: children}
+}
+
+func davText(name xml.Name, text string) davProperty {
+	return davProperty{XMLName: name, Text: text}
+}
+
+func davHref(href string) davProperty {
+	return davText(xml.Name{Space: "DAV:", Local: "href"}, href)
+}
+
+type davPropList struct {
+	Props []davProperty `xml:",any"`
+}
+
+type davPropstat struct {
+	Prop   davPropList `xml:"prop"`
+	Status string      `xml:"status"`
+}
+
+type davResponse struct {
+	Href      string        `xml:"href"`
+	Propstats []davPropstat `xml:"propstat,omitempty"`
+	Status    string        `xml:"status,omitempty"`
+}
+
+type davMultistatus struct {
+	XMLName   xml.Name      `xml:"DAV: multistatus"`
+	Responses []davResponse `xml:"response"`
+}
+
+type davPropNames struct {
+	Names []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+type davTimeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+type davCompFilter struct {
+	Name        string          `xml:"name,attr"`
+	TimeRange   *davTimeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	CompFilters []davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type davFilter struct {
+	CompFilter davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// Body of a PROPFIND or a REPORT, a PROPFIND without a body or with allprop asks for every property
+type davRequest struct {
+	XMLName  xml.Name
+	PropName *struct{}     `xml:"DAV: propname"`
+	Prop     *davPropNames `xml:"DAV: prop"`
+	Hrefs    []string      `xml:"DAV: href"`
+	Filter   *davFilter    `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+func readDAVRequest(c echo.Context) (request davRequest, err error) {
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxDAVBodySize))
+	if err != nil || len(bytes.TrimSpace(body)) == 0 {
+		return request, err
+	}
+
+	return request, xml.Unmarshal(body, &request)
+}
+
+// A resource with all of its properties
+type davResource struct {
+	href  string
+	props []davProperty
+}
+
+// Response of the resource to a PROPFIND or a REPORT, the properties it does not have are listed as not found.
+// calendar-data is left out of allprop like RFC 4791 asks
+func (r davResource) response(request davRequest) davResponse {
+	found := davPropstat{Status: davStatusOK}
+	missing := davPropstat{Status: davStatusNotFound}
+
+	switch {
+	case request.PropName != nil:
+		for _, prop := range r.props {
+			found.Prop.Props = append(found.Prop.Props, davProperty{XMLName: prop.XMLName})
+		}
+	case request.Prop == nil:
+		for _, prop := range r.props {
+			if prop.XMLName != calDAVCalendarData {
+				found.Prop.Props = append(found.Prop.Props, prop)
+			}
+		}
+	default:
+		for _, name := range request.Prop.Names {
+			prop, ok := r.prop(name.XMLName)
+			if ok {
+				found.Prop.Props = append(found.Prop.Props, prop)
+			} else {
+				missing.Prop.Props = append(missing.Prop.Props, davProperty{XMLName: name.XMLName})
+			}
+		}
+	}
+
+	response := davResponse{Href: r.href}
+	if len(found.Prop.Props) > 0 || len(missing.Prop.Props) == 0 {
+		response.Propstats = append(response.Propstats, found)
+	}
+	if len(missing.Prop.Props) > 0 {
+		response.Propstats = append(response.Propstats, missing)
+	}
+
+	return response
+}
+
+func (r davResource) prop(name xml.Name) (davProperty, bool) {
+	for _, prop := range r.props {
+		if prop.XMLName == name {
+			return prop, true
+		}
+	}
+
+	return davProperty{}, false
+}
+
+func davMultiStatus(c echo.Context, responses []davResponse) error {
+	body, err := xml.Marshal(davMultistatus{Responses: responses})
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CALDAV_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.Blob(http.StatusMultiStatus, "application/xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
+
+func calDAVOptions(c echo.Context, methods []string) error {
+	c.Response().Header().Set("DAV", "1, 3, calendar-access")
+	c.Response().Header().Set(echo.HeaderAllow, strings.Join(methods, ", "))
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Depth of a PROPFIND, infinity is answered like 1
+func davDepth(c echo.Context) int {
+	if c.Request().Header.Get("Depth") == "0" {
+		return 0
+	}
+
+	return 1
+}
+
+func calendarHref(calendar string) string {
+	return calDAVRoot + url.PathEscape(calendar) + "/"
+}
+
+// The single task of a resource as iCalendar data with its entity tag. The tag is a hash of the data, it
+// changes with every change a client can see, labels and recurrence included
+func calDAVObject(item entity.TodoItem) (data, etag string) {
+	var out strings.Builder
+	exporter := newICalExporter(&out, "", false)
+	_ = exporter.begin()
+	_ = exporter.write(item)
+	_ = exporter.end()
+
+	hash := sha1.Sum([]byte(out.String()))
+
+	return out.String(), `"` + hex.EncodeToString(hash[:])[:16] + `"`
+}
+
+func calDAVObjectResource(calendar string, item entity.TodoItem) davResource {
+	data, etag := calDAVObject(item)
+
+	return davResource{
+		href: calendarHref(calendar) + url.PathEscape(item.Id) + ".ics",
+		props: []davProperty{
+			davElement(davResourceType),
+			davText(davGetETag, etag),
+			davText(davGetContentType, "text/calendar; charset=utf-8; component=VTODO"),
+			davText(calDAVCalendarData, data),
+		},
+	}
+}
+
+// Properties of a calendar, viewers of a workspace get a read only calendar
+func (as ApiService) calDAVCalendarResource(calendar, name, role, userId string) (davResource, error) {
+	workspaceId := calendar
+	if calendar == calDAVPersonal {
+		workspaceId = ""
+	}
+
+	ctag, err := as.R.InWorkspace(workspaceId).GetListChangeTag(userId)
+	if err != nil {
+		return davResource{}, err
+	}
+
+	privilege := func(local string) davProperty {
+		return davElement(xml.Name{Space: "DAV:", Local: "privilege"}, davElement(xml.Name{Space: "DAV:", Local: local}))
+	}
+	privileges := []davProperty{privilege("read")}
+	if roleAllows(role, entity.RoleEditor) {
+		privileges = append(privileges, privilege("write"), privilege("write-content"), privilege("bind"), privilege("unbind"))
+	}
+
+	report := func(name xml.Name) davProperty {
+		return davElement(xml.Name{Space: "DAV:", Local: "supported-report"}, davElement(xml.Name{Space: "DAV:", Local: "report"}, davElement(name)))
+	}
+
+	return davResource{
+		href: calendarHref(calendar),
+		props: []davProperty{
+			davElement(davResourceType, davElement(xml.Name{Space: "DAV:", Local: "collection"}), davElement(xml.Name{Space: calDAVNamespace, Local: "calendar"})),
+			davText(davDisplayName, name),
+			davElement(calDAVSupportedComponents, davProperty{XMLName: xml.Name{Space: calDAVNamespace, Local: "comp"}, Attrs: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: "VTODO"}}}),
+			davElement(davCurrentUserPrivilegeSet, privileges...),
+			davElement(davSupportedReportSet, report(calDAVCalendarQuery), report(calDAVCalendarMultiget)),
+			davText(calendarServerCTag, `"`+ctag+`"`),
+		},
+	}, nil
+}
+
+// Selects the workspace of the calendar of the request like WorkspaceScope does for a header, the calendars
+// of the workspaces the user is not a member of are not found
+func (as ApiService) calDAVScope(c echo.Context, userId string) (bool, error) {
+	calendar := c.Param("calendar")
+	if calendar == calDAVPersonal {
+		return true, nil
+	}
+
+	role, err := as.R.GetWorkspaceRole(calendar, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_WORKSPACE_ROLE_ERROR, err)
+		return false, c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if role == "" {
+		return false, c.String(http.StatusNotFound, constants.CALDAV_NOT_FOUND)
+	}
+
+	c.Set(workspaceKey, calendar)
+	c.Set(workspaceRoleKey, role)
+
+	return true, nil
+}
+
+// Role of the user on the calendar selected by calDAVScope
+func calDAVRole(c echo.Context) string {
+	if workspaceOf(c) == "" {
+		return entity.RoleOwner
+	}
+
+	role, _ := c.Get(workspaceRoleKey).(string)
+	return role
+}
+
+// Reports whether a calendar-query selects the item. Only the VTODO component and its time range are
+// checked, an item is in a time range when it is due within it
+func (f *davFilter) matches(item entity.TodoItem) (bool, error) {
+	if f == nil || len(f.CompFilter.CompFilters) == 0 {
+		return true, nil
+	}
+
+	for _, filter := range f.CompFilter.CompFilters {
+		if filter.Name != "VTODO" {
+			continue
+		}
+
+		if filter.TimeRange == nil {
+			return true, nil
+		}
+
+		due := item.Item.Details.DueDate
+		if filter.TimeRange.Start != "" {
+			start, err := time.Parse("20060102T150405Z", filter.TimeRange.Start)
+			if err != nil {
+				return false, err
+			}
+			if due.Before(start) {
+				return false, nil
+			}
+		}
+
+		if filter.TimeRange.End != "" {
+			end, err := time.Parse("20060102T150405Z", filter.TimeRange.End)
+			if err != nil {
+				return false, err
+			}
+			if !due.Before(end) {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// Checks If-Match and If-None-Match of a write against the entity tag of the resource, empty when the
+// resource does not exist
+func davPreconditionFails(c echo.Context, etag string) bool {
+	ifMatch := c.Request().Header.Get(HeaderIfMatch)
+	if ifMatch != "" && (etag == "" || noneMatch(ifMatch, etag)) {
+		return true
+	}
+
+	ifNoneMatch := c.Request().Header.Get(HeaderIfNoneMatch)
+	return ifNoneMatch != "" && etag != "" && !noneMatch(ifNoneMatch, etag)
+}
+
+// Labels to add and to remove to turn the labels of an item into the wanted ones
+func labelChanges(current, wanted []string) (add, remove []string) {
+	for _, label := range wanted {
+		if !containsString(current, label) && !containsString(add, label) {
+			add = append(add, label)
+		}
+	}
+
+	for _, label := range current {
+		if !containsString(wanted, label) {
+			remove = append(remove, label)
+		}
+	}
+
+	return add, remove
+}
+
+func calDAVUserId(c echo.Context) (string, bool) {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return "", false
+	}
+
+	return authentication.GetUserFromToken(token)
+}
+
+// Redirects CalDAV clients discovering the server to the principal
+func (as ApiService) CalDAVWellKnown(c echo.Context) error {
+	return c.Redirect(http.StatusMovedPermanently, calDAVRoot)
+}
+
+// The principal of the user, which is also the home of the calendars. The calendars are the personal list
+// and every workspace the user is a member of. CalDAV uses WebDAV methods that cannot be described in
+// Swagger, so the CalDAV handlers are not part of the API documentation
+func (as ApiService) CalDAVHome(c echo.Context) error {
+	if c.Request().Method == http.MethodOptions {
+		return calDAVOptions(c, CalDAVHomeMethods)
+	}
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	request, err := readDAVRequest(c)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	home := davResource{
+		href: calDAVRoot,
+		props: []davProperty{
+			davElement(davResourceType, davElement(xml.Name{Space: "DAV:", Local: "collection"}), davElement(xml.Name{Space: "DAV:", Local: "principal"})),
+			davText(davDisplayName, authentication.GetNameFromToken(token)),
+			davElement(davCurrentUserPrincipal, davHref(calDAVRoot)),
+			davElement(davPrincipalURL, davHref(calDAVRoot)),
+			davElement(calDAVCalendarHomeSet, davHref(calDAVRoot)),
+		},
+	}
+
+	responses := []davResponse{home.response(request)}
+	if davDepth(c) == 0 {
+		return davMultiStatus(c, responses)
+	}
+
+	workspaces, err := as.R.GetWorkspacesOfUser(userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CALDAV_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	calendars := []entity.Workspace{{Id: calDAVPersonal, Name: "Personal", Role: entity.RoleOwner}}
+	for _, workspace := range append(calendars, workspaces...) {
+		calendar, err := as.calDAVCalendarResource(workspace.Id, workspace.Name, workspace.Role, userId)
+		if err != nil {
+			errMessage := fmt.Sprintf(constants.CALDAV_ERROR, err)
+			return c.String(http.StatusInternalServerError, errMessage)
+		}
+
+		responses = append(responses, calendar.response(request))
+	}
+
+	return davMultiStatus(c, responses)
+}
+
+// A calendar with its tasks. PROPFIND lists the tasks with a depth of 1, a calendar-query REPORT lists the
+// tasks due in a time range and a calendar-multiget REPORT the tasks of a list of hrefs
+func (as ApiService) CalDAVCalendar(c echo.Context) error {
+	if c.Request().Method == http.MethodOptions {
+		return calDAVOptions(c, CalDAVCalendarMethods)
+	}
+
+	userId, ok := calDAVUserId(c)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.calDAVScope(c, userId)
+	if !allowed {
+		return err
+	}
+
+	request, err := readDAVRequest(c)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	calendar := c.Param("calendar")
+	var responses []davResponse
+
+	if c.Request().Method == echo.PROPFIND {
+		name := "Personal"
+		if calendar != calDAVPersonal {
+			workspaces, err := as.R.GetWorkspacesOfUser(userId)
+			if err != nil {
+				errMessage := fmt.Sprintf(constants.CALDAV_ERROR, err)
+				return c.String(http.StatusInternalServerError, errMessage)
+			}
+
+			for _, workspace := range workspaces {
+				if workspace.Id == calendar {
+					name = workspace.Name
+				}
+			}
+		}
+
+		resource, err := as.calDAVCalendarResource(calendar, name, calDAVRole(c), userId)
+		if err != nil {
+			errMessage := fmt.Sprintf(constants.CALDAV_ERROR, err)
+			return c.String(http.StatusInternalServerError, errMessage)
+		}
+
+		responses = append(responses, resource.response(request))
+		if davDepth(c) == 0 {
+			return davMultiStatus(c, responses)
+		}
+	}
+
+	switch {
+	case c.Request().Method == echo.PROPFIND || request.XMLName == calDAVCalendarQuery:
+		err = as.repo(c).ForEachListItem(userId, func(item entity.TodoItem) error {
+			matches, err := request.Filter.matches(item)
+			if matches {
+				responses = append(responses, calDAVObjectResource(calendar, item).response(request))
+			}
+
+			return err
+		})
+	case request.XMLName == calDAVCalendarMultiget:
+		responses, err = as.calDAVMultiget(c, userId, calendar, request)
+	default:
+		errMessage := fmt.Sprintf(constants.UNSUPPORTED_REPORT, request.XMLName.Local)
+		return c.String(http.StatusForbidden, errMessage)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CALDAV_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return davMultiStatus(c, responses)
+}
+
+// Responses of a calendar-multiget, the hrefs of other calendars and of missing tasks are not found
+func (as ApiService) calDAVMultiget(c echo.Context, userId, calendar string, request davRequest) ([]davResponse, error) {
+	ids := make([]string, len(request.Hrefs))
+	for i, href := range request.Hrefs {
+		if target, err := url.Parse(strings.TrimSpace(href)); err == nil && path.Dir(target.Path)+"/" == calendarHref(calendar) {
+			ids[i] = strings.TrimSuffix(path.Base(target.Path), ".ics")
+		}
+	}
+
+	items, err := as.repo(c).FindListItems(ids, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]davResponse, 0, len(request.Hrefs))
+	for i, href := range request.Hrefs {
+		item, ok := items[ids[i]]
+		if !ok || ids[i] == "" {
+			responses = append(responses, davResponse{Href: href, Status: davStatusNotFound})
+			continue
+		}
+
+		response := calDAVObjectResource(calendar, item).response(request)
+		response.Href = href
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+// A task of a calendar as an iCalendar object with a single VTODO. A PUT to a new name creates an item with
+// the name as its id, a PUT to an existing task updates the changed fields and a DELETE moves it to trash
+func (as ApiService) CalDAVObject(c echo.Context) error {
+	if c.Request().Method == http.MethodOptions {
+		return calDAVOptions(c, CalDAVObjectMethods)
+	}
+
+	userId, ok := calDAVUserId(c)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.calDAVScope(c, userId)
+	if !allowed {
+		return err
+	}
+
+	id, ok := strings.CutSuffix(c.Param("name"), ".ics")
+	if !ok || !calDAVResourceId.MatchString(id) {
+		return c.String(http.StatusNotFound, constants.CALDAV_NOT_FOUND)
+	}
+
+	items, err := as.repo(c).FindListItems([]string{id}, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CALDAV_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	item, exists := items[id]
+
+	switch c.Request().Method {
+	case http.MethodPut:
+		return as.putCalDAVObject(c, userId, id, item, exists)
+	case http.MethodDelete:
+		return as.deleteCalDAVObject(c, userId, item, exists)
+	}
+
+	if !exists {
+		return c.String(http.StatusNotFound, constants.CALDAV_NOT_FOUND)
+	}
+
+	if c.Request().Method == echo.PROPFIND {
+		request, err := readDAVRequest(c)
+		if err != nil {
+			errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+			return c.String(http.StatusBadRequest, errMessage)
+		}
+
+		return davMultiStatus(c, []davResponse{calDAVObjectResource(c.Param("calendar"), item).response(request)})
+	}
+
+	data, etag := calDAVObject(item)
+	c.Response().Header().Set(HeaderETag, etag)
+
+	if !noneMatch(c.Request().Header.Get(HeaderIfNoneMatch), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", []byte(data))
+}
+
+// Creates or updates the item of a task. The stored task differs from the one sent, e.g. in its UID, so no
+// entity tag is returned and clients fetch the task again
+func (as ApiService) putCalDAVObject(c echo.Context, userId, id string, item entity.TodoItem, exists bool) error {
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxDAVBodySize))
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	calendar, err := parseICal(string(body))
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_CALENDAR_DATA, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	todo, err := parseICalTodo(calendar)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_CALENDAR_DATA, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	etag := ""
+	if exists {
+		_, etag = calDAVObject(item)
+	}
+
+	if davPreconditionFails(c, etag) {
+		return c.String(http.StatusPreconditionFailed, constants.ITEM_VERSION_MISMATCH)
+	}
+
+	if utf8.RuneCountInString(todo.item.Name) < 3 {
+		errMessage := fmt.Sprintf(constants.INVALID_CALENDAR_DATA, errors.New("SUMMARY must be at least 3 characters"))
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	if err := validate.Var(todo.labels, "max=20,dive,required,max=50"); err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_CALENDAR_DATA, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	if err := validate.Var(todo.recurrence, "max=200"); err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_CALENDAR_DATA, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	if !exists {
+		return as.createCalDAVObject(c, userId, id, todo)
+	}
+
+	return as.updateCalDAVObject(c, userId, item, todo)
+}
+
+// Creates the item of a new task, a task without a due date is due at the end of the day
+func (as ApiService) createCalDAVObject(c echo.Context, userId, id string, todo icalTodo) error {
+	allowed, err := as.authorizeCreate(c)
+	if !allowed {
+		return err
+	}
+
+	if !as.statusTransitions().CanTransition(entity.StatusTodo, todo.status) {
+		errMessage := fmt.Sprintf(constants.INVALID_STATUS_TRANSITION, entity.StatusTodo, todo.status)
+		return c.String(http.StatusConflict, errMessage)
+	}
+
+	if todo.item.Details.DueDate.IsZero() {
+		now := time.Now().UTC()
+		todo.item.Details.DueDate = time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC)
+	}
+
+	_, err = as.withRevision(as.repo(c), userId, entity.RevisionCreated, func(r ApiRepository) (entity.TodoItem, error) {
+		item, err := r.CreateTodoItemWithId(id, &todo.item, userId)
+		if err != nil {
+			return item, err
+		}
+
+		if todo.status != entity.StatusTodo {
+			if item, err = r.SetItemStatus(item.Id, todo.status, item.Version); err != nil {
+				return item, err
+			}
+		}
+
+		if len(todo.labels) > 0 {
+			if item, err = r.SetItemLabels(item.Id, todo.labels, nil); err != nil {
+				return item, err
+			}
+		}
+
+		if todo.recurrence != "" {
+			item, err = r.SetItemRecurrence(item.Id, todo.recurrence)
+		}
+
+		return item, err
+	})
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CREATE_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+// Updates the fields of the item that changed in the task. A task without a due date keeps the due date
+// of the item and a blocked item stays blocked while the task needs action
+func (as ApiService) updateCalDAVObject(c echo.Context, userId string, item entity.TodoItem, todo icalTodo) error {
+	allowed, err := as.authorizeItem(c, item.Id, userId, entity.RoleEditor)
+	if !allowed {
+		return err
+	}
+
+	if todo.item.Details.DueDate.IsZero() {
+		todo.item.Details.DueDate = item.Item.Details.DueDate
+	}
+
+	if todo.status == entity.StatusTodo && item.Status == entity.StatusBlocked {
+		todo.status = entity.StatusBlocked
+	}
+
+	if !as.statusTransitions().CanTransition(item.Status, todo.status) {
+		errMessage := fmt.Sprintf(constants.INVALID_STATUS_TRANSITION, item.Status, todo.status)
+		return c.String(http.StatusConflict, errMessage)
+	}
+
+	if todo.status != item.Status && waitsOnBlockers(item, todo.status) {
+		return c.String(http.StatusConflict, constants.ITEM_BLOCKED)
+	}
+
+	changes := changedItemColumns(item.Item, todo.item)
+	add, remove := labelChanges(item.Labels, todo.labels)
+	statusChanged := todo.status != item.Status
+	recurrenceChanged := todo.recurrence != item.Recurrence
+
+	if len(changes) == 0 && len(add) == 0 && len(remove) == 0 && !statusChanged && !recurrenceChanged {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	action := entity.RevisionUpdated
+	if len(changes) == 0 && len(add) == 0 && len(remove) == 0 && !recurrenceChanged {
+		action = entity.RevisionStatusChanged
+	}
+
+	_, err = as.withRevision(as.repo(c), userId, action, func(r ApiRepository) (current entity.TodoItem, err error) {
+		current = item
+
+		if len(changes) > 0 {
+			if current, err = r.PatchTodoItem(item.Id, changes, current.Version); err != nil {
+				return current, err
+			}
+		}
+
+		if statusChanged {
+			if current, err = r.SetItemStatus(item.Id, todo.status, current.Version); err != nil {
+				return current, err
+			}
+		}
+
+		if len(add) > 0 || len(remove) > 0 {
+			if current, err = r.SetItemLabels(item.Id, add, remove); err != nil {
+				return current, err
+			}
+		}
+
+		if recurrenceChanged {
+			current, err = r.SetItemRecurrence(item.Id, todo.recurrence)
+		}
+
+		return current, err
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return c.String(http.StatusPreconditionFailed, constants.ITEM_VERSION_MISMATCH)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.UPDATE_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Moves the item of a task to trash, like deleting it through the API only its owner can
+func (as ApiService) deleteCalDAVObject(c echo.Context, userId string, item entity.TodoItem, exists bool) error {
+	if !exists {
+		return c.String(http.StatusNotFound, constants.CALDAV_NOT_FOUND)
+	}
+
+	_, etag := calDAVObject(item)
+	if davPreconditionFails(c, etag) {
+		return c.String(http.StatusPreconditionFailed, constants.ITEM_VERSION_MISMATCH)
+	}
+
+	allowed, err := as.authorizeItem(c, item.Id, userId, entity.RoleOwner)
+	if !allowed {
+		return err
+	}
+
+	_, err = as.withRevision(as.repo(c), userId, entity.RevisionDeleted, func(r ApiRepository) (entity.TodoItem, error) {
+		if err := r.DeleteTodoItem(item.Id, item.Version); err != nil {
+			return entity.TodoItem{}, err
+		}
+
+		return r.FindItemById(item.Id)
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return c.String(http.StatusPreconditionFailed, constants.ITEM_VERSION_MISMATCH)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.DELETE_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestCalDAV(t *testing.T) {
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	item := entity.TodoItem{
+		Id:        "3a35452e-957c-4588-8d40-c88f370067d2",
+		Item:      entity.TodoItemDto{Name: "Pay rent", Details: entity.TodoItemDetailsDto{DueDate: createdAt, Priority: "HIGH"}},
+		Status:    entity.StatusTodo,
+		Version:   1,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+
+	t.Run("Test Requested properties", func(t *testing.T) {
+		var request davRequest
+		err := xml.Unmarshal([]byte(`<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:a="http://apple.com/ns/ical/">
+			<d:prop><d:getetag/><c:calendar-data/><a:calendar-color/></d:prop></d:propfind>`), &request)
+		assert.NoError(t, err)
+
+		response := calDAVObjectResource("personal", item).response(request)
+
+		assert.Equal(t, "/caldav/personal/3a35452e-957c-4588-8d40-c88f370067d2.ics", response.Href)
+		assert.Len(t, response.Propstats, 2)
+		assert.Equal(t, davStatusOK, response.Propstats[0].Status)
+		assert.Equal(t, davGetETag, response.Propstats[0].Prop.Props[0].XMLName)
+		assert.Contains(t, response.Propstats[0].Prop.Props[1].Text, "BEGIN:VTODO")
+		assert.Equal(t, davStatusNotFound, response.Propstats[1].Status)
+		assert.Equal(t, xml.Name{Space: "http://apple.com/ns/ical/", Local: "calendar-color"}, response.Propstats[1].Prop.Props[0].XMLName)
+	})
+
+	t.Run("Test All properties leave out the calendar data", func(t *testing.T) {
+		response := calDAVObjectResource("personal", item).response(davRequest{})
+
+		assert.Len(t, response.Propstats, 1)
+		for _, prop := range response.Propstats[0].Prop.Props {
+			assert.NotEqual(t, calDAVCalendarData, prop.XMLName)
+		}
+	})
+
+	t.Run("Test Multistatus", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(echo.PROPFIND, "/caldav/", nil), rec)
+
+		resource := davResource{href: "/caldav/", props: []davProperty{davElement(calDAVCalendarHomeSet, davHref("/caldav/"))}}
+		err := davMultiStatus(ctx, []davResponse{resource.response(davRequest{})})
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<multistatus xmlns="DAV:"><response><href>/caldav/</href><propstat><prop>`+
+			`<calendar-home-set xmlns="urn:ietf:params:xml:ns:caldav"><href xmlns="DAV:">/caldav/</href></calendar-home-set>`+
+			`</prop><status>HTTP/1.1 200 OK</status></propstat></response></multistatus>`)
+	})
+
+	t.Run("Test Entity tag changes with the labels", func(t *testing.T) {
+		_, etag := calDAVObject(item)
+		_, same := calDAVObject(item)
+		assert.Equal(t, etag, same)
+
+		labelled := item
+		labelled.Labels = []string{"home"}
+		_, changed := calDAVObject(labelled)
+		assert.NotEqual(t, etag, changed)
+	})
+
+	t.Run("Test Time range filter", func(t *testing.T) {
+		var request davRequest
+		err := xml.Unmarshal([]byte(`<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+			<c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO">
+			<c:time-range start="20231015T000000Z" end="20231016T000000Z"/>
+			</c:comp-filter></c:comp-filter></c:filter></c:calendar-query>`), &request)
+		assert.NoError(t, err)
+		assert.Equal(t, calDAVCalendarQuery, request.XMLName)
+
+		matches, err := request.Filter.matches(item)
+		assert.NoError(t, err)
+		assert.True(t, matches)
+
+		later := item
+		later.Item.Details.DueDate = createdAt.AddDate(0, 0, 1)
+		matches, err = request.Filter.matches(later)
+		assert.NoError(t, err)
+		assert.False(t, matches)
+
+		events := &davFilter{CompFilter: davCompFilter{Name: "VCALENDAR", CompFilters: []davCompFilter{{Name: "VEVENT"}}}}
+		matches, err = events.matches(item)
+		assert.NoError(t, err)
+		assert.False(t, matches)
+
+		var all *davFilter
+		matches, err = all.matches(item)
+		assert.NoError(t, err)
+		assert.True(t, matches)
+	})
+
+	t.Run("Test Preconditions", func(t *testing.T) {
+		e := echo.New()
+		newContext := func(header, value string) echo.Context {
+			req := httptest.NewRequest(http.MethodPut, "/caldav/personal/a.ics", nil)
+			req.Header.Set(header, value)
+			return e.NewContext(req, httptest.NewRecorder())
+		}
+
+		assert.False(t, davPreconditionFails(newContext(HeaderIfMatch, `"abc"`), `"abc"`))
+		assert.True(t, davPreconditionFails(newContext(HeaderIfMatch, `"abc"`), `"def"`))
+		assert.True(t, davPreconditionFails(newContext(HeaderIfMatch, `*`), ""))
+		assert.False(t, davPreconditionFails(newContext(HeaderIfNoneMatch, `*`), ""))
+		assert.True(t, davPreconditionFails(newContext(HeaderIfNoneMatch, `*`), `"abc"`))
+	})
+
+	t.Run("Test Label changes", func(t *testing.T) {
+		add, remove := labelChanges([]string{"home", "bills"}, []string{"bills", "urgent", "urgent"})
+
+		assert.Equal(t, []string{"urgent"}, add)
+		assert.Equal(t, []string{"home"}, remove)
+	})
+
+	t.Run("Test Resource names", func(t *testing.T) {
+		assert.True(t, calDAVResourceId.MatchString("3a35452e-957c-4588-8d40-c88f370067d2"))
+		assert.True(t, calDAVResourceId.MatchString("task@example.com"))
+		assert.False(t, calDAVResourceId.MatchString("x'; DROP TABLE todo_items; --"))
+		assert.False(t, calDAVResourceId.MatchString(strings.Repeat("a", 101)))
+	})
+}
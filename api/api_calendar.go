@@ -0,0 +1,164 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Number of random bytes of a calendar feed token
+const calendarTokenBytes = 32
+
+// Random token of a calendar feed, safe to use in a URL
+func newCalendarToken() (string, error) {
+	token := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// Only the hash of a token is stored, a leaked database does not give away the feeds
+func calendarTokenHash(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// Creates the calendar feed of the list, a feed that already exists gets a new token
+// @Summary creates the calendar feed of the todo items
+// @Description Returns the secret URL of an iCalendar feed of the personal list of the user, or of the workspace selected by the X-Workspace-Id header. Calendar clients poll the URL without a token. The token is only shown once, creating the feed again replaces the token and the previous URL stops working.
+// @Tags Import and export
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param X-Workspace-Id header string false "Workspace of the feed"
+// @Success 201 {object} entity.CalendarFeedResponse
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.CALENDAR_FEED_ERROR
+// @Router /item/calendar [post]
+func (as ApiService) CreateCalendarFeed(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	feedToken, err := newCalendarToken()
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CALENDAR_FEED_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	feed, err := as.repo(c).CreateCalendarFeed(userId, calendarTokenHash(feedToken))
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CALENDAR_FEED_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, entity.CalendarFeedResponse{
+		Url:         c.Scheme() + "://" + c.Request().Host + "/calendar/" + feedToken + ".ics",
+		Token:       feedToken,
+		WorkspaceId: feed.WorkspaceId,
+		CreatedAt:   feed.CreatedAt,
+	}, " ")
+}
+
+// Deletes the calendar feed of the list, the URL stops working
+// @Summary deletes the calendar feed of the todo items
+// @Tags Import and export
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param X-Workspace-Id header string false "Workspace of the feed"
+// @Success 200 string constants.DELETE_CALENDAR_FEED_SUCCESSFULL
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.CALENDAR_FEED_NOT_FOUND
+// @Failure 500 string constants.CALENDAR_FEED_ERROR
+// @Router /item/calendar [delete]
+func (as ApiService) DeleteCalendarFeed(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	err := as.repo(c).DeleteCalendarFeed(userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.CALENDAR_FEED_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CALENDAR_FEED_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.String(http.StatusOK, constants.DELETE_CALENDAR_FEED_SUCCESSFULL)
+}
+
+// Serves the calendar feed of a token, the token in the URL is the only credential
+// @Summary gets the calendar feed of the todo items
+// @Description An iCalendar file with a VTODO for each item of the list of the feed, and with events a VEVENT at the due date of each open item. The feed of a workspace stops working when the user leaves the workspace.
+// @Tags Import and export
+// @Produce text/calendar
+// @Param token path string true "feed token followed by .ics"
+// @Param events query bool false "Add the due dates as events"
+// @Success 200 {file} file
+// @Failure 404 string constants.CALENDAR_FEED_NOT_FOUND
+// @Failure 500 string constants.EXPORT_ITEMS_ERROR
+// @Router /calendar/{token} [get]
+func (as ApiService) GetCalendarFeed(c echo.Context) error {
+	feedToken, ok := strings.CutSuffix(c.Param("token"), ".ics")
+	if !ok || feedToken == "" {
+		return c.String(http.StatusNotFound, constants.CALENDAR_FEED_NOT_FOUND)
+	}
+
+	feed, err := as.R.FindCalendarFeed(calendarTokenHash(feedToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.CALENDAR_FEED_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.EXPORT_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if feed.WorkspaceId != "" {
+		role, err := as.R.GetWorkspaceRole(feed.WorkspaceId, feed.UserId)
+		if err != nil {
+			errMessage := fmt.Sprintf(constants.GET_WORKSPACE_ROLE_ERROR, err)
+			return c.String(http.StatusInternalServerError, errMessage)
+		}
+
+		if role == "" {
+			return c.String(http.StatusNotFound, constants.CALENDAR_FEED_NOT_FOUND)
+		}
+	}
+
+	events, _ := strconv.ParseBool(c.QueryParam("events"))
+	exporter := newICalExporter(c.Response(), "Todo items", events)
+
+	return streamItems(c, as.R.InWorkspace(feed.WorkspaceId), feed.UserId, exporter)
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Nests the replies under their parent comments, oldest first. Deleted comments lose their body
+// and are kept only as long as they have replies, so a thread is never cut in the middle
+func commentThreads(comments []entity.Comment) []entity.Comment {
+	ids := map[string]bool{}
+	for _, comment := range comments {
+		ids[comment.Id] = true
+	}
+
+	replies := map[string][]entity.Comment{}
+	roots := []entity.Comment{}
+	for _, comment := range comments {
+		if comment.ParentId == "" || !ids[comment.ParentId] {
+			roots = append(roots, comment)
+			continue
+		}
+
+		replies[comment.ParentId] = append(replies[comment.ParentId], comment)
+	}
+
+	var thread func(comments []entity.Comment) []entity.Comment
+	thread = func(comments []entity.Comment) []entity.Comment {
+		threaded := []entity.Comment{}
+		for _, comment := range comments {
+			comment.Replies = thread(replies[comment.Id])
+
+			if comment.IsDeleted {
+				if len(comment.Replies) == 0 {
+					continue
+				}
+				comment.Body = ""
+			}
+
+			threaded = append(threaded, comment)
+		}
+
+		return threaded
+	}
+
+	return thread(roots)
+}
+
+// Lists the comments of an item as threads
+// @Summary lists the comments of a todo item
+// @Tags Comment
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Success 200 {array} entity.Comment
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 500 string constants.GET_COMMENTS_ERROR
+// @Router /item/{id}/comments [get]
+func (as ApiService) GetComments(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleViewer)
+	if !allowed {
+		return err
+	}
+
+	comments, err := as.repo(c).GetItemComments(param)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_COMMENTS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, commentThreads(comments), " ")
+}
+
+// Adds a comment or a reply to a comment on an item
+// @Summary adds a comment to a todo item
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param dto body entity.CommentDto true "Comment"
+// @Success 201 {object} entity.Comment
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 404 string constants.COMMENT_NOT_FOUND
+// @Failure 500 string constants.CREATE_COMMENT_ERROR
+// @Router /item/{id}/comments [post]
+func (as ApiService) CreateComment(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	comment := new(entity.CommentDto)
+	if err := c.Bind(comment); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(comment)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleViewer)
+	if !allowed {
+		return err
+	}
+
+	if comment.ParentId != "" {
+		_, err := as.repo(c).FindCommentById(param, comment.ParentId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, constants.COMMENT_NOT_FOUND)
+		}
+
+		if err != nil {
+			errMessage := fmt.Sprintf(constants.CREATE_COMMENT_ERROR, err)
+			return c.String(http.StatusInternalServerError, errMessage)
+		}
+	}
+
+	created, err := as.repo(c).CreateComment(param, userId, comment)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CREATE_COMMENT_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, created, " ")
+}
+
+// Finds a comment of an item which the current user is allowed to change
+func (as ApiService) authoredComment(c echo.Context, userId string) (entity.Comment, error) {
+	param := c.Param("id")
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleViewer)
+	if !allowed {
+		return entity.Comment{}, err
+	}
+
+	comment, err := as.repo(c).FindCommentById(param, c.Param("commentId"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return comment, c.String(http.StatusNotFound, constants.COMMENT_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_COMMENTS_ERROR, err)
+		return comment, c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if comment.UserId != userId {
+		return comment, c.String(http.StatusForbidden, constants.NOT_COMMENT_AUTHOR)
+	}
+
+	return comment, nil
+}
+
+// Edits a comment of the current user
+// @Summary edits a comment on a todo item
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param commentId path string true "comment Id"
+// @Param dto body entity.CommentBodyDto true "New body"
+// @Success 200 {object} entity.Comment
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.NOT_COMMENT_AUTHOR
+// @Failure 404 string constants.COMMENT_NOT_FOUND
+// @Failure 500 string constants.UPDATE_COMMENT_ERROR
+// @Router /item/{id}/comments/{commentId} [patch]
+func (as ApiService) UpdateCommentById(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	u := new(entity.CommentBodyDto)
+	if err := c.Bind(u); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(u)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	comment, err := as.authoredComment(c, userId)
+	if err != nil || c.Response().Committed {
+		return err
+	}
+
+	updated, err := as.repo(c).UpdateComment(comment.Id, u.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.COMMENT_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.UPDATE_COMMENT_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, updated, " ")
+}
+
+// Deletes a comment of the current user, the replies to it are kept
+// @Summary deletes a comment on a todo item
+// @Tags Comment
+// @Produce plain
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param commentId path string true "comment Id"
+// @Success 200 string constants.DELETE_COMMENT_SUCCESSFULL
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.NOT_COMMENT_AUTHOR
+// @Failure 404 string constants.COMMENT_NOT_FOUND
+// @Failure 500 string constants.DELETE_COMMENT_ERROR
+// @Router /item/{id}/comments/{commentId} [delete]
+func (as ApiService) DeleteCommentById(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	comment, err := as.authoredComment(c, userId)
+	if err != nil || c.Response().Committed {
+		return err
+	}
+
+	if err := as.repo(c).DeleteComment(comment.Id); err != nil {
+		errMessage := fmt.Sprintf(constants.DELETE_COMMENT_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.String(http.StatusOK, constants.DELETE_COMMENT_SUCCESSFULL)
+}
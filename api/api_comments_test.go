@@ -0,0 +1,38 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestComments(t *testing.T) {
+	t.Run("Test Comment threads", func(t *testing.T) {
+		comments := []entity.Comment{
+			{Id: "1", Body: "first"},
+			{Id: "2", ParentId: "1", Body: "reply"},
+			{Id: "3", Body: "deleted", IsDeleted: true},
+			{Id: "4", ParentId: "3", Body: "reply to deleted"},
+			{Id: "5", Body: "deleted without replies", IsDeleted: true},
+			{Id: "6", ParentId: "2", Body: "nested reply"},
+			{Id: "7", ParentId: "missing", Body: "orphan"},
+		}
+
+		threads := commentThreads(comments)
+
+		assert.Len(t, threads, 3)
+		assert.Equal(t, "1", threads[0].Id)
+		assert.Equal(t, "2", threads[0].Replies[0].Id)
+		assert.Equal(t, "6", threads[0].Replies[0].Replies[0].Id)
+		assert.Equal(t, "3", threads[1].Id)
+		assert.Empty(t, threads[1].Body)
+		assert.Equal(t, "reply to deleted", threads[1].Replies[0].Body)
+		assert.Equal(t, "7", threads[2].Id)
+	})
+
+	t.Run("Test No comments", func(t *testing.T) {
+		assert.Equal(t, []entity.Comment{}, commentThreads(nil))
+	})
+}
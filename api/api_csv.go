@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+
+	"todo-project/entity"
+)
+
+// Columns of an exported CSV file, an exported file can be imported again without a mapping
+var csvExportColumns = []string{"name", "description", "due_date", "priority", "status", "created_at", "updated_at", "started_at", "completed_at"}
+
+// Fields of an item that can be imported, all but status are required
+var csvImportFields = []string{"name", "description", "due_date", "priority", "status"}
+
+// Writes the items as CSV, the first row names the columns
+type csvExporter struct {
+	writer *csv.Writer
+}
+
+func (e *csvExporter) contentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (e *csvExporter) fileName() string {
+	return "items.csv"
+}
+
+func (e *csvExporter) begin() error {
+	return e.writer.Write(csvExportColumns)
+}
+
+func (e *csvExporter) write(item entity.TodoItem) error {
+	return e.writer.Write(csvExportRow(item))
+}
+
+func (e *csvExporter) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExporter) end() error {
+	return e.flush()
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func csvExportRow(item entity.TodoItem) []string {
+	createdAt, updatedAt := item.CreatedAt, item.UpdatedAt
+
+	return []string{
+		item.Item.Name,
+		item.Item.Details.Description,
+		item.Item.Details.DueDate.UTC().Format(time.RFC3339),
+		item.Item.Details.Priority,
+		item.Status,
+		csvTime(&createdAt),
+		csvTime(&updatedAt),
+		csvTime(item.StartedAt),
+		csvTime(item.CompletedAt),
+	}
+}
+
+// Position of the column of each field in the header. The mapping names the column of a field, the
+// fields that are not mapped are read from the column with their own name
+func csvImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	positions := map[string]int{}
+	for i, column := range header {
+		positions[strings.TrimSpace(column)] = i
+	}
+
+	for field := range mapping {
+		known := false
+		for _, f := range csvImportFields {
+			known = known || f == field
+		}
+
+		if !known {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+	}
+
+	columns := map[string]int{}
+	for _, field := range csvImportFields {
+		column, mapped := mapping[field]
+		if !mapped {
+			column = field
+		}
+
+		position, ok := positions[column]
+		if ok {
+			columns[field] = position
+			continue
+		}
+
+		if mapped || field != "status" {
+			return nil, fmt.Errorf("no column %q for %s", column, field)
+		}
+	}
+
+	return columns, nil
+}
+
+// Due dates are times like 2023-10-15T09:38:24Z, or 2023-10-15 09:38 and 2023-10-15 in location. A date
+// without a time is due at the end of the day
+func parseCSVDueDate(value string, location *time.Location) (time.Time, error) {
+	if due, err := time.Parse(time.RFC3339, value); err == nil {
+		return due, nil
+	}
+
+	if due, err := time.ParseInLocation("2006-01-02 15:04", value, location); err == nil {
+		return due, nil
+	}
+
+	if day, err := time.ParseInLocation("2006-01-02", value, location); err == nil {
+		return time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 59, 0, location), nil
+	}
+
+	return time.Time{}, errors.New("due_date must be a date like 2023-10-15 or 2023-10-15T09:38:24Z")
+}
+
+// Item of a row of an import, checked with the rules of a created item
+func csvImportItem(record []string, columns map[string]int, location *time.Location) (entity.TodoItem, error) {
+	value := func(field string) string {
+		position, ok := columns[field]
+		if !ok || position >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[position])
+	}
+
+	item := entity.TodoItem{Status: entity.StatusTodo}
+	item.Item.Name = value("name")
+	item.Item.Details.Description = value("description")
+	item.Item.Details.Priority = strings.ToUpper(value("priority"))
+
+	if status := value("status"); status != "" {
+		if !isStatus(status) {
+			return item, fmt.Errorf("invalid status %q", status)
+		}
+		item.Status = status
+	}
+
+	due, err := parseCSVDueDate(value("due_date"), location)
+	if err != nil {
+		return item, err
+	}
+	item.Item.Details.DueDate = due.UTC()
+
+	return item, validator.New().Struct(item.Item)
+}
+
+// Reads the rows of a CSV file, the first row names the columns. Rows are numbered like in a spreadsheet, the
+// first item is row 2
+func readCSVItems(file io.Reader, mapping map[string]string, location *time.Location) (result entity.ImportResultDto, items []entity.TodoItem, err error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return result, nil, fmt.Errorf("cannot read the header: %v", err)
+	}
+
+	columns, err := csvImportColumns(header, mapping)
+	if err != nil {
+		return result, nil, importMappingError{err}
+	}
+
+	result.Errors = []entity.ImportRowError{}
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		result.Rows++
+		if result.Rows > maxImportRows {
+			return result, nil, errTooManyRows
+		}
+
+		if err != nil {
+			result.Errors = append(result.Errors, entity.ImportRowError{Row: row, Error: err.Error()})
+			continue
+		}
+
+		item, err := csvImportItem(record, columns, location)
+		if err != nil {
+			result.Errors = append(result.Errors, entity.ImportRowError{Row: row, Error: err.Error()})
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	return result, items, nil
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestCSV(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	t.Run("Test Export row", func(t *testing.T) {
+		createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+		item := entity.TodoItem{
+			Item:        entity.TodoItemDto{Name: "Pay rent", Details: entity.TodoItemDetailsDto{Description: "Flat, March", DueDate: createdAt, Priority: "HIGH"}},
+			Status:      entity.StatusDone,
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+			CompletedAt: &createdAt,
+		}
+
+		assert.Equal(t, []string{"Pay rent", "Flat, March", "2023-10-15T09:38:24Z", "HIGH", "done", "2023-10-15T09:38:24Z", "2023-10-15T09:38:24Z", "", "2023-10-15T09:38:24Z"}, csvExportRow(item))
+	})
+
+	t.Run("Test Import columns", func(t *testing.T) {
+		columns, err := csvImportColumns(csvExportColumns, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"name": 0, "description": 1, "due_date": 2, "priority": 3, "status": 4}, columns)
+
+		columns, err = csvImportColumns([]string{"Title", "Notes", "Due", "priority"}, map[string]string{"name": "Title", "description": "Notes", "due_date": "Due"})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"name": 0, "description": 1, "due_date": 2, "priority": 3}, columns)
+
+		_, err = csvImportColumns([]string{"name", "description", "due_date"}, nil)
+		assert.Error(t, err)
+
+		_, err = csvImportColumns(csvExportColumns, map[string]string{"status": "State"})
+		assert.Error(t, err)
+
+		_, err = csvImportColumns(csvExportColumns, map[string]string{"colour": "name"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Test Due dates", func(t *testing.T) {
+		cases := map[string]time.Time{
+			"2023-10-15T09:38:24Z": time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC),
+			"2023-10-15 09:38":     time.Date(2023, 10, 15, 9, 38, 0, 0, berlin),
+			"2023-10-15":           time.Date(2023, 10, 15, 23, 59, 59, 0, berlin),
+		}
+
+		for value, due := range cases {
+			got, err := parseCSVDueDate(value, berlin)
+			assert.NoError(t, err, value)
+			assert.True(t, due.Equal(got), value)
+		}
+
+		_, err := parseCSVDueDate("15.10.2023", berlin)
+		assert.Error(t, err)
+	})
+
+	t.Run("Test Import item", func(t *testing.T) {
+		columns := map[string]int{"name": 0, "description": 1, "due_date": 2, "priority": 3, "status": 4}
+
+		item, err := csvImportItem([]string{" Pay rent ", "Flat", "2023-10-15", "high", ""}, columns, time.UTC)
+		assert.NoError(t, err)
+		assert.Equal(t, "Pay rent", item.Item.Name)
+		assert.Equal(t, "HIGH", item.Item.Details.Priority)
+		assert.Equal(t, entity.StatusTodo, item.Status)
+
+		_, err = csvImportItem([]string{"Pay rent", "Flat", "2023-10-15", "urgent", ""}, columns, time.UTC)
+		assert.Error(t, err)
+
+		_, err = csvImportItem([]string{"Pay rent", "Flat", "2023-10-15", "HIGH", "later"}, columns, time.UTC)
+		assert.Error(t, err)
+
+		_, err = csvImportItem([]string{"Go", "Flat", "2023-10-15", "HIGH"}, columns, time.UTC)
+		assert.Error(t, err)
+
+		_, err = csvImportItem([]string{"Pay rent"}, columns, time.UTC)
+		assert.Error(t, err)
+	})
+}
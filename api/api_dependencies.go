@@ -0,0 +1,176 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Reports whether moving the item to the status has to wait for its blockers, only done does
+func waitsOnBlockers(item entity.TodoItem, status string) bool {
+	return status == entity.StatusDone && item.Blocked
+}
+
+// Lists the items an item waits on and the items waiting on it
+// @Summary lists the dependencies of a todo item
+// @Description Only the items the user can see are listed.
+// @Tags Dependencies
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Success 200 {object} entity.ItemDependencies
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 500 string constants.GET_DEPENDENCIES_ERROR
+// @Router /item/{id}/dependencies [get]
+func (as ApiService) GetItemDependencies(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleViewer)
+	if !allowed {
+		return err
+	}
+
+	dependencies, err := as.repo(c).GetItemDependencies(param, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_DEPENDENCIES_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, dependencies, " ")
+}
+
+// Makes an item wait on another item, the item is blocked until the blocker is done or cancelled
+// @Summary adds a blocker to a todo item
+// @Description The blocker must be visible to the user and in the same workspace. A blocker that already waits on the item, directly or through other items, is refused.
+// @Tags Dependencies
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param dto body entity.DependencyDto true "Blocker"
+// @Success 201 {object} entity.TodoItem
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_ROLE
+// @Failure 409 string constants.DEPENDENCY_CYCLE
+// @Failure 500 string constants.DEPENDENCY_ERROR
+// @Router /item/{id}/dependencies [post]
+func (as ApiService) AddItemDependency(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	dependency := new(entity.DependencyDto)
+	if err := c.Bind(dependency); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(dependency)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleEditor)
+	if !allowed {
+		return err
+	}
+
+	allowed, err = as.authorizeItem(c, dependency.BlockerId, userId, entity.RoleViewer)
+	if !allowed {
+		return err
+	}
+
+	item, err := as.repo(c).AddItemDependency(param, dependency.BlockerId)
+	if errors.Is(err, ErrDependencyCycle) {
+		return c.String(http.StatusConflict, constants.DEPENDENCY_CYCLE)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.DEPENDENCY_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusCreated, item, " ")
+}
+
+// Stops an item from waiting on one of its blockers
+// @Summary removes a blocker from a todo item
+// @Tags Dependencies
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param blockerId path string true "blocker item Id"
+// @Success 200 {object} entity.TodoItem
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_ROLE
+// @Failure 404 string constants.DEPENDENCY_NOT_FOUND
+// @Failure 500 string constants.DEPENDENCY_ERROR
+// @Router /item/{id}/dependencies/{blockerId} [delete]
+func (as ApiService) RemoveItemDependency(c echo.Context) error {
+	param := c.Param("id")
+	blockerId := c.Param("blockerId")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleEditor)
+	if !allowed {
+		return err
+	}
+
+	item, err := as.repo(c).RemoveItemDependency(param, blockerId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.DEPENDENCY_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.DEPENDENCY_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusOK, item, " ")
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestDependencies(t *testing.T) {
+	blocked := entity.TodoItem{Status: entity.StatusTodo, Blocked: true}
+
+	t.Run("Test Blocked items cannot be done", func(t *testing.T) {
+		assert.True(t, waitsOnBlockers(blocked, entity.StatusDone))
+	})
+
+	t.Run("Test Blocked items can change to other statuses", func(t *testing.T) {
+		assert.False(t, waitsOnBlockers(blocked, entity.StatusInProgress))
+		assert.False(t, waitsOnBlockers(blocked, entity.StatusCancelled))
+	})
+
+	t.Run("Test Items without open blockers can be done", func(t *testing.T) {
+		assert.False(t, waitsOnBlockers(entity.TodoItem{Status: entity.StatusTodo}, entity.StatusDone))
+	})
+}
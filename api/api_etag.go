@@ -0,0 +1,87 @@
+package api
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+var ErrInvalidIfMatch = errors.New(constants.INVALID_IF_MATCH)
+var ErrIfMatchRequired = errors.New(constants.IF_MATCH_REQUIRED)
+
+// Strong entity tag of a single item, its version followed by a digest of the serialized item so that
+// computed fields (blocked, comment count, watchers, rank, ...) change it without a version bump
+func itemETag(item entity.TodoItem) string {
+	return fmt.Sprintf(`"%d-%s"`, item.Version, digest(item))
+}
+
+// Weak entity tag of a list of items, changes whenever a serialized item of the list changes
+func listETag(items []entity.TodoItem) string {
+	return `W/"` + digest(items) + `"`
+}
+
+// Short sha1 digest of the JSON representation of a value
+func digest(value interface{}) string {
+	hash := sha1.New()
+	json.NewEncoder(hash).Encode(value)
+
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// Parses the If-Match header into the expected item version, 0 means any version.
+// The digest after the version is ignored, only the version guards against lost updates
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	if !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || len(header) < 2 {
+		return 0, ErrInvalidIfMatch
+	}
+
+	tag := header[1 : len(header)-1]
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		tag = tag[:i]
+	}
+
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= 0 {
+		return 0, ErrInvalidIfMatch
+	}
+
+	return version, nil
+}
+
+// Checks the If-None-Match header against an entity tag using the weak comparison
+func noneMatch(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return true
+	}
+
+	if header == "*" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return false
+		}
+	}
+
+	return true
+}
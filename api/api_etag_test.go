@@ -0,0 +1,85 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestETag(t *testing.T) {
+	t.Run("Test Item etag", func(t *testing.T) {
+		item := entity.TodoItem{Id: "a", Version: 3}
+		etag := itemETag(item)
+
+		assert.Regexp(t, `^"3-[0-9a-f]{16}"$`, etag)
+		assert.Equal(t, etag, itemETag(item))
+
+		item.CommentCount = 1
+		assert.NotEqual(t, etag, itemETag(item))
+	})
+
+	t.Run("Test Item etag changes when its blocker is completed", func(t *testing.T) {
+		dependent := entity.TodoItem{Id: "a", Version: 2, BlockedBy: []string{"b"}, Blocked: true}
+		etag := itemETag(dependent)
+
+		dependent.Blocked = false
+		assert.NotEqual(t, etag, itemETag(dependent))
+
+		version, err := parseIfMatch(itemETag(dependent))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, version)
+	})
+
+	t.Run("Test List etag", func(t *testing.T) {
+		items := []entity.TodoItem{{Id: "a", Version: 1}, {Id: "b", Version: 2}}
+		etag := listETag(items)
+
+		assert.Regexp(t, `^W/"[0-9a-f]{16}"$`, etag)
+		assert.Equal(t, etag, listETag(items))
+
+		items[1].Version = 3
+		assert.NotEqual(t, etag, listETag(items))
+
+		etag = listETag(items)
+		items[0].CommentCount = 1
+		assert.NotEqual(t, etag, listETag(items))
+
+		etag = listETag(items)
+		items[1].Blocked = true
+		assert.NotEqual(t, etag, listETag(items))
+	})
+
+	t.Run("Test Parse If-Match", func(t *testing.T) {
+		version, err := parseIfMatch(`"4"`)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, version)
+
+		version, err = parseIfMatch(`"4-0123456789abcdef"`)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, version)
+
+		version, err = parseIfMatch("*")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, version)
+
+		version, err = parseIfMatch("")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, version)
+
+		for _, header := range []string{`W/"4"`, `4`, `"abc"`, `"0"`, `"-4"`, `"1", "2"`, `"`} {
+			_, err = parseIfMatch(header)
+			assert.ErrorIs(t, err, ErrInvalidIfMatch, header)
+		}
+	})
+
+	t.Run("Test If-None-Match", func(t *testing.T) {
+		assert.True(t, noneMatch("", `"2"`))
+		assert.True(t, noneMatch(`"1"`, `"2"`))
+		assert.False(t, noneMatch(`"2"`, `"2"`))
+		assert.False(t, noneMatch(`W/"2"`, `"2"`))
+		assert.False(t, noneMatch(`"1", "2"`, `"2"`))
+		assert.False(t, noneMatch("*", `"2"`))
+	})
+}
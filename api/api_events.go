@@ -0,0 +1,386 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"golang.org/x/net/websocket"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+const (
+	// Postgres channel the logged events are notified on
+	ItemEventsChannel = "item_events"
+
+	HeaderLastEventId = "Last-Event-ID"
+
+	// Event sent first on a stream resuming after events that are no longer in the log, the client reloads its items
+	ItemEventReset = "reset"
+)
+
+const (
+	itemEventBatchSize = 100
+	itemEventHeartbeat = 25 * time.Second
+)
+
+// Streams of this instance, woken when an event they get is logged by any instance
+type EventHub struct {
+	mu          sync.Mutex
+	subscribers map[*EventSubscriber]bool
+}
+
+func NewEventHub() *EventHub {
+	return &EventHub{subscribers: map[*EventSubscriber]bool{}}
+}
+
+// Stream of a user, C gets a value when there may be new events in the log for the user. The workspaces are the
+// ones the user was a member of when the stream started
+type EventSubscriber struct {
+	UserId     string
+	C          chan struct{}
+	workspaces map[string]bool
+}
+
+func (s *EventSubscriber) gets(notice entity.ItemEventNotice) bool {
+	return containsString(notice.Recipients, s.UserId) || notice.WorkspaceId != "" && s.workspaces[notice.WorkspaceId]
+}
+
+func (s *EventSubscriber) wake() {
+	select {
+	case s.C <- struct{}{}:
+	default:
+	}
+}
+
+func (h *EventHub) Subscribe(userId string, workspaceIds []string) *EventSubscriber {
+	subscriber := &EventSubscriber{UserId: userId, C: make(chan struct{}, 1), workspaces: map[string]bool{}}
+	for _, workspaceId := range workspaceIds {
+		subscriber.workspaces[workspaceId] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[subscriber] = true
+
+	return subscriber
+}
+
+func (h *EventHub) Unsubscribe(subscriber *EventSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, subscriber)
+}
+
+// Wakes the streams getting the event of the notice
+func (h *EventHub) Publish(notice entity.ItemEventNotice) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscriber := range h.subscribers {
+		if subscriber.gets(notice) {
+			subscriber.wake()
+		}
+	}
+}
+
+func (h *EventHub) wakeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscriber := range h.subscribers {
+		subscriber.wake()
+	}
+}
+
+// Publishes the notifications of a Postgres listener until its channel is closed. A nil notification means
+// the listener reconnected and may have missed some, so every stream reads the log again
+func (h *EventHub) Listen(notifications <-chan *pq.Notification) {
+	for notification := range notifications {
+		if notification == nil {
+			h.wakeAll()
+			continue
+		}
+
+		var notice entity.ItemEventNotice
+		if err := json.Unmarshal([]byte(notification.Extra), &notice); err != nil {
+			log.Printf(constants.LISTEN_ITEM_EVENTS_ERROR, err)
+			h.wakeAll()
+			continue
+		}
+
+		h.Publish(notice)
+	}
+}
+
+// Id of the last event a client got, from the Last-Event-ID header an EventSource sends when it reconnects or
+// from the last_event_id query parameter. A client without one gets the events from now on
+func lastEventId(c echo.Context) (id int64, resume bool, err error) {
+	value := c.Request().Header.Get(HeaderLastEventId)
+	if value == "" {
+		value = c.QueryParam("last_event_id")
+	}
+
+	if value == "" {
+		return 0, false, nil
+	}
+
+	id, err = strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, fmt.Errorf(constants.INVALID_LAST_EVENT_ID, value)
+	}
+
+	return id, true, nil
+}
+
+// Events of a user read from the log as the hub wakes the stream
+type itemEventStream struct {
+	r          ApiRepository
+	hub        *EventHub
+	subscriber *EventSubscriber
+	lastId     int64
+	reset      bool
+}
+
+// Subscribes the user before reading where the stream starts so no event logged in between is missed. A stream
+// resuming after an event that was purged from the log, or that the log never had, starts with a reset
+func (as ApiService) openItemEventStream(userId string, lastId int64, resume bool) (*itemEventStream, error) {
+	workspaces, err := as.R.GetWorkspacesOfUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceIds := make([]string, len(workspaces))
+	for i, workspace := range workspaces {
+		workspaceIds[i] = workspace.Id
+	}
+
+	stream := &itemEventStream{r: as.R, hub: as.Events, lastId: lastId}
+	stream.subscriber = as.Events.Subscribe(userId, workspaceIds)
+
+	first, last, err := as.R.GetItemEventBounds()
+	if err != nil {
+		stream.close()
+		return nil, err
+	}
+
+	if !resume {
+		stream.lastId = last
+	} else if lastId > last || lastId < first-1 {
+		stream.lastId = last
+		stream.reset = true
+	}
+
+	return stream, nil
+}
+
+func (s *itemEventStream) close() {
+	s.hub.Unsubscribe(s.subscriber)
+}
+
+// Sends the events after the last one sent, in batches
+func (s *itemEventStream) flush(send func(entity.ItemEvent) error) error {
+	for {
+		events, err := s.r.GetItemEvents(s.subscriber.UserId, s.lastId, itemEventBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err := send(event); err != nil {
+				return err
+			}
+			s.lastId = event.Id
+		}
+
+		if len(events) < itemEventBatchSize {
+			return nil
+		}
+	}
+}
+
+// Sends the events of the user until the context is done. The log is also read after each heartbeat, so a
+// stream catches up even when a notification is lost
+func (s *itemEventStream) run(ctx context.Context, send func(entity.ItemEvent) error, heartbeat func() error) error {
+	if s.reset {
+		if err := send(entity.ItemEvent{Id: s.lastId, Event: ItemEventReset, CreatedAt: time.Now().UTC()}); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(itemEventHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		if err := s.flush(send); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.subscriber.C:
+		case <-ticker.C:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeServerSentEvent(w io.Writer, event entity.ItemEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Event, data)
+	return err
+}
+
+// Opens the stream of the user of the request, responding with an error when it cannot be opened
+func (as ApiService) itemEventStream(c echo.Context) (*itemEventStream, error) {
+	if as.Events == nil {
+		return nil, c.String(http.StatusNotFound, constants.EVENTS_DISABLED)
+	}
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return nil, c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return nil, c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	lastId, resume, err := lastEventId(c)
+	if err != nil {
+		return nil, c.String(http.StatusBadRequest, err.Error())
+	}
+
+	stream, err := as.openItemEventStream(userId, lastId, resume)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_ITEM_EVENTS_ERROR, err)
+		return nil, c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return stream, nil
+}
+
+// Streams the changes of the items of the user as Server-Sent Events
+// @Summary streams the changes of the items as Server-Sent Events
+// @Description Each change of an item the user owns, follows, is shared with or sees in a workspace is sent as an event named like item.created, item.updated, item.completed or item.deleted. The data is the event as JSON with the item after the change. A client reconnecting with Last-Event-ID gets the events it missed; when they are no longer in the log a reset event is sent first and the client reloads its items. The token can also be given as the access_token query parameter for EventSource.
+// @Tags Events
+// @Produce text/event-stream
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param Last-Event-ID header string false "Id of the last event the client got"
+// @Param last_event_id query string false "Id of the last event the client got"
+// @Success 200 {object} entity.ItemEvent
+// @Failure 400 string constants.INVALID_LAST_EVENT_ID
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.EVENTS_DISABLED
+// @Failure 500 string constants.GET_ITEM_EVENTS_ERROR
+// @Router /events [get]
+func (as ApiService) StreamItemEvents(c echo.Context) error {
+	stream, err := as.itemEventStream(c)
+	if stream == nil {
+		return err
+	}
+	defer stream.close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	send := func(event entity.ItemEvent) error {
+		if err := writeServerSentEvent(res, event); err != nil {
+			return err
+		}
+
+		res.Flush()
+		return nil
+	}
+
+	heartbeat := func() error {
+		if _, err := io.WriteString(res, ": ping\n\n"); err != nil {
+			return err
+		}
+
+		res.Flush()
+		return nil
+	}
+
+	if err := stream.run(c.Request().Context(), send, heartbeat); err != nil {
+		log.Printf(constants.GET_ITEM_EVENTS_ERROR, err)
+	}
+
+	return nil
+}
+
+// Streams the changes of the items of the user over a WebSocket
+// @Summary streams the changes of the items over a WebSocket
+// @Description Same events as /events, each sent as a JSON text message. A ping message is sent while there are no changes. Browsers give the token as the access_token query parameter and resume with last_event_id.
+// @Tags Events
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param last_event_id query string false "Id of the last event the client got"
+// @Success 101 {object} entity.ItemEvent
+// @Failure 400 string constants.INVALID_LAST_EVENT_ID
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.EVENTS_DISABLED
+// @Failure 500 string constants.GET_ITEM_EVENTS_ERROR
+// @Router /events/ws [get]
+func (as ApiService) StreamItemEventsWebSocket(c echo.Context) error {
+	stream, err := as.itemEventStream(c)
+	if stream == nil {
+		return err
+	}
+	defer stream.close()
+
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		ctx, cancel := context.WithCancel(c.Request().Context())
+		defer cancel()
+
+		// The client sends nothing, a failed read means it closed the connection
+		go func() {
+			var message string
+			for websocket.Message.Receive(ws, &message) == nil {
+			}
+			cancel()
+		}()
+
+		send := func(event entity.ItemEvent) error {
+			return websocket.JSON.Send(ws, event)
+		}
+
+		heartbeat := func() error {
+			return websocket.Message.Send(ws, `{"event":"ping"}`)
+		}
+
+		if err := stream.run(ctx, send, heartbeat); err != nil {
+			log.Printf(constants.GET_ITEM_EVENTS_ERROR, err)
+		}
+	}}
+
+	server.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestEvents(t *testing.T) {
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	workspace_id := "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"
+
+	woken := func(subscriber *EventSubscriber) bool {
+		select {
+		case <-subscriber.C:
+			return true
+		default:
+			return false
+		}
+	}
+
+	t.Run("Test Publish wakes the streams getting the event", func(t *testing.T) {
+		hub := NewEventHub()
+		owner := hub.Subscribe(user_id, nil)
+		member := hub.Subscribe("member", []string{workspace_id})
+		other := hub.Subscribe("other", nil)
+
+		hub.Publish(entity.ItemEventNotice{Id: 1, Recipients: []string{user_id}})
+		hub.Publish(entity.ItemEventNotice{Id: 2, Recipients: []string{user_id}})
+
+		assert.True(t, woken(owner))
+		assert.False(t, woken(owner))
+		assert.False(t, woken(member))
+		assert.False(t, woken(other))
+
+		hub.Publish(entity.ItemEventNotice{Id: 3, Recipients: []string{"author"}, WorkspaceId: workspace_id})
+
+		assert.False(t, woken(owner))
+		assert.True(t, woken(member))
+
+		hub.Unsubscribe(member)
+		hub.Publish(entity.ItemEventNotice{Id: 4, WorkspaceId: workspace_id})
+
+		assert.False(t, woken(member))
+	})
+
+	t.Run("Test Listen to notifications", func(t *testing.T) {
+		hub := NewEventHub()
+		owner := hub.Subscribe(user_id, nil)
+		other := hub.Subscribe("other", nil)
+
+		notifications := make(chan *pq.Notification, 3)
+		notifications <- &pq.Notification{Channel: ItemEventsChannel, Extra: `{"id":1,"recipients":["` + user_id + `"]}`}
+		close(notifications)
+
+		hub.Listen(notifications)
+
+		assert.True(t, woken(owner))
+		assert.False(t, woken(other))
+	})
+
+	t.Run("Test Listen wakes every stream after a reconnect", func(t *testing.T) {
+		hub := NewEventHub()
+		owner := hub.Subscribe(user_id, nil)
+		other := hub.Subscribe("other", nil)
+
+		notifications := make(chan *pq.Notification, 1)
+		notifications <- nil
+		close(notifications)
+
+		hub.Listen(notifications)
+
+		assert.True(t, woken(owner))
+		assert.True(t, woken(other))
+	})
+
+	t.Run("Test Last event id", func(t *testing.T) {
+		e := echo.New()
+		newContext := func(target, header string) echo.Context {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			if header != "" {
+				req.Header.Set(HeaderLastEventId, header)
+			}
+
+			return e.NewContext(req, httptest.NewRecorder())
+		}
+
+		_, resume, err := lastEventId(newContext("/events", ""))
+		assert.NoError(t, err)
+		assert.False(t, resume)
+
+		id, resume, err := lastEventId(newContext("/events?last_event_id=7", "42"))
+		assert.NoError(t, err)
+		assert.True(t, resume)
+		assert.Equal(t, int64(42), id)
+
+		id, _, err = lastEventId(newContext("/events/ws?last_event_id=7", ""))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), id)
+
+		_, _, err = lastEventId(newContext("/events", "latest"))
+		assert.EqualError(t, err, `Invalid Last-Event-ID "latest"`)
+	})
+
+	t.Run("Test Write a Server-Sent Event", func(t *testing.T) {
+		var body bytes.Buffer
+		event := entity.ItemEvent{Id: 42, Event: "item.completed", ItemId: "3a35452e", CreatedAt: time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)}
+
+		assert.NoError(t, writeServerSentEvent(&body, event))
+		assert.Equal(t, "id: 42\nevent: item.completed\ndata: {\"id\":42,\"event\":\"item.completed\",\"item_id\":\"3a35452e\",\"created_at\":\"2023-10-15T09:38:24Z\"}\n\n", body.String())
+	})
+}
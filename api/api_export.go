@@ -0,0 +1,346 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Number of exported items written before they are flushed to the client
+const exportFlushItems = 100
+
+// Writes the exported items of a list in a file format. begin is called before the first item and end
+// after the last one, flush sends the items written so far to the client
+type itemExporter interface {
+	contentType() string
+	fileName() string
+	begin() error
+	write(item entity.TodoItem) error
+	flush() error
+	end() error
+}
+
+// Exporter writing a format to w, nil when the format is not supported
+func exporterOf(w io.Writer, format string, events bool) itemExporter {
+	switch format {
+	case "", "csv":
+		return &csvExporter{writer: csv.NewWriter(w)}
+	case "ics":
+		return newICalExporter(w, "Todo items", events)
+	case "txt":
+		return &todoTxtExporter{writer: bufio.NewWriter(w)}
+	case "md":
+		return &markdownExporter{writer: bufio.NewWriter(w)}
+	}
+
+	return nil
+}
+
+// Exporter of a format, nil when the format is not supported
+func newItemExporter(c echo.Context, format string) (itemExporter, error) {
+	events, _ := strconv.ParseBool(c.QueryParam("events"))
+	if exporter := exporterOf(c.Response(), format, events); exporter != nil {
+		return exporter, nil
+	}
+
+	errMessage := fmt.Sprintf(constants.UNSUPPORTED_EXPORT_FORMAT, format)
+	return nil, c.String(http.StatusBadRequest, errMessage)
+}
+
+// Writes every item of the list of the user in a format, like an export without a client to stream to
+func ExportList(r ApiRepository, w io.Writer, userId, format string) error {
+	exporter := exporterOf(w, format, false)
+	if exporter == nil {
+		return fmt.Errorf(constants.UNSUPPORTED_EXPORT_FORMAT, format)
+	}
+
+	if err := exporter.begin(); err != nil {
+		return err
+	}
+
+	if err := r.ForEachListItem(userId, exporter.write); err != nil {
+		return err
+	}
+
+	return exporter.end()
+}
+
+// Streams the items of the list of the user, an error before the first item is sent is answered with a 500.
+// Once the first item is sent the status cannot change and the client sees a truncated file
+func streamItems(c echo.Context, r ApiRepository, userId string, exporter itemExporter) error {
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, exporter.contentType())
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": exporter.fileName()}))
+
+	written := 0
+	start := func() error {
+		c.Response().WriteHeader(http.StatusOK)
+		return exporter.begin()
+	}
+
+	err := r.ForEachListItem(userId, func(item entity.TodoItem) error {
+		if written == 0 {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		written++
+
+		if err := exporter.write(item); err != nil {
+			return err
+		}
+
+		if written%exportFlushItems == 0 {
+			if err := exporter.flush(); err != nil {
+				return err
+			}
+			c.Response().Flush()
+		}
+
+		return nil
+	})
+
+	if err != nil && written == 0 {
+		header.Del(echo.HeaderContentDisposition)
+		errMessage := fmt.Sprintf(constants.EXPORT_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if written == 0 {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+
+	return exporter.end()
+}
+
+// Exports the items of the list
+// @Summary exports the todo items
+// @Description Streams every item of the personal list of the user, or of the workspace selected by the X-Workspace-Id header, by rank. The columns of csv are name, description, due_date, priority, status, created_at, updated_at, started_at and completed_at, with the times in RFC 3339. ics is an iCalendar file with a VTODO for each item, and with events a VEVENT at the due date of each open item. txt is todo.txt with the labels as projects, md a Markdown checklist with the priority and the labels written like in a quick add. Both write the due date as a day in UTC and complete the done and cancelled items.
+// @Tags Import and export
+// @Produce text/csv
+// @Produce text/calendar
+// @Produce text/plain
+// @Produce text/markdown
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param format query string false "Format of the file" Enums(csv, ics, txt, md) default(csv)
+// @Param events query bool false "Add the due dates as events to an ics file"
+// @Success 200 {file} file
+// @Failure 400 string constants.UNSUPPORTED_EXPORT_FORMAT
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.EXPORT_ITEMS_ERROR
+// @Router /item/export [get]
+func (as ApiService) ExportItems(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	exporter, err := newItemExporter(c, c.QueryParam("format"))
+	if exporter == nil {
+		return err
+	}
+
+	return streamItems(c, as.repo(c), userId, exporter)
+}
+
+// Largest number of rows of an import
+const maxImportRows = 10000
+
+var errTooManyRows = fmt.Errorf("at most %d rows can be imported", maxImportRows)
+
+// A mapping that does not fit the columns of an imported file
+type importMappingError struct {
+	err error
+}
+
+func (e importMappingError) Error() string {
+	return e.err.Error()
+}
+
+// Reads the items of an imported file in a format. The rows that are not valid items are listed in the errors
+// of the result, err is set when the file cannot be read at all. Only csv has a mapping of its columns
+func ReadImport(file io.Reader, format string, mapping map[string]string, location *time.Location) (result entity.ImportResultDto, items []entity.TodoItem, err error) {
+	if len(mapping) > 0 && format != "" && format != "csv" {
+		return result, nil, importMappingError{fmt.Errorf("%s has no columns to map", format)}
+	}
+
+	switch format {
+	case "", "csv":
+		return readCSVItems(file, mapping, location)
+	case "txt":
+		return readTodoTxtItems(file, location)
+	case "md":
+		return readMarkdownItems(file, location)
+	}
+
+	return result, nil, fmt.Errorf(constants.UNSUPPORTED_IMPORT_FORMAT, format)
+}
+
+// Due date of a text format, a day is due at its end in location and an item without one at the end of today
+func textDueDate(value string, location *time.Location) (time.Time, error) {
+	if value == "" {
+		now := time.Now().In(location)
+		return time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, location).UTC(), nil
+	}
+
+	due, err := parseCSVDueDate(value, location)
+	if err != nil {
+		return due, fmt.Errorf("due:%s must be a date like 2023-10-15", value)
+	}
+
+	return due.UTC(), nil
+}
+
+// Checks an item of a text format like a created item, but without a description which text formats do not have
+func validateTextItem(item entity.TodoItem) error {
+	validate := validator.New()
+
+	if validate.Var(item.Item.Name, "required,min=3") != nil {
+		return errors.New("the name must be at least 3 characters")
+	}
+
+	if validate.Var(item.Labels, "max=20,dive,max=50") != nil {
+		return errors.New("an item has at most 20 labels of at most 50 characters")
+	}
+
+	return nil
+}
+
+// Imports items from a file
+// @Summary imports todo items from a file
+// @Description csv is read like an exported file, the first row names the columns. The mapping names the column of each field, e.g. {"name": "Title", "due_date": "Due"}, the fields that are not mapped are read from the column with their own name. The fields are name, description, due_date, priority and status, every field but status is required. Rows are numbered like in a spreadsheet, the first item is row 2. txt is todo.txt, with (A) high, (B) medium and (C) to (Z) low priority, x for a done item, projects and contexts as labels and due: as the due date. md is a Markdown checklist, - [ ] for an open and - [x] for a done item, with !high, !medium and !low, #label and due: like in a quick add and the indented lines below a task as its description. Items of txt and md without a due date are due at the end of the day. Their rows are the numbers of the lines. Each row is checked like a created item and nothing is imported when any row is invalid. With dry_run the rows are only checked.
+// @Tags Import and export
+// @Accept multipart/form-data
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param file formData file true "File to import"
+// @Param format formData string false "Format of the file" Enums(csv, txt, md) default(csv)
+// @Param mapping formData string false "Columns of the fields of a csv file as a JSON object"
+// @Param timezone formData string false "Timezone of the due dates without one" default(UTC)
+// @Param dry_run formData bool false "Only check the rows"
+// @Success 200 {object} entity.ImportResultDto
+// @Success 201 {object} entity.ImportResultDto
+// @Failure 400 {object} entity.ImportResultDto
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_WORKSPACE_ROLE
+// @Failure 500 string constants.IMPORT_ITEMS_ERROR
+// @Router /item/import [post]
+func (as ApiService) ImportItems(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeCreate(c)
+	if !allowed {
+		return err
+	}
+
+	format := c.FormValue("format")
+	if format != "" && format != "csv" && format != "txt" && format != "md" {
+		errMessage := fmt.Sprintf(constants.UNSUPPORTED_IMPORT_FORMAT, format)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	mapping := map[string]string{}
+	if value := c.FormValue("mapping"); value != "" {
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			errMessage := fmt.Sprintf(constants.INVALID_IMPORT_MAPPING, err)
+			return c.String(http.StatusBadRequest, errMessage)
+		}
+	}
+
+	timezone := c.FormValue("timezone")
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_TIMEZONE, timezone)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	dryRun := false
+	if value := c.FormValue("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			errMessage := fmt.Sprintf(constants.BAD_REQUEST, "dry_run must be true or false")
+			return c.String(http.StatusBadRequest, errMessage)
+		}
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.IMPORT_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+	defer file.Close()
+
+	result, items, err := ReadImport(file, format, mapping, location)
+
+	var mappingErr importMappingError
+	if errors.As(err, &mappingErr) {
+		errMessage := fmt.Sprintf(constants.INVALID_IMPORT_MAPPING, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	result.DryRun = dryRun
+
+	if len(result.Errors) > 0 {
+		return c.JSONPretty(http.StatusBadRequest, result, " ")
+	}
+
+	if dryRun {
+		result.Imported = len(items)
+		return c.JSONPretty(http.StatusOK, result, " ")
+	}
+
+	imported, err := as.repo(c).ImportTodoItems(items, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.IMPORT_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	result.Imported = len(imported)
+	return c.JSONPretty(http.StatusCreated, result, " ")
+}
@@ -0,0 +1,455 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Filter expressions select the items of a saved filter, e.g.
+//
+//	priority>=medium due<=today+7d status!=done (label=finance or "invoice")
+//
+// A term is a field, an operator and a value, terms next to each other are joined with and.
+// Terms are combined with and, or and not, and grouped with parentheses. A bare word or a
+// quoted text matches the name or the description of the item.
+type Filter struct {
+	root filterNode
+}
+
+type filterNode interface {
+	sql(c *filterCompiler) string
+}
+
+type filterAnd struct{ left, right filterNode }
+
+type filterOr struct{ left, right filterNode }
+
+type filterNot struct{ node filterNode }
+
+type filterTerm struct {
+	field, op, value string
+}
+
+// Operators allowed for each field
+var filterOperators = map[string][]string{
+	"priority": {"=", "!=", "<", "<=", ">", ">="},
+	"due":      {"=", "!=", "<", "<=", ">", ">="},
+	"status":   {"=", "!="},
+	"label":    {"=", "!="},
+	"text":     {"=", "!=", "~"},
+}
+
+var filterPriorities = map[string]int{"LOW": 1, "MEDIUM": 2, "HIGH": 3}
+
+var relativeDate = regexp.MustCompile(`^today(?:([+-])(\d{1,4})([dw]))?$`)
+
+type filterToken struct {
+	text   string
+	quoted bool
+	pos    int
+}
+
+// Splits an expression into words, quoted texts, operators and parentheses
+func tokenizeFilter(expression string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(' || r == ')':
+			tokens = append(tokens, filterToken{text: string(r), pos: i})
+			i++
+
+		case r == '"':
+			var text strings.Builder
+			start := i
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				text.WriteRune(runes[i])
+				i++
+			}
+
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated text at %d", start)
+			}
+
+			tokens = append(tokens, filterToken{text: text.String(), quoted: true, pos: start})
+			i++
+
+		case strings.ContainsRune("=!<>~", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != '=' && r != '~' {
+				op += "="
+			}
+
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected ! at %d", i)
+			}
+
+			tokens = append(tokens, filterToken{text: op, pos: i})
+			i += len(op)
+
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`()"=!<>~`, runes[i]) {
+				i++
+			}
+
+			tokens = append(tokens, filterToken{text: string(runes[start:i]), pos: start})
+		}
+	}
+
+	return tokens, nil
+}
+
+func isFilterOperator(token filterToken) bool {
+	if token.quoted {
+		return false
+	}
+
+	switch token.text {
+	case "=", "!=", "<", "<=", ">", ">=", "~":
+		return true
+	}
+
+	return false
+}
+
+func isFilterKeyword(token filterToken, keyword string) bool {
+	return !token.quoted && strings.EqualFold(token.text, keyword)
+}
+
+type filterParser struct {
+	tokens []filterToken
+	next   int
+}
+
+func (p *filterParser) peek() (filterToken, bool) {
+	if p.next == len(p.tokens) {
+		return filterToken{}, false
+	}
+
+	return p.tokens[p.next], true
+}
+
+// Parses and checks a filter expression
+func ParseFilter(expression string) (*Filter, error) {
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	p := &filterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if token, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q at %d", token.text, token.pos)
+	}
+
+	return &Filter{root: root}, nil
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token, ok := p.peek()
+		if !ok || !isFilterKeyword(token, "or") {
+			return left, nil
+		}
+		p.next++
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = filterOr{left, right}
+	}
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token, ok := p.peek()
+		if !ok || token.text == ")" && !token.quoted || isFilterKeyword(token, "or") {
+			return left, nil
+		}
+
+		if isFilterKeyword(token, "and") {
+			p.next++
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = filterAnd{left, right}
+	}
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	token, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.next++
+
+	if isFilterKeyword(token, "not") {
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return filterNot{node}, nil
+	}
+
+	if token.text == "(" && !token.quoted {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		closing, ok := p.peek()
+		if !ok || closing.text != ")" || closing.quoted {
+			return nil, fmt.Errorf("missing ) for ( at %d", token.pos)
+		}
+		p.next++
+
+		return node, nil
+	}
+
+	if token.quoted {
+		return filterTerm{field: "text", op: "~", value: token.text}, nil
+	}
+
+	if isFilterOperator(token) || token.text == ")" || isFilterKeyword(token, "and") || isFilterKeyword(token, "or") {
+		return nil, fmt.Errorf("unexpected %q at %d", token.text, token.pos)
+	}
+
+	op, ok := p.peek()
+	if !ok || !isFilterOperator(op) {
+		return filterTerm{field: "text", op: "~", value: token.text}, nil
+	}
+	p.next++
+
+	value, ok := p.peek()
+	if !ok || isFilterOperator(value) || (!value.quoted && (value.text == "(" || value.text == ")")) {
+		return nil, fmt.Errorf("missing value after %s%s at %d", token.text, op.text, op.pos)
+	}
+	p.next++
+
+	term := filterTerm{field: strings.ToLower(token.text), op: op.text, value: value.text}
+	if err := term.check(); err != nil {
+		return nil, fmt.Errorf("%s at %d", err, token.pos)
+	}
+
+	return term, nil
+}
+
+// Checks the operator and the value of a term against its field
+func (t filterTerm) check() error {
+	operators, ok := filterOperators[t.field]
+	if !ok {
+		return fmt.Errorf("unknown field %q", t.field)
+	}
+
+	allowed := false
+	for _, op := range operators {
+		allowed = allowed || op == t.op
+	}
+
+	if !allowed {
+		return fmt.Errorf("%s cannot be used with %s", t.op, t.field)
+	}
+
+	switch t.field {
+	case "priority":
+		if _, ok := filterPriorities[strings.ToUpper(t.value)]; !ok {
+			return fmt.Errorf("invalid priority %q", t.value)
+		}
+	case "status":
+		if !isStatus(t.value) {
+			return fmt.Errorf("invalid status %q", t.value)
+		}
+	case "due":
+		if t.value == "none" {
+			if t.op != "=" && t.op != "!=" {
+				return fmt.Errorf("%s cannot be used with none", t.op)
+			}
+
+			return nil
+		}
+
+		if _, err := filterDay(t.value, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Start of the day of a date value, either today moved by days or weeks or a date like 2023-10-15
+func filterDay(value string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	match := relativeDate.FindStringSubmatch(strings.ToLower(value))
+	if match == nil {
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return day, fmt.Errorf("invalid date %q", value)
+		}
+
+		return day, nil
+	}
+
+	if match[1] == "" {
+		return today, nil
+	}
+
+	days, _ := strconv.Atoi(match[2])
+	if match[3] == "w" {
+		days *= 7
+	}
+	if match[1] == "-" {
+		days = -days
+	}
+
+	return today.AddDate(0, 0, days), nil
+}
+
+type filterCompiler struct {
+	now  time.Time
+	args []interface{}
+	next int
+}
+
+func (c *filterCompiler) arg(value interface{}) string {
+	c.args = append(c.args, value)
+	c.next++
+
+	return fmt.Sprintf("$%d", c.next-1)
+}
+
+// Condition on todo_items selecting the items of the filter, the arguments are numbered from first.
+// Relative dates are resolved against now
+func (f *Filter) SQL(now time.Time, first int) (string, []interface{}) {
+	c := &filterCompiler{now: now, next: first}
+	condition := f.root.sql(c)
+
+	return condition, c.args
+}
+
+func (n filterAnd) sql(c *filterCompiler) string {
+	return "(" + n.left.sql(c) + " AND " + n.right.sql(c) + ")"
+}
+
+func (n filterOr) sql(c *filterCompiler) string {
+	return "(" + n.left.sql(c) + " OR " + n.right.sql(c) + ")"
+}
+
+func (n filterNot) sql(c *filterCompiler) string {
+	return "NOT (" + n.node.sql(c) + ")"
+}
+
+func (t filterTerm) sql(c *filterCompiler) string {
+	switch t.field {
+	case "priority":
+		if t.op == "=" || t.op == "!=" {
+			return "COALESCE(priority, '') " + sqlOperator(t.op) + " " + c.arg(strings.ToUpper(t.value))
+		}
+
+		return "COALESCE(CASE priority WHEN 'LOW' THEN 1 WHEN 'MEDIUM' THEN 2 WHEN 'HIGH' THEN 3 END, 0) " + t.op + " " + c.arg(filterPriorities[strings.ToUpper(t.value)])
+
+	case "status":
+		return "status " + sqlOperator(t.op) + " " + c.arg(t.value)
+
+	case "label":
+		exists := "EXISTS (SELECT 1 FROM item_labels WHERE item_id = todo_items.id AND label = " + c.arg(t.value) + ")"
+		if t.op == "!=" {
+			return "NOT " + exists
+		}
+
+		return exists
+
+	case "due":
+		return t.dueSQL(c)
+	}
+
+	pattern := c.arg("%" + escapeLike(t.value) + "%")
+	matches := "(name ILIKE " + pattern + " OR COALESCE(description, '') ILIKE " + pattern + ")"
+	if t.op == "!=" {
+		return "NOT " + matches
+	}
+
+	return matches
+}
+
+// Due dates are compared by day, due<=today+7d keeps the items due by the end of that day
+func (t filterTerm) dueSQL(c *filterCompiler) string {
+	if t.value == "none" {
+		if t.op == "=" {
+			return "due_date IS NULL"
+		}
+
+		return "due_date IS NOT NULL"
+	}
+
+	start, _ := filterDay(t.value, c.now)
+	end := start.AddDate(0, 0, 1)
+	layout := "2006-01-02T15:04:05Z07:00"
+
+	switch t.op {
+	case "<":
+		return "due_date < " + c.arg(start.Format(layout))
+	case "<=":
+		return "due_date < " + c.arg(end.Format(layout))
+	case ">":
+		return "due_date >= " + c.arg(end.Format(layout))
+	case ">=":
+		return "due_date >= " + c.arg(start.Format(layout))
+	case "!=":
+		return "(due_date IS NULL OR due_date < " + c.arg(start.Format(layout)) + " OR due_date >= " + c.arg(end.Format(layout)) + ")"
+	}
+
+	return "(due_date >= " + c.arg(start.Format(layout)) + " AND due_date < " + c.arg(end.Format(layout)) + ")"
+}
+
+func sqlOperator(op string) string {
+	if op == "!=" {
+		return "<>"
+	}
+
+	return op
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterExpression(t *testing.T) {
+	now := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	compile := func(expression string) (string, []interface{}) {
+		filter, err := ParseFilter(expression)
+		assert.NoError(t, err)
+
+		return filter.SQL(now, 4)
+	}
+
+	t.Run("Test Terms", func(t *testing.T) {
+		condition, args := compile("priority=high")
+		assert.Equal(t, "COALESCE(priority, '') = $4", condition)
+		assert.Equal(t, []interface{}{"HIGH"}, args)
+
+		condition, args = compile("priority>=medium")
+		assert.Contains(t, condition, "END, 0) >= $4")
+		assert.Equal(t, []interface{}{2}, args)
+
+		condition, args = compile("status!=done")
+		assert.Equal(t, "status <> $4", condition)
+		assert.Equal(t, []interface{}{"done"}, args)
+
+		condition, args = compile("label!=finance")
+		assert.Equal(t, "NOT EXISTS (SELECT 1 FROM item_labels WHERE item_id = todo_items.id AND label = $4)", condition)
+		assert.Equal(t, []interface{}{"finance"}, args)
+
+		condition, args = compile(`"50% off"`)
+		assert.Equal(t, "(name ILIKE $4 OR COALESCE(description, '') ILIKE $4)", condition)
+		assert.Equal(t, []interface{}{`%50\% off%`}, args)
+
+		condition, _ = compile("due=none")
+		assert.Equal(t, "due_date IS NULL", condition)
+	})
+
+	t.Run("Test Relative due dates", func(t *testing.T) {
+		condition, args := compile("due<=today+7d")
+		assert.Equal(t, "due_date < $4", condition)
+		assert.Equal(t, []interface{}{"2023-10-23T00:00:00Z"}, args)
+
+		condition, args = compile("due<today")
+		assert.Equal(t, "due_date < $4", condition)
+		assert.Equal(t, []interface{}{"2023-10-15T00:00:00Z"}, args)
+
+		condition, args = compile("due=today-1w")
+		assert.Equal(t, "(due_date >= $4 AND due_date < $5)", condition)
+		assert.Equal(t, []interface{}{"2023-10-08T00:00:00Z", "2023-10-09T00:00:00Z"}, args)
+
+		_, args = compile("due>2023-12-31")
+		assert.Equal(t, []interface{}{"2024-01-01T00:00:00Z"}, args)
+	})
+
+	t.Run("Test Combinations", func(t *testing.T) {
+		condition, args := compile("priority=HIGH due<=today+7d status!=done")
+		assert.Equal(t, "((COALESCE(priority, '') = $4 AND due_date < $5) AND status <> $6)", condition)
+		assert.Len(t, args, 3)
+
+		condition, _ = compile("not (label=home or label=finance) and invoice")
+		assert.Equal(t, "(NOT ((EXISTS (SELECT 1 FROM item_labels WHERE item_id = todo_items.id AND label = $4) OR EXISTS (SELECT 1 FROM item_labels WHERE item_id = todo_items.id AND label = $5))) AND (name ILIKE $6 OR COALESCE(description, '') ILIKE $6))", condition)
+
+		condition, _ = compile("status=todo OR status=in_progress label=work")
+		assert.Equal(t, "(status = $4 OR (status = $5 AND EXISTS (SELECT 1 FROM item_labels WHERE item_id = todo_items.id AND label = $6)))", condition)
+	})
+
+	t.Run("Test Invalid expressions", func(t *testing.T) {
+		for _, expression := range []string{
+			"",
+			"colour=red",
+			"priority=urgent",
+			"status=later",
+			"status<done",
+			"due<=next-week",
+			"due>none",
+			"label~home",
+			"(status=done",
+			"status=done)",
+			"status=",
+			"and status=done",
+			`"unterminated`,
+			"status ! done",
+		} {
+			_, err := ParseFilter(expression)
+			assert.Error(t, err, expression)
+		}
+	})
+}
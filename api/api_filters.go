@@ -0,0 +1,264 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Binds and checks a saved filter, the response is written when it is not valid
+func bindSavedFilter(c echo.Context) (*entity.SavedFilterDto, error) {
+	filter := new(entity.SavedFilterDto)
+	if err := c.Bind(filter); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return nil, c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(filter); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return nil, c.String(http.StatusBadRequest, errMessage)
+	}
+
+	if _, err := ParseFilter(filter.Expression); err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_FILTER, err)
+		return nil, c.String(http.StatusBadRequest, errMessage)
+	}
+
+	return filter, nil
+}
+
+// Lists the saved filters of the user
+// @Summary lists the saved filters of the user
+// @Tags Filters
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Success 200 {array} entity.SavedFilter
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.GET_SAVED_FILTERS_ERROR
+// @Router /item/filters [get]
+func (as ApiService) GetSavedFilters(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	filters, err := as.repo(c).GetSavedFilters(userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_SAVED_FILTERS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, filters, " ")
+}
+
+// Saves a named filter expression of the user
+// @Summary saves a filter
+// @Description Terms are a field, an operator and a value, e.g. priority>=medium due<=today+7d status!=done. The fields are priority, due, status, label and text, due takes today, today+Nd, today-Nw, a date like 2023-10-15 or none. Terms next to each other must all match, and, or, not and parentheses combine them. A bare word or a quoted text matches the name or the description.
+// @Tags Filters
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param dto body entity.SavedFilterDto true "Filter"
+// @Success 201 {object} entity.SavedFilter
+// @Failure 400 string constants.INVALID_FILTER
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.SAVE_FILTER_ERROR
+// @Router /item/filters [post]
+func (as ApiService) CreateSavedFilter(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	filter, err := bindSavedFilter(c)
+	if filter == nil {
+		return err
+	}
+
+	saved, err := as.repo(c).CreateSavedFilter(userId, filter)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.SAVE_FILTER_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, saved, " ")
+}
+
+// Renames or changes the expression of a saved filter
+// @Summary updates a saved filter
+// @Tags Filters
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "saved filter Id"
+// @Param dto body entity.SavedFilterDto true "Filter"
+// @Success 200 {object} entity.SavedFilter
+// @Failure 400 string constants.INVALID_FILTER
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.FILTER_NOT_FOUND
+// @Failure 500 string constants.SAVE_FILTER_ERROR
+// @Router /item/filters/{id} [put]
+func (as ApiService) UpdateSavedFilterById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	filter, err := bindSavedFilter(c)
+	if filter == nil {
+		return err
+	}
+
+	saved, err := as.repo(c).UpdateSavedFilter(param, userId, filter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.FILTER_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.SAVE_FILTER_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, saved, " ")
+}
+
+// Deletes a saved filter of the user
+// @Summary deletes a saved filter
+// @Tags Filters
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "saved filter Id"
+// @Success 200 string constants.DELETE_SAVED_FILTER_SUCCESSFULL
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.FILTER_NOT_FOUND
+// @Failure 500 string constants.DELETE_SAVED_FILTER_ERROR
+// @Router /item/filters/{id} [delete]
+func (as ApiService) DeleteSavedFilterById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	err := as.repo(c).DeleteSavedFilter(param, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.FILTER_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.DELETE_SAVED_FILTER_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.String(http.StatusOK, constants.DELETE_SAVED_FILTER_SUCCESSFULL)
+}
+
+// Lists the items selected by a saved filter, relative dates are resolved when the items are listed
+// @Summary lists the todo items of a saved filter
+// @Description The items are taken from the personal list of the user, or from the workspace selected by the X-Workspace-Id header, and ordered like the item list.
+// @Tags Filters
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "saved filter Id"
+// @Param limit query int false "Maximum number of items" default(50)
+// @Param If-None-Match header string false "ETag of the cached list"
+// @Success 200 {array} entity.TodoItem
+// @Success 304 "Not Modified"
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.FILTER_NOT_FOUND
+// @Failure 500 string constants.GET_FILTERED_ITEMS_ERROR
+// @Router /item/filters/{id}/items [get]
+func (as ApiService) GetFilteredItems(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	limit := 50
+	if param := c.QueryParam("limit"); param != "" {
+		value, err := strconv.Atoi(param)
+		if err != nil || value <= 0 {
+			errMessage := fmt.Sprintf(constants.BAD_REQUEST, "limit must be a positive number")
+			return c.String(http.StatusBadRequest, errMessage)
+		}
+		limit = value
+	}
+
+	saved, err := as.repo(c).FindSavedFilter(param, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.FILTER_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_FILTERED_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	filter, err := ParseFilter(saved.Expression)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_FILTER, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	items, err := as.repo(c).GetFilteredItems(filter, limit, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_FILTERED_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	etag := listETag(items)
+	c.Response().Header().Set(HeaderETag, etag)
+
+	if !noneMatch(c.Request().Header.Get(HeaderIfNoneMatch), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.JSONPretty(http.StatusOK, items, " ")
+}
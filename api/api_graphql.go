@@ -0,0 +1,1112 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+const (
+	// Deepest nesting of fields a query may select
+	graphqlMaxDepth = 10
+
+	// Highest complexity of a query, each field costs one for every time it is resolved
+	graphqlMaxComplexity = 5000
+
+	graphqlDefaultPageSize = 20
+	graphqlMaxPageSize     = 100
+
+	// Items a list field other than items is assumed to hold when the complexity of a query is computed
+	graphqlListSize = 10
+)
+
+// Fields resolving to a list of objects, the fields selected on them are resolved once per object
+var graphqlListFields = map[string]bool{
+	"comments":   true,
+	"replies":    true,
+	"watchers":   true,
+	"blockedBy":  true,
+	"workspaces": true,
+}
+
+type graphqlContextKey struct{}
+
+// Ids the resolvers of a level of a query asked for, loaded together when the executor calls the first of
+// their thunks. The executor calls the thunks of a level before it resolves the fields of the next one, so
+// a list of items loads its owners, comments or blockers with one query rather than one per item
+type graphqlBatch struct {
+	load    func(ids []string) (map[string]interface{}, error)
+	pending []string
+	loaded  map[string]interface{}
+}
+
+func newGraphQLBatch(load func(ids []string) (map[string]interface{}, error)) *graphqlBatch {
+	return &graphqlBatch{load: load, loaded: map[string]interface{}{}}
+}
+
+func (b *graphqlBatch) queue(id string) {
+	if _, ok := b.loaded[id]; !ok && !containsString(b.pending, id) {
+		b.pending = append(b.pending, id)
+	}
+}
+
+// Loads the queued ids, the ones that are not found are kept as nil so they are not loaded again
+func (b *graphqlBatch) flush() error {
+	if len(b.pending) == 0 {
+		return nil
+	}
+
+	ids := b.pending
+	b.pending = nil
+
+	values, err := b.load(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		b.loaded[id] = values[id]
+	}
+
+	return nil
+}
+
+// Thunk of the value of an id, nil when it is not found
+func (b *graphqlBatch) thunk(id string) func() (interface{}, error) {
+	b.queue(id)
+
+	return func() (interface{}, error) {
+		if err := b.flush(); err != nil {
+			return nil, err
+		}
+
+		return b.loaded[id], nil
+	}
+}
+
+// Thunk of the values of ids, the ones that are not found are left out
+func (b *graphqlBatch) thunkAll(ids []string) func() (interface{}, error) {
+	for _, id := range ids {
+		b.queue(id)
+	}
+
+	return func() (interface{}, error) {
+		if err := b.flush(); err != nil {
+			return nil, err
+		}
+
+		values := []interface{}{}
+		for _, id := range ids {
+			if value := b.loaded[id]; value != nil {
+				values = append(values, value)
+			}
+		}
+
+		return values, nil
+	}
+}
+
+// State of a GraphQL request shared by its resolvers
+type graphqlRequest struct {
+	as         ApiService
+	c          echo.Context
+	repo       ApiRepository
+	userId     string
+	users      *graphqlBatch
+	comments   *graphqlBatch
+	blockers   *graphqlBatch
+	workspaces map[string]entity.Workspace
+}
+
+func (as ApiService) newGraphQLRequest(c echo.Context, userId string) *graphqlRequest {
+	gr := &graphqlRequest{
+		as:     as,
+		c:      c,
+		repo:   as.repo(c),
+		userId: userId,
+	}
+
+	gr.users = newGraphQLBatch(func(ids []string) (map[string]interface{}, error) {
+		users, err := gr.repo.FindUsersByIds(ids)
+		if err != nil {
+			return nil, fmt.Errorf(constants.INTERNAL_SERVER_ERROR, err)
+		}
+
+		values := map[string]interface{}{}
+		for id, user := range users {
+			values[id] = user
+		}
+
+		return values, nil
+	})
+
+	gr.comments = newGraphQLBatch(func(ids []string) (map[string]interface{}, error) {
+		comments, err := gr.repo.GetCommentsOfItems(ids)
+		if err != nil {
+			return nil, fmt.Errorf(constants.GET_COMMENTS_ERROR, err)
+		}
+
+		values := map[string]interface{}{}
+		for _, id := range ids {
+			values[id] = commentThreads(comments[id])
+		}
+
+		return values, nil
+	})
+
+	gr.blockers = newGraphQLBatch(func(ids []string) (map[string]interface{}, error) {
+		items, err := gr.repo.FindListItems(ids, userId)
+		if err != nil {
+			return nil, fmt.Errorf(constants.GET_DEPENDENCIES_ERROR, err)
+		}
+
+		values := map[string]interface{}{}
+		for id, item := range items {
+			values[id] = item
+		}
+
+		return values, nil
+	})
+
+	return gr
+}
+
+func graphqlRequestOf(p graphql.ResolveParams) *graphqlRequest {
+	return p.Context.Value(graphqlContextKey{}).(*graphqlRequest)
+}
+
+// Workspace of the user by id, nil for the personal list. The workspaces of the user are loaded once per request
+func (gr *graphqlRequest) workspace(id string) (interface{}, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	if gr.workspaces == nil {
+		workspaces, err := gr.as.R.GetWorkspacesOfUser(gr.userId)
+		if err != nil {
+			return nil, fmt.Errorf(constants.GET_WORKSPACES_ERROR, err)
+		}
+
+		gr.workspaces = map[string]entity.Workspace{}
+		for _, workspace := range workspaces {
+			gr.workspaces[workspace.Id] = workspace
+		}
+	}
+
+	workspace, ok := gr.workspaces[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return workspace, nil
+}
+
+// Checks the user has at least the required role on the item, like authorizeItem does for the REST handlers
+func (gr *graphqlRequest) authorizeItem(id, required string) error {
+	role, err := gr.repo.GetItemRole(id, gr.userId)
+	if err != nil {
+		return fmt.Errorf(constants.BAD_REQUEST, err)
+	}
+
+	if role == "" {
+		return errors.New(constants.DOES_NOT_BELONG_TO_USER)
+	}
+
+	if !roleAllows(role, required) {
+		return fmt.Errorf(constants.INSUFFICIENT_ROLE, required)
+	}
+
+	return nil
+}
+
+// Checks the user can create items in the selected workspace, like authorizeCreate does for the REST handlers
+func (gr *graphqlRequest) authorizeCreate() error {
+	if workspaceOf(gr.c) == "" {
+		return nil
+	}
+
+	role, _ := gr.c.Get(workspaceRoleKey).(string)
+	if !roleAllows(role, entity.RoleEditor) {
+		return fmt.Errorf(constants.INSUFFICIENT_WORKSPACE_ROLE, entity.RoleEditor)
+	}
+
+	return nil
+}
+
+// Version of the item a mutation expects, 0 means any version. The version is required when the REST
+// handlers require an If-Match header
+func (gr *graphqlRequest) version(p graphql.ResolveParams) (int, error) {
+	version, _ := p.Args["version"].(int)
+	if version == 0 && gr.as.RequireIfMatch {
+		return 0, errors.New(constants.GRAPHQL_VERSION_REQUIRED)
+	}
+
+	return version, nil
+}
+
+// Error of a change of an item, a stale version is reported the same way for every mutation
+func itemChangeError(format string, err error) error {
+	if errors.Is(err, ErrVersionMismatch) {
+		return errors.New(constants.ITEM_VERSION_MISMATCH)
+	}
+
+	return fmt.Errorf(format, err)
+}
+
+// Opaque cursor of the items of a page after an offset
+func graphqlCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func parseGraphQLCursor(cursor string) (int, error) {
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(decoded), "offset:") {
+		return 0, fmt.Errorf(constants.INVALID_CURSOR, cursor)
+	}
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(decoded), "offset:"))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf(constants.INVALID_CURSOR, cursor)
+	}
+
+	return offset, nil
+}
+
+func detailsInput(input map[string]interface{}) entity.TodoItemDetailsDto {
+	details := entity.TodoItemDetailsDto{}
+	details.Description, _ = input["description"].(string)
+	details.DueDate, _ = input["dueDate"].(time.Time)
+	details.Priority, _ = input["priority"].(string)
+
+	return details
+}
+
+func (gr *graphqlRequest) item(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+
+	if err := gr.authorizeItem(id, entity.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	item, err := gr.repo.FindItemById(id)
+	if err != nil {
+		return nil, fmt.Errorf(constants.FIND_ITEM_BY_ITEM_ERROR, err)
+	}
+
+	return item, nil
+}
+
+// A page of the items of the list, selected by a filter expression like the saved filters
+func (gr *graphqlRequest) items(p graphql.ResolveParams) (interface{}, error) {
+	first := graphqlDefaultPageSize
+	if value, ok := p.Args["first"].(int); ok {
+		first = value
+	}
+
+	if first < 1 || first > graphqlMaxPageSize {
+		return nil, fmt.Errorf(constants.INVALID_PAGE_SIZE, graphqlMaxPageSize)
+	}
+
+	offset := 0
+	if after, _ := p.Args["after"].(string); after != "" {
+		var err error
+		if offset, err = parseGraphQLCursor(after); err != nil {
+			return nil, err
+		}
+	}
+
+	var filter *Filter
+	if expression, _ := p.Args["filter"].(string); strings.TrimSpace(expression) != "" {
+		var err error
+		if filter, err = ParseFilter(expression); err != nil {
+			return nil, fmt.Errorf(constants.INVALID_FILTER, err)
+		}
+	}
+
+	items, err := gr.repo.GetItemsPage(filter, first+1, offset, gr.userId)
+	if err != nil {
+		return nil, fmt.Errorf(constants.GET_ALL_ITEMS_ERROR, err)
+	}
+
+	hasNextPage := len(items) > first
+	if hasNextPage {
+		items = items[:first]
+	}
+
+	var endCursor interface{}
+	if len(items) > 0 {
+		endCursor = graphqlCursor(offset + len(items))
+	}
+
+	return map[string]interface{}{
+		"nodes": items,
+		"pageInfo": map[string]interface{}{
+			"hasNextPage": hasNextPage,
+			"endCursor":   endCursor,
+		},
+	}, nil
+}
+
+func (gr *graphqlRequest) createItem(p graphql.ResolveParams) (interface{}, error) {
+	input, _ := p.Args["input"].(map[string]interface{})
+	name, _ := input["name"].(string)
+	t := entity.TodoItemDto{Name: name, Details: detailsInput(input)}
+
+	validate := validator.New()
+	if err := validate.Struct(t); err != nil {
+		return nil, fmt.Errorf(constants.BAD_REQUEST, err)
+	}
+
+	if err := gr.authorizeCreate(); err != nil {
+		return nil, err
+	}
+
+	item, err := gr.as.withRevision(gr.repo, gr.userId, entity.RevisionCreated, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.CreateTodoItem(&t, gr.userId)
+	})
+	if err != nil {
+		return nil, fmt.Errorf(constants.CREATE_ITEM_ERROR, err)
+	}
+
+	return item, nil
+}
+
+func (gr *graphqlRequest) updateItem(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+	input, _ := p.Args["details"].(map[string]interface{})
+	details := detailsInput(input)
+
+	validate := validator.New()
+	if err := validate.Struct(details); err != nil {
+		return nil, fmt.Errorf(constants.BAD_REQUEST, err)
+	}
+
+	version, err := gr.version(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gr.authorizeItem(id, entity.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	item, err := gr.as.withRevision(gr.repo, gr.userId, entity.RevisionUpdated, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.UpdateTodoItem(&details, id, version)
+	})
+	if err != nil {
+		return nil, itemChangeError(constants.UPDATE_ITEM_ERROR, err)
+	}
+
+	return item, nil
+}
+
+// Changes the status of an item after checking the version and that the transition is allowed, like
+// changeItemStatus does for the REST handlers. Completing an item is recorded as such
+func (gr *graphqlRequest) changeStatus(p graphql.ResolveParams, status, action string) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+
+	version, err := gr.version(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gr.authorizeItem(id, entity.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	current, err := gr.repo.FindItemById(id)
+	if err != nil {
+		return nil, fmt.Errorf(constants.FIND_ITEM_BY_ITEM_ERROR, err)
+	}
+
+	if version != 0 && version != current.Version {
+		return nil, errors.New(constants.ITEM_VERSION_MISMATCH)
+	}
+
+	if !gr.as.statusTransitions().CanTransition(current.Status, status) {
+		return nil, fmt.Errorf(constants.INVALID_STATUS_TRANSITION, current.Status, status)
+	}
+
+	if waitsOnBlockers(current, status) {
+		return nil, errors.New(constants.ITEM_BLOCKED)
+	}
+
+	item, err := gr.as.withRevision(gr.repo, gr.userId, action, func(r ApiRepository) (entity.TodoItem, error) {
+		if action == entity.RevisionCompleted {
+			return r.SetItemStatusToComplete(id, current.Version)
+		}
+
+		return r.SetItemStatus(id, status, current.Version)
+	})
+	if err != nil {
+		return nil, itemChangeError(constants.CHANGE_STATUS_ITEM_ERROR, err)
+	}
+
+	return item, nil
+}
+
+func (gr *graphqlRequest) deleteItem(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+
+	version, err := gr.version(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gr.authorizeItem(id, entity.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	item, err := gr.as.withRevision(gr.repo, gr.userId, entity.RevisionDeleted, func(r ApiRepository) (entity.TodoItem, error) {
+		if err := r.DeleteTodoItem(id, version); err != nil {
+			return entity.TodoItem{}, err
+		}
+
+		return r.FindItemById(id)
+	})
+	if err != nil {
+		return nil, itemChangeError(constants.DELETE_ITEM_ERROR, err)
+	}
+
+	return item, nil
+}
+
+func (gr *graphqlRequest) restoreItem(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+
+	if err := gr.authorizeItem(id, entity.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	item, err := gr.as.withRevision(gr.repo, gr.userId, entity.RevisionRestored, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.RestoreTodoItem(id)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New(constants.ITEM_NOT_IN_TRASH)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf(constants.RESTORE_ITEM_ERROR, err)
+	}
+
+	return item, nil
+}
+
+func (gr *graphqlRequest) assignItem(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+	email, _ := p.Args["email"].(string)
+
+	if err := gr.authorizeItem(id, entity.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	assigneeId, err := gr.repo.FindUserIdByEmail(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New(constants.EMAIL_NOT_REGISTERED)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf(constants.ASSIGN_ITEM_ERROR, err)
+	}
+
+	role, err := gr.repo.GetItemRole(id, assigneeId)
+	if err != nil {
+		return nil, fmt.Errorf(constants.ASSIGN_ITEM_ERROR, err)
+	}
+
+	if !roleAllows(role, entity.RoleEditor) {
+		return nil, errors.New(constants.ASSIGNEE_WITHOUT_ACCESS)
+	}
+
+	item, err := gr.as.withRevision(gr.repo, gr.userId, entity.RevisionAssigned, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.SetItemAssignee(id, assigneeId)
+	})
+	if err != nil {
+		return nil, fmt.Errorf(constants.ASSIGN_ITEM_ERROR, err)
+	}
+
+	return item, nil
+}
+
+func (gr *graphqlRequest) unassignItem(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+
+	if err := gr.authorizeItem(id, entity.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	current, err := gr.repo.FindItemById(id)
+	if err != nil {
+		return nil, fmt.Errorf(constants.ASSIGN_ITEM_ERROR, err)
+	}
+
+	if current.AssigneeId == "" {
+		return current, nil
+	}
+
+	item, err := gr.as.withRevision(gr.repo, gr.userId, entity.RevisionUnassigned, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.SetItemAssignee(id, "")
+	})
+	if err != nil {
+		return nil, fmt.Errorf(constants.ASSIGN_ITEM_ERROR, err)
+	}
+
+	if !containsString(item.Watchers, current.AssigneeId) {
+		gr.as.notifyUsers(entity.RevisionUnassigned, item, gr.userId, []string{current.AssigneeId})
+	}
+
+	return item, nil
+}
+
+func (gr *graphqlRequest) setWatching(p graphql.ResolveParams, watch bool) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+
+	if err := gr.authorizeItem(id, entity.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	var item entity.TodoItem
+	var err error
+	if watch {
+		item, err = gr.repo.AddItemWatcher(id, gr.userId)
+	} else {
+		item, err = gr.repo.RemoveItemWatcher(id, gr.userId)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf(constants.WATCH_ITEM_ERROR, err)
+	}
+
+	return item, nil
+}
+
+func (gr *graphqlRequest) addComment(p graphql.ResolveParams) (interface{}, error) {
+	itemId, _ := p.Args["itemId"].(string)
+	comment := entity.CommentDto{}
+	comment.Body, _ = p.Args["body"].(string)
+	comment.ParentId, _ = p.Args["parentId"].(string)
+
+	validate := validator.New()
+	if err := validate.Struct(comment); err != nil {
+		return nil, fmt.Errorf(constants.BAD_REQUEST, err)
+	}
+
+	if err := gr.authorizeItem(itemId, entity.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	if comment.ParentId != "" {
+		_, err := gr.repo.FindCommentById(itemId, comment.ParentId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New(constants.COMMENT_NOT_FOUND)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf(constants.CREATE_COMMENT_ERROR, err)
+		}
+	}
+
+	created, err := gr.repo.CreateComment(itemId, gr.userId, &comment)
+	if err != nil {
+		return nil, fmt.Errorf(constants.CREATE_COMMENT_ERROR, err)
+	}
+
+	return created, nil
+}
+
+// Resolver of a field of the value the parent field resolved to
+func sourceField[T any](value func(source T) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return value(p.Source.(T)), nil
+	}
+}
+
+// Resolver of a field using the state of the request
+func requestField(resolve func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error)) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return resolve(graphqlRequestOf(p), p)
+	}
+}
+
+func userField(userId func(item entity.TodoItem) string) graphql.FieldResolveFn {
+	return requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+		id := userId(p.Source.(entity.TodoItem))
+		if id == "" {
+			return nil, nil
+		}
+
+		return gr.users.thunk(id), nil
+	})
+}
+
+// Schema of the items, their comments, users and workspaces. Every field of a user, comment, blocker or
+// workspace of an item is loaded in batches
+func newGraphQLSchema() (graphql.Schema, error) {
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: sourceField(func(user entity.User) interface{} { return user.Id })},
+			"email": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: sourceField(func(user entity.User) interface{} { return user.Email })},
+		},
+	})
+
+	workspaceType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Workspace",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: sourceField(func(workspace entity.Workspace) interface{} { return workspace.Id })},
+			"name":      &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: sourceField(func(workspace entity.Workspace) interface{} { return workspace.Name })},
+			"role":      &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: sourceField(func(workspace entity.Workspace) interface{} { return workspace.Role })},
+			"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: sourceField(func(workspace entity.Workspace) interface{} { return workspace.CreatedAt })},
+			"owner": &graphql.Field{Type: userType, Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+				return gr.users.thunk(p.Source.(entity.Workspace).OwnerId), nil
+			})},
+		},
+	})
+
+	commentType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Comment",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: sourceField(func(comment entity.Comment) interface{} { return comment.Id })},
+			"body":      &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: sourceField(func(comment entity.Comment) interface{} { return comment.Body })},
+			"isDeleted": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: sourceField(func(comment entity.Comment) interface{} { return comment.IsDeleted })},
+			"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: sourceField(func(comment entity.Comment) interface{} { return comment.CreatedAt })},
+			"editedAt":  &graphql.Field{Type: graphql.DateTime, Resolve: sourceField(func(comment entity.Comment) interface{} { return comment.EditedAt })},
+			"author": &graphql.Field{Type: userType, Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+				return gr.users.thunk(p.Source.(entity.Comment).UserId), nil
+			})},
+		},
+	})
+	commentType.AddFieldConfig("replies", &graphql.Field{
+		Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(commentType))),
+		Resolve: sourceField(func(comment entity.Comment) interface{} { return comment.Replies }),
+	})
+
+	itemType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TodoItem",
+		Fields: graphql.Fields{
+			"id":           &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.Id })},
+			"name":         &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.Item.Name })},
+			"description":  &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.Item.Details.Description })},
+			"dueDate":      &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.Item.Details.DueDate })},
+			"priority":     &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.Item.Details.Priority })},
+			"status":       &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.Status })},
+			"isCompleted":  &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.IsCompleted })},
+			"isDeleted":    &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.IsDeleted })},
+			"blocked":      &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.Blocked })},
+			"version":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.Version })},
+			"rank":         &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.Rank })},
+			"recurrence":   &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.Recurrence })},
+			"commentCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.CommentCount })},
+			"labels": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), Resolve: sourceField(func(item entity.TodoItem) interface{} {
+				if item.Labels == nil {
+					return []string{}
+				}
+
+				return item.Labels
+			})},
+			"createdAt":   &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.CreatedAt })},
+			"updatedAt":   &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.UpdatedAt })},
+			"deletedAt":   &graphql.Field{Type: graphql.DateTime, Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.DeletedAt })},
+			"startedAt":   &graphql.Field{Type: graphql.DateTime, Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.StartedAt })},
+			"completedAt": &graphql.Field{Type: graphql.DateTime, Resolve: sourceField(func(item entity.TodoItem) interface{} { return item.CompletedAt })},
+			"owner":       &graphql.Field{Type: userType, Resolve: userField(func(item entity.TodoItem) string { return item.UserId })},
+			"assignee":    &graphql.Field{Type: userType, Resolve: userField(func(item entity.TodoItem) string { return item.AssigneeId })},
+			"watchers": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))), Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+				return gr.users.thunkAll(p.Source.(entity.TodoItem).Watchers), nil
+			})},
+			"workspace": &graphql.Field{Type: workspaceType, Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+				return gr.workspace(p.Source.(entity.TodoItem).WorkspaceId)
+			})},
+			"comments": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(commentType))), Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+				return gr.comments.thunk(p.Source.(entity.TodoItem).Id), nil
+			})},
+		},
+	})
+	itemType.AddFieldConfig("blockedBy", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemType))),
+		Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+			return gr.blockers.thunkAll(p.Source.(entity.TodoItem).BlockedBy), nil
+		}),
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	itemConnectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TodoItemConnection",
+		Fields: graphql.Fields{
+			"nodes":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemType)))},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+		},
+	})
+
+	priorityType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Priority",
+		Values: graphql.EnumValueConfigMap{
+			"HIGH":   &graphql.EnumValueConfig{Value: "HIGH"},
+			"MEDIUM": &graphql.EnumValueConfig{Value: "MEDIUM"},
+			"LOW":    &graphql.EnumValueConfig{Value: "LOW"},
+		},
+	})
+
+	statusType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Status",
+		Values: graphql.EnumValueConfigMap{
+			"TODO":        &graphql.EnumValueConfig{Value: entity.StatusTodo},
+			"IN_PROGRESS": &graphql.EnumValueConfig{Value: entity.StatusInProgress},
+			"BLOCKED":     &graphql.EnumValueConfig{Value: entity.StatusBlocked},
+			"DONE":        &graphql.EnumValueConfig{Value: entity.StatusDone},
+			"CANCELLED":   &graphql.EnumValueConfig{Value: entity.StatusCancelled},
+		},
+	})
+
+	detailsFields := graphql.InputObjectConfigFieldMap{
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"dueDate":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.DateTime)},
+		"priority":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(priorityType)},
+	}
+
+	detailsInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:   "TodoItemDetailsInput",
+		Fields: detailsFields,
+	})
+
+	itemInputFields := graphql.InputObjectConfigFieldMap{
+		"name": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+	}
+	for name, field := range detailsFields {
+		itemInputFields[name] = field
+	}
+
+	itemInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:   "TodoItemInput",
+		Fields: itemInputFields,
+	})
+
+	idArgs := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+
+	versionArgs := graphql.FieldConfigArgument{
+		"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+		"version": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Version of the item being changed, like the If-Match header"},
+	}
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{Type: graphql.NewNonNull(userType), Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+				return gr.users.thunk(gr.userId), nil
+			})},
+			"item": &graphql.Field{Type: itemType, Args: idArgs, Resolve: requestField((*graphqlRequest).item)},
+			"items": &graphql.Field{
+				Type: graphql.NewNonNull(itemConnectionType),
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: graphql.String, Description: "Filter expression, like the ones of the saved filters"},
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: graphqlDefaultPageSize},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String, Description: "End cursor of the previous page"},
+				},
+				Resolve: requestField((*graphqlRequest).items),
+			},
+			"workspaces": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(workspaceType))), Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+				workspaces, err := gr.as.R.GetWorkspacesOfUser(gr.userId)
+				if err != nil {
+					return nil, fmt.Errorf(constants.GET_WORKSPACES_ERROR, err)
+				}
+
+				return workspaces, nil
+			})},
+		},
+	})
+
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createItem": &graphql.Field{
+				Type:    itemType,
+				Args:    graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(itemInputType)}},
+				Resolve: requestField((*graphqlRequest).createItem),
+			},
+			"updateItem": &graphql.Field{
+				Type: itemType,
+				Args: graphql.FieldConfigArgument{
+					"id":      versionArgs["id"],
+					"version": versionArgs["version"],
+					"details": &graphql.ArgumentConfig{Type: graphql.NewNonNull(detailsInputType)},
+				},
+				Resolve: requestField((*graphqlRequest).updateItem),
+			},
+			"changeItemStatus": &graphql.Field{
+				Type: itemType,
+				Args: graphql.FieldConfigArgument{
+					"id":      versionArgs["id"],
+					"version": versionArgs["version"],
+					"status":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(statusType)},
+				},
+				Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+					status, _ := p.Args["status"].(string)
+					return gr.changeStatus(p, status, entity.RevisionStatusChanged)
+				}),
+			},
+			"completeItem": &graphql.Field{Type: itemType, Args: versionArgs, Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+				return gr.changeStatus(p, entity.StatusDone, entity.RevisionCompleted)
+			})},
+			"reopenItem": &graphql.Field{Type: itemType, Args: versionArgs, Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+				return gr.changeStatus(p, entity.StatusTodo, entity.RevisionStatusChanged)
+			})},
+			"deleteItem":  &graphql.Field{Type: itemType, Args: versionArgs, Resolve: requestField((*graphqlRequest).deleteItem)},
+			"restoreItem": &graphql.Field{Type: itemType, Args: idArgs, Resolve: requestField((*graphqlRequest).restoreItem)},
+			"assignItem": &graphql.Field{
+				Type: itemType,
+				Args: graphql.FieldConfigArgument{
+					"id":    idArgs["id"],
+					"email": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: requestField((*graphqlRequest).assignItem),
+			},
+			"unassignItem": &graphql.Field{Type: itemType, Args: idArgs, Resolve: requestField((*graphqlRequest).unassignItem)},
+			"watchItem": &graphql.Field{Type: itemType, Args: idArgs, Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+				return gr.setWatching(p, true)
+			})},
+			"unwatchItem": &graphql.Field{Type: itemType, Args: idArgs, Resolve: requestField(func(gr *graphqlRequest, p graphql.ResolveParams) (interface{}, error) {
+				return gr.setWatching(p, false)
+			})},
+			"addComment": &graphql.Field{
+				Type: commentType,
+				Args: graphql.FieldConfigArgument{
+					"itemId":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"body":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"parentId": &graphql.ArgumentConfig{Type: graphql.ID},
+				},
+				Resolve: requestField((*graphqlRequest).addComment),
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+	})
+}
+
+var graphqlSchema = mustGraphQLSchema()
+
+func mustGraphQLSchema() graphql.Schema {
+	schema, err := newGraphQLSchema()
+	if err != nil {
+		panic(err)
+	}
+
+	return schema
+}
+
+// Page size of the items field, out of range sizes count as the largest page since they are rejected anyway
+func graphqlPageSize(field *ast.Field, variables map[string]interface{}) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+
+		size := -1
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			size, _ = strconv.Atoi(value.Value)
+		case *ast.Variable:
+			switch variable := variables[value.Name.Value].(type) {
+			case float64:
+				size = int(variable)
+			case int:
+				size = variable
+			}
+		}
+
+		if size < 1 || size > graphqlMaxPageSize {
+			return graphqlMaxPageSize
+		}
+
+		return size
+	}
+
+	return graphqlDefaultPageSize
+}
+
+// Depth and complexity of a selection set. A field costs one, plus the cost of the fields selected on it once
+// for every object of a list. Introspection fields are left out
+func graphqlCost(set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, variables map[string]interface{}) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, selection := range set.Selections {
+		var selectionDepth, selectionComplexity int
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			name := selection.Name.Value
+			if strings.HasPrefix(name, "__") {
+				continue
+			}
+
+			childDepth, childComplexity := graphqlCost(selection.SelectionSet, fragments, variables)
+
+			times := 1
+			if name == "items" {
+				times = graphqlPageSize(selection, variables)
+			} else if graphqlListFields[name] {
+				times = graphqlListSize
+			}
+
+			selectionDepth, selectionComplexity = childDepth+1, 1+times*childComplexity
+		case *ast.InlineFragment:
+			selectionDepth, selectionComplexity = graphqlCost(selection.SelectionSet, fragments, variables)
+		case *ast.FragmentSpread:
+			if fragment, ok := fragments[selection.Name.Value]; ok {
+				selectionDepth, selectionComplexity = graphqlCost(fragment.SelectionSet, fragments, variables)
+			}
+		}
+
+		if selectionDepth > depth {
+			depth = selectionDepth
+		}
+		complexity += selectionComplexity
+	}
+
+	return depth, complexity
+}
+
+// Checks the depth and complexity of every operation of a validated document, whose fragments have no cycles
+func checkGraphQLLimits(document *ast.Document, variables map[string]interface{}) error {
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		depth, complexity := graphqlCost(operation.SelectionSet, fragments, variables)
+		if depth > graphqlMaxDepth {
+			return fmt.Errorf(constants.GRAPHQL_QUERY_TOO_DEEP, depth, graphqlMaxDepth)
+		}
+
+		if complexity > graphqlMaxComplexity {
+			return fmt.Errorf(constants.GRAPHQL_QUERY_TOO_COMPLEX, complexity, graphqlMaxComplexity)
+		}
+	}
+
+	return nil
+}
+
+// Parses and validates a query and checks its limits before it is executed, a query that cannot be executed
+// only gets the errors
+func executeGraphQL(ctx context.Context, request *entity.GraphQLRequestDto) (*graphql.Result, bool) {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(request.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}, false
+	}
+
+	validation := graphql.ValidateDocument(&graphqlSchema, document, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}, false
+	}
+
+	if err := checkGraphQLLimits(document, request.Variables); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}, false
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        graphqlSchema,
+		AST:           document,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       ctx,
+	}), true
+}
+
+func graphqlResponse(result *graphql.Result) entity.GraphQLResponseDto {
+	response := entity.GraphQLResponseDto{Data: result.Data}
+	for _, err := range result.Errors {
+		response.Errors = append(response.Errors, entity.GraphQLErrorDto{Message: err.Message, Path: err.Path})
+	}
+
+	return response
+}
+
+// Runs a GraphQL query or mutation
+// @Summary runs a GraphQL query or mutation over the todo items
+// @Description The schema covers the items with their owners, assignees, watchers, comments, blockers and workspaces, and has mutations for the changes of the item endpoints. A query deeper than 10 fields or more complex than 5000, where the fields selected on a list count once per item, is rejected with 400 without being run.
+// @Tags GraphQL
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param dto body entity.GraphQLRequestDto true "Query"
+// @Success 200 {object} entity.GraphQLResponseDto
+// @Failure 400 {object} entity.GraphQLResponseDto
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Router /graphql [post]
+func (as ApiService) GraphQL(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	request := new(entity.GraphQLRequestDto)
+	if err := c.Bind(request); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(request)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	ctx := context.WithValue(c.Request().Context(), graphqlContextKey{}, as.newGraphQLRequest(c, userId))
+
+	result, executed := executeGraphQL(ctx, request)
+	if !executed {
+		return c.JSONPretty(http.StatusBadRequest, graphqlResponse(result), " ")
+	}
+
+	return c.JSONPretty(http.StatusOK, graphqlResponse(result), " ")
+}
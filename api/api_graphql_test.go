@@ -0,0 +1,69 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphQL(t *testing.T) {
+	limits := func(query string, variables map[string]interface{}) error {
+		document, err := parser.Parse(parser.ParseParams{Source: query})
+		assert.NoError(t, err)
+
+		return checkGraphQLLimits(document, variables)
+	}
+
+	t.Run("Test Cursor keeps the offset", func(t *testing.T) {
+		offset, err := parseGraphQLCursor(graphqlCursor(40))
+		assert.NoError(t, err)
+		assert.Equal(t, 40, offset)
+
+		_, err = parseGraphQLCursor("40")
+		assert.EqualError(t, err, `Invalid cursor "40"`)
+	})
+
+	t.Run("Test Depth of a query", func(t *testing.T) {
+		assert.NoError(t, limits(`{ item(id: "a") { blockedBy { blockedBy { id } } } }`, nil))
+
+		err := limits(`{ item(id: "a") { ...blockers } } fragment blockers on TodoItem { blockedBy { blockedBy { blockedBy { blockedBy { blockedBy { blockedBy { blockedBy { blockedBy { blockedBy { blockedBy { id } } } } } } } } } } }`, nil)
+		assert.EqualError(t, err, "Query depth 12 exceeds the limit of 10")
+	})
+
+	t.Run("Test Complexity of a query", func(t *testing.T) {
+		assert.NoError(t, limits(`{ items(first: 50) { nodes { id owner { email } comments { body author { email } } } } }`, nil))
+
+		err := limits(`query($first: Int) { items(first: $first) { nodes { comments { replies { author { email } } } } } }`, map[string]interface{}{"first": float64(100)})
+		assert.EqualError(t, err, "Query complexity 21201 exceeds the limit of 5000")
+	})
+
+	t.Run("Test Introspection is not limited", func(t *testing.T) {
+		assert.NoError(t, limits(`{ __schema { types { fields { type { ofType { ofType { ofType { ofType { ofType { ofType { name } } } } } } } } } } }`, nil))
+	})
+
+	t.Run("Test Batch loads the queued ids once", func(t *testing.T) {
+		var loads [][]string
+		batch := newGraphQLBatch(func(ids []string) (map[string]interface{}, error) {
+			loads = append(loads, ids)
+			return map[string]interface{}{"a": "A", "b": "B"}, nil
+		})
+
+		first := batch.thunk("a")
+		all := batch.thunkAll([]string{"b", "a", "missing"})
+
+		value, err := first()
+		assert.NoError(t, err)
+		assert.Equal(t, "A", value)
+
+		values, err := all()
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"B", "A"}, values)
+
+		value, err = batch.thunk("a")()
+		assert.NoError(t, err)
+		assert.Equal(t, "A", value)
+
+		assert.Equal(t, [][]string{{"a", "b", "missing"}}, loads)
+	})
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"reflect"
+
+	"todo-project/entity"
+)
+
+// Fields of an item that differ between two snapshots, keyed by their JSON names
+func diffItems(before, after entity.TodoItem) []entity.FieldChange {
+	changes := []entity.FieldChange{}
+
+	add := func(field string, from, to interface{}) {
+		if !reflect.DeepEqual(from, to) {
+			changes = append(changes, entity.FieldChange{Field: field, From: from, To: to})
+		}
+	}
+
+	add("name", before.Item.Name, after.Item.Name)
+	add("description", before.Item.Details.Description, after.Item.Details.Description)
+	if !before.Item.Details.DueDate.Equal(after.Item.Details.DueDate) {
+		changes = append(changes, entity.FieldChange{Field: "due_date", From: before.Item.Details.DueDate, To: after.Item.Details.DueDate})
+	}
+	add("priority", before.Item.Details.Priority, after.Item.Details.Priority)
+	add("status", before.Status, after.Status)
+	add("is_completed", before.IsCompleted, after.IsCompleted)
+	add("is_deleted", before.IsDeleted, after.IsDeleted)
+	add("assignee_id", before.AssigneeId, after.AssigneeId)
+	if len(before.Labels) > 0 || len(after.Labels) > 0 {
+		add("labels", before.Labels, after.Labels)
+	}
+
+	return changes
+}
+
+// Runs a change of an item and records the changed item as a revision in the same transaction,
+// so an item never changes without its history. The followers of the item are notified once it is committed
+func (as ApiService) withRevision(r ApiRepository, userId, action string, change func(r ApiRepository) (entity.TodoItem, error)) (item entity.TodoItem, err error) {
+	err = r.WithTx(func(tx ApiRepository) error {
+		item, err = change(tx)
+		if err != nil {
+			return err
+		}
+
+		return as.recordRevision(tx, item, userId, action)
+	})
+
+	if err == nil {
+		as.notifyUsers(action, item, userId, itemFollowers(item))
+	}
+
+	return item, err
+}
+
+// Event of an action on an item, a status change to done completes the item
+func itemEvent(action string, item entity.TodoItem) string {
+	if action == entity.RevisionStatusChanged && item.Status == entity.StatusDone {
+		action = entity.RevisionCompleted
+	}
+
+	return "item." + action
+}
+
+// Records the changed item as a revision, with the webhook deliveries and the stream event of the change, so
+// webhooks and streams only get the changes that are committed
+func (as ApiService) recordRevision(r ApiRepository, item entity.TodoItem, userId, action string) error {
+	if err := r.CreateItemRevision(item, userId, action); err != nil {
+		return err
+	}
+
+	if as.Webhooks != nil {
+		if _, err := r.EnqueueWebhookDeliveries(itemEvent(action, item), userId, item); err != nil {
+			return err
+		}
+	}
+
+	if as.Events != nil {
+		if _, err := r.CreateItemEvent(itemEvent(action, item), userId, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
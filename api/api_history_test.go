@@ -0,0 +1,58 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestHistory(t *testing.T) {
+	dueDate := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	before := entity.TodoItem{
+		Item: entity.TodoItemDto{
+			Name: "Todo list item 1",
+			Details: entity.TodoItemDetailsDto{
+				Description: "This is item 1",
+				DueDate:     dueDate,
+				Priority:    "LOW",
+			},
+		},
+		Status: entity.StatusTodo,
+	}
+
+	t.Run("Test No changes", func(t *testing.T) {
+		after := before
+		after.Item.Details.DueDate = dueDate.In(time.FixedZone("IST", 19800))
+		after.Version = 2
+
+		assert.Empty(t, diffItems(before, after))
+	})
+
+	t.Run("Test Changed fields", func(t *testing.T) {
+		after := before
+		after.Item.Details.Priority = "HIGH"
+		after.Item.Details.DueDate = dueDate.Add(24 * time.Hour)
+		after.Status = entity.StatusDone
+		after.IsCompleted = true
+		after.Labels = []string{"finance"}
+
+		assert.Equal(t, []entity.FieldChange{
+			{Field: "due_date", From: dueDate, To: dueDate.Add(24 * time.Hour)},
+			{Field: "priority", From: "LOW", To: "HIGH"},
+			{Field: "status", From: entity.StatusTodo, To: entity.StatusDone},
+			{Field: "is_completed", From: false, To: true},
+			{Field: "labels", From: []string(nil), To: []string{"finance"}},
+		}, diffItems(before, after))
+	})
+
+	t.Run("Test First revision", func(t *testing.T) {
+		changes := diffItems(entity.TodoItem{}, before)
+
+		assert.Len(t, changes, 5)
+		assert.Equal(t, "name", changes[0].Field)
+	})
+}
@@ -0,0 +1,417 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"todo-project/entity"
+)
+
+// Lines of an iCalendar file are folded after 75 octets
+const icalLineLength = 75
+
+// PRIORITY of RFC 5545, 1 is the highest and 9 the lowest
+var icalPriorities = map[string]int{"HIGH": 1, "MEDIUM": 5, "LOW": 9}
+
+// STATUS of a VTODO for each status of an item, blocked items still need an action
+var icalStatuses = map[string]string{
+	entity.StatusTodo:       "NEEDS-ACTION",
+	entity.StatusInProgress: "IN-PROCESS",
+	entity.StatusBlocked:    "NEEDS-ACTION",
+	entity.StatusDone:       "COMPLETED",
+	entity.StatusCancelled:  "CANCELLED",
+}
+
+func icalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// Escapes a TEXT value, backslashes, semicolons, commas and line breaks
+func icalText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(value)
+}
+
+// Splits a content line into lines of at most 75 octets, the continuation lines start with a space.
+// A line is never split inside a character
+func icalFold(line string) string {
+	if len(line) <= icalLineLength {
+		return line + "\r\n"
+	}
+
+	var folded strings.Builder
+	limit := icalLineLength
+
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		folded.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = icalLineLength - 1
+	}
+
+	folded.WriteString(line + "\r\n")
+	return folded.String()
+}
+
+// Writes the items as a VCALENDAR with a VTODO for each item. With events every open item also
+// gets a VEVENT at its due date, for the calendars that do not show todos. The UID of an item is its
+// id and DTSTAMP its last update, so an item is written the same way until it changes
+type icalExporter struct {
+	writer *bufio.Writer
+	name   string
+	events bool
+}
+
+func newICalExporter(w io.Writer, name string, events bool) *icalExporter {
+	return &icalExporter{writer: bufio.NewWriter(w), name: name, events: events}
+}
+
+func (e *icalExporter) contentType() string {
+	return "text/calendar; charset=utf-8"
+}
+
+func (e *icalExporter) fileName() string {
+	return "items.ics"
+}
+
+func (e *icalExporter) line(name, value string) {
+	e.writer.WriteString(icalFold(name + ":" + value))
+}
+
+func (e *icalExporter) begin() error {
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", "-//todo-project//Todo API//EN")
+	e.line("CALSCALE", "GREGORIAN")
+	if e.name != "" {
+		e.line("X-WR-CALNAME", icalText(e.name))
+	}
+
+	return nil
+}
+
+func (e *icalExporter) write(item entity.TodoItem) error {
+	due := icalTime(item.Item.Details.DueDate)
+
+	e.line("BEGIN", "VTODO")
+	e.line("UID", item.Id)
+	e.line("DTSTAMP", icalTime(item.UpdatedAt))
+	e.line("CREATED", icalTime(item.CreatedAt))
+	e.line("LAST-MODIFIED", icalTime(item.UpdatedAt))
+	e.line("SEQUENCE", fmt.Sprint(item.Version))
+	e.line("SUMMARY", icalText(item.Item.Name))
+	if item.Item.Details.Description != "" {
+		e.line("DESCRIPTION", icalText(item.Item.Details.Description))
+	}
+	e.line("DUE", due)
+	if priority, ok := icalPriorities[item.Item.Details.Priority]; ok {
+		e.line("PRIORITY", fmt.Sprint(priority))
+	}
+	if status, ok := icalStatuses[item.Status]; ok {
+		e.line("STATUS", status)
+	}
+	if item.CompletedAt != nil {
+		e.line("COMPLETED", icalTime(*item.CompletedAt))
+		e.line("PERCENT-COMPLETE", "100")
+	}
+	if len(item.Labels) > 0 {
+		labels := make([]string, len(item.Labels))
+		for i, label := range item.Labels {
+			labels[i] = icalText(label)
+		}
+		e.line("CATEGORIES", strings.Join(labels, ","))
+	}
+	if item.Recurrence != "" {
+		e.line("RRULE", item.Recurrence)
+	}
+	e.line("END", "VTODO")
+
+	if e.events && item.Status != entity.StatusDone && item.Status != entity.StatusCancelled {
+		e.line("BEGIN", "VEVENT")
+		e.line("UID", item.Id+"-due")
+		e.line("DTSTAMP", icalTime(item.UpdatedAt))
+		e.line("DTSTART", due)
+		e.line("SUMMARY", icalText(item.Item.Name))
+		e.line("RELATED-TO", item.Id)
+		e.line("TRANSP", "TRANSPARENT")
+		e.line("END", "VEVENT")
+	}
+
+	return nil
+}
+
+func (e *icalExporter) flush() error {
+	return e.writer.Flush()
+}
+
+func (e *icalExporter) end() error {
+	e.line("END", "VCALENDAR")
+
+	return e.writer.Flush()
+}
+
+// A content line of an iCalendar file, the names of the property and its parameters are upper case
+type icalProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// A component like VCALENDAR or VTODO with its properties and nested components
+type icalComponent struct {
+	name       string
+	properties []icalProperty
+	components []*icalComponent
+}
+
+// First property with the name, the values of the other ones are ignored
+func (c *icalComponent) property(name string) (icalProperty, bool) {
+	for _, property := range c.properties {
+		if property.name == name {
+			return property, true
+		}
+	}
+
+	return icalProperty{}, false
+}
+
+// Reverts the escaping of a TEXT value
+func icalUnescape(value string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(value)
+}
+
+// Splits a list of TEXT values at the commas that are not escaped
+func icalTextList(value string) []string {
+	var values []string
+	start := 0
+
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, icalUnescape(value[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(values, icalUnescape(value[start:]))
+}
+
+// Parses a content line like DUE;TZID=Europe/Berlin:20231015T093824, parameter values may be quoted
+func parseICalLine(line string) (icalProperty, error) {
+	property := icalProperty{params: map[string]string{}}
+	quoted := false
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '"':
+			quoted = !quoted
+		case quoted:
+		case line[i] == ':':
+			head := strings.Split(line[:i], ";")
+			property.name = strings.ToUpper(head[0])
+			property.value = line[i+1:]
+
+			for _, param := range head[1:] {
+				name, value, _ := strings.Cut(param, "=")
+				property.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+			}
+
+			if property.name == "" {
+				return property, fmt.Errorf("line without a name: %q", line)
+			}
+
+			return property, nil
+		}
+	}
+
+	return property, fmt.Errorf("line without a value: %q", line)
+}
+
+// Parses an iCalendar file into its outermost component. Folded lines are joined and every component
+// has to be closed with the name it was opened with
+func parseICal(data string) (*icalComponent, error) {
+	data = strings.NewReplacer("\r\n ", "", "\r\n\t", "", "\n ", "", "\n\t", "").Replace(data)
+
+	var root *icalComponent
+	var open []*icalComponent
+
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+
+		property, err := parseICalLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch property.name {
+		case "BEGIN":
+			if root != nil && len(open) == 0 {
+				return nil, errors.New("content after the end of the calendar")
+			}
+
+			component := &icalComponent{name: strings.ToUpper(property.value)}
+			if len(open) > 0 {
+				parent := open[len(open)-1]
+				parent.components = append(parent.components, component)
+			} else {
+				root = component
+			}
+			open = append(open, component)
+		case "END":
+			if len(open) == 0 || open[len(open)-1].name != strings.ToUpper(property.value) {
+				return nil, fmt.Errorf("unexpected END:%s", property.value)
+			}
+			open = open[:len(open)-1]
+		default:
+			if len(open) == 0 {
+				return nil, fmt.Errorf("property %s outside of a component", property.name)
+			}
+
+			component := open[len(open)-1]
+			component.properties = append(component.properties, property)
+		}
+	}
+
+	if root == nil || len(open) > 0 {
+		return nil, errors.New("the calendar is not complete")
+	}
+
+	return root, nil
+}
+
+// Parses a DATE-TIME in UTC, in the time zone of TZID or floating, which is read as UTC like an unknown
+// time zone. A DATE is the end of the day
+func parseICalTime(property icalProperty) (time.Time, error) {
+	location := time.UTC
+	if tzid := property.params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			location = tz
+		}
+	}
+
+	if property.params["VALUE"] == "DATE" || len(property.value) == len("20060102") {
+		day, err := time.ParseInLocation("20060102", property.value, location)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 59, 0, location), nil
+	}
+
+	if strings.HasSuffix(property.value, "Z") {
+		return time.Parse("20060102T150405Z", property.value)
+	}
+
+	return time.ParseInLocation("20060102T150405", property.value, location)
+}
+
+// Fields of an item read from a VTODO. The due date is zero when the VTODO has none and the status is
+// todo, in_progress, done or cancelled
+type icalTodo struct {
+	item       entity.TodoItemDto
+	status     string
+	labels     []string
+	recurrence string
+}
+
+// Reads the only VTODO of a calendar, any other component like a VEVENT is rejected
+func parseICalTodo(calendar *icalComponent) (todo icalTodo, err error) {
+	if calendar.name != "VCALENDAR" {
+		return todo, errors.New("not a VCALENDAR")
+	}
+
+	var vtodo *icalComponent
+	for _, component := range calendar.components {
+		switch component.name {
+		case "VTODO":
+			if vtodo != nil {
+				return todo, errors.New("more than one VTODO")
+			}
+			vtodo = component
+		case "VTIMEZONE":
+		default:
+			return todo, fmt.Errorf("%s is not supported, only VTODO", component.name)
+		}
+	}
+
+	if vtodo == nil {
+		return todo, errors.New("no VTODO")
+	}
+
+	if summary, ok := vtodo.property("SUMMARY"); ok {
+		todo.item.Name = strings.TrimSpace(icalUnescape(summary.value))
+	}
+
+	if description, ok := vtodo.property("DESCRIPTION"); ok {
+		todo.item.Details.Description = icalUnescape(description.value)
+	}
+
+	if due, ok := vtodo.property("DUE"); ok {
+		if todo.item.Details.DueDate, err = parseICalTime(due); err != nil {
+			return todo, fmt.Errorf("invalid DUE: %v", err)
+		}
+		todo.item.Details.DueDate = todo.item.Details.DueDate.UTC()
+	}
+
+	// 1 to 4 is high, 5 medium and 6 to 9 low, 0 leaves the priority undefined
+	todo.item.Details.Priority = "MEDIUM"
+	if property, ok := vtodo.property("PRIORITY"); ok {
+		priority, err := strconv.Atoi(strings.TrimSpace(property.value))
+		if err != nil || priority < 0 || priority > 9 {
+			return todo, fmt.Errorf("invalid PRIORITY %q", property.value)
+		}
+
+		switch {
+		case priority >= 1 && priority <= 4:
+			todo.item.Details.Priority = "HIGH"
+		case priority >= 6:
+			todo.item.Details.Priority = "LOW"
+		}
+	}
+
+	todo.status = entity.StatusTodo
+	if status, ok := vtodo.property("STATUS"); ok {
+		switch strings.ToUpper(status.value) {
+		case "IN-PROCESS":
+			todo.status = entity.StatusInProgress
+		case "COMPLETED":
+			todo.status = entity.StatusDone
+		case "CANCELLED":
+			todo.status = entity.StatusCancelled
+		}
+	} else if _, ok := vtodo.property("COMPLETED"); ok {
+		todo.status = entity.StatusDone
+	}
+
+	for _, property := range vtodo.properties {
+		if property.name != "CATEGORIES" {
+			continue
+		}
+
+		for _, label := range icalTextList(property.value) {
+			if label = strings.TrimSpace(label); label != "" {
+				todo.labels = append(todo.labels, label)
+			}
+		}
+	}
+
+	if rrule, ok := vtodo.property("RRULE"); ok {
+		todo.recurrence = rrule.value
+	}
+
+	return todo, nil
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestICal(t *testing.T) {
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	t.Run("Test Escape text", func(t *testing.T) {
+		assert.Equal(t, `Flat\, March\; rent\nC:\\rent`, icalText("Flat, March; rent\nC:\\rent"))
+	})
+
+	t.Run("Test Fold long lines", func(t *testing.T) {
+		assert.Equal(t, "SUMMARY:Pay rent\r\n", icalFold("SUMMARY:Pay rent"))
+
+		folded := icalFold("DESCRIPTION:" + strings.Repeat("ü", 80))
+		lines := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+
+		assert.Len(t, lines, 3)
+		for i, line := range lines {
+			assert.LessOrEqual(t, len(line), icalLineLength)
+			if i > 0 {
+				assert.True(t, strings.HasPrefix(line, " "))
+			}
+		}
+		assert.Equal(t, "DESCRIPTION:"+strings.Repeat("ü", 80), strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""))
+	})
+
+	t.Run("Test Todo of a completed item", func(t *testing.T) {
+		var out bytes.Buffer
+		exporter := newICalExporter(&out, "Todo items", true)
+
+		item := entity.TodoItem{
+			Id:          "3a35452e-957c-4588-8d40-c88f370067d2",
+			Item:        entity.TodoItemDto{Name: "Pay rent", Details: entity.TodoItemDetailsDto{Description: "Flat, March", DueDate: createdAt, Priority: "HIGH"}},
+			Status:      entity.StatusDone,
+			Version:     2,
+			Labels:      []string{"home", "bills"},
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+			CompletedAt: &createdAt,
+		}
+
+		assert.NoError(t, exporter.begin())
+		assert.NoError(t, exporter.write(item))
+		assert.NoError(t, exporter.end())
+
+		assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+			"VERSION:2.0\r\n"+
+			"PRODID:-//todo-project//Todo API//EN\r\n"+
+			"CALSCALE:GREGORIAN\r\n"+
+			"X-WR-CALNAME:Todo items\r\n"+
+			"BEGIN:VTODO\r\n"+
+			"UID:3a35452e-957c-4588-8d40-c88f370067d2\r\n"+
+			"DTSTAMP:20231015T093824Z\r\n"+
+			"CREATED:20231015T093824Z\r\n"+
+			"LAST-MODIFIED:20231015T093824Z\r\n"+
+			"SEQUENCE:2\r\n"+
+			"SUMMARY:Pay rent\r\n"+
+			"DESCRIPTION:Flat\\, March\r\n"+
+			"DUE:20231015T093824Z\r\n"+
+			"PRIORITY:1\r\n"+
+			"STATUS:COMPLETED\r\n"+
+			"COMPLETED:20231015T093824Z\r\n"+
+			"PERCENT-COMPLETE:100\r\n"+
+			"CATEGORIES:home,bills\r\n"+
+			"END:VTODO\r\n"+
+			"END:VCALENDAR\r\n", out.String())
+	})
+
+	t.Run("Test Event of an open item", func(t *testing.T) {
+		var out bytes.Buffer
+		exporter := newICalExporter(&out, "Todo items", true)
+
+		item := entity.TodoItem{
+			Id:     "3a35452e-957c-4588-8d40-c88f370067d2",
+			Item:   entity.TodoItemDto{Name: "Pay rent", Details: entity.TodoItemDetailsDto{DueDate: createdAt, Priority: "LOW"}},
+			Status: entity.StatusInProgress,
+		}
+
+		assert.NoError(t, exporter.write(item))
+		assert.NoError(t, exporter.flush())
+
+		assert.Contains(t, out.String(), "PRIORITY:9\r\n")
+		assert.Contains(t, out.String(), "STATUS:IN-PROCESS\r\n")
+		assert.Contains(t, out.String(), "BEGIN:VEVENT\r\nUID:3a35452e-957c-4588-8d40-c88f370067d2-due\r\n")
+		assert.Contains(t, out.String(), "DTSTART:20231015T093824Z\r\n")
+		assert.NotContains(t, out.String(), "COMPLETED:")
+	})
+
+	t.Run("Test Parse a todo", func(t *testing.T) {
+		calendar, err := parseICal("BEGIN:VCALENDAR\r\n" +
+			"VERSION:2.0\r\n" +
+			"BEGIN:VTIMEZONE\r\n" +
+			"TZID:Europe/Berlin\r\n" +
+			"END:VTIMEZONE\r\n" +
+			"BEGIN:VTODO\r\n" +
+			"UID:3a35452e-957c-4588-8d40-c88f370067d2\r\n" +
+			"SUMMARY:Pay rent\r\n" +
+			"DESCRIPTION:Flat\\, March\\nAsk the land\r\n lord\r\n" +
+			"DUE;TZID=\"Europe/Berlin\":20231015T113824\r\n" +
+			"PRIORITY:3\r\n" +
+			"STATUS:IN-PROCESS\r\n" +
+			"CATEGORIES:home,bills\\, rent\r\n" +
+			"CATEGORIES:urgent\r\n" +
+			"RRULE:FREQ=MONTHLY;BYMONTHDAY=1\r\n" +
+			"END:VTODO\r\n" +
+			"END:VCALENDAR\r\n")
+		assert.NoError(t, err)
+
+		todo, err := parseICalTodo(calendar)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Pay rent", todo.item.Name)
+		assert.Equal(t, "Flat, March\nAsk the landlord", todo.item.Details.Description)
+		assert.Equal(t, createdAt, todo.item.Details.DueDate)
+		assert.Equal(t, "HIGH", todo.item.Details.Priority)
+		assert.Equal(t, entity.StatusInProgress, todo.status)
+		assert.Equal(t, []string{"home", "bills, rent", "urgent"}, todo.labels)
+		assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=1", todo.recurrence)
+	})
+
+	t.Run("Test Parse the defaults of a todo", func(t *testing.T) {
+		calendar, err := parseICal("BEGIN:VCALENDAR\nBEGIN:VTODO\nSUMMARY:Pay rent\nDUE;VALUE=DATE:20231015\nCOMPLETED:20231015T093824Z\nEND:VTODO\nEND:VCALENDAR\n")
+		assert.NoError(t, err)
+
+		todo, err := parseICalTodo(calendar)
+		assert.NoError(t, err)
+
+		assert.Equal(t, time.Date(2023, 10, 15, 23, 59, 59, 0, time.UTC), todo.item.Details.DueDate)
+		assert.Equal(t, "MEDIUM", todo.item.Details.Priority)
+		assert.Equal(t, entity.StatusDone, todo.status)
+		assert.Empty(t, todo.labels)
+	})
+
+	t.Run("Test Priorities", func(t *testing.T) {
+		for priority, expected := range map[string]string{"0": "MEDIUM", "1": "HIGH", "4": "HIGH", "5": "MEDIUM", "6": "LOW", "9": "LOW"} {
+			calendar, err := parseICal("BEGIN:VCALENDAR\nBEGIN:VTODO\nPRIORITY:" + priority + "\nEND:VTODO\nEND:VCALENDAR\n")
+			assert.NoError(t, err)
+
+			todo, err := parseICalTodo(calendar)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, todo.item.Details.Priority, priority)
+		}
+	})
+
+	t.Run("Test Invalid calendars", func(t *testing.T) {
+		for _, data := range []string{
+			"",
+			"BEGIN:VCALENDAR\nBEGIN:VTODO\nEND:VCALENDAR\n",
+			"BEGIN:VCALENDAR\nEND:VCALENDAR\nBEGIN:VCALENDAR\nEND:VCALENDAR\n",
+			"SUMMARY:Pay rent\n",
+			"BEGIN:VCALENDAR\nSUMMARY\nEND:VCALENDAR\n",
+		} {
+			_, err := parseICal(data)
+			assert.Error(t, err, data)
+		}
+
+		for _, data := range []string{
+			"BEGIN:VCALENDAR\nEND:VCALENDAR\n",
+			"BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VEVENT\nEND:VCALENDAR\n",
+			"BEGIN:VCALENDAR\nBEGIN:VTODO\nEND:VTODO\nBEGIN:VTODO\nEND:VTODO\nEND:VCALENDAR\n",
+			"BEGIN:VCALENDAR\nBEGIN:VTODO\nPRIORITY:10\nEND:VTODO\nEND:VCALENDAR\n",
+			"BEGIN:VCALENDAR\nBEGIN:VTODO\nDUE:tomorrow\nEND:VTODO\nEND:VCALENDAR\n",
+			"BEGIN:VTODO\nEND:VTODO\n",
+		} {
+			calendar, err := parseICal(data)
+			assert.NoError(t, err, data)
+
+			_, err = parseICalTodo(calendar)
+			assert.Error(t, err, data)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"log"
+	"time"
+
+	"todo-project/constants"
+	"todo-project/storage"
+)
+
+// Periodically purges the items which are in trash for longer than the retention days,
+// together with the blobs of their attachments when a blob store is given
+func (r ApiRepository) StartTrashRetentionJob(retentionDays int, interval time.Duration, blobs storage.BlobStore) *time.Ticker {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for range ticker.C {
+			var keys []string
+			if blobs != nil {
+				var err error
+				keys, err = r.GetExpiredAttachmentKeys(retentionDays)
+				if err != nil {
+					log.Printf(constants.PURGE_EXPIRED_ITEMS_ERROR, err)
+					continue
+				}
+			}
+
+			purged, err := r.PurgeExpiredItems(retentionDays)
+			if err != nil {
+				log.Printf(constants.PURGE_EXPIRED_ITEMS_ERROR, err)
+				continue
+			}
+
+			deleteBlobs(blobs, keys)
+
+			if purged > 0 {
+				log.Printf(constants.PURGED_EXPIRED_ITEMS, purged)
+			}
+		}
+	}()
+
+	return ticker
+}
+
+// Periodically sends the webhook deliveries that are due, a delivery failing every attempt is kept as a dead letter
+func (r ApiRepository) StartWebhookDeliveryJob(settings WebhookSettings, interval time.Duration) *time.Ticker {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for range ticker.C {
+			if _, err := r.DeliverWebhooks(settings); err != nil {
+				log.Printf(constants.DELIVER_WEBHOOKS_ERROR, err)
+			}
+		}
+	}()
+
+	return ticker
+}
+
+// Periodically purges the events older than the retention from the log the streams resume from
+func (r ApiRepository) StartItemEventRetentionJob(retention, interval time.Duration) *time.Ticker {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for range ticker.C {
+			if _, err := r.PurgeItemEvents(time.Now().UTC().Add(-retention)); err != nil {
+				log.Printf(constants.PURGE_ITEM_EVENTS_ERROR, err)
+			}
+		}
+	}()
+
+	return ticker
+}
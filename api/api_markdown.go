@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"todo-project/entity"
+)
+
+// A task of a GitHub style checklist like - [ ] Pay rent or * [x] Pay rent
+var markdownTask = regexp.MustCompile(`^\s*[-*+] \[([ xX])\]\s+(.*)$`)
+
+// Writes the items as a Markdown checklist. The description is indented below the task, so it belongs to
+// the list item
+type markdownExporter struct {
+	writer *bufio.Writer
+}
+
+func (e *markdownExporter) contentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+func (e *markdownExporter) fileName() string {
+	return "items.md"
+}
+
+func (e *markdownExporter) begin() error {
+	return nil
+}
+
+func (e *markdownExporter) write(item entity.TodoItem) error {
+	_, err := e.writer.WriteString(markdownTaskLines(item))
+	return err
+}
+
+func (e *markdownExporter) flush() error {
+	return e.writer.Flush()
+}
+
+func (e *markdownExporter) end() error {
+	return e.flush()
+}
+
+// Lines of an item like - [ ] Pay rent !high #home due:2023-10-15, with the words of a quick add. Done and
+// cancelled items are checked, a medium priority is left out and the due date is the day in UTC
+func markdownTaskLines(item entity.TodoItem) string {
+	check := " "
+	if item.Status == entity.StatusDone || item.Status == entity.StatusCancelled {
+		check = "x"
+	}
+
+	words := append([]string{"-", "[" + check + "]"}, strings.Fields(item.Item.Name)...)
+	if priority := item.Item.Details.Priority; priority != "MEDIUM" && priority != "" {
+		words = append(words, "!"+strings.ToLower(priority))
+	}
+
+	for _, label := range item.Labels {
+		words = append(words, "#"+strings.Join(strings.Fields(label), "_"))
+	}
+
+	words = append(words, "due:"+item.Item.Details.DueDate.UTC().Format("2006-01-02"))
+
+	lines := strings.Join(words, " ") + "\n"
+	if description := strings.TrimSpace(item.Item.Details.Description); description != "" {
+		for _, line := range strings.Split(description, "\n") {
+			lines += strings.TrimRight("  "+line, " ") + "\n"
+		}
+	}
+
+	return lines
+}
+
+// Item of the text of a task. !high, !medium and !low set the priority and #label adds a label like in a
+// quick add, due: is the due date
+func markdownItem(checked bool, text string, location *time.Location) (entity.TodoItem, error) {
+	item := entity.TodoItem{Status: entity.StatusTodo}
+	item.Item.Details.Priority = "MEDIUM"
+	if checked {
+		item.Status = entity.StatusDone
+	}
+
+	var name []string
+	due := ""
+
+	for _, word := range strings.Fields(text) {
+		priority := strings.ToUpper(strings.TrimPrefix(word, "!"))
+
+		switch {
+		case strings.HasPrefix(word, "!") && filterPriorities[priority] != 0:
+			item.Item.Details.Priority = priority
+		case len(word) > 1 && word[0] == '#':
+			if !containsString(item.Labels, word[1:]) {
+				item.Labels = append(item.Labels, word[1:])
+			}
+		case strings.HasPrefix(word, "due:") && len(word) > len("due:"):
+			due = word[len("due:"):]
+		default:
+			name = append(name, word)
+		}
+	}
+
+	item.Item.Name = strings.Join(name, " ")
+
+	dueDate, err := textDueDate(due, location)
+	if err != nil {
+		return item, err
+	}
+	item.Item.Details.DueDate = dueDate
+
+	return item, validateTextItem(item)
+}
+
+// Reads the tasks of a Markdown file, nested tasks are items of their own. The indented lines below a task are
+// its description, any other line like a heading is skipped. Rows are the numbers of the lines
+func readMarkdownItems(file io.Reader, location *time.Location) (result entity.ImportResultDto, items []entity.TodoItem, err error) {
+	result.Errors = []entity.ImportRowError{}
+
+	// The task the indented lines belong to, nil after a line that ends it or a task that is not valid
+	var task *entity.TodoItem
+	blank := 0
+
+	scanner := bufio.NewScanner(file)
+	for row := 1; scanner.Scan(); row++ {
+		line := scanner.Text()
+
+		if match := markdownTask.FindStringSubmatch(line); match != nil {
+			result.Rows++
+			if result.Rows > maxImportRows {
+				return result, nil, errTooManyRows
+			}
+
+			item, err := markdownItem(match[1] != " ", match[2], location)
+			if err != nil {
+				result.Errors = append(result.Errors, entity.ImportRowError{Row: row, Error: err.Error()})
+				task = nil
+				continue
+			}
+
+			items = append(items, item)
+			task, blank = &items[len(items)-1], 0
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			blank++
+		case task != nil && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			description := &task.Item.Details.Description
+			if *description != "" {
+				*description += strings.Repeat("\n", blank+1)
+			}
+			*description += strings.TrimSpace(line)
+			blank = 0
+		default:
+			task = nil
+		}
+	}
+
+	return result, items, scanner.Err()
+}
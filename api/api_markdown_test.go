@@ -0,0 +1,87 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestMarkdown(t *testing.T) {
+	dueDate := time.Date(2023, 10, 15, 23, 59, 59, 0, time.UTC)
+
+	t.Run("Test Lines of an item", func(t *testing.T) {
+		item := entity.TodoItem{
+			Item:   entity.TodoItemDto{Name: "Pay rent", Details: entity.TodoItemDetailsDto{Description: "Flat, March\n\nAsk the landlord", DueDate: dueDate, Priority: "HIGH"}},
+			Status: entity.StatusDone,
+			Labels: []string{"home"},
+		}
+
+		assert.Equal(t, "- [x] Pay rent !high #home due:2023-10-15\n  Flat, March\n\n  Ask the landlord\n", markdownTaskLines(item))
+	})
+
+	t.Run("Test Lines of an item with a medium priority", func(t *testing.T) {
+		item := entity.TodoItem{
+			Item:   entity.TodoItemDto{Name: "Call mom", Details: entity.TodoItemDetailsDto{DueDate: dueDate, Priority: "MEDIUM"}},
+			Status: entity.StatusTodo,
+		}
+
+		assert.Equal(t, "- [ ] Call mom due:2023-10-15\n", markdownTaskLines(item))
+	})
+
+	t.Run("Test Read a checklist", func(t *testing.T) {
+		checklist := "# Chores\n" +
+			"\n" +
+			"- [ ] Pay rent !high #home due:2023-10-15\n" +
+			"  Flat, March\n" +
+			"\n" +
+			"  Ask the landlord\n" +
+			"  * [X] Call the bank #home #home\n" +
+			"Some notes\n" +
+			"  not a description\n" +
+			"- [ ] Go\n" +
+			"  ignored\n" +
+			"+ [ ] Water the plants due:someday\n" +
+			"- Buy milk\n"
+
+		result, items, err := readMarkdownItems(strings.NewReader(checklist), time.UTC)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 4, result.Rows)
+		assert.Len(t, items, 2)
+
+		assert.Equal(t, "Pay rent", items[0].Item.Name)
+		assert.Equal(t, "Flat, March\n\nAsk the landlord", items[0].Item.Details.Description)
+		assert.Equal(t, "HIGH", items[0].Item.Details.Priority)
+		assert.Equal(t, dueDate, items[0].Item.Details.DueDate)
+		assert.Equal(t, entity.StatusTodo, items[0].Status)
+
+		assert.Equal(t, "Call the bank", items[1].Item.Name)
+		assert.Equal(t, "", items[1].Item.Details.Description)
+		assert.Equal(t, "MEDIUM", items[1].Item.Details.Priority)
+		assert.Equal(t, []string{"home"}, items[1].Labels)
+		assert.Equal(t, entity.StatusDone, items[1].Status)
+
+		assert.Len(t, result.Errors, 2)
+		assert.Equal(t, 10, result.Errors[0].Row)
+		assert.Equal(t, 12, result.Errors[1].Row)
+	})
+
+	t.Run("Test Export and import again", func(t *testing.T) {
+		item := entity.TodoItem{
+			Item:   entity.TodoItemDto{Name: "Pay rent", Details: entity.TodoItemDetailsDto{Description: "Flat, March\nAsk the landlord", DueDate: dueDate, Priority: "LOW"}},
+			Status: entity.StatusTodo,
+			Labels: []string{"home", "bills"},
+		}
+
+		_, items, err := readMarkdownItems(strings.NewReader(markdownTaskLines(item)), time.UTC)
+		assert.NoError(t, err)
+
+		assert.Len(t, items, 1)
+		assert.Equal(t, item.Item, items[0].Item)
+		assert.Equal(t, item.Labels, items[0].Labels)
+	})
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"todo-project/entity"
+)
+
+var (
+	msTodoLineBreak = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>`)
+	msTodoTag       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Export of Microsoft To Do with the lists and their tasks like Microsoft Graph returns them. The lists are
+// under lists, or under value like in a response of Graph
+type msTodoExport struct {
+	Lists []msTodoList `json:"lists"`
+	Value []msTodoList `json:"value"`
+}
+
+type msTodoList struct {
+	Id                string       `json:"id"`
+	DisplayName       string       `json:"displayName"`
+	WellknownListName string       `json:"wellknownListName"`
+	Tasks             []msTodoTask `json:"tasks"`
+}
+
+type msTodoTask struct {
+	Id          string          `json:"id"`
+	Title       string          `json:"title"`
+	Body        msTodoBody      `json:"body"`
+	Importance  string          `json:"importance"`
+	Status      string          `json:"status"`
+	DueDateTime *msTodoDateTime `json:"dueDateTime"`
+	Categories  []string        `json:"categories"`
+}
+
+type msTodoBody struct {
+	Content     string `json:"content"`
+	ContentType string `json:"contentType"`
+}
+
+type msTodoDateTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+func msTodoPriority(importance string) string {
+	switch importance {
+	case "high":
+		return "HIGH"
+	case "low":
+		return "LOW"
+	}
+
+	return "MEDIUM"
+}
+
+func msTodoStatus(status string) string {
+	switch status {
+	case "inProgress":
+		return entity.StatusInProgress
+	case "waitingOnOthers":
+		return entity.StatusBlocked
+	case "completed":
+		return entity.StatusDone
+	}
+
+	return entity.StatusTodo
+}
+
+// Text of the body of a task, an HTML body loses its tags
+func msTodoDescription(body msTodoBody) string {
+	if !strings.EqualFold(body.ContentType, "html") {
+		return strings.TrimSpace(body.Content)
+	}
+
+	text := msTodoLineBreak.ReplaceAllString(body.Content, "\n")
+	text = html.UnescapeString(msTodoTag.ReplaceAllString(text, ""))
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Due date of a task in its timezone, or in location when Go does not know the timezone. To Do keeps the
+// day of a task at midnight, so a due date at midnight is due at the end of the day
+func msTodoDueDate(due msTodoDateTime, location *time.Location) (time.Time, error) {
+	if timezone, err := time.LoadLocation(due.TimeZone); due.TimeZone != "" && err == nil {
+		location = timezone
+	}
+
+	date, err := time.ParseInLocation("2006-01-02T15:04:05.9999999", due.DateTime, location)
+	if err != nil {
+		return date, fmt.Errorf("due date %q must be a date and time like 2023-10-15T00:00:00.0000000", due.DateTime)
+	}
+
+	if date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0 && date.Nanosecond() == 0 {
+		date = time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, location)
+	}
+
+	return date.UTC(), nil
+}
+
+// Reads the tasks of the lists of a Microsoft To Do export. The list of a task is a label unless it is the
+// default list of the account, the categories of a task are labels too
+func readMSTodoTasks(file io.Reader, location *time.Location) ([]appTask, error) {
+	var export msTodoExport
+	if err := json.NewDecoder(file).Decode(&export); err != nil {
+		return nil, err
+	}
+
+	lists := append(export.Lists, export.Value...)
+	if len(lists) == 0 {
+		return nil, errors.New("the export has no lists")
+	}
+
+	tasks := []appTask{}
+	for _, list := range lists {
+		listLabel := list.DisplayName
+		if list.WellknownListName == "defaultList" {
+			listLabel = ""
+		}
+
+		for _, msTask := range list.Tasks {
+			if msTask.Id == "" {
+				return nil, fmt.Errorf("a task of the list %q has no id", list.DisplayName)
+			}
+
+			task := appTask{sourceId: msTask.Id}
+			task.item.Item.Name = strings.TrimSpace(msTask.Title)
+			task.item.Item.Details.Description = msTodoDescription(msTask.Body)
+			task.item.Item.Details.Priority = msTodoPriority(msTask.Importance)
+			task.item.Status = msTodoStatus(msTask.Status)
+			task.item.Labels = addAppLabels(nil, listLabel)
+			task.item.Labels = addAppLabels(task.item.Labels, msTask.Categories...)
+
+			if msTask.DueDateTime != nil && msTask.DueDateTime.DateTime != "" {
+				due, err := msTodoDueDate(*msTask.DueDateTime, location)
+				if err != nil {
+					return nil, fmt.Errorf("task %s: %v", msTask.Id, err)
+				}
+
+				task.item.Item.Details.DueDate = due
+				task.hasDue = true
+			}
+
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
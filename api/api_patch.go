@@ -0,0 +1,294 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"todo-project/entity"
+)
+
+const (
+	MIMEMergePatch = "application/merge-patch+json"
+	MIMEJSONPatch  = "application/json-patch+json"
+)
+
+// Applies a JSON Merge Patch (RFC 7396) to a JSON document
+func MergePatch(document, patch []byte) ([]byte, error) {
+	var target, p interface{}
+
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+
+		targetObject[key] = mergeValue(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+type JSONPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Applies a JSON Patch (RFC 6902) to a JSON document
+func ApplyJSONPatch(document, patch []byte) ([]byte, error) {
+	var target interface{}
+	var operations []JSONPatchOperation
+
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, err
+	}
+
+	for i, operation := range operations {
+		var err error
+		target, err = applyOperation(target, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %v", i, operation.Op, operation.Path, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func applyOperation(target interface{}, operation JSONPatchOperation) (interface{}, error) {
+	switch operation.Op {
+	case "add", "replace", "test":
+		var value interface{}
+		if operation.Value == nil {
+			return nil, fmt.Errorf("missing value")
+		}
+		if err := json.Unmarshal(operation.Value, &value); err != nil {
+			return nil, err
+		}
+
+		if operation.Op == "test" {
+			current, err := getPointer(target, operation.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("test failed")
+			}
+			return target, nil
+		}
+
+		if operation.Op == "replace" {
+			if _, err := getPointer(target, operation.Path); err != nil {
+				return nil, err
+			}
+		}
+
+		return setPointer(target, operation.Path, value, operation.Op == "add")
+	case "remove":
+		return removePointer(target, operation.Path)
+	case "move", "copy":
+		value, err := getPointer(target, operation.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if operation.Op == "move" {
+			target, err = removePointer(target, operation.From)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return setPointer(target, operation.Path, value, true)
+	}
+
+	return nil, fmt.Errorf("unsupported operation %q", operation.Op)
+}
+
+// Splits a JSON Pointer (RFC 6901) into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func arrayIndex(token string, length int, appendAllowed bool) (int, error) {
+	if token == "-" && appendAllowed {
+		return length, nil
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > length || (index == length && !appendAllowed) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	return index, nil
+}
+
+func getPointer(target interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	current := target
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path %q does not exist", pointer)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("path %q does not exist", pointer)
+		}
+	}
+
+	return current, nil
+}
+
+func setPointer(target interface{}, pointer string, value interface{}, insert bool) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+	parent, err := getPointer(target, parentPointer)
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return target, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(node), insert)
+		if err != nil {
+			return nil, err
+		}
+
+		if insert {
+			node = append(node[:index], append([]interface{}{value}, node[index:]...)...)
+		} else {
+			node[index] = value
+		}
+
+		return setPointer(target, parentPointer, node, false)
+	}
+
+	return nil, fmt.Errorf("path %q does not exist", pointer)
+}
+
+func removePointer(target interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+
+	parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+	parent, err := getPointer(target, parentPointer)
+	if err != nil {
+		return nil, err
+	}
+
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[last]; !ok {
+			return nil, fmt.Errorf("path %q does not exist", pointer)
+		}
+		delete(node, last)
+		return target, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+
+		node = append(node[:index], node[index+1:]...)
+		return setPointer(target, parentPointer, node, false)
+	}
+
+	return nil, fmt.Errorf("path %q does not exist", pointer)
+}
+
+// Columns of todo_items that differ between the current and the patched item
+func changedItemColumns(current, patched entity.TodoItemDto) map[string]interface{} {
+	changes := map[string]interface{}{}
+
+	if current.Name != patched.Name {
+		changes["name"] = patched.Name
+	}
+
+	if current.Details.Description != patched.Details.Description {
+		changes["description"] = patched.Details.Description
+	}
+
+	if !current.Details.DueDate.Equal(patched.Details.DueDate) {
+		changes["due_date"] = patched.Details.DueDate.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	if current.Details.Priority != patched.Details.Priority {
+		changes["priority"] = patched.Details.Priority
+	}
+
+	return changes
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestPatch(t *testing.T) {
+	t.Run("Test Merge patch", func(t *testing.T) {
+		// Examples from RFC 7396 appendix A
+		cases := []struct {
+			document, patch, want string
+		}{
+			{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+			{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+			{`{"a":"b"}`, `{"a":null}`, `{}`},
+			{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+			{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+			{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+			{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+			{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+			{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		}
+
+		for _, c := range cases {
+			got, err := MergePatch([]byte(c.document), []byte(c.patch))
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, c.want, string(got))
+		}
+
+		_, err := MergePatch([]byte(`{}`), []byte(`{`))
+		assert.Error(t, err)
+	})
+
+	t.Run("Test JSON patch", func(t *testing.T) {
+		document := `{"name":"Todo list 1","details":{"priority":"LOW","tags":["a","b"]}}`
+
+		cases := []struct {
+			patch, want string
+		}{
+			{`[{"op":"replace","path":"/name","value":"Todo list 2"}]`, `{"name":"Todo list 2","details":{"priority":"LOW","tags":["a","b"]}}`},
+			{`[{"op":"add","path":"/details/tags/1","value":"c"}]`, `{"name":"Todo list 1","details":{"priority":"LOW","tags":["a","c","b"]}}`},
+			{`[{"op":"add","path":"/details/tags/-","value":"c"}]`, `{"name":"Todo list 1","details":{"priority":"LOW","tags":["a","b","c"]}}`},
+			{`[{"op":"remove","path":"/details/tags/0"}]`, `{"name":"Todo list 1","details":{"priority":"LOW","tags":["b"]}}`},
+			{`[{"op":"test","path":"/details/priority","value":"LOW"},{"op":"replace","path":"/details/priority","value":"HIGH"}]`, `{"name":"Todo list 1","details":{"priority":"HIGH","tags":["a","b"]}}`},
+			{`[{"op":"move","from":"/details/priority","path":"/priority"}]`, `{"name":"Todo list 1","priority":"LOW","details":{"tags":["a","b"]}}`},
+			{`[{"op":"copy","from":"/name","path":"/details/name"}]`, `{"name":"Todo list 1","details":{"name":"Todo list 1","priority":"LOW","tags":["a","b"]}}`},
+		}
+
+		for _, c := range cases {
+			got, err := ApplyJSONPatch([]byte(document), []byte(c.patch))
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, c.want, string(got))
+		}
+
+		failing := []string{
+			`[{"op":"test","path":"/details/priority","value":"HIGH"}]`,
+			`[{"op":"replace","path":"/missing","value":"x"}]`,
+			`[{"op":"remove","path":"/details/tags/5"}]`,
+			`[{"op":"increment","path":"/name"}]`,
+			`[{"op":"add","path":"name","value":"x"}]`,
+		}
+
+		for _, patch := range failing {
+			_, err := ApplyJSONPatch([]byte(document), []byte(patch))
+			assert.Error(t, err, patch)
+		}
+	})
+
+	t.Run("Test Changed item columns", func(t *testing.T) {
+		dueDate := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+		current := entity.TodoItemDto{
+			Name: "Todo list 1",
+			Details: entity.TodoItemDetailsDto{
+				Description: "This is item 1",
+				DueDate:     dueDate,
+				Priority:    "LOW",
+			},
+		}
+
+		patched := current
+		assert.Empty(t, changedItemColumns(current, patched))
+
+		patched.Name = "Todo list 2"
+		patched.Details.DueDate = dueDate.In(time.FixedZone("IST", 19800))
+		patched.Details.Priority = "HIGH"
+
+		assert.Equal(t, map[string]interface{}{
+			"name":     "Todo list 2",
+			"priority": "HIGH",
+		}, changedItemColumns(current, patched))
+	})
+}
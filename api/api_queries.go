@@ -1,19 +1,262 @@
 package api
 
+const itemLabelsColumn = `COALESCE((SELECT string_agg(label, ',' ORDER BY label) FROM item_labels WHERE item_id = todo_items.id), '') AS labels`
+
+const itemCommentCountColumn = `(SELECT COUNT(*) FROM item_comments WHERE item_id = todo_items.id AND is_deleted = false) AS comment_count`
+
+const itemWatchersColumn = `COALESCE((SELECT string_agg(user_id, ',' ORDER BY user_id) FROM item_watchers WHERE item_id = todo_items.id), '') AS watchers`
+
+const itemBlockedByColumn = `COALESCE((SELECT string_agg(blocker_id, ',' ORDER BY blocker_id) FROM item_dependencies WHERE item_id = todo_items.id), '') AS blocked_by`
+
+// An item is blocked while any of its blockers is neither done, cancelled nor in the trash
+const itemBlockedColumn = `EXISTS (SELECT 1 FROM item_dependencies JOIN todo_items AS blockers ON blockers.id = item_dependencies.blocker_id
+	WHERE item_dependencies.item_id = todo_items.id AND blockers.is_deleted = false AND blockers.status NOT IN ('done', 'cancelled')) AS blocked`
+
+const itemColumns = `id,name,description,due_date,priority,created_at,updated_at,is_completed,is_deleted,user_id,deleted_at,status,started_at,completed_at,version,` + itemLabelsColumn + `,` + itemCommentCountColumn + `,COALESCE(assignee_id, '') AS assignee_id,` + itemWatchersColumn + `,COALESCE(workspace_id, '') AS workspace_id,` + itemBlockedByColumn + `,` + itemBlockedColumn + `,COALESCE(rank, '') AS rank,COALESCE(recurrence, '') AS recurrence`
+
+// Items of the list ordered by their rank, the oldest first among items with the same rank
+const itemListOrder = ` ORDER BY rank, created_at, id`
+
+const attachmentColumns = `id,item_id,user_id,file_name,content_type,size,storage_key,created_at`
+
+const shareColumns = `id,item_id,user_id,(SELECT email FROM users WHERE users.id = item_shares.user_id) AS email,role,status,invited_by,created_at,responded_at`
+
+const workspaceMemberColumns = `id,workspace_id,user_id,(SELECT email FROM users WHERE users.id = workspace_members.user_id) AS email,role,status,invited_by,created_at,responded_at`
+
+const savedFilterColumns = `id,user_id,name,expression,created_at,updated_at`
+const templateColumns = `id,user_id,name,items,created_at`
+const calendarFeedColumns = `id,user_id,COALESCE(workspace_id, '') AS workspace_id,created_at`
+const commentColumns = `id,item_id,parent_id,user_id,body,created_at,edited_at,is_deleted`
+const webhookColumns = `id,user_id,COALESCE(workspace_id, '') AS workspace_id,url,secret,events,created_at`
+const webhookDeliveryColumns = `id,webhook_id,event,status,attempts,next_attempt_at,last_status_code,last_error,created_at,delivered_at,payload`
+
 const IfItemBelongToUserQuery = `SELECT EXISTS (SELECT 1 FROM todo_items WHERE id='%s' AND user_id='%s') AS is_current_user`
 
+// The highest of the workspace role and the share role of the user $2, empty when the user has no role
+const itemRoleOfUserColumn = `CASE WHEN user_id=$2 THEN 'owner' ELSE COALESCE((SELECT role FROM (
+	SELECT role FROM workspace_members WHERE workspace_id = todo_items.workspace_id AND user_id=$2 AND status = 'accepted'
+	UNION ALL SELECT role FROM item_shares WHERE item_id = todo_items.id AND user_id=$2 AND status = 'accepted') AS roles
+	ORDER BY CASE role WHEN 'owner' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END LIMIT 1), '') END AS role`
+
+// Items outside the workspace $3 have no role
+const ItemRoleOfUserQuery = `SELECT ` + itemRoleOfUserColumn + ` FROM todo_items WHERE id=$1 AND workspace_id IS NOT DISTINCT FROM NULLIF($3, '')`
+
+// Items the user $3 can see, as the owner, through an accepted share or as a member of their workspace
+const itemVisibleToUserCondition = `(user_id=$3 OR id IN (SELECT item_id FROM item_shares WHERE user_id=$3 AND status = 'accepted')
+	OR workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id=$3 AND status = 'accepted'))`
+
+const WorkspaceRoleOfUserQuery = `SELECT role FROM workspace_members WHERE workspace_id=$1 AND user_id=$2 AND status = 'accepted'`
+
 //POST
-const CreateTodoItemQuery = `INSERT INTO todo_items (id,name,description,due_date,priority,created_at,updated_at,user_id) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING *;`
+const CreateTodoItemQuery = `INSERT INTO todo_items (id,name,description,due_date,priority,created_at,updated_at,user_id,workspace_id,rank) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,NULLIF($9, ''),$10)
+	ON CONFLICT (id) DO NOTHING RETURNING ` + itemColumns + `;`
+const CreateAttachmentQuery = `INSERT INTO item_attachments (` + attachmentColumns + `) VALUES ($1,$2,$3,$4,$5,$6,$7,$8);`
+const CreateCommentQuery = `INSERT INTO item_comments (id,item_id,parent_id,user_id,body,created_at) VALUES ($1,$2,$3,$4,$5,$6) RETURNING ` + commentColumns + `;`
+const ShareItemQuery = `INSERT INTO item_shares (id,item_id,user_id,role,status,invited_by,created_at) VALUES ($1,$2,$3,$4,'pending',$5,$6)
+	ON CONFLICT (item_id, user_id) DO UPDATE SET role=EXCLUDED.role,
+	status=CASE WHEN item_shares.status = 'declined' THEN 'pending' ELSE item_shares.status END,
+	responded_at=CASE WHEN item_shares.status = 'declined' THEN NULL ELSE item_shares.responded_at END
+	RETURNING ` + shareColumns + `;`
+const CreateWorkspaceQuery = `INSERT INTO workspaces (id,name,owner_id,created_at) VALUES ($1,$2,$3,$4);`
+const AddWorkspaceOwnerQuery = `INSERT INTO workspace_members (id,workspace_id,user_id,role,status,invited_by,created_at,responded_at) VALUES ($1,$2,$3,'owner','accepted',$3,$4,$4);`
+const InviteWorkspaceMemberQuery = `INSERT INTO workspace_members (id,workspace_id,user_id,role,status,invited_by,created_at) VALUES ($1,$2,$3,$4,'pending',$5,$6)
+	ON CONFLICT (workspace_id, user_id) DO UPDATE SET role=EXCLUDED.role,
+	status=CASE WHEN workspace_members.status = 'declined' THEN 'pending' ELSE workspace_members.status END,
+	responded_at=CASE WHEN workspace_members.status = 'declined' THEN NULL ELSE workspace_members.responded_at END
+	RETURNING ` + workspaceMemberColumns + `;`
+const CreateSavedFilterQuery = `INSERT INTO saved_filters (id,user_id,name,expression,created_at,updated_at) VALUES ($1,$2,$3,$4,$5,$5) RETURNING ` + savedFilterColumns + `;`
+const AddItemDependencyQuery = `INSERT INTO item_dependencies (item_id, blocker_id, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING;`
+const CreateTemplateQuery = `INSERT INTO item_templates (id,user_id,name,items,created_at) VALUES ($1,$2,$3,$4,$5) RETURNING ` + templateColumns + `;`
+
+// A new token of a list replaces the previous one, the old feed URL stops working
+const CreateCalendarFeedQuery = `INSERT INTO calendar_feeds (id,user_id,workspace_id,token_hash,created_at) VALUES ($1,$2,NULLIF($3, ''),$4,$5)
+	ON CONFLICT (user_id, (COALESCE(workspace_id, ''))) DO UPDATE SET token_hash=EXCLUDED.token_hash, created_at=EXCLUDED.created_at
+	RETURNING ` + calendarFeedColumns + `;`
+const CreateItemRevisionQuery = `INSERT INTO item_revisions (item_id,user_id,action,snapshot,created_at) VALUES ($1,$2,$3,$4,$5);`
+
+// Imported items are inserted a batch at a time, the values of each item are filled in with importItemValues
+const ImportTodoItemsQuery = `INSERT INTO todo_items (id,name,description,due_date,priority,created_at,updated_at,user_id,workspace_id,rank,status,is_completed,started_at,completed_at) VALUES %s RETURNING ` + itemColumns + `;`
+const importItemValues = `($%[1]d,$%[2]d,$%[3]d,$%[4]d,$%[5]d,$%[6]d,$%[6]d,$%[7]d,NULLIF($%[8]d, ''),$%[9]d,$%[10]d::text,$%[10]d::text = 'done',
+	CASE WHEN $%[10]d::text = 'in_progress' THEN $%[6]d::timestamp END,CASE WHEN $%[10]d::text = 'done' THEN $%[6]d::timestamp END)`
+const ImportItemLabelsQuery = `INSERT INTO item_labels (item_id, label) VALUES %s ON CONFLICT DO NOTHING;`
+const CreateItemRevisionsQuery = `INSERT INTO item_revisions (item_id,user_id,action,snapshot,created_at) VALUES %s;`
+const CreateItemSourcesQuery = `INSERT INTO item_sources (item_id,user_id,workspace_id,source,source_id,imported_at) VALUES %s;`
+const CreateWebhookQuery = `INSERT INTO webhooks (id,user_id,workspace_id,url,secret,events,created_at) VALUES ($1,$2,NULLIF($3, ''),$4,$5,$6,$7) RETURNING ` + webhookColumns + `;`
+const CreateWebhookDeliveriesQuery = `INSERT INTO webhook_deliveries (id,webhook_id,event,payload,next_attempt_at,created_at) VALUES %s RETURNING ` + webhookDeliveryColumns + `;`
+
+// Logs an event of the item $1, the users the item is shared with get it too
+const CreateItemEventQuery = `INSERT INTO item_events (item_id,workspace_id,recipients,event,actor_id,payload,created_at)
+	VALUES ($1,NULLIF($2, ''),$3::text[] || ARRAY(SELECT user_id FROM item_shares WHERE item_id=$1 AND status = 'accepted'),$4,$5,$6,$7) RETURNING id, recipients;`
+
+// Wakes the streams of every instance, Postgres sends the notification when the transaction commits
+const NotifyItemEventQuery = `SELECT pg_notify($1, $2);`
 
 //GET
-const FindByIdQuery = `SELECT * FROM todo_items WHERE id='%s'`
-const GetAllItemsQuery = `SELECT * FROM todo_items WHERE is_deleted = false AND user_id='%s' LIMIT %d`
+const FindByIdQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE id=$1 AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '')`
+const GetAllItemsQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE is_deleted = false AND workspace_id IS NULL AND user_id='%s'` + itemListOrder + ` LIMIT %d`
+const GetWorkspaceItemsQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE is_deleted = false AND workspace_id=$1` + itemListOrder + ` LIMIT $2`
+
+// Items of the personal list of the user $1 or of the workspace $2
+const GetBoardItemsQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND ($2 <> '' OR user_id=$1)` + itemListOrder
+const FindListItemsQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND ($2 <> '' OR user_id=$1) AND id = ANY($3)`
+const ListChangeTagQuery = `SELECT COUNT(*), COALESCE(SUM(version), 0), MAX(updated_at) FROM todo_items WHERE is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND ($2 <> '' OR user_id=$1)`
+const FindItemsOfUserQuery = `SELECT * FROM (SELECT ` + itemColumns + `,` + itemRoleOfUserColumn + ` FROM todo_items
+	WHERE id = ANY($1) AND workspace_id IS NOT DISTINCT FROM NULLIF($3, '')) AS items WHERE role <> ''`
+const GetSavedFiltersQuery = `SELECT ` + savedFilterColumns + ` FROM saved_filters WHERE user_id=$1 ORDER BY name, id`
+const FindSavedFilterQuery = `SELECT ` + savedFilterColumns + ` FROM saved_filters WHERE id=$1 AND user_id=$2`
+const GetTemplatesQuery = `SELECT ` + templateColumns + ` FROM item_templates WHERE user_id=$1 ORDER BY name, id`
+const FindTemplateQuery = `SELECT ` + templateColumns + ` FROM item_templates WHERE id=$1 AND user_id=$2`
+const FindCalendarFeedQuery = `SELECT ` + calendarFeedColumns + ` FROM calendar_feeds WHERE token_hash=$1`
+
+// Items imported into the list from tasks of an app, any member of a workspace may have imported them
+const FindItemSourcesQuery = `SELECT item_id, source_id FROM item_sources WHERE source=$1 AND source_id = ANY($2)
+	AND workspace_id IS NOT DISTINCT FROM NULLIF($4, '') AND ($4 <> '' OR user_id=$3)`
+const FindItemsByIdsQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE id = ANY($1) AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '')`
+const GetWebhooksQuery = `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id=$1 ORDER BY created_at, id`
+const FindWebhookQuery = `SELECT ` + webhookColumns + ` FROM webhooks WHERE id=$1 AND user_id=$2`
+
+// Webhooks subscribed to the event $1 of an item of the user $2 or of the workspace $3, the webhooks of a
+// workspace stop getting events when their user leaves it
+const FindEventWebhooksQuery = `SELECT id FROM webhooks WHERE $1 = ANY(events) AND ((workspace_id IS NULL AND $3 = '' AND user_id=$2)
+	OR (workspace_id = NULLIF($3, '') AND user_id IN (SELECT user_id FROM workspace_members WHERE workspace_id=$3 AND status = 'accepted')))`
+
+// Deliveries of the webhooks of the user $1, of one webhook when $2 is set and with one status when $3 is set
+const GetWebhookDeliveriesQuery = `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE webhook_id IN (SELECT id FROM webhooks WHERE user_id=$1)
+	AND ($2 = '' OR webhook_id=$2) AND ($3 = '' OR status=$3) ORDER BY created_at DESC, id LIMIT $4`
+const FindWebhookDeliveryQuery = `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id=$1 AND webhook_id=$2 AND webhook_id IN (SELECT id FROM webhooks WHERE user_id=$3)`
+const GetWebhookAttemptsQuery = `SELECT attempted_at,status_code,error,duration_ms FROM webhook_attempts WHERE delivery_id=$1 ORDER BY id`
+
+// The condition of the saved filter is compiled from its expression, its arguments start at $4
+const GetFilteredItemsQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND ($2 <> '' OR user_id=$1) AND %s` + itemListOrder + ` LIMIT $3`
+
+// A page of the items of the list selected by a filter, the arguments of its condition start at $5
+const GetItemsPageQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND ($2 <> '' OR user_id=$1) AND %s` + itemListOrder + ` LIMIT $3 OFFSET $4`
+const GetItemRevisionsQuery = `SELECT id,item_id,user_id,action,snapshot,created_at FROM item_revisions WHERE item_id=$1 ORDER BY id`
+const FindItemRevisionQuery = `SELECT id,item_id,user_id,action,snapshot,created_at FROM item_revisions WHERE item_id=$1 AND id=$2`
+const GetItemCommentsQuery = `SELECT ` + commentColumns + ` FROM item_comments WHERE item_id=$1 ORDER BY created_at, id`
+const GetCommentsOfItemsQuery = `SELECT ` + commentColumns + ` FROM item_comments WHERE item_id = ANY($1) ORDER BY created_at, id`
+const FindCommentByIdQuery = `SELECT ` + commentColumns + ` FROM item_comments WHERE item_id=$1 AND id=$2 AND is_deleted = false`
+const GetItemAttachmentsQuery = `SELECT ` + attachmentColumns + ` FROM item_attachments WHERE item_id=$1 ORDER BY created_at, id`
+const FindAttachmentByIdQuery = `SELECT ` + attachmentColumns + ` FROM item_attachments WHERE item_id=$1 AND id=$2`
+const GetStorageUsageQuery = `SELECT COALESCE(SUM(size), 0) FROM item_attachments WHERE user_id=$1`
+
+// Serializes the quota checks of the uploads of the user $1
+const LockStorageUsageQuery = `SELECT pg_advisory_xact_lock(hashtext('item_attachments:' || $1::text));`
+const GetItemAttachmentKeysQuery = `SELECT storage_key FROM item_attachments WHERE item_id=$1`
+const GetTrashAttachmentKeysQuery = `SELECT storage_key FROM item_attachments WHERE item_id IN (SELECT id FROM todo_items WHERE user_id=$1 AND is_deleted = true AND workspace_id IS NOT DISTINCT FROM NULLIF($2, ''))`
+const GetExpiredAttachmentKeysQuery = `SELECT storage_key FROM item_attachments WHERE item_id IN (SELECT id FROM todo_items WHERE is_deleted = true AND deleted_at < $1)`
+const FindUserIdByEmailQuery = `SELECT id FROM users WHERE email=$1`
+const FindUsersByIdsQuery = `SELECT id,email FROM users WHERE id = ANY($1)`
+const GetItemSharesQuery = `SELECT ` + shareColumns + ` FROM item_shares WHERE item_id=$1 ORDER BY created_at, id`
+const FindShareByIdQuery = `SELECT ` + shareColumns + ` FROM item_shares WHERE item_id=$1 AND id=$2`
+const GetInvitationsQuery = `SELECT ` + shareColumns + ` FROM item_shares WHERE user_id=$1 AND status = 'pending' ORDER BY created_at DESC, id`
+const GetSharedItemsQuery = `SELECT ` + itemColumns + `,(SELECT role FROM item_shares WHERE item_id = todo_items.id AND user_id=$1) AS role,(SELECT email FROM users WHERE users.id = todo_items.user_id) AS owner_email
+	FROM todo_items WHERE is_deleted = false AND id IN (SELECT item_id FROM item_shares WHERE user_id=$1 AND status = 'accepted') AND workspace_id IS NOT DISTINCT FROM NULLIF($3, '') ORDER BY updated_at DESC LIMIT $2`
+const GetAssignedItemsQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE is_deleted = false AND assignee_id=$1 AND ($2 = false OR user_id=$1) AND workspace_id IS NOT DISTINCT FROM NULLIF($4, '') LIMIT $3`
+const GetTrashItemsQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE is_deleted = true AND user_id=$1 AND workspace_id IS NOT DISTINCT FROM NULLIF($3, '') ORDER BY deleted_at DESC LIMIT $2`
+const GetItemBlockersQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE id IN (SELECT blocker_id FROM item_dependencies WHERE item_id=$1)
+	AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND ` + itemVisibleToUserCondition + ` ORDER BY created_at, id`
+const GetBlockedItemsQuery = `SELECT ` + itemColumns + ` FROM todo_items WHERE id IN (SELECT item_id FROM item_dependencies WHERE blocker_id=$1)
+	AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND ` + itemVisibleToUserCondition + ` ORDER BY created_at, id`
+
+// Whether the item $1 waits on the item $2, directly or through a chain of blockers
+const DependsOnQuery = `WITH RECURSIVE blockers(id) AS (
+	SELECT blocker_id FROM item_dependencies WHERE item_id=$1
+	UNION SELECT item_dependencies.blocker_id FROM item_dependencies JOIN blockers ON item_dependencies.item_id = blockers.id)
+	SELECT EXISTS (SELECT 1 FROM blockers WHERE id=$2)`
+
+// Serializes the cycle checks of the dependencies added in the workspace $1, the personal lists share one lock.
+// Both items of a dependency are in the same workspace, so a cycle cannot span two locks
+const LockItemDependenciesQuery = `SELECT pg_advisory_xact_lock(hashtext('item_dependencies:' || $1::text));`
+const GetWorkspacesOfUserQuery = `SELECT workspaces.id,workspaces.name,workspaces.owner_id,workspace_members.role,workspaces.created_at FROM workspaces
+	JOIN workspace_members ON workspace_members.workspace_id = workspaces.id WHERE workspace_members.user_id=$1 AND workspace_members.status = 'accepted' ORDER BY workspaces.name, workspaces.id`
+const GetWorkspaceMembersQuery = `SELECT ` + workspaceMemberColumns + ` FROM workspace_members WHERE workspace_id=$1 ORDER BY created_at, id`
+const FindWorkspaceMemberQuery = `SELECT ` + workspaceMemberColumns + ` FROM workspace_members WHERE workspace_id=$1 AND user_id=$2`
+const GetWorkspaceInvitationsQuery = `SELECT ` + workspaceMemberColumns + ` FROM workspace_members WHERE user_id=$1 AND status = 'pending' ORDER BY created_at DESC, id`
+
+// Events after the id $2 the user $1 gets, as a recipient or as a member of the workspace of the item
+const GetItemEventsQuery = `SELECT id,item_id,event,actor_id,payload,created_at FROM item_events WHERE id > $2
+	AND ($1 = ANY(recipients) OR workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id=$1 AND status = 'accepted')) ORDER BY id LIMIT $3`
+const GetItemEventBoundsQuery = `SELECT COALESCE(MIN(id), 0), COALESCE(MAX(id), 0) FROM item_events`
+
+// Items and tombstones of the personal list of the user $1 or of the workspace $2 changed after the number $3 of
+// the change sequence, in the order of the sequence. The lock waits for the changes being written to commit
+const LockItemChangesQuery = `SELECT pg_advisory_xact_lock(hashtext('todo_items_change_seq'));`
+const GetItemChangesQuery = `SELECT ` + itemColumns + `,change_seq FROM todo_items WHERE change_seq > $3 AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND ($2 <> '' OR user_id=$1) ORDER BY change_seq LIMIT $4`
+const GetItemTombstonesQuery = `SELECT item_id,change_seq,deleted_at FROM item_tombstones WHERE change_seq > $3 AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND ($2 <> '' OR user_id=$1) ORDER BY change_seq LIMIT $4`
 
 //UPDATE
-const UpdateItemQuery = `UPDATE todo_items SET description=$1, due_date=$2, priority=$3, updated_at=$4 WHERE id=$5 RETURNING *;`
+const AddItemLabelsQuery = `INSERT INTO item_labels (item_id, label) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING;`
+const TouchItemQuery = `UPDATE todo_items SET updated_at=$1 WHERE id=$2 AND workspace_id IS NOT DISTINCT FROM NULLIF($3, '') RETURNING ` + itemColumns + `;`
+const UpdateItemQuery = `UPDATE todo_items SET description=$1, due_date=$2, priority=$3, updated_at=$4 WHERE id=$5 AND workspace_id IS NOT DISTINCT FROM NULLIF($7, '') AND ($6 = 0 OR version=$6) RETURNING ` + itemColumns + `;`
+const PatchItemQuery = `UPDATE todo_items SET %[1]s, updated_at=$%[2]d WHERE id=$%[3]d AND workspace_id IS NOT DISTINCT FROM NULLIF($%[5]d, '') AND ($%[4]d = 0 OR version=$%[4]d) RETURNING ` + itemColumns + `;`
+const SetItemRankQuery = `UPDATE todo_items SET rank=$1, updated_at=$2 WHERE id=$3 AND workspace_id IS NOT DISTINCT FROM NULLIF($5, '') AND ($4 = 0 OR version=$4) RETURNING ` + itemColumns + `;`
+
+// Items of the personal list of the user $1 or of the workspace $2 sharing the rank $3, in their list order. They are
+// locked so that concurrent moves spread them one after the other
+const GetRankTieQuery = `SELECT id FROM todo_items WHERE rank=$3 AND is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND ($2 <> '' OR user_id=$1)
+	ORDER BY created_at, id FOR UPDATE`
+const GetNextRankQuery = `SELECT COALESCE(MIN(rank), '') FROM todo_items WHERE rank > $3 AND is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND ($2 <> '' OR user_id=$1)`
+const UpdateSavedFilterQuery = `UPDATE saved_filters SET name=$1, expression=$2, updated_at=$3 WHERE id=$4 AND user_id=$5 RETURNING ` + savedFilterColumns + `;`
+const SetItemRecurrenceQuery = `UPDATE todo_items SET recurrence=NULLIF($1, ''), updated_at=$2 WHERE id=$3 AND workspace_id IS NOT DISTINCT FROM NULLIF($4, '') RETURNING ` + itemColumns + `;`
+const SetItemAssigneeQuery = `UPDATE todo_items SET assignee_id=NULLIF($1, ''), updated_at=$2 WHERE id=$3 AND workspace_id IS NOT DISTINCT FROM NULLIF($4, '') RETURNING ` + itemColumns + `;`
+const AddItemWatcherQuery = `INSERT INTO item_watchers (item_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;`
+const UpdateCommentQuery = `UPDATE item_comments SET body=$1, edited_at=$2 WHERE id=$3 AND is_deleted = false RETURNING ` + commentColumns + `;`
+const RespondToShareQuery = `UPDATE item_shares SET status=$1, responded_at=$2 WHERE id=$3 AND user_id=$4 AND status = 'pending' RETURNING ` + shareColumns + `;`
+const RespondToWorkspaceInvitationQuery = `UPDATE workspace_members SET status=$1, responded_at=$2 WHERE id=$3 AND user_id=$4 AND status = 'pending' RETURNING ` + workspaceMemberColumns + `;`
+const RestoreItemQuery = `UPDATE todo_items SET is_deleted = 'false', deleted_at=NULL, updated_at=$1 WHERE id=$2 AND workspace_id IS NOT DISTINCT FROM NULLIF($3, '') AND is_deleted = true RETURNING ` + itemColumns + `;`
+
+//DELETE
+const RemoveItemLabelsQuery = `DELETE FROM item_labels WHERE item_id=$1 AND label = ANY($2);`
+const DeleteItemQuery = `UPDATE todo_items SET is_deleted = 'true', updated_at=$1, deleted_at=$1 WHERE id=$2 AND workspace_id IS NOT DISTINCT FROM NULLIF($4, '') AND ($3 = 0 OR version=$3);`
+const DeleteAttachmentQuery = `DELETE FROM item_attachments WHERE id=$1;`
+const DeleteCommentQuery = `UPDATE item_comments SET is_deleted = true, edited_at=$1 WHERE id=$2 AND is_deleted = false;`
+const RemoveItemDependencyQuery = `DELETE FROM item_dependencies WHERE item_id=$1 AND blocker_id=$2;`
+const DeleteSavedFilterQuery = `DELETE FROM saved_filters WHERE id=$1 AND user_id=$2;`
+const DeleteTemplateQuery = `DELETE FROM item_templates WHERE id=$1 AND user_id=$2;`
+const DeleteCalendarFeedQuery = `DELETE FROM calendar_feeds WHERE user_id=$1 AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '');`
+const RemoveItemWatcherQuery = `DELETE FROM item_watchers WHERE item_id=$1 AND user_id=$2;`
 
-//DELETE 
-const DeleteItemQuery = `UPDATE todo_items SET is_deleted = 'true', updated_at=$1 WHERE id=$2;`
+// Revoking a share also drops the assignment and the watch of the user, who can no longer see the item
+const DeleteShareQuery = `WITH share AS (DELETE FROM item_shares WHERE id=$1 RETURNING item_id, user_id),
+	unwatched AS (DELETE FROM item_watchers WHERE (item_id, user_id) IN (SELECT item_id, user_id FROM share))
+	UPDATE todo_items SET assignee_id = NULL WHERE (id, assignee_id) IN (SELECT item_id, user_id FROM share);`
+
+// Locks the accepted owners of the workspace $1 until the transaction ends, counting them and whether the user $2
+// is one of them. Removals of owners wait on each other, so two owners removing each other cannot leave no owner
+const LockWorkspaceOwnersQuery = `SELECT COUNT(*), COALESCE(BOOL_OR(user_id=$2), false) FROM (SELECT user_id FROM workspace_members
+	WHERE workspace_id=$1 AND role = 'owner' AND status = 'accepted' ORDER BY id FOR UPDATE) AS owners`
+
+// Removing a member also drops their item shares, assignments and watches in the workspace
+const DeleteWorkspaceMemberQuery = `WITH member AS (DELETE FROM workspace_members WHERE workspace_id=$1 AND user_id=$2 RETURNING workspace_id, user_id),
+	unshared AS (DELETE FROM item_shares WHERE user_id IN (SELECT user_id FROM member) AND item_id IN (SELECT id FROM todo_items WHERE workspace_id=$1)),
+	unwatched AS (DELETE FROM item_watchers WHERE user_id IN (SELECT user_id FROM member) AND item_id IN (SELECT id FROM todo_items WHERE workspace_id=$1))
+	UPDATE todo_items SET assignee_id = NULL WHERE workspace_id=$1 AND assignee_id IN (SELECT user_id FROM member);`
+const PurgeItemQuery = `DELETE FROM todo_items WHERE id=$1 AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '') AND is_deleted = true;`
+const EmptyTrashQuery = `DELETE FROM todo_items WHERE user_id=$1 AND is_deleted = true AND workspace_id IS NOT DISTINCT FROM NULLIF($2, '');`
+const PurgeExpiredItemsQuery = `DELETE FROM todo_items WHERE is_deleted = true AND deleted_at < $1;`
+const DeleteWebhookQuery = `DELETE FROM webhooks WHERE id=$1 AND user_id=$2;`
+const PurgeItemEventsQuery = `DELETE FROM item_events WHERE created_at < $1;`
 
 //PATCH
-const SetItemStatusAsCompletedQuery = `UPDATE todo_items SET is_completed = 'true', updated_at=$1 WHERE id=$2 RETURNING *;`
\ No newline at end of file
+const SetItemStatusAsCompletedQuery = `UPDATE todo_items SET is_completed = 'true', status = 'done', completed_at=COALESCE(completed_at, $1), updated_at=$1 WHERE id=$2 AND workspace_id IS NOT DISTINCT FROM NULLIF($4, '') AND ($3 = 0 OR version=$3) RETURNING ` + itemColumns + `;`
+const SetItemStatusQuery = `UPDATE todo_items SET status=$1::text, is_completed=($1::text = 'done'),
+	started_at=CASE WHEN $1::text = 'todo' THEN NULL WHEN $1::text = 'in_progress' THEN COALESCE(started_at, $2) ELSE started_at END,
+	completed_at=CASE WHEN $1::text = 'done' THEN COALESCE(completed_at, $2) ELSE NULL END,
+	updated_at=$2 WHERE id=$3 AND workspace_id IS NOT DISTINCT FROM NULLIF($5, '') AND ($4 = 0 OR version=$4) RETURNING ` + itemColumns + `;`
+
+// Claims the pending deliveries due at $1 until $2, other workers skip them while they are sent and pick
+// them up again when the worker sending them stopped before recording the attempt
+const ClaimWebhookDeliveriesQuery = `UPDATE webhook_deliveries SET next_attempt_at=$2 FROM webhooks WHERE webhooks.id = webhook_deliveries.webhook_id
+	AND webhook_deliveries.id IN (SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= $1 ORDER BY next_attempt_at LIMIT $3 FOR UPDATE SKIP LOCKED)
+	RETURNING webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.event, webhook_deliveries.attempts, webhook_deliveries.payload, webhooks.url, webhooks.secret;`
+const RecordWebhookAttemptQuery = `WITH attempt AS (INSERT INTO webhook_attempts (delivery_id,attempted_at,status_code,error,duration_ms) VALUES ($1,$2,$3,$4,$5))
+	UPDATE webhook_deliveries SET attempts=attempts+1, status=$6::text, next_attempt_at=$7, last_status_code=$3, last_error=$4,
+	delivered_at=CASE WHEN $6::text = 'delivered' THEN $2::timestamp END WHERE id=$1;`
+const RedeliverWebhookDeliveryQuery = `UPDATE webhook_deliveries SET status='pending', attempts=0, next_attempt_at=$4
+	WHERE id=$1 AND webhook_id=$2 AND webhook_id IN (SELECT id FROM webhooks WHERE user_id=$3) RETURNING ` + webhookDeliveryColumns + `;`
+
+//TRANSACTION
+const SavepointQuery = `SAVEPOINT bulk_operation;`
+const RollbackToSavepointQuery = `ROLLBACK TO SAVEPOINT bulk_operation;`
+const ReleaseSavepointQuery = `RELEASE SAVEPOINT bulk_operation;`
@@ -0,0 +1,449 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Kinds of the words of a quick add line that are not part of the name
+const (
+	QuickAddDate       = "date"
+	QuickAddTime       = "time"
+	QuickAddPriority   = "priority"
+	QuickAddLabel      = "label"
+	QuickAddRecurrence = "recurrence"
+)
+
+// Priority of the items added without one
+const defaultQuickAddPriority = "MEDIUM"
+
+var quickAddWeekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+var quickAddUnits = map[string]string{
+	"day": "DAILY", "days": "DAILY", "week": "WEEKLY", "weeks": "WEEKLY",
+	"month": "MONTHLY", "months": "MONTHLY", "year": "YEARLY", "years": "YEARLY",
+}
+
+var quickAddFrequencies = map[string]string{"daily": "DAILY", "weekly": "WEEKLY", "monthly": "MONTHLY", "yearly": "YEARLY"}
+
+var quickAddTime = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+
+type quickAddWord struct {
+	text       string
+	start, end int
+}
+
+// Splits a line into words with their character offsets
+func quickAddWords(text string) []quickAddWord {
+	var words []quickAddWord
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+
+		words = append(words, quickAddWord{text: string(runes[start:i]), start: start, end: i})
+	}
+
+	return words
+}
+
+type quickAddParser struct {
+	words  []quickAddWord
+	next   int
+	now    time.Time
+	result entity.QuickAddParsed
+	tokens []entity.QuickAddToken
+	name   []string
+
+	date                   *time.Time
+	hour, minute           int
+	hasTime, hasRecurrence bool
+	hasPriority            bool
+}
+
+// Lower cased word at the offset from the next word, empty past the end of the line
+func (p *quickAddParser) peek(offset int) string {
+	if p.next+offset >= len(p.words) {
+		return ""
+	}
+
+	return strings.ToLower(p.words[p.next+offset].text)
+}
+
+// Records the next n words as a token of the kind
+func (p *quickAddParser) take(n int, kind, value string) {
+	first, last := p.words[p.next], p.words[p.next+n-1]
+
+	var text []string
+	for _, word := range p.words[p.next : p.next+n] {
+		text = append(text, word.text)
+	}
+
+	p.tokens = append(p.tokens, entity.QuickAddToken{
+		Text:  strings.Join(text, " "),
+		Kind:  kind,
+		Value: value,
+		Start: first.start,
+		End:   last.end,
+	})
+	p.next += n
+}
+
+func (p *quickAddParser) today() time.Time {
+	return time.Date(p.now.Year(), p.now.Month(), p.now.Day(), 0, 0, 0, 0, p.now.Location())
+}
+
+// The coming weekday, today included
+func (p *quickAddParser) comingWeekday(weekday time.Weekday) time.Time {
+	days := (int(weekday) - int(p.now.Weekday()) + 7) % 7
+	return p.today().AddDate(0, 0, days)
+}
+
+// Monday of the next week
+func (p *quickAddParser) nextWeek() time.Time {
+	days := (int(time.Monday) - int(p.now.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+
+	return p.today().AddDate(0, 0, days)
+}
+
+// Moves the day forward by a number of days, weeks, months or years
+func addUnits(day time.Time, n int, frequency string) time.Time {
+	switch frequency {
+	case "WEEKLY":
+		return day.AddDate(0, 0, 7*n)
+	case "MONTHLY":
+		return day.AddDate(0, n, 0)
+	case "YEARLY":
+		return day.AddDate(n, 0, 0)
+	}
+
+	return day.AddDate(0, 0, n)
+}
+
+// Parses a time like 9am, 9:30pm or 21:00
+func parseQuickAddTime(word string) (hour, minute int, ok bool) {
+	match := quickAddTime.FindStringSubmatch(word)
+	if match == nil || (match[2] == "" && match[3] == "") {
+		return 0, 0, false
+	}
+
+	hour, _ = strconv.Atoi(match[1])
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+
+	switch match[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+
+	return hour, minute, true
+}
+
+// Date of the next words, the number of words and whether they are a date at all
+func (p *quickAddParser) parseDate() (time.Time, int, bool) {
+	word := p.peek(0)
+
+	switch word {
+	case "today":
+		return p.today(), 1, true
+	case "tomorrow":
+		return p.today().AddDate(0, 0, 1), 1, true
+	case "on":
+		if day, n, ok := p.parseDateAt(1); ok {
+			return day, n + 1, true
+		}
+		return time.Time{}, 0, false
+	case "next":
+		if p.peek(1) == "week" {
+			return p.nextWeek(), 2, true
+		}
+		if p.peek(1) == "month" {
+			today := p.today()
+			return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location()), 2, true
+		}
+		if weekday, ok := quickAddWeekdays[p.peek(1)]; ok {
+			return p.nextWeek().AddDate(0, 0, (int(weekday)+6)%7), 2, true
+		}
+		return time.Time{}, 0, false
+	case "in":
+		n, err := strconv.Atoi(p.peek(1))
+		frequency, ok := quickAddUnits[p.peek(2)]
+		if err != nil || !ok || n < 1 || n > 1000 {
+			return time.Time{}, 0, false
+		}
+		return addUnits(p.today(), n, frequency), 3, true
+	}
+
+	return p.parseDateAt(0)
+}
+
+// A weekday or a date like 2023-10-20
+func (p *quickAddParser) parseDateAt(offset int) (time.Time, int, bool) {
+	word := p.peek(offset)
+
+	if weekday, ok := quickAddWeekdays[word]; ok {
+		return p.comingWeekday(weekday), 1, true
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", word, p.now.Location())
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	return day, 1, true
+}
+
+// Recurrence rule of the next words, the number of words and whether they are a recurrence at all
+func (p *quickAddParser) parseRecurrence() (string, int, bool) {
+	if frequency, ok := quickAddFrequencies[p.peek(0)]; ok {
+		return "FREQ=" + frequency, 1, true
+	}
+
+	if p.peek(0) != "every" {
+		return "", 0, false
+	}
+
+	if frequency, ok := quickAddUnits[p.peek(1)]; ok && !strings.HasSuffix(p.peek(1), "s") {
+		return "FREQ=" + frequency, 2, true
+	}
+
+	if p.peek(1) == "weekday" {
+		return "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", 2, true
+	}
+
+	if weekday, ok := quickAddWeekdays[p.peek(1)]; ok {
+		if p.date == nil {
+			day := p.comingWeekday(weekday)
+			p.date = &day
+		}
+		return "FREQ=WEEKLY;BYDAY=" + strings.ToUpper(weekday.String()[:2]), 2, true
+	}
+
+	n, err := strconv.Atoi(p.peek(1))
+	frequency, ok := quickAddUnits[p.peek(2)]
+	if err != nil || !ok || n < 1 || n > 1000 {
+		return "", 0, false
+	}
+
+	if n == 1 {
+		return "FREQ=" + frequency, 3, true
+	}
+
+	return fmt.Sprintf("FREQ=%s;INTERVAL=%d", frequency, n), 3, true
+}
+
+// Parses a quick add line into the fields of an item. Dates are relative to now, in its location.
+// Without a date the item is due today, and without a time at the end of the day
+func parseQuickAdd(text string, now time.Time) (entity.QuickAddParsed, []entity.QuickAddToken) {
+	p := &quickAddParser{words: quickAddWords(text), now: now}
+	p.result.Labels = []string{}
+	p.tokens = []entity.QuickAddToken{}
+
+	for p.next < len(p.words) {
+		word := p.peek(0)
+
+		if priority := strings.ToUpper(strings.TrimPrefix(word, "!")); strings.HasPrefix(word, "!") && !p.hasPriority && filterPriorities[priority] != 0 {
+			p.hasPriority = true
+			p.result.Priority = priority
+			p.take(1, QuickAddPriority, priority)
+			continue
+		}
+
+		if label := p.words[p.next].text[1:]; strings.HasPrefix(word, "#") && label != "" && len(label) <= 50 && !strings.Contains(label, ",") {
+			p.result.Labels = append(p.result.Labels, label)
+			p.take(1, QuickAddLabel, label)
+			continue
+		}
+
+		if !p.hasRecurrence {
+			if rule, n, ok := p.parseRecurrence(); ok {
+				p.hasRecurrence = true
+				p.result.Recurrence = rule
+				p.take(n, QuickAddRecurrence, rule)
+				continue
+			}
+		}
+
+		if p.date == nil {
+			if day, n, ok := p.parseDate(); ok {
+				p.date = &day
+				p.take(n, QuickAddDate, day.Format("2006-01-02"))
+				continue
+			}
+		}
+
+		if !p.hasTime {
+			n := 1
+			if word == "at" {
+				n = 2
+			}
+
+			if hour, minute, ok := parseQuickAddTime(p.peek(n - 1)); ok {
+				p.hasTime, p.hour, p.minute = true, hour, minute
+				p.take(n, QuickAddTime, fmt.Sprintf("%02d:%02d", hour, minute))
+				continue
+			}
+		}
+
+		p.name = append(p.name, p.words[p.next].text)
+		p.next++
+	}
+
+	day := p.today()
+	if p.date != nil {
+		day = *p.date
+	}
+
+	hour, minute, second := 23, 59, 59
+	if p.hasTime {
+		hour, minute, second = p.hour, p.minute, 0
+	}
+
+	p.result.Name = strings.Join(p.name, " ")
+	p.result.DueDate = time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, day.Location())
+	if !p.hasPriority {
+		p.result.Priority = defaultQuickAddPriority
+	}
+
+	return p.result, p.tokens
+}
+
+// Creates an item from a single line of text
+// @Summary adds a todo item from a line of text
+// @Description Parses a line like "Pay rent tomorrow 9am !high #finance every month". Dates are today, tomorrow, a weekday, next week, next month, next friday, in 3 days or 2023-10-20, times are 9am, 9:30pm or 21:00, optionally after at. !high, !medium or !low is the priority and every #word a label. Recurrences are daily, weekly, monthly, yearly, every week, every 2 weeks, every monday or every weekday. The other words are the name. Without a date the item is due today and without a time at the end of the day, in the timezone of the request. With preview the item is not created.
+// @Tags Item
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param dto body entity.QuickAddDto true "Line of text"
+// @Success 200 {object} entity.QuickAddResponse
+// @Success 201 {object} entity.QuickAddResponse
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_WORKSPACE_ROLE
+// @Failure 500 string constants.CREATE_ITEM_ERROR
+// @Router /item/quick [post]
+func (as ApiService) QuickAddItem(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	quickAdd := new(entity.QuickAddDto)
+	if err := c.Bind(quickAdd); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(quickAdd)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	location, err := time.LoadLocation(quickAdd.Timezone)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_TIMEZONE, quickAdd.Timezone)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	parsed, tokens := parseQuickAdd(quickAdd.Text, time.Now().In(location))
+	response := entity.QuickAddResponse{Parsed: parsed, Tokens: tokens}
+
+	if len([]rune(parsed.Name)) < 3 {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, errors.New("the name must be at least 3 characters"))
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	if quickAdd.Preview {
+		return c.JSONPretty(http.StatusOK, response, " ")
+	}
+
+	allowed, err := as.authorizeCreate(c)
+	if !allowed {
+		return err
+	}
+
+	t := &entity.TodoItemDto{
+		Name: parsed.Name,
+		Details: entity.TodoItemDetailsDto{
+			DueDate:  parsed.DueDate.UTC(),
+			Priority: parsed.Priority,
+		},
+	}
+
+	item, err := as.withRevision(as.repo(c), userId, entity.RevisionCreated, func(r ApiRepository) (entity.TodoItem, error) {
+		item, err := r.CreateTodoItem(t, userId)
+		if err != nil || len(parsed.Labels) == 0 && parsed.Recurrence == "" {
+			return item, err
+		}
+
+		if len(parsed.Labels) > 0 {
+			if item, err = r.SetItemLabels(item.Id, parsed.Labels, nil); err != nil {
+				return item, err
+			}
+		}
+
+		if parsed.Recurrence != "" {
+			item, err = r.SetItemRecurrence(item.Id, parsed.Recurrence)
+		}
+
+		return item, err
+	})
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CREATE_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	response.Item = &item
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusCreated, response, " ")
+}
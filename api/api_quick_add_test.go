@@ -0,0 +1,102 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestQuickAdd(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	// A Sunday
+	now := time.Date(2023, 10, 15, 9, 38, 24, 0, berlin)
+
+	t.Run("Test Parse a full line", func(t *testing.T) {
+		parsed, tokens := parseQuickAdd("Pay rent tomorrow 9am !high #finance every month", now)
+
+		assert.Equal(t, "Pay rent", parsed.Name)
+		assert.Equal(t, time.Date(2023, 10, 16, 9, 0, 0, 0, berlin), parsed.DueDate)
+		assert.Equal(t, "HIGH", parsed.Priority)
+		assert.Equal(t, []string{"finance"}, parsed.Labels)
+		assert.Equal(t, "FREQ=MONTHLY", parsed.Recurrence)
+
+		assert.Equal(t, []entity.QuickAddToken{
+			{Text: "tomorrow", Kind: QuickAddDate, Value: "2023-10-16", Start: 9, End: 17},
+			{Text: "9am", Kind: QuickAddTime, Value: "09:00", Start: 18, End: 21},
+			{Text: "!high", Kind: QuickAddPriority, Value: "HIGH", Start: 22, End: 27},
+			{Text: "#finance", Kind: QuickAddLabel, Value: "finance", Start: 28, End: 36},
+			{Text: "every month", Kind: QuickAddRecurrence, Value: "FREQ=MONTHLY", Start: 37, End: 48},
+		}, tokens)
+	})
+
+	t.Run("Test Defaults", func(t *testing.T) {
+		parsed, tokens := parseQuickAdd("Buy 5 apples", now)
+
+		assert.Equal(t, "Buy 5 apples", parsed.Name)
+		assert.Equal(t, time.Date(2023, 10, 15, 23, 59, 59, 0, berlin), parsed.DueDate)
+		assert.Equal(t, defaultQuickAddPriority, parsed.Priority)
+		assert.Equal(t, []string{}, parsed.Labels)
+		assert.Empty(t, parsed.Recurrence)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("Test Dates", func(t *testing.T) {
+		cases := map[string]time.Time{
+			"Call mom on friday":        time.Date(2023, 10, 20, 23, 59, 59, 0, berlin),
+			"Call mom sunday":           time.Date(2023, 10, 15, 23, 59, 59, 0, berlin),
+			"Call mom next week":        time.Date(2023, 10, 16, 23, 59, 59, 0, berlin),
+			"Call mom next friday":      time.Date(2023, 10, 20, 23, 59, 59, 0, berlin),
+			"Call mom next month":       time.Date(2023, 11, 1, 23, 59, 59, 0, berlin),
+			"Call mom in 2 weeks":       time.Date(2023, 10, 29, 23, 59, 59, 0, berlin),
+			"Call mom 2023-12-24 18:30": time.Date(2023, 12, 24, 18, 30, 0, 0, berlin),
+			"Call mom at 12pm":          time.Date(2023, 10, 15, 12, 0, 0, 0, berlin),
+			"Call mom at 12am":          time.Date(2023, 10, 15, 0, 0, 0, 0, berlin),
+		}
+
+		for line, due := range cases {
+			parsed, _ := parseQuickAdd(line, now)
+
+			assert.Equal(t, "Call mom", parsed.Name, line)
+			assert.Equal(t, due, parsed.DueDate, line)
+		}
+	})
+
+	t.Run("Test Recurrences", func(t *testing.T) {
+		cases := map[string]string{
+			"Water plants daily":         "FREQ=DAILY",
+			"Water plants every week":    "FREQ=WEEKLY",
+			"Water plants every 2 weeks": "FREQ=WEEKLY;INTERVAL=2",
+			"Water plants every weekday": "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		}
+
+		for line, rule := range cases {
+			parsed, _ := parseQuickAdd(line, now)
+
+			assert.Equal(t, "Water plants", parsed.Name, line)
+			assert.Equal(t, rule, parsed.Recurrence, line)
+		}
+
+		parsed, _ := parseQuickAdd("Water plants every tuesday", now)
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=TU", parsed.Recurrence)
+		assert.Equal(t, time.Date(2023, 10, 17, 23, 59, 59, 0, berlin), parsed.DueDate)
+	})
+
+	t.Run("Test Words that are only part of the name", func(t *testing.T) {
+		parsed, tokens := parseQuickAdd("Read chapter 13 in the book at home !urgent #", now)
+
+		assert.Equal(t, "Read chapter 13 in the book at home !urgent #", parsed.Name)
+		assert.Empty(t, tokens)
+	})
+
+	t.Run("Test Only the first date is used", func(t *testing.T) {
+		parsed, _ := parseQuickAdd("Plan monday meeting tomorrow", now)
+
+		assert.Equal(t, "Plan meeting tomorrow", parsed.Name)
+		assert.Equal(t, time.Date(2023, 10, 16, 23, 59, 59, 0, berlin), parsed.DueDate)
+	})
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"todo-project/constants"
+)
+
+// Digits of the rank keys in ascending order, the ranks sort as plain strings under the C collation
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+var ErrInvalidRankRange = errors.New(constants.INVALID_RANK_RANGE)
+
+// Rank placing an item after every item ranked before the time, new items are appended with it.
+// A key never ends with the lowest digit, so there is always a key before it
+func timeRank(t time.Time) string {
+	rank := fmt.Sprintf("%016x", t.UnixNano())
+	if strings.HasSuffix(rank, "0") {
+		rank = rank[:len(rank)-1] + "1"
+	}
+
+	return rank
+}
+
+// Rank sorting between the ranks a and b, an empty a is the top and an empty b the bottom of the list
+func rankBetween(a, b string) (string, error) {
+	if b != "" && a >= b {
+		return "", ErrInvalidRankRange
+	}
+
+	if strings.Trim(a+b, rankDigits) != "" || strings.HasSuffix(b, "0") {
+		return "", ErrInvalidRankRange
+	}
+
+	return midpoint(a, b), nil
+}
+
+func midpoint(a, b string) string {
+	if b != "" {
+		// Keep the common prefix, a is padded with the lowest digit
+		n := 0
+		for n < len(b) {
+			digit := rankDigits[0]
+			if n < len(a) {
+				digit = a[n]
+			}
+
+			if digit != b[n] {
+				break
+			}
+			n++
+		}
+
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(rankDigits, a[0])
+	}
+
+	digitB := len(rankDigits)
+	if b != "" {
+		digitB = strings.IndexByte(rankDigits, b[0])
+	}
+
+	if digitB-digitA > 1 {
+		return string(rankDigits[(digitA+digitB+1)/2])
+	}
+
+	// The first digits are consecutive
+	if len(b) > 1 {
+		return b[:1]
+	}
+
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+
+	return string(rankDigits[digitA]) + midpoint(rest, "")
+}
+
+// Rank of an item moved between two neighbours, an item moved to the bottom gets a time rank so
+// the items created later still come after it
+func moveRank(after, before string, now time.Time) (string, error) {
+	if before == "" {
+		if rank := timeRank(now); rank > after {
+			return rank, nil
+		}
+	}
+
+	return rankBetween(after, before)
+}
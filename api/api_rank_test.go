@@ -0,0 +1,83 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRank(t *testing.T) {
+	t.Run("Test Time rank", func(t *testing.T) {
+		earlier := timeRank(time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC))
+		later := timeRank(time.Date(2023, 10, 15, 9, 38, 25, 0, time.UTC))
+
+		assert.Len(t, earlier, 16)
+		assert.Less(t, earlier, later)
+		assert.NotEqual(t, byte('0'), earlier[len(earlier)-1])
+	})
+
+	t.Run("Test Rank between", func(t *testing.T) {
+		cases := []struct{ a, b string }{
+			{"", ""},
+			{"", "1"},
+			{"", "01"},
+			{"a", "b"},
+			{"a", "a1"},
+			{"az", "b"},
+			{"zz", ""},
+			{"0017a2b3c4d5e6f1", "0017a2b3c4d5e6f2"},
+		}
+
+		for _, c := range cases {
+			got, err := rankBetween(c.a, c.b)
+
+			assert.NoError(t, err)
+			assert.Less(t, c.a, got)
+			if c.b != "" {
+				assert.Less(t, got, c.b)
+			}
+			assert.NotEqual(t, byte('0'), got[len(got)-1])
+		}
+	})
+
+	t.Run("Test Repeated moves to the top keep sorting", func(t *testing.T) {
+		first := timeRank(time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC))
+
+		for i := 0; i < 50; i++ {
+			rank, err := rankBetween("", first)
+
+			assert.NoError(t, err)
+			assert.Less(t, rank, first)
+			first = rank
+		}
+	})
+
+	t.Run("Test Invalid range", func(t *testing.T) {
+		_, err := rankBetween("b", "a")
+		assert.ErrorIs(t, err, ErrInvalidRankRange)
+
+		_, err = rankBetween("a", "a")
+		assert.ErrorIs(t, err, ErrInvalidRankRange)
+
+		_, err = rankBetween("A", "")
+		assert.ErrorIs(t, err, ErrInvalidRankRange)
+	})
+
+	t.Run("Test Move rank", func(t *testing.T) {
+		now := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+		last := timeRank(now.Add(-time.Hour))
+
+		rank, err := moveRank(last, "", now)
+		assert.NoError(t, err)
+		assert.Equal(t, timeRank(now), rank)
+
+		rank, err = moveRank("z", "", now)
+		assert.NoError(t, err)
+		assert.Less(t, "z", rank)
+
+		rank, err = moveRank("", last, now)
+		assert.NoError(t, err)
+		assert.Less(t, rank, last)
+	})
+}
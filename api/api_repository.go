@@ -2,12 +2,17 @@ package api
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
+	"todo-project/constants"
 	"todo-project/entity"
 )
 
@@ -15,6 +20,9 @@ type Repository interface {
 	//Create an item
 	CreateTodoItem(item *entity.TodoItemDto, userId string) (todoItem entity.TodoItem, err error)
 
+	//Create an item with an id chosen by the client
+	CreateTodoItemWithId(id string, item *entity.TodoItemDto, userId string) (todoItem entity.TodoItem, err error)
+
 	//Find a todo item by id
 	FindItemById(id string) (todoItem entity.TodoItem, err error)
 
@@ -22,26 +30,359 @@ type Repository interface {
 	GetTodoListItems(limit int, userId string) (todoItemList []entity.TodoItem, err error)
 
 	//Update an todo list item
-	UpdateTodoItem(item *entity.TodoItemDetailsDto, id string) (todoItem entity.TodoItem, err error)
+	UpdateTodoItem(item *entity.TodoItemDetailsDto, id string, version int) (todoItem entity.TodoItem, err error)
 
 	//Delete an todo Item
-	DeleteTodoItem(id string) error
+	DeleteTodoItem(id string, version int) error
 
 	//Update status to Complete by Id
-	SetItemStatusToComplete(id string) (todoItem entity.TodoItem, err error)
+	SetItemStatusToComplete(id string, version int) (todoItem entity.TodoItem, err error)
+
+	//Update only the changed columns of a todo item
+	PatchTodoItem(id string, changes map[string]interface{}, version int) (todoItem entity.TodoItem, err error)
+
+	//Find the todo items the user has a role on by ids, with the role of the user on each of them
+	FindItemsOfUser(ids []string, userId string) (todoItems map[string]entity.TodoItem, roles map[string]string, err error)
+
+	//Add and remove labels of a todo item
+	SetItemLabels(id string, add, remove []string) (todoItem entity.TodoItem, err error)
+
+	//Change the status of a todo item
+	SetItemStatus(id, status string, version int) (todoItem entity.TodoItem, err error)
+
+	//Get the deleted todo items
+	GetTrashItems(limit int, userId string) (todoItemList []entity.TodoItem, err error)
+
+	//Restore a deleted todo item
+	RestoreTodoItem(id string) (todoItem entity.TodoItem, err error)
+
+	//Permanently delete a todo item in trash
+	PurgeTodoItem(id string) error
+
+	//Permanently delete all the todo items in trash
+	EmptyTrash(userId string) (int64, error)
+
+	//Permanently delete the items in trash for longer than the retention days
+	PurgeExpiredItems(retentionDays int) (int64, error)
+
+	//Record a change of a todo item
+	CreateItemRevision(item entity.TodoItem, userId, action string) error
+
+	//Get the revisions of a todo item, oldest first
+	GetItemRevisions(itemId string) (revisions []entity.ItemRevision, err error)
+
+	//Find a revision of a todo item
+	FindItemRevision(itemId string, revisionId int64) (revision entity.ItemRevision, err error)
+
+	//Add a comment to a todo item
+	CreateComment(itemId, userId string, comment *entity.CommentDto) (entity.Comment, error)
+
+	//Get all the comments of a todo item, including the deleted ones
+	GetItemComments(itemId string) (comments []entity.Comment, err error)
+
+	//Find a comment of a todo item
+	FindCommentById(itemId, commentId string) (comment entity.Comment, err error)
+
+	//Edit the body of a comment
+	UpdateComment(commentId, body string) (comment entity.Comment, err error)
+
+	//Soft delete a comment
+	DeleteComment(commentId string) error
+
+	//Store the metadata of an attachment when the attachments of its user fit in the quota
+	CreateAttachment(attachment entity.Attachment, quota int64) error
+
+	//Get the attachments of a todo item
+	GetItemAttachments(itemId string) (attachments []entity.Attachment, err error)
+
+	//Find an attachment of a todo item
+	FindAttachmentById(itemId, attachmentId string) (attachment entity.Attachment, err error)
+
+	//Total size of the attachments uploaded by the user
+	GetStorageUsage(userId string) (int64, error)
+
+	//Delete the metadata of an attachment
+	DeleteAttachment(attachmentId string) error
+
+	//Storage keys of the attachments of a todo item
+	GetItemAttachmentKeys(itemId string) (keys []string, err error)
+
+	//Storage keys of the attachments of the todo items in trash of the user
+	GetTrashAttachmentKeys(userId string) (keys []string, err error)
+
+	//Storage keys of the attachments of the todo items in trash for longer than the retention days
+	GetExpiredAttachmentKeys(retentionDays int) (keys []string, err error)
+
+	//Role of the user on a todo item, empty when the user has no access to it
+	GetItemRole(id, userId string) (string, error)
+
+	//Find the id of a registered user by email
+	FindUserIdByEmail(email string) (string, error)
+
+	//Share a todo item with a user, the share is pending until the user accepts it
+	ShareItem(itemId, userId, role, invitedBy string) (share entity.Share, err error)
+
+	//Get the shares of a todo item
+	GetItemShares(itemId string) (shares []entity.Share, err error)
+
+	//Find a share of a todo item
+	FindShareById(itemId, shareId string) (share entity.Share, err error)
+
+	//Revoke a share
+	DeleteShare(shareId string) error
+
+	//Get the pending invitations of the user
+	GetInvitations(userId string) (shares []entity.Share, err error)
+
+	//Accept or decline a pending invitation of the user
+	RespondToShare(shareId, userId, status string) (share entity.Share, err error)
+
+	//Get the todo items shared with the user
+	GetSharedItems(limit int, userId string) (items []entity.SharedItem, err error)
+
+	//Get the todo items assigned to the user, only the ones the user created when createdByMe is set
+	GetAssignedItems(limit int, userId string, createdByMe bool) (todoItemList []entity.TodoItem, err error)
+
+	//Assign a todo item to a user, an empty assignee unassigns it
+	SetItemAssignee(id, assigneeId string) (todoItem entity.TodoItem, err error)
+
+	//Add the user to the watchers of a todo item
+	AddItemWatcher(id, userId string) (todoItem entity.TodoItem, err error)
+
+	//Remove the user from the watchers of a todo item
+	RemoveItemWatcher(id, userId string) (todoItem entity.TodoItem, err error)
+
+	//Set the recurrence rule of a todo item, an empty rule stops it from recurring
+	SetItemRecurrence(id, rule string) (todoItem entity.TodoItem, err error)
+
+	//Change the rank of a todo item within its list
+	SetItemRank(id, rank string, version int) (todoItem entity.TodoItem, err error)
+
+	//Give the items of the list sharing the rank distinct ranks, keeping their order
+	SpreadRankTie(userId, rank string) error
+
+	//Get all the todo items of the list by rank
+	GetBoardItems(userId string) (todoItemList []entity.TodoItem, err error)
+
+	//Make a todo item wait on another one, failing when the link would form a cycle
+	AddItemDependency(id, blockerId string) (todoItem entity.TodoItem, err error)
+
+	//Remove the link of a todo item to one of its blockers
+	RemoveItemDependency(id, blockerId string) (todoItem entity.TodoItem, err error)
+
+	//Get the items a todo item waits on and the items waiting on it
+	GetItemDependencies(id, userId string) (dependencies entity.ItemDependencies, err error)
+
+	//Save a named filter of the user
+	CreateSavedFilter(userId string, filter *entity.SavedFilterDto) (savedFilter entity.SavedFilter, err error)
+
+	//Get the saved filters of the user by name
+	GetSavedFilters(userId string) (savedFilters []entity.SavedFilter, err error)
+
+	//Find a saved filter of the user
+	FindSavedFilter(id, userId string) (savedFilter entity.SavedFilter, err error)
+
+	//Rename or change the expression of a saved filter of the user
+	UpdateSavedFilter(id, userId string, filter *entity.SavedFilterDto) (savedFilter entity.SavedFilter, err error)
+
+	//Delete a saved filter of the user
+	DeleteSavedFilter(id, userId string) error
+
+	//Get the todo items of the list selected by a filter, by rank
+	GetFilteredItems(filter *Filter, limit int, userId string) (todoItemList []entity.TodoItem, err error)
+
+	//Make a todo item wait on other items, without checking for cycles
+	LinkItemDependencies(id string, blockerIds []string) (todoItem entity.TodoItem, err error)
+
+	//Save a template of the user
+	CreateTemplate(userId string, template *entity.TemplateDto) (savedTemplate entity.Template, err error)
+
+	//Get the templates of the user by name
+	GetTemplates(userId string) (templates []entity.Template, err error)
+
+	//Find a template of the user
+	FindTemplate(id, userId string) (template entity.Template, err error)
+
+	//Delete a template of the user
+	DeleteTemplate(id, userId string) error
+
+	//Call fn with each todo item of the list by rank
+	ForEachListItem(userId string, fn func(item entity.TodoItem) error) error
+
+	//Find the todo items of the list by ids, other ids are left out
+	FindListItems(ids []string, userId string) (todoItems map[string]entity.TodoItem, err error)
+
+	//Tag changing whenever an item of the list is added, removed or modified
+	GetListChangeTag(userId string) (string, error)
+
+	//Insert imported todo items in batches inside one transaction
+	ImportTodoItems(items []entity.TodoItem, userId string) (imported []entity.TodoItem, err error)
+
+	//Find the items imported into the list from tasks of an app by the ids of the tasks
+	FindItemSources(source string, sourceIds []string, userId string) (todoItems map[string]entity.TodoItem, err error)
+
+	//Save the ids of the tasks of an app the items were imported from
+	CreateItemSources(source string, sourceIds map[string]string, userId string) error
+
+	//Save a webhook of the personal list of the user or of the workspace
+	CreateWebhook(url, secret string, events []string, userId string) (entity.Webhook, error)
+
+	//Get the webhooks of the user in all of their lists
+	GetWebhooks(userId string) (webhooks []entity.Webhook, err error)
+
+	//Find a webhook of the user
+	FindWebhook(id, userId string) (webhook entity.Webhook, err error)
+
+	//Delete a webhook of the user with its deliveries
+	DeleteWebhook(id, userId string) error
+
+	//Queue a delivery of an event to each webhook
+	CreateWebhookDeliveries(webhookIds []string, event, actorId string, item *entity.TodoItem) (deliveries []entity.WebhookDelivery, err error)
+
+	//Queue a delivery of an event of an item to the subscribed webhooks of its list
+	EnqueueWebhookDeliveries(event, actorId string, item entity.TodoItem) (deliveries []entity.WebhookDelivery, err error)
+
+	//Claim the pending deliveries that are due
+	ClaimWebhookDeliveries(limit int, lease time.Duration) (jobs []entity.WebhookJob, err error)
+
+	//Log an attempt to send a delivery and set its status
+	RecordWebhookAttempt(deliveryId string, attempt entity.WebhookAttempt, status string, nextAttemptAt *time.Time) error
+
+	//Get the latest deliveries of the webhooks of the user
+	GetWebhookDeliveries(userId, webhookId, status string, limit int) (deliveries []entity.WebhookDelivery, err error)
+
+	//Find a delivery of a webhook of the user with the log of its attempts
+	FindWebhookDelivery(id, webhookId, userId string) (delivery entity.WebhookDelivery, err error)
+
+	//Queue a delivery of a webhook of the user again
+	RedeliverWebhookDelivery(id, webhookId, userId string) (delivery entity.WebhookDelivery, err error)
+
+	//Log an event of an item and notify the streams of every instance
+	CreateItemEvent(event, actorId string, item entity.TodoItem) (notice entity.ItemEventNotice, err error)
+
+	//Get the events after the id the user gets
+	GetItemEvents(userId string, afterId int64, limit int) (events []entity.ItemEvent, err error)
+
+	//Get the ids of the oldest and the latest events in the log
+	GetItemEventBounds() (first, last int64, err error)
+
+	//Purge the events logged before the time
+	PurgeItemEvents(createdBefore time.Time) (int64, error)
+
+	//Get the items and tombstones of the list changed after a number of the change sequence, in its order
+	GetItemChanges(userId string, after int64, limit int) (changes []entity.ItemChange, tombstones []entity.ItemTombstone, err error)
+
+	//Get a page of the todo items of the list by rank, only the ones selected by the filter when there is one
+	GetItemsPage(filter *Filter, limit, offset int, userId string) (todoItemList []entity.TodoItem, err error)
+
+	//Get the comments of todo items by the id of their item, oldest first
+	GetCommentsOfItems(itemIds []string) (comments map[string][]entity.Comment, err error)
+
+	//Find registered users by ids, other ids are left out
+	FindUsersByIds(ids []string) (users map[string]entity.User, err error)
+
+	//Save the hash of the calendar feed token of the list, replacing the previous token
+	CreateCalendarFeed(userId, tokenHash string) (feed entity.CalendarFeed, err error)
+
+	//Find the calendar feed of a token hash
+	FindCalendarFeed(tokenHash string) (feed entity.CalendarFeed, err error)
+
+	//Delete the calendar feed of the list
+	DeleteCalendarFeed(userId string) error
+
+	//Role of the user in a workspace, empty when the user is not a member of it
+	GetWorkspaceRole(workspaceId, userId string) (string, error)
+
+	//Create a workspace owned by the user
+	CreateWorkspace(name, userId string) (workspace entity.Workspace, err error)
+
+	//Get the workspaces the user is a member of
+	GetWorkspacesOfUser(userId string) (workspaces []entity.Workspace, err error)
+
+	//Get the members of a workspace, including the pending invitations
+	GetWorkspaceMembers(workspaceId string) (members []entity.WorkspaceMember, err error)
+
+	//Find the membership of a user in a workspace
+	FindWorkspaceMember(workspaceId, userId string) (member entity.WorkspaceMember, err error)
+
+	//Invite a user to a workspace, the membership is pending until the user accepts it
+	InviteWorkspaceMember(workspaceId, userId, role, invitedBy string) (member entity.WorkspaceMember, err error)
+
+	//Remove a user from a workspace, the last owner of the workspace cannot be removed
+	DeleteWorkspaceMember(workspaceId, userId string) error
+
+	//Get the pending workspace invitations of the user
+	GetWorkspaceInvitations(userId string) (members []entity.WorkspaceMember, err error)
+
+	//Accept or decline a pending workspace invitation of the user
+	RespondToWorkspaceInvitation(memberId, userId, status string) (member entity.WorkspaceMember, err error)
+}
+
+var ErrItemNotInTrash = errors.New(constants.ITEM_NOT_IN_TRASH)
+var ErrVersionMismatch = errors.New(constants.ITEM_VERSION_MISMATCH)
+var ErrDependencyCycle = errors.New(constants.DEPENDENCY_CYCLE)
+var ErrLastWorkspaceOwner = errors.New(constants.LAST_WORKSPACE_OWNER)
+var ErrItemIdTaken = errors.New(constants.ITEM_ID_TAKEN)
+var ErrAttachmentOverQuota = errors.New(constants.ATTACHMENT_OVER_QUOTA)
+
+// Satisfied by both *sql.DB and *sql.Tx, so the repository can run inside a transaction
+type DBTX interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
 }
 
+// Every query finding, listing or changing todo items is scoped to WorkspaceId, so the items of other workspaces are never
+// returned or changed
 type ApiRepository struct {
-	DB *sql.DB
+	DB          DBTX
+	WorkspaceId string
 }
 
-//Get todo item from a sql query
-func getItemFromQuery(row *sql.Row, id string) (todoItem entity.TodoItem, err error) {
-	var name, description, priority, user_id string
+// Repository scoped to a workspace, an empty workspace is the personal space of the user
+func (r ApiRepository) InWorkspace(workspaceId string) ApiRepository {
+	return ApiRepository{DB: r.DB, WorkspaceId: workspaceId}
+}
+
+// Runs fn with a repository bound to a transaction, committing when fn succeeds
+func (r ApiRepository) WithTx(fn func(tx ApiRepository) error) error {
+	db, ok := r.DB.(interface{ Begin() (*sql.Tx, error) })
+	if !ok {
+		return errors.New(constants.TRANSACTION_NOT_SUPPORTED)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(ApiRepository{DB: tx, WorkspaceId: r.WorkspaceId}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Scans a single todo item from a row or rows cursor
+type itemScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanItem(row itemScanner) (todoItem entity.TodoItem, err error) {
+	var id, name, description, priority, user_id string
 	var is_completed, is_deleted bool
 	var due_date, created_at, updated_at time.Time
+	var deleted_at, started_at, completed_at sql.NullTime
+	var status sql.NullString
+	var version int
+	var labels string
+	var comment_count int
+	var assignee_id, watchers, workspace_id, blocked_by string
+	var blocked bool
+	var rank, recurrence string
 
-	err = row.Scan(&id, &name, &description, &due_date, &priority, &created_at, &updated_at, &is_completed, &is_deleted, &user_id)
+	err = row.Scan(&id, &name, &description, &due_date, &priority, &created_at, &updated_at, &is_completed, &is_deleted, &user_id, &deleted_at, &status, &started_at, &completed_at, &version, &labels, &comment_count, &assignee_id, &watchers, &workspace_id, &blocked_by, &blocked, &rank, &recurrence)
 
 	if err != nil {
 		return todoItem, err
@@ -59,16 +400,86 @@ func getItemFromQuery(row *sql.Row, id string) (todoItem entity.TodoItem, err er
 	}
 
 	todoItem = entity.TodoItem{
-		Id:          id,
-		Item:        item,
-		IsCompleted: is_completed,
-		IsDeleted:   is_deleted,
-		CreatedAt:   created_at,
-		UpdatedAt:   updated_at}
+		Id:           id,
+		Item:         item,
+		UserId:       user_id,
+		IsCompleted:  is_completed,
+		Status:       status.String,
+		IsDeleted:    is_deleted,
+		CreatedAt:    created_at,
+		UpdatedAt:    updated_at,
+		Version:      version,
+		CommentCount: comment_count,
+		AssigneeId:   assignee_id,
+		WorkspaceId:  workspace_id,
+		Blocked:      blocked,
+		Rank:         rank,
+		Recurrence:   recurrence}
+
+	if deleted_at.Valid {
+		todoItem.DeletedAt = &deleted_at.Time
+	}
+
+	if started_at.Valid {
+		todoItem.StartedAt = &started_at.Time
+	}
+
+	if completed_at.Valid {
+		todoItem.CompletedAt = &completed_at.Time
+	}
+
+	if labels != "" {
+		todoItem.Labels = strings.Split(labels, ",")
+	}
+
+	if watchers != "" {
+		todoItem.Watchers = strings.Split(watchers, ",")
+	}
+
+	if blocked_by != "" {
+		todoItem.BlockedBy = strings.Split(blocked_by, ",")
+	}
 
 	return todoItem, nil
 }
 
+//Get todo item from a sql query
+func getItemFromQuery(row *sql.Row, id string) (todoItem entity.TodoItem, err error) {
+	return scanItem(row)
+}
+
+//Get todo item from a sql query guarded by the version of the item, 0 means any version
+func getVersionedItemFromQuery(row *sql.Row, id string, version int) (todoItem entity.TodoItem, err error) {
+	todoItem, err = getItemFromQuery(row, id)
+	if errors.Is(err, sql.ErrNoRows) && version != 0 {
+		return todoItem, ErrVersionMismatch
+	}
+
+	return todoItem, err
+}
+
+//Get todo items from a sql query
+func getItemsFromQuery(rows *sql.Rows) (todoItemList []entity.TodoItem, err error) {
+	defer rows.Close()
+
+	var items []entity.TodoItem
+
+	for rows.Next() {
+		todoItem, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, todoItem)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
 //Checks whether the item belongs to current user
 func(a ApiRepository)IsItemOftheUser(id, userId string) (bool, error) {
 	var is_current_user bool
@@ -82,98 +493,1886 @@ func(a ApiRepository)IsItemOftheUser(id, userId string) (bool, error) {
 	return is_current_user, nil
 }
 
-// Create a to do list item
+// Create a to do list item, it is ranked after the items created before it
 func (r ApiRepository) CreateTodoItem(item *entity.TodoItemDto, userId string) (todoItem entity.TodoItem, err error) {
-	id := (uuid.New()).String()
+	return r.CreateTodoItemWithId((uuid.New()).String(), item, userId)
+}
+
+// Create an item with an id chosen by the client, like the name of a CalDAV resource. ErrItemIdTaken is
+// returned when another item has the id, which may be an item the user cannot see
+func (r ApiRepository) CreateTodoItemWithId(id string, item *entity.TodoItemDto, userId string) (todoItem entity.TodoItem, err error) {
 	due_date := item.Details.DueDate.Format("2006-01-02T15:04:05Z07:00")
-	created_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	now := time.Now()
+	created_at := now.Format("2006-01-02T15:04:05Z07:00")
 
-	row := r.DB.QueryRow(CreateTodoItemQuery, id, item.Name, item.Details.Description, due_date, item.Details.Priority, created_at, created_at, userId)
+	row := r.DB.QueryRow(CreateTodoItemQuery, id, item.Name, item.Details.Description, due_date, item.Details.Priority, created_at, created_at, userId, r.WorkspaceId, timeRank(now))
 
-	return getItemFromQuery(row, id)
+	todoItem, err = getItemFromQuery(row, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return todoItem, ErrItemIdTaken
+	}
+
+	return todoItem, err
 }
 
 // Find a todo item by id
 func (r ApiRepository) FindItemById(id string) (todoItem entity.TodoItem, err error) {
-	findItem := fmt.Sprintf(FindByIdQuery, id)
-	row := r.DB.QueryRow(findItem)
+	row := r.DB.QueryRow(FindByIdQuery, id, r.WorkspaceId)
 
 	return getItemFromQuery(row, id)
 }
 
-// Get all the todo list item
+// Get all the todo list item by rank, the items of the user in the personal space or all the items of the workspace
 func (r ApiRepository) GetTodoListItems(limit int, userId string) (todoItemList []entity.TodoItem, err error) {
-	getAllItems := fmt.Sprintf(GetAllItemsQuery, userId, limit)
+	var rows *sql.Rows
+
+	if r.WorkspaceId != "" {
+		rows, err = r.DB.Query(GetWorkspaceItemsQuery, r.WorkspaceId, limit)
+	} else {
+		rows, err = r.DB.Query(fmt.Sprintf(GetAllItemsQuery, userId, limit))
+	}
 
-	rows, err := r.DB.Query(getAllItems)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var items []entity.TodoItem
+	return getItemsFromQuery(rows)
+}
 
-	for rows.Next() {
-		var name, description, priority, user_id, id string
-		var is_completed, is_deleted bool
-		var due_date, created_at, updated_at time.Time
+// Update an todo list item
+func (r ApiRepository) UpdateTodoItem(item *entity.TodoItemDetailsDto, id string, version int) (todoItem entity.TodoItem, err error) {
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(UpdateItemQuery, item.Description, item.DueDate, item.Priority, updated_at, id, version, r.WorkspaceId)
+
+	return getVersionedItemFromQuery(row, id, version)
+}
 
-		err = rows.Scan(&id, &name, &description, &due_date, &priority, &created_at, &updated_at, &is_completed, &is_deleted, &user_id)
+// Delete an todo Item
+func (r ApiRepository) DeleteTodoItem(id string, version int) error {
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+
+	result, err := r.DB.Exec(DeleteItemQuery, updated_at, id, version, r.WorkspaceId)
+
+	if err != nil {
+		return err
+	}
 
-		details := entity.TodoItemDetailsDto{
-			Description: description,
-			DueDate:     due_date,
-			Priority:    priority,
+	if version != 0 {
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return err
 		}
 
-		item := entity.TodoItemDto{
-			Name:    name,
-			Details: details,
+		if deleted == 0 {
+			return ErrVersionMismatch
 		}
+	}
 
-		todoItem := entity.TodoItem{
-			Id:          id,
-			Item:        item,
-			IsCompleted: is_completed,
-			IsDeleted:   is_deleted,
-			CreatedAt:   created_at,
-			UpdatedAt:   updated_at}
+	return nil
+}
 
-		items = append(items, todoItem)
+// Update only the given columns of a todo item
+func (r ApiRepository) PatchTodoItem(id string, changes map[string]interface{}, version int) (todoItem entity.TodoItem, err error) {
+	columns := make([]string, 0, len(changes))
+	for column := range changes {
+		columns = append(columns, column)
 	}
+	sort.Strings(columns)
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	assignments := make([]string, 0, len(columns))
+	args := make([]interface{}, 0, len(columns)+4)
+	for i, column := range columns {
+		assignments = append(assignments, fmt.Sprintf("%s=$%d", column, i+1))
+		args = append(args, changes[column])
 	}
 
-	return items, nil
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	args = append(args, updated_at, id, version, r.WorkspaceId)
+
+	patchItem := fmt.Sprintf(PatchItemQuery, strings.Join(assignments, ", "), len(columns)+1, len(columns)+2, len(columns)+3, len(columns)+4)
+	row := r.DB.QueryRow(patchItem, args...)
+
+	return getVersionedItemFromQuery(row, id, version)
 }
 
-// Update an todo list item
-func (r ApiRepository) UpdateTodoItem(item *entity.TodoItemDetailsDto, id string) (todoItem entity.TodoItem, err error) {
+// Find the todo items the user has a role on by ids in a single query, with the role of the user on each of them.
+// Other ids are left out
+func (r ApiRepository) FindItemsOfUser(ids []string, userId string) (todoItems map[string]entity.TodoItem, roles map[string]string, err error) {
+	rows, err := r.DB.Query(FindItemsOfUserQuery, pq.Array(ids), userId, r.WorkspaceId)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	todoItems = map[string]entity.TodoItem{}
+	roles = map[string]string{}
+	for rows.Next() {
+		var role string
+
+		item, err := scanItem(extraColumnsScanner{row: rows, extra: []any{&role}})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		todoItems[item.Id] = item
+		roles[item.Id] = role
+	}
+
+	return todoItems, roles, rows.Err()
+}
+
+// Add and remove labels of a todo item
+func (r ApiRepository) SetItemLabels(id string, add, remove []string) (todoItem entity.TodoItem, err error) {
+	if len(add) > 0 {
+		if _, err := r.DB.Exec(AddItemLabelsQuery, id, pq.Array(add)); err != nil {
+			return todoItem, err
+		}
+	}
+
+	if len(remove) > 0 {
+		if _, err := r.DB.Exec(RemoveItemLabelsQuery, id, pq.Array(remove)); err != nil {
+			return todoItem, err
+		}
+	}
+
 	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
-	row := r.DB.QueryRow(UpdateItemQuery, item.Description, item.DueDate, item.Priority, updated_at, id)
+	row := r.DB.QueryRow(TouchItemQuery, updated_at, id, r.WorkspaceId)
 
 	return getItemFromQuery(row, id)
 }
 
-// Delete an todo Item
-func (r ApiRepository) DeleteTodoItem(id string) error {
+// Change the status of a todo item, keeping is_completed and the transition timestamps in sync
+func (r ApiRepository) SetItemStatus(id, status string, version int) (todoItem entity.TodoItem, err error) {
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(SetItemStatusQuery, status, updated_at, id, version, r.WorkspaceId)
+
+	return getVersionedItemFromQuery(row, id, version)
+}
+
+// Get the deleted todo items of the user
+func (r ApiRepository) GetTrashItems(limit int, userId string) (todoItemList []entity.TodoItem, err error) {
+	rows, err := r.DB.Query(GetTrashItemsQuery, userId, limit, r.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	return getItemsFromQuery(rows)
+}
+
+// Restore a deleted todo item
+func (r ApiRepository) RestoreTodoItem(id string) (todoItem entity.TodoItem, err error) {
 	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(RestoreItemQuery, updated_at, id, r.WorkspaceId)
+
+	return getItemFromQuery(row, id)
+}
 
-	_, err := r.DB.Exec(DeleteItemQuery, updated_at, id)
+// Permanently delete a todo item which is in trash
+func (r ApiRepository) PurgeTodoItem(id string) error {
+	result, err := r.DB.Exec(PurgeItemQuery, id, r.WorkspaceId)
+	if err != nil {
+		return err
+	}
 
+	purged, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
+	if purged == 0 {
+		return ErrItemNotInTrash
+	}
+
 	return nil
 }
 
+// Permanently delete all the todo items in the trash of the user
+func (r ApiRepository) EmptyTrash(userId string) (int64, error) {
+	result, err := r.DB.Exec(EmptyTrashQuery, userId, r.WorkspaceId)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Permanently delete the todo items which are in trash for longer than the retention days
+func expiredBefore(retentionDays int) string {
+	return time.Now().AddDate(0, 0, -retentionDays).Format("2006-01-02T15:04:05Z07:00")
+}
+
+func (r ApiRepository) PurgeExpiredItems(retentionDays int) (int64, error) {
+	deleted_before := expiredBefore(retentionDays)
+
+	result, err := r.DB.Exec(PurgeExpiredItemsQuery, deleted_before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
 // Update status to Complete by Id
-func (r ApiRepository) SetItemStatusToComplete(id string) (todoItem entity.TodoItem, err error) {
+func (r ApiRepository) SetItemStatusToComplete(id string, version int) (todoItem entity.TodoItem, err error) {
 	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
-	row := r.DB.QueryRow(SetItemStatusAsCompletedQuery, updated_at, id)
+	row := r.DB.QueryRow(SetItemStatusAsCompletedQuery, updated_at, id, version, r.WorkspaceId)
 
-	return getItemFromQuery(row, id)
+	return getVersionedItemFromQuery(row, id, version)
+}
+
+// Record a change of a todo item as a snapshot of the changed item
+func (r ApiRepository) CreateItemRevision(item entity.TodoItem, userId, action string) error {
+	snapshot, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	created_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	_, err = r.DB.Exec(CreateItemRevisionQuery, item.Id, userId, action, snapshot, created_at)
+
+	return err
+}
+
+func scanRevision(row itemScanner) (revision entity.ItemRevision, err error) {
+	var snapshot []byte
+
+	err = row.Scan(&revision.Id, &revision.ItemId, &revision.UserId, &revision.Action, &snapshot, &revision.CreatedAt)
+	if err != nil {
+		return revision, err
+	}
+
+	err = json.Unmarshal(snapshot, &revision.Item)
+
+	return revision, err
+}
+
+// Get the revisions of a todo item, oldest first, with the fields changed since the previous revision
+func (r ApiRepository) GetItemRevisions(itemId string) (revisions []entity.ItemRevision, err error) {
+	rows, err := r.DB.Query(GetItemRevisionsQuery, itemId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions = []entity.ItemRevision{}
+	previous := entity.TodoItem{}
+
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		revision.Changes = diffItems(previous, revision.Item)
+		previous = revision.Item
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// Find a revision of a todo item
+func (r ApiRepository) FindItemRevision(itemId string, revisionId int64) (revision entity.ItemRevision, err error) {
+	row := r.DB.QueryRow(FindItemRevisionQuery, itemId, revisionId)
+
+	return scanRevision(row)
+}
+
+func scanComment(row itemScanner) (comment entity.Comment, err error) {
+	var parent_id sql.NullString
+	var edited_at sql.NullTime
+
+	err = row.Scan(&comment.Id, &comment.ItemId, &parent_id, &comment.UserId, &comment.Body, &comment.CreatedAt, &edited_at, &comment.IsDeleted)
+	if err != nil {
+		return comment, err
+	}
+
+	comment.ParentId = parent_id.String
+	if edited_at.Valid {
+		comment.EditedAt = &edited_at.Time
+	}
+
+	return comment, nil
+}
+
+// Add a comment to a todo item, optionally as a reply to another comment
+func (r ApiRepository) CreateComment(itemId, userId string, comment *entity.CommentDto) (entity.Comment, error) {
+	id := (uuid.New()).String()
+	created_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	parent_id := sql.NullString{String: comment.ParentId, Valid: comment.ParentId != ""}
+
+	row := r.DB.QueryRow(CreateCommentQuery, id, itemId, parent_id, userId, comment.Body, created_at)
+
+	return scanComment(row)
+}
+
+// Get all the comments of a todo item, oldest first
+func (r ApiRepository) GetItemComments(itemId string) (comments []entity.Comment, err error) {
+	rows, err := r.DB.Query(GetItemCommentsQuery, itemId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments = []entity.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// Get the comments of todo items by the id of their item, oldest first. Items without comments are left out
+func (r ApiRepository) GetCommentsOfItems(itemIds []string) (comments map[string][]entity.Comment, err error) {
+	rows, err := r.DB.Query(GetCommentsOfItemsQuery, pq.Array(itemIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments = map[string][]entity.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		comments[comment.ItemId] = append(comments[comment.ItemId], comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// Find a comment of a todo item which is not deleted
+func (r ApiRepository) FindCommentById(itemId, commentId string) (comment entity.Comment, err error) {
+	row := r.DB.QueryRow(FindCommentByIdQuery, itemId, commentId)
+
+	return scanComment(row)
+}
+
+// Edit the body of a comment
+func (r ApiRepository) UpdateComment(commentId, body string) (comment entity.Comment, err error) {
+	edited_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(UpdateCommentQuery, body, edited_at, commentId)
+
+	return scanComment(row)
+}
+
+// Soft delete a comment, its replies are kept
+func (r ApiRepository) DeleteComment(commentId string) error {
+	edited_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	_, err := r.DB.Exec(DeleteCommentQuery, edited_at, commentId)
+
+	return err
+}
+
+func scanAttachment(row itemScanner) (attachment entity.Attachment, err error) {
+	err = row.Scan(&attachment.Id, &attachment.ItemId, &attachment.UserId, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.StorageKey, &attachment.CreatedAt)
+
+	return attachment, err
+}
+
+// Store the metadata of an attachment whose content is already in the blob store, ErrAttachmentOverQuota is
+// returned when the attachments of the user would not fit in the quota. The usage is summed under a lock of the
+// user so that parallel uploads cannot exceed the quota together
+func (r ApiRepository) CreateAttachment(attachment entity.Attachment, quota int64) error {
+	return r.WithTx(func(tx ApiRepository) error {
+		if _, err := tx.DB.Exec(LockStorageUsageQuery, attachment.UserId); err != nil {
+			return err
+		}
+
+		usage, err := tx.GetStorageUsage(attachment.UserId)
+		if err != nil {
+			return err
+		}
+
+		if usage+attachment.Size > quota {
+			return ErrAttachmentOverQuota
+		}
+
+		created_at := attachment.CreatedAt.Format("2006-01-02T15:04:05Z07:00")
+		_, err = tx.DB.Exec(CreateAttachmentQuery, attachment.Id, attachment.ItemId, attachment.UserId, attachment.FileName, attachment.ContentType, attachment.Size, attachment.StorageKey, created_at)
+
+		return err
+	})
+}
+
+// Get the attachments of a todo item, oldest first
+func (r ApiRepository) GetItemAttachments(itemId string) (attachments []entity.Attachment, err error) {
+	rows, err := r.DB.Query(GetItemAttachmentsQuery, itemId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments = []entity.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+// Find an attachment of a todo item
+func (r ApiRepository) FindAttachmentById(itemId, attachmentId string) (attachment entity.Attachment, err error) {
+	row := r.DB.QueryRow(FindAttachmentByIdQuery, itemId, attachmentId)
+
+	return scanAttachment(row)
+}
+
+// Total size in bytes of the attachments uploaded by the user
+func (r ApiRepository) GetStorageUsage(userId string) (int64, error) {
+	var usage int64
+	err := r.DB.QueryRow(GetStorageUsageQuery, userId).Scan(&usage)
+
+	return usage, err
+}
+
+// Delete the metadata of an attachment
+func (r ApiRepository) DeleteAttachment(attachmentId string) error {
+	_, err := r.DB.Exec(DeleteAttachmentQuery, attachmentId)
+
+	return err
+}
+
+func getKeysFromQuery(rows *sql.Rows) (keys []string, err error) {
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// Storage keys of the attachments of a todo item
+func (r ApiRepository) GetItemAttachmentKeys(itemId string) (keys []string, err error) {
+	rows, err := r.DB.Query(GetItemAttachmentKeysQuery, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	return getKeysFromQuery(rows)
+}
+
+// Storage keys of the attachments of the todo items in trash of the user
+func (r ApiRepository) GetTrashAttachmentKeys(userId string) (keys []string, err error) {
+	rows, err := r.DB.Query(GetTrashAttachmentKeysQuery, userId, r.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	return getKeysFromQuery(rows)
+}
+
+// Storage keys of the attachments of the todo items in trash for longer than the retention days
+func (r ApiRepository) GetExpiredAttachmentKeys(retentionDays int) (keys []string, err error) {
+	rows, err := r.DB.Query(GetExpiredAttachmentKeysQuery, expiredBefore(retentionDays))
+	if err != nil {
+		return nil, err
+	}
+
+	return getKeysFromQuery(rows)
+}
+
+// Role of the user on a todo item of the workspace, the owner or the highest of the workspace role and the role of an accepted share
+func (r ApiRepository) GetItemRole(id, userId string) (string, error) {
+	var role string
+	err := r.DB.QueryRow(ItemRoleOfUserQuery, id, userId, r.WorkspaceId).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return role, err
+}
+
+// Find the id of a registered user by email
+func (r ApiRepository) FindUserIdByEmail(email string) (string, error) {
+	var id string
+	err := r.DB.QueryRow(FindUserIdByEmailQuery, email).Scan(&id)
+
+	return id, err
+}
+
+// Find registered users by ids, other ids are left out
+func (r ApiRepository) FindUsersByIds(ids []string) (users map[string]entity.User, err error) {
+	rows, err := r.DB.Query(FindUsersByIdsQuery, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users = make(map[string]entity.User, len(ids))
+	for rows.Next() {
+		var user entity.User
+
+		if err := rows.Scan(&user.Id, &user.Email); err != nil {
+			return nil, err
+		}
+
+		users[user.Id] = user
+	}
+
+	return users, rows.Err()
+}
+
+func scanShare(row itemScanner) (share entity.Share, err error) {
+	var responded_at sql.NullTime
+
+	err = row.Scan(&share.Id, &share.ItemId, &share.UserId, &share.Email, &share.Role, &share.Status, &share.InvitedBy, &share.CreatedAt, &responded_at)
+	if responded_at.Valid {
+		share.RespondedAt = &responded_at.Time
+	}
+
+	return share, err
+}
+
+func getSharesFromQuery(rows *sql.Rows) (shares []entity.Share, err error) {
+	defer rows.Close()
+
+	shares = []entity.Share{}
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
+
+// Share a todo item with a user, sharing it again changes the role and re-invites a user who declined
+func (r ApiRepository) ShareItem(itemId, userId, role, invitedBy string) (share entity.Share, err error) {
+	id := (uuid.New()).String()
+	created_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+
+	row := r.DB.QueryRow(ShareItemQuery, id, itemId, userId, role, invitedBy, created_at)
+
+	return scanShare(row)
+}
+
+// Get the shares of a todo item
+func (r ApiRepository) GetItemShares(itemId string) (shares []entity.Share, err error) {
+	rows, err := r.DB.Query(GetItemSharesQuery, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	return getSharesFromQuery(rows)
+}
+
+// Find a share of a todo item
+func (r ApiRepository) FindShareById(itemId, shareId string) (share entity.Share, err error) {
+	row := r.DB.QueryRow(FindShareByIdQuery, itemId, shareId)
+
+	return scanShare(row)
+}
+
+// Revoke a share
+func (r ApiRepository) DeleteShare(shareId string) error {
+	_, err := r.DB.Exec(DeleteShareQuery, shareId)
+
+	return err
+}
+
+// Get the pending invitations of the user, newest first
+func (r ApiRepository) GetInvitations(userId string) (shares []entity.Share, err error) {
+	rows, err := r.DB.Query(GetInvitationsQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return getSharesFromQuery(rows)
+}
+
+// Accept or decline a pending invitation of the user
+func (r ApiRepository) RespondToShare(shareId, userId, status string) (share entity.Share, err error) {
+	responded_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(RespondToShareQuery, status, responded_at, shareId, userId)
+
+	return scanShare(row)
+}
+
+// Scans the columns following the item columns of a row into extra
+type extraColumnsScanner struct {
+	row   itemScanner
+	extra []any
+}
+
+func (s extraColumnsScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// Get the todo items shared with the user, most recently updated first
+func (r ApiRepository) GetSharedItems(limit int, userId string) (items []entity.SharedItem, err error) {
+	rows, err := r.DB.Query(GetSharedItemsQuery, userId, limit, r.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items = []entity.SharedItem{}
+	for rows.Next() {
+		var item entity.SharedItem
+
+		item.TodoItem, err = scanItem(extraColumnsScanner{row: rows, extra: []any{&item.Role, &item.OwnerEmail}})
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// Get the todo items assigned to the user, only the ones the user created when createdByMe is set
+func (r ApiRepository) GetAssignedItems(limit int, userId string, createdByMe bool) (todoItemList []entity.TodoItem, err error) {
+	rows, err := r.DB.Query(GetAssignedItemsQuery, userId, createdByMe, limit, r.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	return getItemsFromQuery(rows)
+}
+
+// Assign a todo item to a user, an empty assignee unassigns it
+func (r ApiRepository) SetItemAssignee(id, assigneeId string) (todoItem entity.TodoItem, err error) {
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(SetItemAssigneeQuery, assigneeId, updated_at, id, r.WorkspaceId)
+
+	return getItemFromQuery(row, id)
+}
+
+// Add the user to the watchers of a todo item, touching the item so its version changes
+func (r ApiRepository) AddItemWatcher(id, userId string) (todoItem entity.TodoItem, err error) {
+	if _, err := r.DB.Exec(AddItemWatcherQuery, id, userId); err != nil {
+		return todoItem, err
+	}
+
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(TouchItemQuery, updated_at, id, r.WorkspaceId)
+
+	return getItemFromQuery(row, id)
+}
+
+// Remove the user from the watchers of a todo item, touching the item so its version changes
+func (r ApiRepository) RemoveItemWatcher(id, userId string) (todoItem entity.TodoItem, err error) {
+	if _, err := r.DB.Exec(RemoveItemWatcherQuery, id, userId); err != nil {
+		return todoItem, err
+	}
+
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(TouchItemQuery, updated_at, id, r.WorkspaceId)
+
+	return getItemFromQuery(row, id)
+}
+
+// Set the recurrence rule of a todo item, an empty rule stops it from recurring
+func (r ApiRepository) SetItemRecurrence(id, rule string) (todoItem entity.TodoItem, err error) {
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(SetItemRecurrenceQuery, rule, updated_at, id, r.WorkspaceId)
+
+	return getItemFromQuery(row, id)
+}
+
+// Change the rank of a todo item, a single row update moving it within its list
+func (r ApiRepository) SetItemRank(id, rank string, version int) (todoItem entity.TodoItem, err error) {
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(SetItemRankQuery, rank, updated_at, id, version, r.WorkspaceId)
+
+	return getVersionedItemFromQuery(row, id, version)
+}
+
+// Give the items of the list of the user sharing the rank distinct ranks between it and the next rank of the list,
+// keeping their order. Items created in the same second or moved into the same gap at the same time share a rank,
+// and no item can be moved between them until they are spread
+func (r ApiRepository) SpreadRankTie(userId, rank string) error {
+	return r.WithTx(func(tx ApiRepository) error {
+		rows, err := tx.DB.Query(GetRankTieQuery, userId, tx.WorkspaceId, rank)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var ids []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+
+			ids = append(ids, id)
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		var next string
+		if err := tx.DB.QueryRow(GetNextRankQuery, userId, tx.WorkspaceId, rank).Scan(&next); err != nil {
+			return err
+		}
+
+		// The first item keeps the rank
+		previous := rank
+		for i := 1; i < len(ids); i++ {
+			previous, err = rankBetween(previous, next)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.SetItemRank(ids[i], previous, 0); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Get all the todo items of the personal list of the user or of the workspace by rank
+func (r ApiRepository) GetBoardItems(userId string) (todoItemList []entity.TodoItem, err error) {
+	rows, err := r.DB.Query(GetBoardItemsQuery, userId, r.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	return getItemsFromQuery(rows)
+}
+
+// Whether the item waits on the other item, directly or through a chain of blockers
+func (r ApiRepository) dependsOn(id, otherId string) (bool, error) {
+	var depends bool
+	err := r.DB.QueryRow(DependsOnQuery, id, otherId).Scan(&depends)
+
+	return depends, err
+}
+
+// Make a todo item wait on another one, touching the item so its version changes. The link is refused
+// with ErrDependencyCycle when the blocker already waits on the item. The check runs under a lock of the
+// workspace so that two links added at the same time cannot close a cycle together
+func (r ApiRepository) AddItemDependency(id, blockerId string) (todoItem entity.TodoItem, err error) {
+	if id == blockerId {
+		return todoItem, ErrDependencyCycle
+	}
+
+	err = r.WithTx(func(tx ApiRepository) error {
+		if _, err := tx.DB.Exec(LockItemDependenciesQuery, tx.WorkspaceId); err != nil {
+			return err
+		}
+
+		cycle, err := tx.dependsOn(blockerId, id)
+		if err != nil {
+			return err
+		}
+
+		if cycle {
+			return ErrDependencyCycle
+		}
+
+		updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+		if _, err := tx.DB.Exec(AddItemDependencyQuery, id, blockerId, updated_at); err != nil {
+			return err
+		}
+
+		todoItem, err = getItemFromQuery(tx.DB.QueryRow(TouchItemQuery, updated_at, id, tx.WorkspaceId), id)
+
+		return err
+	})
+
+	return todoItem, err
+}
+
+// Remove the link of a todo item to one of its blockers, touching the item so its version changes.
+// sql.ErrNoRows is returned when the item does not wait on the blocker
+func (r ApiRepository) RemoveItemDependency(id, blockerId string) (todoItem entity.TodoItem, err error) {
+	result, err := r.DB.Exec(RemoveItemDependencyQuery, id, blockerId)
+	if err != nil {
+		return todoItem, err
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return todoItem, err
+	}
+
+	if removed == 0 {
+		return todoItem, sql.ErrNoRows
+	}
+
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(TouchItemQuery, updated_at, id, r.WorkspaceId)
+
+	return getItemFromQuery(row, id)
+}
+
+// Get the items a todo item waits on and the items waiting on it, leaving out the ones the user cannot see
+func (r ApiRepository) GetItemDependencies(id, userId string) (dependencies entity.ItemDependencies, err error) {
+	rows, err := r.DB.Query(GetItemBlockersQuery, id, r.WorkspaceId, userId)
+	if err != nil {
+		return dependencies, err
+	}
+
+	dependencies.BlockedBy, err = getItemsFromQuery(rows)
+	if err != nil {
+		return dependencies, err
+	}
+
+	rows, err = r.DB.Query(GetBlockedItemsQuery, id, r.WorkspaceId, userId)
+	if err != nil {
+		return dependencies, err
+	}
+
+	dependencies.Blocking, err = getItemsFromQuery(rows)
+	if err != nil {
+		return dependencies, err
+	}
+
+	if dependencies.BlockedBy == nil {
+		dependencies.BlockedBy = []entity.TodoItem{}
+	}
+
+	if dependencies.Blocking == nil {
+		dependencies.Blocking = []entity.TodoItem{}
+	}
+
+	return dependencies, nil
+}
+
+func scanSavedFilter(row itemScanner) (savedFilter entity.SavedFilter, err error) {
+	err = row.Scan(&savedFilter.Id, &savedFilter.UserId, &savedFilter.Name, &savedFilter.Expression, &savedFilter.CreatedAt, &savedFilter.UpdatedAt)
+
+	return savedFilter, err
+}
+
+// Save a named filter of the user, the expression is checked by the caller
+func (r ApiRepository) CreateSavedFilter(userId string, filter *entity.SavedFilterDto) (savedFilter entity.SavedFilter, err error) {
+	id := (uuid.New()).String()
+	created_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+
+	row := r.DB.QueryRow(CreateSavedFilterQuery, id, userId, filter.Name, filter.Expression, created_at)
+
+	return scanSavedFilter(row)
+}
+
+// Get the saved filters of the user by name
+func (r ApiRepository) GetSavedFilters(userId string) (savedFilters []entity.SavedFilter, err error) {
+	rows, err := r.DB.Query(GetSavedFiltersQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	savedFilters = []entity.SavedFilter{}
+	for rows.Next() {
+		savedFilter, err := scanSavedFilter(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		savedFilters = append(savedFilters, savedFilter)
+	}
+
+	return savedFilters, rows.Err()
+}
+
+// Find a saved filter of the user, the filters of other users are not found
+func (r ApiRepository) FindSavedFilter(id, userId string) (savedFilter entity.SavedFilter, err error) {
+	row := r.DB.QueryRow(FindSavedFilterQuery, id, userId)
+
+	return scanSavedFilter(row)
+}
+
+// Rename or change the expression of a saved filter of the user
+func (r ApiRepository) UpdateSavedFilter(id, userId string, filter *entity.SavedFilterDto) (savedFilter entity.SavedFilter, err error) {
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(UpdateSavedFilterQuery, filter.Name, filter.Expression, updated_at, id, userId)
+
+	return scanSavedFilter(row)
+}
+
+// Delete a saved filter of the user, sql.ErrNoRows when the user has no such filter
+func (r ApiRepository) DeleteSavedFilter(id, userId string) error {
+	result, err := r.DB.Exec(DeleteSavedFilterQuery, id, userId)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Get the todo items of the personal list of the user or of the workspace selected by a filter, by rank
+func (r ApiRepository) GetFilteredItems(filter *Filter, limit int, userId string) (todoItemList []entity.TodoItem, err error) {
+	condition, args := filter.SQL(time.Now(), 4)
+
+	rows, err := r.DB.Query(fmt.Sprintf(GetFilteredItemsQuery, condition), append([]interface{}{userId, r.WorkspaceId, limit}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return getItemsFromQuery(rows)
+}
+
+// Get a page of the todo items of the personal list of the user or of the workspace by rank, only the ones
+// selected by the filter when there is one
+func (r ApiRepository) GetItemsPage(filter *Filter, limit, offset int, userId string) (todoItemList []entity.TodoItem, err error) {
+	condition, args := "true", []interface{}{}
+	if filter != nil {
+		condition, args = filter.SQL(time.Now(), 5)
+	}
+
+	rows, err := r.DB.Query(fmt.Sprintf(GetItemsPageQuery, condition), append([]interface{}{userId, r.WorkspaceId, limit, offset}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return getItemsFromQuery(rows)
+}
+
+// Make a todo item wait on other items, touching the item so its version changes. The links are not checked
+// for cycles, the caller makes sure there are none, e.g. because the item and its blockers are new
+func (r ApiRepository) LinkItemDependencies(id string, blockerIds []string) (todoItem entity.TodoItem, err error) {
+	updated_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+
+	for _, blockerId := range blockerIds {
+		if _, err := r.DB.Exec(AddItemDependencyQuery, id, blockerId, updated_at); err != nil {
+			return todoItem, err
+		}
+	}
+
+	row := r.DB.QueryRow(TouchItemQuery, updated_at, id, r.WorkspaceId)
+
+	return getItemFromQuery(row, id)
+}
+
+func scanTemplate(row itemScanner) (template entity.Template, err error) {
+	var items []byte
+
+	err = row.Scan(&template.Id, &template.UserId, &template.Name, &items, &template.CreatedAt)
+	if err != nil {
+		return template, err
+	}
+
+	err = json.Unmarshal(items, &template.Items)
+
+	return template, err
+}
+
+// Save a template of the user, the items are checked by the caller
+func (r ApiRepository) CreateTemplate(userId string, template *entity.TemplateDto) (entity.Template, error) {
+	items, err := json.Marshal(template.Items)
+	if err != nil {
+		return entity.Template{}, err
+	}
+
+	id := (uuid.New()).String()
+	created_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+
+	row := r.DB.QueryRow(CreateTemplateQuery, id, userId, template.Name, items, created_at)
+
+	return scanTemplate(row)
+}
+
+// Get the templates of the user by name, they can be used in every workspace of the user
+func (r ApiRepository) GetTemplates(userId string) (templates []entity.Template, err error) {
+	rows, err := r.DB.Query(GetTemplatesQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates = []entity.Template{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+// Find a template of the user, the templates of other users are not found
+func (r ApiRepository) FindTemplate(id, userId string) (template entity.Template, err error) {
+	row := r.DB.QueryRow(FindTemplateQuery, id, userId)
+
+	return scanTemplate(row)
+}
+
+// Delete a template of the user, sql.ErrNoRows when the user has no such template
+func (r ApiRepository) DeleteTemplate(id, userId string) error {
+	result, err := r.DB.Exec(DeleteTemplateQuery, id, userId)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Call fn with each todo item of the personal list of the user or of the workspace by rank, the items
+// are read one at a time so a large list is never held in memory
+func (r ApiRepository) ForEachListItem(userId string, fn func(item entity.TodoItem) error) error {
+	rows, err := r.DB.Query(GetBoardItemsQuery, userId, r.WorkspaceId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanCalendarFeed(row itemScanner) (feed entity.CalendarFeed, err error) {
+	err = row.Scan(&feed.Id, &feed.UserId, &feed.WorkspaceId, &feed.CreatedAt)
+
+	return feed, err
+}
+
+// Save the hash of the calendar feed token of the personal list of the user or of the workspace. A list
+// has one feed, a new token replaces the previous one
+func (r ApiRepository) CreateCalendarFeed(userId, tokenHash string) (entity.CalendarFeed, error) {
+	id := (uuid.New()).String()
+	created_at := time.Now()
+
+	row := r.DB.QueryRow(CreateCalendarFeedQuery, id, userId, r.WorkspaceId, tokenHash, created_at)
+
+	return scanCalendarFeed(row)
+}
+
+// Find the calendar feed of a token hash, sql.ErrNoRows when no feed has the token
+func (r ApiRepository) FindCalendarFeed(tokenHash string) (feed entity.CalendarFeed, err error) {
+	row := r.DB.QueryRow(FindCalendarFeedQuery, tokenHash)
+
+	return scanCalendarFeed(row)
+}
+
+// Delete the calendar feed of the list, sql.ErrNoRows when the list has no feed
+func (r ApiRepository) DeleteCalendarFeed(userId string) error {
+	result, err := r.DB.Exec(DeleteCalendarFeedQuery, userId, r.WorkspaceId)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Find the todo items of the personal list of the user or of the workspace by ids in a single query, deleted
+// items and the ids of other lists are left out
+func (r ApiRepository) FindListItems(ids []string, userId string) (todoItems map[string]entity.TodoItem, err error) {
+	rows, err := r.DB.Query(FindListItemsQuery, userId, r.WorkspaceId, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := getItemsFromQuery(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	todoItems = make(map[string]entity.TodoItem, len(items))
+	for _, item := range items {
+		todoItems[item.Id] = item
+	}
+
+	return todoItems, nil
+}
+
+// Tag of the list made of the number of items, the sum of their versions and the last update, any change of an
+// item updates it
+func (r ApiRepository) GetListChangeTag(userId string) (string, error) {
+	var count, versions int64
+	var updated_at sql.NullTime
+
+	err := r.DB.QueryRow(ListChangeTagQuery, userId, r.WorkspaceId).Scan(&count, &versions, &updated_at)
+	if err != nil {
+		return "", err
+	}
+
+	var updated int64
+	if updated_at.Valid {
+		updated = updated_at.Time.Unix()
+	}
+
+	return fmt.Sprintf("%d-%d-%d", count, versions, updated), nil
+}
+
+// Number of items inserted by a single statement of an import
+const importBatchSize = 100
+
+// Insert imported todo items in batches inside one transaction, each with a created revision. The items keep
+// their status and labels and are ranked after the items of the list, in their order
+func (r ApiRepository) ImportTodoItems(items []entity.TodoItem, userId string) (imported []entity.TodoItem, err error) {
+	err = r.WithTx(func(tx ApiRepository) error {
+		imported, err = tx.insertImportedItems(items, userId)
+		return err
+	})
+
+	return imported, err
+}
+
+// Inserts imported items with their labels and revisions in the transaction of r. An item keeps its id
+// when it has one, so the caller can refer to it before it is inserted
+func (r ApiRepository) insertImportedItems(items []entity.TodoItem, userId string) (imported []entity.TodoItem, err error) {
+	now := time.Now()
+	created_at := now.Format("2006-01-02T15:04:05Z07:00")
+
+	for start := 0; start < len(items); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		var values, labelValues []string
+		var args, labelArgs []interface{}
+		labels := map[string][]string{}
+
+		for i, item := range items[start:end] {
+			// Ranks two nanoseconds apart stay distinct when timeRank replaces a last digit of 0
+			rank := timeRank(now.Add(time.Duration(2 * (start + i))))
+			due_date := item.Item.Details.DueDate.Format("2006-01-02T15:04:05Z07:00")
+			id := item.Id
+			if id == "" {
+				id = (uuid.New()).String()
+			}
+
+			n := len(args)
+			values = append(values, fmt.Sprintf(importItemValues, n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10))
+			args = append(args, id, item.Item.Name, item.Item.Details.Description, due_date, item.Item.Details.Priority, created_at, userId, r.WorkspaceId, rank, item.Status)
+
+			for _, label := range item.Labels {
+				n := len(labelArgs)
+				labelValues = append(labelValues, fmt.Sprintf("($%d,$%d)", n+1, n+2))
+				labelArgs = append(labelArgs, id, label)
+				labels[id] = append(labels[id], label)
+			}
+		}
+
+		rows, err := r.DB.Query(fmt.Sprintf(ImportTodoItemsQuery, strings.Join(values, ",")), args...)
+		if err != nil {
+			return nil, err
+		}
+
+		batch, err := getItemsFromQuery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		// The labels are added after their items, the items returned by the insert have none yet
+		if len(labelValues) > 0 {
+			if _, err := r.DB.Exec(fmt.Sprintf(ImportItemLabelsQuery, strings.Join(labelValues, ",")), labelArgs...); err != nil {
+				return nil, err
+			}
+
+			for i := range batch {
+				batch[i].Labels = append([]string(nil), labels[batch[i].Id]...)
+				sort.Strings(batch[i].Labels)
+			}
+		}
+
+		values, args = nil, nil
+		for _, item := range batch {
+			snapshot, err := json.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+
+			n := len(args)
+			values = append(values, fmt.Sprintf("($%d,$%d,$%d,$%d,$%d)", n+1, n+2, n+3, n+4, n+5))
+			args = append(args, item.Id, userId, entity.RevisionCreated, snapshot, created_at)
+		}
+
+		if len(values) > 0 {
+			if _, err := r.DB.Exec(fmt.Sprintf(CreateItemRevisionsQuery, strings.Join(values, ",")), args...); err != nil {
+				return nil, err
+			}
+		}
+
+		imported = append(imported, batch...)
+	}
+
+	return imported, nil
+}
+
+// Find the items imported into the list from tasks of an app by the ids of the tasks, including the items in
+// trash. Tasks that were not imported are left out
+func (r ApiRepository) FindItemSources(source string, sourceIds []string, userId string) (todoItems map[string]entity.TodoItem, err error) {
+	rows, err := r.DB.Query(FindItemSourcesQuery, source, pq.Array(sourceIds), userId, r.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := map[string]string{}
+	ids := []string{}
+	for rows.Next() {
+		var item_id, source_id string
+		if err := rows.Scan(&item_id, &source_id); err != nil {
+			return nil, err
+		}
+
+		sources[item_id] = source_id
+		ids = append(ids, item_id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	todoItems = make(map[string]entity.TodoItem, len(ids))
+	if len(ids) == 0 {
+		return todoItems, nil
+	}
+
+	rows, err = r.DB.Query(FindItemsByIdsQuery, pq.Array(ids), r.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := getItemsFromQuery(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		todoItems[sources[item.Id]] = item
+	}
+
+	return todoItems, nil
+}
+
+// Save the ids of the tasks of an app the items were imported from, sourceIds maps the id of an item to the
+// id of its task
+func (r ApiRepository) CreateItemSources(source string, sourceIds map[string]string, userId string) error {
+	imported_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+
+	itemIds := make([]string, 0, len(sourceIds))
+	for id := range sourceIds {
+		itemIds = append(itemIds, id)
+	}
+	sort.Strings(itemIds)
+
+	for start := 0; start < len(itemIds); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(itemIds) {
+			end = len(itemIds)
+		}
+
+		var values []string
+		var args []interface{}
+		for _, id := range itemIds[start:end] {
+			n := len(args)
+			values = append(values, fmt.Sprintf("($%d,$%d,NULLIF($%d, ''),$%d,$%d,$%d)", n+1, n+2, n+3, n+4, n+5, n+6))
+			args = append(args, id, userId, r.WorkspaceId, source, sourceIds[id], imported_at)
+		}
+
+		if _, err := r.DB.Exec(fmt.Sprintf(CreateItemSourcesQuery, strings.Join(values, ",")), args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanWebhook(row itemScanner) (webhook entity.Webhook, err error) {
+	err = row.Scan(&webhook.Id, &webhook.UserId, &webhook.WorkspaceId, &webhook.Url, &webhook.Secret, pq.Array(&webhook.Events), &webhook.CreatedAt)
+
+	return webhook, err
+}
+
+// Save a webhook of the personal list of the user or of the workspace
+func (r ApiRepository) CreateWebhook(url, secret string, events []string, userId string) (entity.Webhook, error) {
+	id := (uuid.New()).String()
+	created_at := time.Now()
+
+	row := r.DB.QueryRow(CreateWebhookQuery, id, userId, r.WorkspaceId, url, secret, pq.Array(events), created_at)
+
+	return scanWebhook(row)
+}
+
+// Get the webhooks of the user in all of their lists
+func (r ApiRepository) GetWebhooks(userId string) (webhooks []entity.Webhook, err error) {
+	rows, err := r.DB.Query(GetWebhooksQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks = []entity.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// Find a webhook of the user, the webhooks of other users are not found
+func (r ApiRepository) FindWebhook(id, userId string) (webhook entity.Webhook, err error) {
+	row := r.DB.QueryRow(FindWebhookQuery, id, userId)
+
+	return scanWebhook(row)
+}
+
+// Delete a webhook of the user with its deliveries, sql.ErrNoRows when the user has no such webhook
+func (r ApiRepository) DeleteWebhook(id, userId string) error {
+	result, err := r.DB.Exec(DeleteWebhookQuery, id, userId)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func scanWebhookDelivery(row itemScanner) (delivery entity.WebhookDelivery, err error) {
+	var next_attempt_at, delivered_at sql.NullTime
+	var payload []byte
+
+	err = row.Scan(&delivery.Id, &delivery.WebhookId, &delivery.Event, &delivery.Status, &delivery.Attempts, &next_attempt_at,
+		&delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt, &delivered_at, &payload)
+	if err != nil {
+		return delivery, err
+	}
+
+	if next_attempt_at.Valid && delivery.Status == entity.WebhookDeliveryPending {
+		delivery.NextAttemptAt = &next_attempt_at.Time
+	}
+
+	if delivered_at.Valid {
+		delivery.DeliveredAt = &delivered_at.Time
+	}
+
+	delivery.Payload = append(json.RawMessage(nil), payload...)
+
+	return delivery, nil
+}
+
+func getWebhookDeliveriesFromQuery(rows *sql.Rows) (deliveries []entity.WebhookDelivery, err error) {
+	defer rows.Close()
+
+	deliveries = []entity.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// Queue a delivery of an event to each webhook, the payload is the item as it is now. A test event has no item
+func (r ApiRepository) CreateWebhookDeliveries(webhookIds []string, event, actorId string, item *entity.TodoItem) (deliveries []entity.WebhookDelivery, err error) {
+	if len(webhookIds) == 0 {
+		return []entity.WebhookDelivery{}, nil
+	}
+
+	created_at := time.Now().UTC()
+
+	var values []string
+	var args []interface{}
+	for _, webhookId := range webhookIds {
+		id := (uuid.New()).String()
+		payload, err := json.Marshal(entity.WebhookPayload{
+			Id:        id,
+			Event:     event,
+			CreatedAt: created_at,
+			ActorId:   actorId,
+			Item:      item,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d,$%d,$%d,$%d,$%d,$%d)", n+1, n+2, n+3, n+4, n+5, n+5))
+		args = append(args, id, webhookId, event, string(payload), created_at)
+	}
+
+	rows, err := r.DB.Query(fmt.Sprintf(CreateWebhookDeliveriesQuery, strings.Join(values, ",")), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return getWebhookDeliveriesFromQuery(rows)
+}
+
+// Queue a delivery of an event of an item to the webhooks of its list that are subscribed to the event
+func (r ApiRepository) EnqueueWebhookDeliveries(event, actorId string, item entity.TodoItem) (deliveries []entity.WebhookDelivery, err error) {
+	rows, err := r.DB.Query(FindEventWebhooksQuery, event, item.UserId, item.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhookIds []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		webhookIds = append(webhookIds, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.CreateWebhookDeliveries(webhookIds, event, actorId, &item)
+}
+
+// Claim up to limit pending deliveries that are due, with the URL and the secret of their webhooks. Claimed
+// deliveries are not claimed again before the lease ends
+func (r ApiRepository) ClaimWebhookDeliveries(limit int, lease time.Duration) (jobs []entity.WebhookJob, err error) {
+	now := time.Now().UTC()
+
+	rows, err := r.DB.Query(ClaimWebhookDeliveriesQuery, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var job entity.WebhookJob
+		var payload []byte
+
+		err := rows.Scan(&job.Delivery.Id, &job.Delivery.WebhookId, &job.Delivery.Event, &job.Delivery.Attempts, &payload, &job.Url, &job.Secret)
+		if err != nil {
+			return nil, err
+		}
+
+		job.Delivery.Status = entity.WebhookDeliveryPending
+		job.Delivery.Payload = append(json.RawMessage(nil), payload...)
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Log an attempt to send a delivery and set the status the delivery has after it, a pending delivery is sent
+// again at nextAttemptAt
+func (r ApiRepository) RecordWebhookAttempt(deliveryId string, attempt entity.WebhookAttempt, status string, nextAttemptAt *time.Time) error {
+	_, err := r.DB.Exec(RecordWebhookAttemptQuery, deliveryId, attempt.AttemptedAt, attempt.StatusCode, attempt.Error, attempt.DurationMs, status, nextAttemptAt)
+
+	return err
+}
+
+// Get the latest deliveries of the webhooks of the user, of one webhook when webhookId is set and with one
+// status when status is set
+func (r ApiRepository) GetWebhookDeliveries(userId, webhookId, status string, limit int) (deliveries []entity.WebhookDelivery, err error) {
+	rows, err := r.DB.Query(GetWebhookDeliveriesQuery, userId, webhookId, status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return getWebhookDeliveriesFromQuery(rows)
+}
+
+// Find a delivery of a webhook of the user with the log of its attempts
+func (r ApiRepository) FindWebhookDelivery(id, webhookId, userId string) (delivery entity.WebhookDelivery, err error) {
+	row := r.DB.QueryRow(FindWebhookDeliveryQuery, id, webhookId, userId)
+
+	delivery, err = scanWebhookDelivery(row)
+	if err != nil {
+		return delivery, err
+	}
+
+	rows, err := r.DB.Query(GetWebhookAttemptsQuery, id)
+	if err != nil {
+		return delivery, err
+	}
+	defer rows.Close()
+
+	delivery.Log = []entity.WebhookAttempt{}
+	for rows.Next() {
+		var attempt entity.WebhookAttempt
+		if err := rows.Scan(&attempt.AttemptedAt, &attempt.StatusCode, &attempt.Error, &attempt.DurationMs); err != nil {
+			return delivery, err
+		}
+
+		delivery.Log = append(delivery.Log, attempt)
+	}
+
+	return delivery, rows.Err()
+}
+
+// Queue a delivery of a webhook of the user again with all its attempts, sql.ErrNoRows when there is no such
+// delivery
+func (r ApiRepository) RedeliverWebhookDelivery(id, webhookId, userId string) (delivery entity.WebhookDelivery, err error) {
+	row := r.DB.QueryRow(RedeliverWebhookDeliveryQuery, id, webhookId, userId, time.Now().UTC())
+
+	return scanWebhookDelivery(row)
+}
+
+// Log an event of an item for the streams of the owner, the followers, the users it is shared with and the members
+// of its workspace, then notify the streams of every instance
+func (r ApiRepository) CreateItemEvent(event, actorId string, item entity.TodoItem) (notice entity.ItemEventNotice, err error) {
+	payload, err := json.Marshal(item)
+	if err != nil {
+		return notice, err
+	}
+
+	recipients := []string{}
+	for _, userId := range append([]string{item.UserId}, itemFollowers(item)...) {
+		if userId != "" && !containsString(recipients, userId) {
+			recipients = append(recipients, userId)
+		}
+	}
+
+	row := r.DB.QueryRow(CreateItemEventQuery, item.Id, item.WorkspaceId, pq.Array(recipients), event, actorId, string(payload), time.Now().UTC())
+	if err := row.Scan(&notice.Id, pq.Array(&notice.Recipients)); err != nil {
+		return notice, err
+	}
+	notice.WorkspaceId = item.WorkspaceId
+
+	body, err := json.Marshal(notice)
+	if err != nil {
+		return notice, err
+	}
+
+	_, err = r.DB.Exec(NotifyItemEventQuery, ItemEventsChannel, string(body))
+
+	return notice, err
+}
+
+// Get the events after the id the user gets, oldest first
+func (r ApiRepository) GetItemEvents(userId string, afterId int64, limit int) (events []entity.ItemEvent, err error) {
+	rows, err := r.DB.Query(GetItemEventsQuery, userId, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events = []entity.ItemEvent{}
+	for rows.Next() {
+		var event entity.ItemEvent
+		var payload string
+
+		if err := rows.Scan(&event.Id, &event.ItemId, &event.Event, &event.ActorId, &payload, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		event.Item = new(entity.TodoItem)
+		if err := json.Unmarshal([]byte(payload), event.Item); err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// Get the ids of the oldest and the latest events in the log, 0 when the log is empty
+func (r ApiRepository) GetItemEventBounds() (first, last int64, err error) {
+	err = r.DB.QueryRow(GetItemEventBoundsQuery).Scan(&first, &last)
+
+	return first, last, err
+}
+
+// Purge the events logged before the time, the streams resuming from them are reset
+func (r ApiRepository) PurgeItemEvents(createdBefore time.Time) (int64, error) {
+	result, err := r.DB.Exec(PurgeItemEventsQuery, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Scans an item followed by its number in the change sequence
+type itemChangeScanner struct {
+	row       itemScanner
+	changeSeq *int64
+}
+
+func (s itemChangeScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.changeSeq)...)
+}
+
+// Get the items and tombstones of the personal list of the user or of the workspace changed after a number of
+// the change sequence, in its order. They are read under the lock of the sequence, so every change with a
+// smaller number is committed
+func (r ApiRepository) GetItemChanges(userId string, after int64, limit int) (changes []entity.ItemChange, tombstones []entity.ItemTombstone, err error) {
+	err = r.WithTx(func(tx ApiRepository) error {
+		if _, err := tx.DB.Exec(LockItemChangesQuery); err != nil {
+			return err
+		}
+
+		changes, err = tx.getItemChanges(userId, after, limit)
+		if err != nil {
+			return err
+		}
+
+		tombstones, err = tx.getItemTombstones(userId, after, limit)
+		return err
+	})
+
+	return changes, tombstones, err
+}
+
+func (r ApiRepository) getItemChanges(userId string, after int64, limit int) ([]entity.ItemChange, error) {
+	rows, err := r.DB.Query(GetItemChangesQuery, userId, r.WorkspaceId, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []entity.ItemChange{}
+	for rows.Next() {
+		var change entity.ItemChange
+		change.Item, err = scanItem(itemChangeScanner{row: rows, changeSeq: &change.ChangeSeq})
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func (r ApiRepository) getItemTombstones(userId string, after int64, limit int) ([]entity.ItemTombstone, error) {
+	rows, err := r.DB.Query(GetItemTombstonesQuery, userId, r.WorkspaceId, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tombstones := []entity.ItemTombstone{}
+	for rows.Next() {
+		tombstone := entity.ItemTombstone{Purged: true}
+		if err := rows.Scan(&tombstone.Id, &tombstone.ChangeSeq, &tombstone.DeletedAt); err != nil {
+			return nil, err
+		}
+
+		tombstones = append(tombstones, tombstone)
+	}
+
+	return tombstones, rows.Err()
+}
+
+// Role of the user in a workspace, empty when the user is not a member of it or has not accepted the invitation yet
+func (r ApiRepository) GetWorkspaceRole(workspaceId, userId string) (string, error) {
+	var role string
+	err := r.DB.QueryRow(WorkspaceRoleOfUserQuery, workspaceId, userId).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return role, err
+}
+
+// Create a workspace with the user as its owner
+func (r ApiRepository) CreateWorkspace(name, userId string) (workspace entity.Workspace, err error) {
+	created_at := time.Now()
+
+	workspace = entity.Workspace{
+		Id:        (uuid.New()).String(),
+		Name:      name,
+		OwnerId:   userId,
+		Role:      entity.RoleOwner,
+		CreatedAt: created_at,
+	}
+
+	err = r.WithTx(func(tx ApiRepository) error {
+		if _, err := tx.DB.Exec(CreateWorkspaceQuery, workspace.Id, name, userId, created_at); err != nil {
+			return err
+		}
+
+		_, err := tx.DB.Exec(AddWorkspaceOwnerQuery, (uuid.New()).String(), workspace.Id, userId, created_at)
+
+		return err
+	})
+
+	return workspace, err
+}
+
+// Get the workspaces the user is a member of, by name
+func (r ApiRepository) GetWorkspacesOfUser(userId string) (workspaces []entity.Workspace, err error) {
+	rows, err := r.DB.Query(GetWorkspacesOfUserQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces = []entity.Workspace{}
+	for rows.Next() {
+		var workspace entity.Workspace
+
+		err := rows.Scan(&workspace.Id, &workspace.Name, &workspace.OwnerId, &workspace.Role, &workspace.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, rows.Err()
+}
+
+func scanWorkspaceMember(row itemScanner) (member entity.WorkspaceMember, err error) {
+	var responded_at sql.NullTime
+
+	err = row.Scan(&member.Id, &member.WorkspaceId, &member.UserId, &member.Email, &member.Role, &member.Status, &member.InvitedBy, &member.CreatedAt, &responded_at)
+	if responded_at.Valid {
+		member.RespondedAt = &responded_at.Time
+	}
+
+	return member, err
+}
+
+func getWorkspaceMembersFromQuery(rows *sql.Rows) (members []entity.WorkspaceMember, err error) {
+	defer rows.Close()
+
+	members = []entity.WorkspaceMember{}
+	for rows.Next() {
+		member, err := scanWorkspaceMember(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// Get the members of a workspace, including the pending and declined invitations
+func (r ApiRepository) GetWorkspaceMembers(workspaceId string) (members []entity.WorkspaceMember, err error) {
+	rows, err := r.DB.Query(GetWorkspaceMembersQuery, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	return getWorkspaceMembersFromQuery(rows)
+}
+
+// Find the membership of a user in a workspace
+func (r ApiRepository) FindWorkspaceMember(workspaceId, userId string) (member entity.WorkspaceMember, err error) {
+	row := r.DB.QueryRow(FindWorkspaceMemberQuery, workspaceId, userId)
+
+	return scanWorkspaceMember(row)
+}
+
+// Invite a user to a workspace, inviting again changes the role and re-invites a user who declined
+func (r ApiRepository) InviteWorkspaceMember(workspaceId, userId, role, invitedBy string) (member entity.WorkspaceMember, err error) {
+	id := (uuid.New()).String()
+	created_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+
+	row := r.DB.QueryRow(InviteWorkspaceMemberQuery, id, workspaceId, userId, role, invitedBy, created_at)
+
+	return scanWorkspaceMember(row)
+}
+
+// Remove a user from a workspace along with their shares, assignments and watches of its items. The removal
+// is refused with ErrLastWorkspaceOwner when the user is the last owner of the workspace
+func (r ApiRepository) DeleteWorkspaceMember(workspaceId, userId string) error {
+	return r.WithTx(func(tx ApiRepository) error {
+		var owners int
+		var isOwner bool
+
+		if err := tx.DB.QueryRow(LockWorkspaceOwnersQuery, workspaceId, userId).Scan(&owners, &isOwner); err != nil {
+			return err
+		}
+
+		if isOwner && owners == 1 {
+			return ErrLastWorkspaceOwner
+		}
+
+		_, err := tx.DB.Exec(DeleteWorkspaceMemberQuery, workspaceId, userId)
+
+		return err
+	})
+}
+
+// Get the pending workspace invitations of the user, newest first
+func (r ApiRepository) GetWorkspaceInvitations(userId string) (members []entity.WorkspaceMember, err error) {
+	rows, err := r.DB.Query(GetWorkspaceInvitationsQuery, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return getWorkspaceMembersFromQuery(rows)
+}
+
+// Accept or decline a pending workspace invitation of the user
+func (r ApiRepository) RespondToWorkspaceInvitation(memberId, userId, status string) (member entity.WorkspaceMember, err error) {
+	responded_at := time.Now().Format("2006-01-02T15:04:05Z07:00")
+	row := r.DB.QueryRow(RespondToWorkspaceInvitationQuery, status, responded_at, memberId, userId)
+
+	return scanWorkspaceMember(row)
 }
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"testing"
 	"time"
@@ -8,9 +10,12 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 
+	"todo-project/constants"
 	"todo-project/entity"
 )
 
+var todoItemColumns = []string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id", "deleted_at", "status", "started_at", "completed_at", "version", "labels", "comment_count", "assignee_id", "watchers", "workspace_id", "blocked_by", "blocked", "rank", "recurrence"}
+
 func TestApiRepo(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -43,8 +48,8 @@ func TestApiRepo(t *testing.T) {
 	defer db.Close()
 
 	t.Run("Test Get Item from query", func(t *testing.T) {
-		rows := mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+		rows := mock.NewRows(todoItemColumns).
+			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
 
 		t.Run("Success", func(t *testing.T) {
 			mock.ExpectQuery("SELECT").WillReturnRows(rows)
@@ -58,7 +63,8 @@ func TestApiRepo(t *testing.T) {
 			}
 
 			want := entity.TodoItem{
-				Id: "3a35452e-957c-4588-8d40-c88f370067d2",
+				Id:     "3a35452e-957c-4588-8d40-c88f370067d2",
+				UserId: "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
 				Item: entity.TodoItemDto{
 					Name: "Todo list item 1",
 					Details: entity.TodoItemDetailsDto{
@@ -68,9 +74,11 @@ func TestApiRepo(t *testing.T) {
 					},
 				},
 				IsCompleted: true,
+				Status:      "done",
 				IsDeleted:   false,
 				CreatedAt:   createdTime,
 				UpdatedAt:   updatedAt,
+				Version:     1,
 			}
 
 			assert.Equal(t, got, want)
@@ -87,8 +95,8 @@ func TestApiRepo(t *testing.T) {
 	})
 
 	t.Run("Test Create an item", func(t *testing.T) {
-		rows := mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+		rows := mock.NewRows(todoItemColumns).
+			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
 
 		var item = &entity.TodoItemDto{
 			Name: "Todo list item 1",
@@ -100,7 +108,7 @@ func TestApiRepo(t *testing.T) {
 		}
 
 		mock.ExpectQuery("INSERT INTO todo_items").
-			WithArgs(sqlmock.AnyArg(), item.Name, item.Details.Description, sqlmock.AnyArg(), item.Details.Priority, sqlmock.AnyArg(), sqlmock.AnyArg(), "user_id").
+			WithArgs(sqlmock.AnyArg(), item.Name, item.Details.Description, sqlmock.AnyArg(), item.Details.Priority, sqlmock.AnyArg(), sqlmock.AnyArg(), "user_id", "", sqlmock.AnyArg()).
 			WillReturnRows(rows)
 
 		got, err := as.R.CreateTodoItem(item, "user_id")
@@ -110,7 +118,8 @@ func TestApiRepo(t *testing.T) {
 		}
 
 		want := entity.TodoItem{
-			Id: "3a35452e-957c-4588-8d40-c88f370067d2",
+			Id:     "3a35452e-957c-4588-8d40-c88f370067d2",
+			UserId: "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
 			Item: entity.TodoItemDto{
 				Name: "Todo list item 1",
 				Details: entity.TodoItemDetailsDto{
@@ -120,19 +129,22 @@ func TestApiRepo(t *testing.T) {
 				},
 			},
 			IsCompleted: true,
+			Status:      "done",
 			IsDeleted:   false,
 			CreatedAt:   createdTime,
 			UpdatedAt:   updatedAt,
+			Version:     1,
 		}
 
 		assert.Equal(t, got, want)
 	})
 
 	t.Run("Test find item by id", func(t *testing.T) {
-		rows := mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+		rows := mock.NewRows(todoItemColumns).
+			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
 
-		mock.ExpectQuery(`SELECT \* FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2'`).
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).
+			WithArgs("3a35452e-957c-4588-8d40-c88f370067d2", "").
 			WillReturnRows(rows)
 
 		got, err := as.R.FindItemById("3a35452e-957c-4588-8d40-c88f370067d2")
@@ -142,7 +154,8 @@ func TestApiRepo(t *testing.T) {
 		}
 
 		want := entity.TodoItem{
-			Id: "3a35452e-957c-4588-8d40-c88f370067d2",
+			Id:     "3a35452e-957c-4588-8d40-c88f370067d2",
+			UserId: "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
 			Item: entity.TodoItemDto{
 				Name: "Todo list item 1",
 				Details: entity.TodoItemDetailsDto{
@@ -152,25 +165,37 @@ func TestApiRepo(t *testing.T) {
 				},
 			},
 			IsCompleted: true,
+			Status:      "done",
 			IsDeleted:   false,
 			CreatedAt:   createdTime,
 			UpdatedAt:   updatedAt,
+			Version:     1,
 		}
 
 		assert.Equal(t, got, want)
 	})
 
+	t.Run("Test find item of another workspace by id", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).
+			WithArgs("3a35452e-957c-4588-8d40-c88f370067d2", "0b6a3c1e-9f4d-4a57-8e2b-5d1c7f3a9e60").
+			WillReturnRows(mock.NewRows(todoItemColumns))
+
+		_, err := as.R.InWorkspace("0b6a3c1e-9f4d-4a57-8e2b-5d1c7f3a9e60").FindItemById("3a35452e-957c-4588-8d40-c88f370067d2")
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
 	t.Run("Test update todo item", func(t *testing.T) {
 		newUpdatedAtString := time.Now().Format("2006-01-02T15:04:05Z07:00")
 		newUpdatedDate, _ := time.Parse(newUpdatedAtString, "2006-01-02T15:04:05Z07:00")
 
-		rows := mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 2", dueDate, "HIGH", createdTime, newUpdatedDate, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+		rows := mock.NewRows(todoItemColumns).
+			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 2", dueDate, "HIGH", createdTime, newUpdatedDate, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
 
-		newUpdatedQuery := `UPDATE todo_items SET description=\$1, due_date=\$2, priority=\$3, updated_at=\$4 WHERE id=\$5 RETURNING \*;`
+		newUpdatedQuery := `UPDATE todo_items SET description=\$1, due_date=\$2, priority=\$3, updated_at=\$4 WHERE id=\$5 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$7, ''\) AND \(\$6 = 0 OR version=\$6\) RETURNING .+;`
 
 		mock.ExpectQuery(newUpdatedQuery).
-			WithArgs("This is item 2", dueDate, "HIGH", newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2").
+			WithArgs("This is item 2", dueDate, "HIGH", newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2", 0, "").
 			WillReturnRows(rows)
 
 		details := entity.TodoItemDetailsDto{
@@ -179,13 +204,14 @@ func TestApiRepo(t *testing.T) {
 			DueDate:     dueDate,
 		}
 
-		got, err := as.R.UpdateTodoItem(&details, "3a35452e-957c-4588-8d40-c88f370067d2")
+		got, err := as.R.UpdateTodoItem(&details, "3a35452e-957c-4588-8d40-c88f370067d2", 0)
 		if err != nil {
 			t.Fatalf("Error getting the item from the query: %v", err)
 		}
 
 		want := entity.TodoItem{
-			Id: "3a35452e-957c-4588-8d40-c88f370067d2",
+			Id:     "3a35452e-957c-4588-8d40-c88f370067d2",
+			UserId: "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
 			Item: entity.TodoItemDto{
 				Name: "Todo list item 1",
 				Details: entity.TodoItemDetailsDto{
@@ -195,9 +221,11 @@ func TestApiRepo(t *testing.T) {
 				},
 			},
 			IsCompleted: true,
+			Status:      "done",
 			IsDeleted:   false,
 			CreatedAt:   createdTime,
 			UpdatedAt:   newUpdatedDate,
+			Version:     1,
 		}
 
 		assert.Equal(t, got, want)
@@ -205,44 +233,45 @@ func TestApiRepo(t *testing.T) {
 
 	t.Run("Test Delete todo item", func(t *testing.T) {
 		newUpdatedAtString := time.Now().Format("2006-01-02T15:04:05Z07:00")
-		newDeleteQuery := `UPDATE todo_items SET is_deleted = 'true', updated_at=\$1 WHERE id=\$2;`
+		newDeleteQuery := `UPDATE todo_items SET is_deleted = 'true', updated_at=\$1, deleted_at=\$1 WHERE id=\$2 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$4, ''\) AND \(\$3 = 0 OR version=\$3\);`
 
 		t.Run("SUCCESS", func(t *testing.T) {
-			mock.ExpectExec(newDeleteQuery).WithArgs(newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2").
+			mock.ExpectExec(newDeleteQuery).WithArgs(newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2", 0, "").
 				WillReturnResult(sqlmock.NewResult(1, 1))
 
-			err := as.R.DeleteTodoItem("3a35452e-957c-4588-8d40-c88f370067d2")
+			err := as.R.DeleteTodoItem("3a35452e-957c-4588-8d40-c88f370067d2", 0)
 
 			assert.NoError(t, err)
 		})
 
 		t.Run("ERROR", func(t *testing.T) {
-			mock.ExpectExec(newDeleteQuery).WithArgs(newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2").
+			mock.ExpectExec(newDeleteQuery).WithArgs(newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2", 0, "").
 				WillReturnError(errors.New("internal server error"))
 
-			err := as.R.DeleteTodoItem("3a35452e-957c-4588-8d40-c88f370067d2")
+			err := as.R.DeleteTodoItem("3a35452e-957c-4588-8d40-c88f370067d2", 0)
 
 			assert.Error(t, err)
 		})
 	})
 
 	t.Run("Test Set item status to complete", func(t *testing.T) {
-		rows := mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+		rows := mock.NewRows(todoItemColumns).
+			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
 
 		newUpdatedAtString := time.Now().Format("2006-01-02T15:04:05Z07:00")
-		newCompleteQuery := `UPDATE todo_items SET is_completed = 'true', updated_at=\$1 WHERE id=\$2 RETURNING \*;`
+		newCompleteQuery := `UPDATE todo_items SET is_completed = 'true', status = 'done', completed_at=COALESCE\(completed_at, \$1\), updated_at=\$1 WHERE id=\$2 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$4, ''\) AND \(\$3 = 0 OR version=\$3\) RETURNING .+;`
 
-		mock.ExpectQuery(newCompleteQuery).WithArgs(newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2").
+		mock.ExpectQuery(newCompleteQuery).WithArgs(newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2", 0, "").
 			WillReturnRows(rows)
 
-		got, err := as.R.SetItemStatusToComplete("3a35452e-957c-4588-8d40-c88f370067d2")
+		got, err := as.R.SetItemStatusToComplete("3a35452e-957c-4588-8d40-c88f370067d2", 0)
 		if err != nil {
 			t.Fatalf("Error getting the item from the query: %v", err)
 		}
 
 		want := entity.TodoItem{
-			Id: "3a35452e-957c-4588-8d40-c88f370067d2",
+			Id:     "3a35452e-957c-4588-8d40-c88f370067d2",
+			UserId: "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
 			Item: entity.TodoItemDto{
 				Name: "Todo list item 1",
 				Details: entity.TodoItemDetailsDto{
@@ -252,20 +281,22 @@ func TestApiRepo(t *testing.T) {
 				},
 			},
 			IsCompleted: true,
+			Status:      "done",
 			IsDeleted:   false,
 			CreatedAt:   createdTime,
 			UpdatedAt:   updatedAt,
+			Version:     1,
 		}
 
 		assert.Equal(t, got, want)
 	})
 
 	t.Run("Test Get todo list items", func(t *testing.T) {
-		rows := mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+		rows := mock.NewRows(todoItemColumns).
+			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
 
 		t.Run("SUCCESS", func(t *testing.T) {
-			GetItemsQuery := `SELECT \* FROM todo_items WHERE is_deleted = false AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67' LIMIT 1`
+			GetItemsQuery := `SELECT .+ FROM todo_items WHERE is_deleted = false AND workspace_id IS NULL AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67' ORDER BY rank, created_at, id LIMIT 1`
 			mock.ExpectQuery(GetItemsQuery).WillReturnRows(rows)
 
 			got, err := as.R.GetTodoListItems(1, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
@@ -274,7 +305,8 @@ func TestApiRepo(t *testing.T) {
 			}
 
 			item := entity.TodoItem{
-				Id: "3a35452e-957c-4588-8d40-c88f370067d2",
+				Id:     "3a35452e-957c-4588-8d40-c88f370067d2",
+				UserId: "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
 				Item: entity.TodoItemDto{
 					Name: "Todo list item 1",
 					Details: entity.TodoItemDetailsDto{
@@ -284,9 +316,11 @@ func TestApiRepo(t *testing.T) {
 					},
 				},
 				IsCompleted: true,
+				Status:      "done",
 				IsDeleted:   false,
 				CreatedAt:   createdTime,
 				UpdatedAt:   updatedAt,
+				Version:     1,
 			}
 
 			assert.Len(t, got, 1)
@@ -296,7 +330,7 @@ func TestApiRepo(t *testing.T) {
 		})
 
 		t.Run("FAILED", func(t *testing.T) {
-			GetItemsQuery := `SELECT \* FROM todo_items WHERE is_deleted = false AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67' LIMIT 1`
+			GetItemsQuery := `SELECT .+ FROM todo_items WHERE is_deleted = false AND workspace_id IS NULL AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67' ORDER BY rank, created_at, id LIMIT 1`
 			mock.ExpectQuery(GetItemsQuery).WillReturnError(errors.ErrUnsupported)
 
 			_, err := as.R.GetTodoListItems(1, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
@@ -304,3 +338,1919 @@ func TestApiRepo(t *testing.T) {
 		})
 	})
 }
+
+func TestApiRepoTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	dueDate, err := time.Parse("2006-01-02 15:04:05", "2023-10-15 09:38:24")
+	if err != nil {
+		t.Fatalf("Failed to parse due_date: %v", err)
+	}
+
+	deletedAt, err := time.Parse("2006-01-02 15:04:05", "2023-10-16 10:00:00")
+	if err != nil {
+		t.Fatalf("Failed to parse deleted_at: %v", err)
+	}
+
+	t.Run("Test Get trash items", func(t *testing.T) {
+		rows := mock.NewRows(todoItemColumns).
+			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, deletedAt, false, true, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", deletedAt, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = true AND user_id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\) ORDER BY deleted_at DESC LIMIT \$2`).
+			WithArgs("ed6caeda-1fa9-442e-a41d-dd2b135cea67", 10, "").
+			WillReturnRows(rows)
+
+		got, err := repo.GetTrashItems(10, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.True(t, got[0].IsDeleted)
+		assert.Equal(t, deletedAt, *got[0].DeletedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Restore item", func(t *testing.T) {
+		t.Run("SUCCESS", func(t *testing.T) {
+			rows := mock.NewRows(todoItemColumns).
+				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, false, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+
+			mock.ExpectQuery(`UPDATE todo_items SET is_deleted = 'false', deleted_at=NULL, updated_at=\$1 WHERE id=\$2 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\) AND is_deleted = true RETURNING .+;`).
+				WithArgs(sqlmock.AnyArg(), "3a35452e-957c-4588-8d40-c88f370067d2", "").
+				WillReturnRows(rows)
+
+			got, err := repo.RestoreTodoItem("3a35452e-957c-4588-8d40-c88f370067d2")
+
+			assert.NoError(t, err)
+			assert.False(t, got.IsDeleted)
+			assert.Nil(t, got.DeletedAt)
+		})
+
+		t.Run("Not in trash", func(t *testing.T) {
+			mock.ExpectQuery(`UPDATE todo_items SET is_deleted = 'false'`).
+				WithArgs(sqlmock.AnyArg(), "3a35452e-957c-4588-8d40-c88f370067d2", "").
+				WillReturnRows(mock.NewRows(todoItemColumns))
+
+			_, err := repo.RestoreTodoItem("3a35452e-957c-4588-8d40-c88f370067d2")
+
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+		})
+	})
+
+	t.Run("Test Purge item", func(t *testing.T) {
+		purgeQuery := `DELETE FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\) AND is_deleted = true;`
+
+		t.Run("SUCCESS", func(t *testing.T) {
+			mock.ExpectExec(purgeQuery).WithArgs("3a35452e-957c-4588-8d40-c88f370067d2", "").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err := repo.PurgeTodoItem("3a35452e-957c-4588-8d40-c88f370067d2")
+
+			assert.NoError(t, err)
+		})
+
+		t.Run("Not in trash", func(t *testing.T) {
+			mock.ExpectExec(purgeQuery).WithArgs("3a35452e-957c-4588-8d40-c88f370067d2", "").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			err := repo.PurgeTodoItem("3a35452e-957c-4588-8d40-c88f370067d2")
+
+			assert.ErrorIs(t, err, ErrItemNotInTrash)
+		})
+
+		t.Run("ERROR", func(t *testing.T) {
+			mock.ExpectExec(purgeQuery).WithArgs("3a35452e-957c-4588-8d40-c88f370067d2", "").
+				WillReturnError(errors.New("internal server error"))
+
+			err := repo.PurgeTodoItem("3a35452e-957c-4588-8d40-c88f370067d2")
+
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("Test Empty trash", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM todo_items WHERE user_id=\$1 AND is_deleted = true AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\);`).
+			WithArgs("ed6caeda-1fa9-442e-a41d-dd2b135cea67", "").
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		got, err := repo.EmptyTrash("ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), got)
+	})
+
+	t.Run("Test Purge expired items", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM todo_items WHERE is_deleted = true AND deleted_at < \$1;`).
+			WithArgs(time.Now().AddDate(0, 0, -30).Format("2006-01-02T15:04:05Z07:00")).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		got, err := repo.PurgeExpiredItems(30)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	dueDate, err := time.Parse("2006-01-02 15:04:05", "2023-10-15 09:38:24")
+	if err != nil {
+		t.Fatalf("Failed to parse due_date: %v", err)
+	}
+
+	t.Run("Test Set item status", func(t *testing.T) {
+		rows := mock.NewRows(todoItemColumns).
+			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, false, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "in_progress", dueDate, nil, 1, "", 0, "", "", "", "", false, "", "")
+
+		mock.ExpectQuery(`UPDATE todo_items SET status=\$1::text`).
+			WithArgs("in_progress", sqlmock.AnyArg(), "3a35452e-957c-4588-8d40-c88f370067d2", 0, "").
+			WillReturnRows(rows)
+
+		got, err := repo.SetItemStatus("3a35452e-957c-4588-8d40-c88f370067d2", "in_progress", 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "in_progress", got.Status)
+		assert.Equal(t, dueDate, *got.StartedAt)
+		assert.Nil(t, got.CompletedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoPatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	dueDate, err := time.Parse("2006-01-02 15:04:05", "2023-10-15 09:38:24")
+	if err != nil {
+		t.Fatalf("Failed to parse due_date: %v", err)
+	}
+
+	t.Run("Test Patch todo item", func(t *testing.T) {
+		rows := mock.NewRows(todoItemColumns).
+			AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 2", "This is item 1", dueDate, "LOW", dueDate, dueDate, false, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+
+		mock.ExpectQuery(`UPDATE todo_items SET name=\$1, priority=\$2, updated_at=\$3 WHERE id=\$4 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$6, ''\) AND \(\$5 = 0 OR version=\$5\) RETURNING .+;`).
+			WithArgs("Todo list item 2", "LOW", sqlmock.AnyArg(), "3a35452e-957c-4588-8d40-c88f370067d2", 0, "").
+			WillReturnRows(rows)
+
+		got, err := repo.PatchTodoItem("3a35452e-957c-4588-8d40-c88f370067d2", map[string]interface{}{
+			"priority": "LOW",
+			"name":     "Todo list item 2",
+		}, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Todo list item 2", got.Item.Name)
+		assert.Equal(t, "LOW", got.Item.Details.Priority)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	details := entity.TodoItemDetailsDto{
+		Description: "This is item 2",
+		Priority:    "HIGH",
+		DueDate:     time.Now(),
+	}
+
+	t.Run("Test Update with a stale version", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE todo_items SET description=\$1`).
+			WithArgs(details.Description, details.DueDate, details.Priority, sqlmock.AnyArg(), "3a35452e-957c-4588-8d40-c88f370067d2", 2, "").
+			WillReturnRows(mock.NewRows(todoItemColumns))
+
+		_, err := repo.UpdateTodoItem(&details, "3a35452e-957c-4588-8d40-c88f370067d2", 2)
+
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+	})
+
+	t.Run("Test Delete with a stale version", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE todo_items SET is_deleted = 'true'`).
+			WithArgs(sqlmock.AnyArg(), "3a35452e-957c-4588-8d40-c88f370067d2", 2, "").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.DeleteTodoItem("3a35452e-957c-4588-8d40-c88f370067d2", 2)
+
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+	})
+
+	t.Run("Test Delete with the current version", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE todo_items SET is_deleted = 'true'`).
+			WithArgs(sqlmock.AnyArg(), "3a35452e-957c-4588-8d40-c88f370067d2", 3, "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.DeleteTodoItem("3a35452e-957c-4588-8d40-c88f370067d2", 3)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoBulk(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	dueDate, err := time.Parse("2006-01-02 15:04:05", "2023-10-15 09:38:24")
+	if err != nil {
+		t.Fatalf("Failed to parse due_date: %v", err)
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+
+	t.Run("Test Find items of user", func(t *testing.T) {
+		rows := mock.NewRows(append(todoItemColumns, "role")).
+			AddRow(item_id, "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, false, false, user_id, nil, "todo", nil, nil, 1, "finance,home", 0, "", "", "", "", false, "", "", "editor")
+
+		mock.ExpectQuery(`SELECT \* FROM \(SELECT .+,CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items\s+WHERE id = ANY\(\$1\) AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\)\) AS items WHERE role <> ''`).
+			WithArgs(sqlmock.AnyArg(), user_id, "").
+			WillReturnRows(rows)
+
+		got, roles, err := repo.FindItemsOfUser([]string{item_id, "b6c3bb51-8a62-4c37-9e4f-55b0f4f0a0a1"}, user_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, []string{"finance", "home"}, got[item_id].Labels)
+		assert.Equal(t, map[string]string{item_id: entity.RoleEditor}, roles)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Set item labels", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO item_labels \(item_id, label\) SELECT \$1, unnest\(\$2::text\[\]\) ON CONFLICT DO NOTHING;`).
+			WithArgs(item_id, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM item_labels WHERE item_id=\$1 AND label = ANY\(\$2\);`).
+			WithArgs(item_id, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\) RETURNING .+;`).
+			WithArgs(sqlmock.AnyArg(), item_id, "").
+			WillReturnRows(mock.NewRows(todoItemColumns).
+				AddRow(item_id, "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, false, false, user_id, nil, "todo", nil, nil, 2, "finance", 0, "", "", "", "", false, "", ""))
+
+		got, err := repo.SetItemLabels(item_id, []string{"finance"}, []string{"home"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"finance"}, got.Labels)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test With transaction", func(t *testing.T) {
+		t.Run("Commit", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\) AND is_deleted = true;`).
+				WithArgs(item_id, "").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			err := repo.WithTx(func(tx ApiRepository) error {
+				return tx.PurgeTodoItem(item_id)
+			})
+
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Rollback", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\) AND is_deleted = true;`).
+				WithArgs(item_id, "").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			err := repo.WithTx(func(tx ApiRepository) error {
+				return tx.PurgeTodoItem(item_id)
+			})
+
+			assert.ErrorIs(t, err, ErrItemNotInTrash)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Already in a transaction", func(t *testing.T) {
+			mock.ExpectBegin()
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatalf("Failed to begin: %v", err)
+			}
+
+			err = ApiRepository{DB: tx}.WithTx(func(tx ApiRepository) error {
+				return nil
+			})
+
+			assert.EqualError(t, err, constants.TRANSACTION_NOT_SUPPORTED)
+		})
+	})
+}
+
+func TestApiRepoHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	revisionColumns := []string{"id", "item_id", "user_id", "action", "snapshot", "created_at"}
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	t.Run("Test Create item revision", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO item_revisions \(item_id,user_id,action,snapshot,created_at\) VALUES \(\$1,\$2,\$3,\$4,\$5\);`).
+			WithArgs(item_id, user_id, entity.RevisionCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.CreateItemRevision(entity.TodoItem{Id: item_id}, user_id, entity.RevisionCreated)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get item revisions", func(t *testing.T) {
+		rows := mock.NewRows(revisionColumns).
+			AddRow(1, item_id, user_id, entity.RevisionCreated, []byte(`{"id": "`+item_id+`", "item": {"name": "Todo list item 1", "details": {"priority": "LOW"}}, "status": "todo"}`), createdAt).
+			AddRow(2, item_id, user_id, entity.RevisionUpdated, []byte(`{"id": "`+item_id+`", "item": {"name": "Todo list item 1", "details": {"priority": "HIGH"}}, "status": "todo"}`), createdAt)
+
+		mock.ExpectQuery(`SELECT id,item_id,user_id,action,snapshot,created_at FROM item_revisions WHERE item_id=\$1 ORDER BY id`).
+			WithArgs(item_id).
+			WillReturnRows(rows)
+
+		got, err := repo.GetItemRevisions(item_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, got, 2)
+		assert.Len(t, got[0].Changes, 3)
+		assert.Equal(t, []entity.FieldChange{{Field: "priority", From: "LOW", To: "HIGH"}}, got[1].Changes)
+		assert.Equal(t, "HIGH", got[1].Item.Item.Details.Priority)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Find item revision", func(t *testing.T) {
+		t.Run("SUCCESS", func(t *testing.T) {
+			rows := mock.NewRows(revisionColumns).
+				AddRow(2, item_id, user_id, entity.RevisionUpdated, []byte(`{"id": "`+item_id+`", "item": {"name": "Todo list item 1"}}`), createdAt)
+
+			mock.ExpectQuery(`SELECT id,item_id,user_id,action,snapshot,created_at FROM item_revisions WHERE item_id=\$1 AND id=\$2`).
+				WithArgs(item_id, 2).
+				WillReturnRows(rows)
+
+			got, err := repo.FindItemRevision(item_id, 2)
+
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), got.Id)
+			assert.Equal(t, "Todo list item 1", got.Item.Item.Name)
+		})
+
+		t.Run("Not found", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT id,item_id,user_id,action,snapshot,created_at FROM item_revisions`).
+				WithArgs(item_id, 9).
+				WillReturnRows(mock.NewRows(revisionColumns))
+
+			_, err := repo.FindItemRevision(item_id, 9)
+
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+		})
+	})
+}
+
+func TestApiRepoComments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	comment_id := "9b2f1c8e-4d7a-4f43-b0b5-2d6a7c1e5f10"
+	commentColumns := []string{"id", "item_id", "parent_id", "user_id", "body", "created_at", "edited_at", "is_deleted"}
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	t.Run("Test Create comment", func(t *testing.T) {
+		rows := mock.NewRows(commentColumns).
+			AddRow(comment_id, item_id, nil, user_id, "Waiting for the **invoice**", createdAt, nil, false)
+
+		mock.ExpectQuery(`INSERT INTO item_comments \(id,item_id,parent_id,user_id,body,created_at\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\) RETURNING .+;`).
+			WithArgs(sqlmock.AnyArg(), item_id, nil, user_id, "Waiting for the **invoice**", sqlmock.AnyArg()).
+			WillReturnRows(rows)
+
+		got, err := repo.CreateComment(item_id, user_id, &entity.CommentDto{Body: "Waiting for the **invoice**"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, comment_id, got.Id)
+		assert.Empty(t, got.ParentId)
+		assert.Nil(t, got.EditedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get item comments", func(t *testing.T) {
+		rows := mock.NewRows(commentColumns).
+			AddRow(comment_id, item_id, nil, user_id, "first", createdAt, nil, false).
+			AddRow("5c1d2e3f-6a7b-4c8d-9e0f-1a2b3c4d5e6f", item_id, comment_id, user_id, "reply", createdAt, createdAt, false)
+
+		mock.ExpectQuery(`SELECT .+ FROM item_comments WHERE item_id=\$1 ORDER BY created_at, id`).
+			WithArgs(item_id).
+			WillReturnRows(rows)
+
+		got, err := repo.GetItemComments(item_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, got, 2)
+		assert.Equal(t, comment_id, got[1].ParentId)
+		assert.Equal(t, createdAt, *got[1].EditedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Update comment", func(t *testing.T) {
+		rows := mock.NewRows(commentColumns).
+			AddRow(comment_id, item_id, nil, user_id, "edited", createdAt, createdAt, false)
+
+		mock.ExpectQuery(`UPDATE item_comments SET body=\$1, edited_at=\$2 WHERE id=\$3 AND is_deleted = false RETURNING .+;`).
+			WithArgs("edited", sqlmock.AnyArg(), comment_id).
+			WillReturnRows(rows)
+
+		got, err := repo.UpdateComment(comment_id, "edited")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "edited", got.Body)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Delete comment", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE item_comments SET is_deleted = true, edited_at=\$1 WHERE id=\$2 AND is_deleted = false;`).
+			WithArgs(sqlmock.AnyArg(), comment_id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.DeleteComment(comment_id)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoAttachments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	attachment_id := "6f0c2b7e-8a1d-4e5f-9a3b-2c4d6e8f0a1b"
+	storage_key := "items/" + item_id + "/" + attachment_id
+	attachmentColumns := []string{"id", "item_id", "user_id", "file_name", "content_type", "size", "storage_key", "created_at"}
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	attachment := entity.Attachment{
+		Id:          attachment_id,
+		ItemId:      item_id,
+		UserId:      user_id,
+		FileName:    "invoice.pdf",
+		ContentType: "application/pdf",
+		Size:        2048,
+		StorageKey:  storage_key,
+		CreatedAt:   createdAt,
+	}
+
+	t.Run("Test Create attachment", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\('item_attachments:' \|\| \$1::text\)\)`).
+			WithArgs(user_id).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(size\), 0\) FROM item_attachments WHERE user_id=\$1`).
+			WithArgs(user_id).
+			WillReturnRows(mock.NewRows([]string{"coalesce"}).AddRow(1024))
+		mock.ExpectExec(`INSERT INTO item_attachments \(.+\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8\);`).
+			WithArgs(attachment_id, item_id, user_id, "invoice.pdf", "application/pdf", int64(2048), storage_key, "2023-10-15T09:38:24Z").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.CreateAttachment(attachment, 4096)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Create attachment over quota", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\('item_attachments:' \|\| \$1::text\)\)`).
+			WithArgs(user_id).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(size\), 0\) FROM item_attachments WHERE user_id=\$1`).
+			WithArgs(user_id).
+			WillReturnRows(mock.NewRows([]string{"coalesce"}).AddRow(3072))
+		mock.ExpectRollback()
+
+		err := repo.CreateAttachment(attachment, 4096)
+
+		assert.ErrorIs(t, err, ErrAttachmentOverQuota)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get item attachments", func(t *testing.T) {
+		rows := mock.NewRows(attachmentColumns).
+			AddRow(attachment_id, item_id, user_id, "invoice.pdf", "application/pdf", 2048, storage_key, createdAt)
+
+		mock.ExpectQuery(`SELECT .+ FROM item_attachments WHERE item_id=\$1 ORDER BY created_at, id`).
+			WithArgs(item_id).
+			WillReturnRows(rows)
+
+		got, err := repo.GetItemAttachments(item_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, storage_key, got[0].StorageKey)
+		assert.Equal(t, int64(2048), got[0].Size)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Find attachment not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM item_attachments WHERE item_id=\$1 AND id=\$2`).
+			WithArgs(item_id, attachment_id).
+			WillReturnRows(mock.NewRows(attachmentColumns))
+
+		_, err := repo.FindAttachmentById(item_id, attachment_id)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get storage usage", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(size\), 0\) FROM item_attachments WHERE user_id=\$1`).
+			WithArgs(user_id).
+			WillReturnRows(mock.NewRows([]string{"coalesce"}).AddRow(4096))
+
+		got, err := repo.GetStorageUsage(user_id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4096), got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Delete attachment", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM item_attachments WHERE id=\$1;`).
+			WithArgs(attachment_id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.DeleteAttachment(attachment_id))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Attachment keys", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT storage_key FROM item_attachments WHERE item_id=\$1`).
+			WithArgs(item_id).
+			WillReturnRows(mock.NewRows([]string{"storage_key"}).AddRow(storage_key))
+		mock.ExpectQuery(`SELECT storage_key FROM item_attachments WHERE item_id IN \(SELECT id FROM todo_items WHERE user_id=\$1 AND is_deleted = true AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)\)`).
+			WithArgs(user_id, "").
+			WillReturnRows(mock.NewRows([]string{"storage_key"}).AddRow(storage_key).AddRow("items/other/key"))
+		mock.ExpectQuery(`SELECT storage_key FROM item_attachments WHERE item_id IN \(SELECT id FROM todo_items WHERE is_deleted = true AND deleted_at < \$1\)`).
+			WithArgs(sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows([]string{"storage_key"}))
+
+		keys, err := repo.GetItemAttachmentKeys(item_id)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{storage_key}, keys)
+
+		keys, err = repo.GetTrashAttachmentKeys(user_id)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 2)
+
+		keys, err = repo.GetExpiredAttachmentKeys(30)
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoSharing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	share_id := "1d9c7a4e-2b3f-4c5d-8e6f-7a8b9c0d1e2f"
+	colleague_id := "8c3e5f7a-9b1d-4e2f-a4c6-d8e0f2a4b6c8"
+	shareColumns := []string{"id", "item_id", "user_id", "email", "role", "status", "invited_by", "created_at", "responded_at"}
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	roleQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+
+	t.Run("Test Get item role", func(t *testing.T) {
+		mock.ExpectQuery(roleQuery).WithArgs(item_id, colleague_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("editor"))
+		mock.ExpectQuery(roleQuery).WithArgs("missing", colleague_id, "").WillReturnRows(mock.NewRows([]string{"role"}))
+
+		role, err := repo.GetItemRole(item_id, colleague_id)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.RoleEditor, role)
+
+		role, err = repo.GetItemRole("missing", colleague_id)
+		assert.NoError(t, err)
+		assert.Empty(t, role)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Find user id by email", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).
+			WithArgs("colleague@gmail.com").
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(colleague_id))
+
+		got, err := repo.FindUserIdByEmail("colleague@gmail.com")
+
+		assert.NoError(t, err)
+		assert.Equal(t, colleague_id, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Share item", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO item_shares \(id,item_id,user_id,role,status,invited_by,created_at\) VALUES .+ ON CONFLICT \(item_id, user_id\) DO UPDATE .+ RETURNING .+;`).
+			WithArgs(sqlmock.AnyArg(), item_id, colleague_id, entity.RoleViewer, user_id, sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(shareColumns).
+				AddRow(share_id, item_id, colleague_id, "colleague@gmail.com", "viewer", "pending", user_id, createdAt, nil))
+
+		got, err := repo.ShareItem(item_id, colleague_id, entity.RoleViewer, user_id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, share_id, got.Id)
+		assert.Equal(t, "colleague@gmail.com", got.Email)
+		assert.Equal(t, entity.ShareStatusPending, got.Status)
+		assert.Nil(t, got.RespondedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get item shares", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM item_shares WHERE item_id=\$1 ORDER BY created_at, id`).
+			WithArgs(item_id).
+			WillReturnRows(mock.NewRows(shareColumns).
+				AddRow(share_id, item_id, colleague_id, "colleague@gmail.com", "viewer", "accepted", user_id, createdAt, createdAt))
+
+		got, err := repo.GetItemShares(item_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, createdAt, *got[0].RespondedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Respond to a missing invitation", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE item_shares SET status=\$1, responded_at=\$2 WHERE id=\$3 AND user_id=\$4 AND status = 'pending' RETURNING .+;`).
+			WithArgs(entity.ShareStatusAccepted, sqlmock.AnyArg(), share_id, colleague_id).
+			WillReturnRows(mock.NewRows(shareColumns))
+
+		_, err := repo.RespondToShare(share_id, colleague_id, entity.ShareStatusAccepted)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get invitations", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM item_shares WHERE user_id=\$1 AND status = 'pending'`).
+			WithArgs(colleague_id).
+			WillReturnRows(mock.NewRows(shareColumns))
+
+		got, err := repo.GetInvitations(colleague_id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.Share{}, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Delete share", func(t *testing.T) {
+		mock.ExpectExec(`WITH share AS \(DELETE FROM item_shares WHERE id=\$1 .+\) UPDATE todo_items SET assignee_id = NULL .+;`).WithArgs(share_id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.DeleteShare(share_id))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get shared items", func(t *testing.T) {
+		rows := mock.NewRows(append(todoItemColumns, "role", "owner_email")).
+			AddRow(item_id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", "", "editor", "example@gmail.com")
+
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false AND id IN \(SELECT item_id FROM item_shares WHERE user_id=\$1 AND status = 'accepted'\) AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\) ORDER BY updated_at DESC LIMIT \$2`).
+			WithArgs(colleague_id, 10, "").
+			WillReturnRows(rows)
+
+		got, err := repo.GetSharedItems(10, colleague_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, item_id, got[0].Id)
+		assert.Equal(t, user_id, got[0].UserId)
+		assert.Equal(t, entity.RoleEditor, got[0].Role)
+		assert.Equal(t, "example@gmail.com", got[0].OwnerEmail)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoAssignment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	assignee_id := "8c3e5f7a-9b1d-4e2f-a4c6-d8e0f2a4b6c8"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	itemRow := func(assignee, watchers string) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 2, "", 0, assignee, watchers, "", "", false, "", "")
+	}
+
+	t.Run("Test Set item assignee", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE todo_items SET assignee_id=NULLIF\(\$1, ''\), updated_at=\$2 WHERE id=\$3 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$4, ''\) RETURNING .+;`).
+			WithArgs(assignee_id, sqlmock.AnyArg(), item_id, "").
+			WillReturnRows(itemRow(assignee_id, ""))
+
+		got, err := repo.SetItemAssignee(item_id, assignee_id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, assignee_id, got.AssigneeId)
+		assert.Nil(t, got.Watchers)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get assigned items", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false AND assignee_id=\$1 AND \(\$2 = false OR user_id=\$1\) AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$4, ''\) LIMIT \$3`).
+			WithArgs(assignee_id, false, 10, "").
+			WillReturnRows(itemRow(assignee_id, ""))
+
+		got, err := repo.GetAssignedItems(10, assignee_id, false)
+
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, user_id, got[0].UserId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Add and remove item watcher", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO item_watchers \(item_id, user_id\) VALUES \(\$1, \$2\) ON CONFLICT DO NOTHING;`).
+			WithArgs(item_id, assignee_id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\) RETURNING .+;`).
+			WillReturnRows(itemRow("", assignee_id+","+user_id))
+		mock.ExpectExec(`DELETE FROM item_watchers WHERE item_id=\$1 AND user_id=\$2;`).
+			WithArgs(item_id, assignee_id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\) RETURNING .+;`).
+			WillReturnRows(itemRow("", user_id))
+
+		got, err := repo.AddItemWatcher(item_id, assignee_id)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{assignee_id, user_id}, got.Watchers)
+
+		got, err = repo.RemoveItemWatcher(item_id, assignee_id)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{user_id}, got.Watchers)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoDependencies(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	blocker_id := "7c1f2e3d-4b5a-4c6d-8e7f-9a0b1c2d3e4f"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	dependsOnQuery := `WITH RECURSIVE blockers\(id\) AS \(.+\) SELECT EXISTS \(SELECT 1 FROM blockers WHERE id=\$2\)`
+	lockQuery := `SELECT pg_advisory_xact_lock\(hashtext\('item_dependencies:' \|\| \$1::text\)\);`
+
+	itemRow := func(id, blockedBy string, blocked bool) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 2, "", 0, "", "", "", blockedBy, blocked, "", "")
+	}
+
+	t.Run("Test Add item dependency", func(t *testing.T) {
+		t.Run("Item cannot wait on itself", func(t *testing.T) {
+			_, err := repo.AddItemDependency(item_id, item_id)
+
+			assert.ErrorIs(t, err, ErrDependencyCycle)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Cycle", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectExec(lockQuery).WithArgs("").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(dependsOnQuery).
+				WithArgs(blocker_id, item_id).
+				WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			_, err := repo.AddItemDependency(item_id, blocker_id)
+
+			assert.ErrorIs(t, err, ErrDependencyCycle)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Lock error", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectExec(lockQuery).WithArgs("").WillReturnError(errors.New("canceling statement due to lock timeout"))
+			mock.ExpectRollback()
+
+			_, err := repo.AddItemDependency(item_id, blocker_id)
+
+			assert.EqualError(t, err, "canceling statement due to lock timeout")
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Locks the dependencies of the workspace", func(t *testing.T) {
+			workspace_id := "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"
+
+			mock.ExpectBegin()
+			mock.ExpectExec(lockQuery).WithArgs(workspace_id).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(dependsOnQuery).
+				WithArgs(blocker_id, item_id).
+				WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			_, err := repo.InWorkspace(workspace_id).AddItemDependency(item_id, blocker_id)
+
+			assert.ErrorIs(t, err, ErrDependencyCycle)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectExec(lockQuery).WithArgs("").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(dependsOnQuery).
+				WithArgs(blocker_id, item_id).
+				WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(`INSERT INTO item_dependencies \(item_id, blocker_id, created_at\) VALUES \(\$1, \$2, \$3\) ON CONFLICT DO NOTHING;`).
+				WithArgs(item_id, blocker_id, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\) RETURNING .+;`).
+				WillReturnRows(itemRow(item_id, blocker_id, true))
+			mock.ExpectCommit()
+
+			got, err := repo.AddItemDependency(item_id, blocker_id)
+
+			assert.NoError(t, err)
+			assert.True(t, got.Blocked)
+			assert.Equal(t, []string{blocker_id}, got.BlockedBy)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Remove item dependency", func(t *testing.T) {
+		t.Run("Not found", func(t *testing.T) {
+			mock.ExpectExec(`DELETE FROM item_dependencies WHERE item_id=\$1 AND blocker_id=\$2;`).
+				WithArgs(item_id, blocker_id).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			_, err := repo.RemoveItemDependency(item_id, blocker_id)
+
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectExec(`DELETE FROM item_dependencies WHERE item_id=\$1 AND blocker_id=\$2;`).
+				WithArgs(item_id, blocker_id).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\) RETURNING .+;`).
+				WillReturnRows(itemRow(item_id, "", false))
+
+			got, err := repo.RemoveItemDependency(item_id, blocker_id)
+
+			assert.NoError(t, err)
+			assert.False(t, got.Blocked)
+			assert.Nil(t, got.BlockedBy)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Get item dependencies", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id IN \(SELECT blocker_id FROM item_dependencies WHERE item_id=\$1\)`).
+			WithArgs(item_id, "", user_id).
+			WillReturnRows(itemRow(blocker_id, "", false))
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id IN \(SELECT item_id FROM item_dependencies WHERE blocker_id=\$1\)`).
+			WithArgs(item_id, "", user_id).
+			WillReturnRows(mock.NewRows(todoItemColumns))
+
+		got, err := repo.GetItemDependencies(item_id, user_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, got.BlockedBy, 1)
+		assert.Equal(t, blocker_id, got.BlockedBy[0].Id)
+		assert.Equal(t, []entity.TodoItem{}, got.Blocking)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoBoard(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	itemRow := func(rank string) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 2, "", 0, "", "", "", "", false, rank, "")
+	}
+
+	t.Run("Test Set item rank", func(t *testing.T) {
+		rankQuery := `UPDATE todo_items SET rank=\$1, updated_at=\$2 WHERE id=\$3 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$5, ''\) AND \(\$4 = 0 OR version=\$4\) RETURNING .+;`
+
+		t.Run("Version mismatch", func(t *testing.T) {
+			mock.ExpectQuery(rankQuery).
+				WithArgs("8", sqlmock.AnyArg(), item_id, 1, "").
+				WillReturnRows(mock.NewRows(todoItemColumns))
+
+			_, err := repo.SetItemRank(item_id, "8", 1)
+
+			assert.ErrorIs(t, err, ErrVersionMismatch)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(rankQuery).
+				WithArgs("8", sqlmock.AnyArg(), item_id, 1, "").
+				WillReturnRows(itemRow("8"))
+
+			got, err := repo.SetItemRank(item_id, "8", 1)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "8", got.Rank)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Spread rank tie", func(t *testing.T) {
+		rankQuery := `UPDATE todo_items SET rank=\$1, updated_at=\$2 WHERE id=\$3`
+		second_id := "5c1d2e3f-6a7b-4c8d-9e0f-1a2b3c4d5e6f"
+		third_id := "7c1f2e3d-4b5a-4c6d-8e7f-9a0b1c2d3e4f"
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id FROM todo_items WHERE rank=\$3 AND is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\) AND \(\$2 <> '' OR user_id=\$1\)\s+ORDER BY created_at, id FOR UPDATE`).
+			WithArgs(user_id, "", "4").
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(item_id).AddRow(second_id).AddRow(third_id))
+		mock.ExpectQuery(`SELECT COALESCE\(MIN\(rank\), ''\) FROM todo_items WHERE rank > \$3`).
+			WithArgs(user_id, "", "4").
+			WillReturnRows(mock.NewRows([]string{"rank"}).AddRow("8"))
+		mock.ExpectQuery(rankQuery).WithArgs("6", sqlmock.AnyArg(), second_id, 0, "").WillReturnRows(itemRow("6"))
+		mock.ExpectQuery(rankQuery).WithArgs("7", sqlmock.AnyArg(), third_id, 0, "").WillReturnRows(itemRow("7"))
+		mock.ExpectCommit()
+
+		err := repo.SpreadRankTie(user_id, "4")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get board items", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\) AND \(\$2 <> '' OR user_id=\$1\) ORDER BY rank, created_at, id`).
+			WithArgs(user_id, "").
+			WillReturnRows(itemRow("8"))
+
+		got, err := repo.GetBoardItems(user_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, "8", got[0].Rank)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	filter_id := "2e7d4c1b-9a8f-4b6e-a5d3-c1b2a3f4e5d6"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	filterColumns := []string{"id", "user_id", "name", "expression", "created_at", "updated_at"}
+	dto := &entity.SavedFilterDto{Name: "Due this week", Expression: "due<=today+7d status!=done"}
+
+	t.Run("Test Create saved filter", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO saved_filters \(id,user_id,name,expression,created_at,updated_at\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$5\) RETURNING .+;`).
+			WithArgs(sqlmock.AnyArg(), user_id, dto.Name, dto.Expression, sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(filterColumns).AddRow(filter_id, user_id, dto.Name, dto.Expression, createdAt, createdAt))
+
+		got, err := repo.CreateSavedFilter(user_id, dto)
+
+		assert.NoError(t, err)
+		assert.Equal(t, filter_id, got.Id)
+		assert.Equal(t, dto.Expression, got.Expression)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get saved filters", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM saved_filters WHERE user_id=\$1 ORDER BY name, id`).
+			WithArgs(user_id).
+			WillReturnRows(mock.NewRows(filterColumns))
+
+		got, err := repo.GetSavedFilters(user_id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.SavedFilter{}, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Delete saved filter of another user", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM saved_filters WHERE id=\$1 AND user_id=\$2;`).
+			WithArgs(filter_id, user_id).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.DeleteSavedFilter(filter_id, user_id)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get filtered items", func(t *testing.T) {
+		filter, err := ParseFilter("priority=HIGH status!=done")
+		assert.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\) AND \(\$2 <> '' OR user_id=\$1\) AND \(COALESCE\(priority, ''\) = \$4 AND status <> \$5\) ORDER BY rank, created_at, id LIMIT \$3`).
+			WithArgs(user_id, "", 10, "HIGH", "done").
+			WillReturnRows(mock.NewRows(todoItemColumns).
+				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", ""))
+
+		got, err := repo.GetFilteredItems(filter, 10, user_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoRecurrence(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	mock.ExpectQuery(`UPDATE todo_items SET recurrence=NULLIF\(\$1, ''\), updated_at=\$2 WHERE id=\$3 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$4, ''\) RETURNING .+;`).
+		WithArgs("FREQ=MONTHLY", sqlmock.AnyArg(), item_id, "").
+		WillReturnRows(mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 2, "", 0, "", "", "", "", false, "", "FREQ=MONTHLY"))
+
+	got, err := repo.SetItemRecurrence(item_id, "FREQ=MONTHLY")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=MONTHLY", got.Recurrence)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApiRepoTemplates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	template_id := "9b2e4c6a-1d3f-4a5b-8c7d-0e1f2a3b4c5d"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	templateColumns := []string{"id", "user_id", "name", "items", "created_at"}
+	dto := &entity.TemplateDto{Name: "Monthly close", Items: []entity.TemplateItem{{Name: "Reconcile accounts", Priority: "HIGH", DueInDays: 2}}}
+	items := `[{"name":"Reconcile accounts","description":"","priority":"HIGH","due_in_days":2}]`
+
+	t.Run("Test Create template", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO item_templates \(id,user_id,name,items,created_at\) VALUES \(\$1,\$2,\$3,\$4,\$5\) RETURNING .+;`).
+			WithArgs(sqlmock.AnyArg(), user_id, dto.Name, []byte(items), sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(templateColumns).AddRow(template_id, user_id, dto.Name, []byte(items), createdAt))
+
+		got, err := repo.CreateTemplate(user_id, dto)
+
+		assert.NoError(t, err)
+		assert.Equal(t, template_id, got.Id)
+		assert.Equal(t, dto.Items, got.Items)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Find template of another user", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM item_templates WHERE id=\$1 AND user_id=\$2`).
+			WithArgs(template_id, user_id).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.FindTemplate(template_id, user_id)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get templates", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM item_templates WHERE user_id=\$1 ORDER BY name, id`).
+			WithArgs(user_id).
+			WillReturnRows(mock.NewRows(templateColumns).AddRow(template_id, user_id, dto.Name, []byte(items), createdAt))
+
+		got, err := repo.GetTemplates(user_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, "Reconcile accounts", got[0].Items[0].Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Delete template of another user", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM item_templates WHERE id=\$1 AND user_id=\$2;`).
+			WithArgs(template_id, user_id).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.DeleteTemplate(template_id, user_id)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Link item dependencies", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO item_dependencies \(item_id, blocker_id, created_at\) VALUES \(\$1, \$2, \$3\) ON CONFLICT DO NOTHING;`).
+			WithArgs(item_id, "blocker-1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO item_dependencies`).
+			WithArgs(item_id, "blocker-2", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\) RETURNING .+;`).
+			WithArgs(sqlmock.AnyArg(), item_id, "").
+			WillReturnRows(mock.NewRows(todoItemColumns).
+				AddRow(item_id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 2, "", 0, "", "", "", "blocker-1,blocker-2", true, "", ""))
+
+		got, err := repo.LinkItemDependencies(item_id, []string{"blocker-1", "blocker-2"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"blocker-1", "blocker-2"}, got.BlockedBy)
+		assert.True(t, got.Blocked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoImportExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	itemRow := func(rows *sqlmock.Rows, id, status string) *sqlmock.Rows {
+		return rows.AddRow(id, "Todo list item "+id, "This is item "+id, createdAt, "HIGH", createdAt, createdAt, status == entity.StatusDone, false, user_id, nil, status, nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+	}
+
+	t.Run("Test For each list item", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\) AND \(\$2 <> '' OR user_id=\$1\) ORDER BY rank, created_at, id`).
+			WithArgs(user_id, "").
+			WillReturnRows(itemRow(itemRow(mock.NewRows(todoItemColumns), "1", "todo"), "2", "done"))
+
+		var ids []string
+		err := repo.ForEachListItem(user_id, func(item entity.TodoItem) error {
+			ids = append(ids, item.Id)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "2"}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import in batches", func(t *testing.T) {
+		items := make([]entity.TodoItem, importBatchSize+1)
+		for i := range items {
+			items[i] = entity.TodoItem{
+				Item:   entity.TodoItemDto{Name: "Imported item", Details: entity.TodoItemDetailsDto{Description: "Imported", DueDate: createdAt, Priority: "LOW"}},
+				Status: entity.StatusTodo,
+			}
+		}
+		items[importBatchSize].Status = entity.StatusDone
+
+		batch := mock.NewRows(todoItemColumns)
+		for i := 0; i < importBatchSize; i++ {
+			batch = itemRow(batch, "item", "todo")
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO todo_items \(id,name,description,due_date,priority,created_at,updated_at,user_id,workspace_id,rank,status,is_completed,started_at,completed_at\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$6,\$7,NULLIF\(\$8, ''\),\$9,\$10::text,.+\),\(\$11,.+ RETURNING .+;`).
+			WillReturnRows(batch)
+		mock.ExpectExec(`INSERT INTO item_revisions \(item_id,user_id,action,snapshot,created_at\) VALUES \(\$1,\$2,\$3,\$4,\$5\),\(\$6,.+;`).
+			WillReturnResult(sqlmock.NewResult(0, int64(importBatchSize)))
+		mock.ExpectQuery(`INSERT INTO todo_items .+ VALUES \(\$1,.+\) RETURNING .+;`).
+			WithArgs(sqlmock.AnyArg(), "Imported item", "Imported", "2023-10-15T09:38:24Z", "LOW", sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg(), entity.StatusDone).
+			WillReturnRows(itemRow(mock.NewRows(todoItemColumns), "last", "done"))
+		mock.ExpectExec(`INSERT INTO item_revisions .+ VALUES \(\$1,\$2,\$3,\$4,\$5\);`).
+			WithArgs("last", user_id, entity.RevisionCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		imported, err := repo.ImportTodoItems(items, user_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, imported, importBatchSize+1)
+		assert.Equal(t, entity.StatusDone, imported[importBatchSize].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import with labels", func(t *testing.T) {
+		items := []entity.TodoItem{
+			{Item: entity.TodoItemDto{Name: "Imported item", Details: entity.TodoItemDetailsDto{DueDate: createdAt, Priority: "LOW"}}, Status: entity.StatusTodo, Labels: []string{"home", "bills"}},
+			{Item: entity.TodoItemDto{Name: "Imported item", Details: entity.TodoItemDetailsDto{DueDate: createdAt, Priority: "LOW"}}, Status: entity.StatusTodo},
+		}
+
+		id := sameArg{value: new(driver.Value)}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO todo_items .+ VALUES \(\$1,.+\),\(\$11,.+\) RETURNING .+;`).
+			WithArgs(id, "Imported item", "", "2023-10-15T09:38:24Z", "LOW", sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg(), entity.StatusTodo,
+				sqlmock.AnyArg(), "Imported item", "", "2023-10-15T09:38:24Z", "LOW", sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg(), entity.StatusTodo).
+			WillReturnRows(itemRow(itemRow(mock.NewRows(todoItemColumns), "1", "todo"), "2", "todo"))
+		mock.ExpectExec(`INSERT INTO item_labels \(item_id, label\) VALUES \(\$1,\$2\),\(\$3,\$4\) ON CONFLICT DO NOTHING;`).
+			WithArgs(id, "home", id, "bills").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`INSERT INTO item_revisions`).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		imported, err := repo.ImportTodoItems(items, user_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, imported, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import keeps the ids of the items", func(t *testing.T) {
+		items := []entity.TodoItem{
+			{Id: "1", Item: entity.TodoItemDto{Name: "Imported item", Details: entity.TodoItemDetailsDto{DueDate: createdAt, Priority: "LOW"}}, Status: entity.StatusTodo},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO todo_items .+ VALUES \(\$1,.+\) RETURNING .+;`).
+			WithArgs("1", "Imported item", "", "2023-10-15T09:38:24Z", "LOW", sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg(), entity.StatusTodo).
+			WillReturnRows(itemRow(mock.NewRows(todoItemColumns), "1", "todo"))
+		mock.ExpectExec(`INSERT INTO item_revisions`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		imported, err := repo.ImportTodoItems(items, user_id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1", imported[0].Id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Find item sources", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT item_id, source_id FROM item_sources WHERE source=\$1 AND source_id = ANY\(\$2\)\s+AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$4, ''\) AND \(\$4 <> '' OR user_id=\$3\)`).
+			WithArgs("todoist", sqlmock.AnyArg(), user_id, "").
+			WillReturnRows(mock.NewRows([]string{"item_id", "source_id"}).AddRow("1", "6Jf8VQXxpwv56VQ7").AddRow("2", "2995104339"))
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id = ANY\(\$1\)`).
+			WillReturnRows(itemRow(itemRow(mock.NewRows(todoItemColumns), "1", "todo"), "2", "done"))
+
+		items, err := repo.FindItemSources("todoist", []string{"6Jf8VQXxpwv56VQ7", "2995104339", "2995104340"}, user_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, items, 2)
+		assert.Equal(t, "1", items["6Jf8VQXxpwv56VQ7"].Id)
+		assert.Equal(t, entity.StatusDone, items["2995104339"].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Find item sources of tasks not imported", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT item_id, source_id FROM item_sources`).
+			WithArgs("mstodo", sqlmock.AnyArg(), user_id, "").
+			WillReturnRows(mock.NewRows([]string{"item_id", "source_id"}))
+
+		items, err := repo.FindItemSources("mstodo", []string{"AlMKXwbQAAAJws6wcAAAA="}, user_id)
+
+		assert.NoError(t, err)
+		assert.Empty(t, items)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Create item sources", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO item_sources \(item_id,user_id,workspace_id,source,source_id,imported_at\) VALUES \(\$1,\$2,NULLIF\(\$3, ''\),\$4,\$5,\$6\),\(\$7,.+\);`).
+			WithArgs("1", user_id, "", "todoist", "6Jf8VQXxpwv56VQ7", sqlmock.AnyArg(), "2", user_id, "", "todoist", "2995104339", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err := repo.CreateItemSources("todoist", map[string]string{"2": "2995104339", "1": "6Jf8VQXxpwv56VQ7"}, user_id)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// Matches the first value it is compared with and from then on only that value, like the generated id of an
+// item that is used again by a later statement
+type sameArg struct {
+	value *driver.Value
+}
+
+func (a sameArg) Match(v driver.Value) bool {
+	if *a.value == nil {
+		*a.value = v
+	}
+
+	return *a.value == v
+}
+
+func TestApiRepoCalendar(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	workspace_id := "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"
+	feed_id := "4d8e2f1a-6b3c-4a5d-9e7f-0a1b2c3d4e5f"
+	token_hash := calendarTokenHash("secret")
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	feedColumns := []string{"id", "user_id", "workspace_id", "created_at"}
+
+	t.Run("Test Create calendar feed of a workspace", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO calendar_feeds \(id,user_id,workspace_id,token_hash,created_at\) VALUES \(\$1,\$2,NULLIF\(\$3, ''\),\$4,\$5\)\s+ON CONFLICT .+ DO UPDATE SET token_hash=EXCLUDED.token_hash`).
+			WithArgs(sqlmock.AnyArg(), user_id, workspace_id, token_hash, sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(feedColumns).AddRow(feed_id, user_id, workspace_id, createdAt))
+
+		feed, err := repo.InWorkspace(workspace_id).CreateCalendarFeed(user_id, token_hash)
+
+		assert.NoError(t, err)
+		assert.Equal(t, feed_id, feed.Id)
+		assert.Equal(t, workspace_id, feed.WorkspaceId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Find calendar feed of an unknown token", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM calendar_feeds WHERE token_hash=\$1`).
+			WithArgs(token_hash).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.FindCalendarFeed(token_hash)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Delete missing calendar feed", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM calendar_feeds WHERE user_id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\);`).
+			WithArgs(user_id, "").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.DeleteCalendarFeed(user_id)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoCalDAV(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	workspace_id := "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	t.Run("Test Create item with the id of the client", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO todo_items \(id,name,description,due_date,priority,created_at,updated_at,user_id,workspace_id,rank\)`).
+			WithArgs(item_id, "Pay rent", "", sqlmock.AnyArg(), "HIGH", sqlmock.AnyArg(), sqlmock.AnyArg(), user_id, workspace_id, sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(todoItemColumns).
+				AddRow(item_id, "Pay rent", "", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", workspace_id, "", false, "", ""))
+
+		item, err := repo.InWorkspace(workspace_id).CreateTodoItemWithId(item_id, &entity.TodoItemDto{Name: "Pay rent", Details: entity.TodoItemDetailsDto{DueDate: createdAt, Priority: "HIGH"}}, user_id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, item_id, item.Id)
+		assert.Equal(t, workspace_id, item.WorkspaceId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Find items of the list by id", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\) AND \(\$2 <> '' OR user_id=\$1\) AND id = ANY\(\$3\)`).
+			WillReturnRows(mock.NewRows(todoItemColumns).
+				AddRow(item_id, "Pay rent", "", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", ""))
+
+		items, err := repo.FindListItems([]string{item_id, "missing"}, user_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, "Pay rent", items[item_id].Item.Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Change tag of the list", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COUNT\(\*\), COALESCE\(SUM\(version\), 0\), MAX\(updated_at\) FROM todo_items`).
+			WithArgs(user_id, workspace_id).
+			WillReturnRows(mock.NewRows([]string{"count", "sum", "max"}).AddRow(2, 5, createdAt))
+
+		tag, err := repo.InWorkspace(workspace_id).GetListChangeTag(user_id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2-5-1697362704", tag)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Change tag of an empty list", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COUNT\(\*\), COALESCE\(SUM\(version\), 0\), MAX\(updated_at\) FROM todo_items`).
+			WithArgs(user_id, "").
+			WillReturnRows(mock.NewRows([]string{"count", "sum", "max"}).AddRow(0, 0, nil))
+
+		tag, err := repo.GetListChangeTag(user_id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0-0-0", tag)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoWebhooks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	workspace_id := "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	webhook_id := "9b2e4c6a-8d1f-4a3b-9c5e-7f0a2b4c6d8e"
+	delivery_id := "1c3e5a7b-9d2f-4b6a-8c0e-2d4f6a8b0c1e"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	webhookColumns := []string{"id", "user_id", "workspace_id", "url", "secret", "events", "created_at"}
+	deliveryColumns := []string{"id", "webhook_id", "event", "status", "attempts", "next_attempt_at", "last_status_code", "last_error", "created_at", "delivered_at", "payload"}
+
+	t.Run("Test Create webhook of a workspace", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO webhooks \(id,user_id,workspace_id,url,secret,events,created_at\) VALUES \(\$1,\$2,NULLIF\(\$3, ''\),\$4,\$5,\$6,\$7\)`).
+			WithArgs(sqlmock.AnyArg(), user_id, workspace_id, "https://ci.example.com/hooks/todo", "4f9d2c7a1e8b3f6d", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(webhookColumns).
+				AddRow(webhook_id, user_id, workspace_id, "https://ci.example.com/hooks/todo", "4f9d2c7a1e8b3f6d", "{item.created,item.completed}", createdAt))
+
+		webhook, err := repo.InWorkspace(workspace_id).CreateWebhook("https://ci.example.com/hooks/todo", "4f9d2c7a1e8b3f6d", []string{"item.created", "item.completed"}, user_id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, webhook_id, webhook.Id)
+		assert.Equal(t, workspace_id, webhook.WorkspaceId)
+		assert.Equal(t, []string{"item.created", "item.completed"}, webhook.Events)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Delete missing webhook", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM webhooks WHERE id=\$1 AND user_id=\$2;`).
+			WithArgs(webhook_id, user_id).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.DeleteWebhook(webhook_id, user_id)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Enqueue deliveries of an event", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM webhooks WHERE \$1 = ANY\(events\)`).
+			WithArgs("item.completed", user_id, "").
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(webhook_id).AddRow("other-webhook"))
+		mock.ExpectQuery(`INSERT INTO webhook_deliveries \(id,webhook_id,event,payload,next_attempt_at,created_at\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$5\),\(\$6,\$7,\$8,\$9,\$10,\$10\)`).
+			WithArgs(sqlmock.AnyArg(), webhook_id, "item.completed", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "other-webhook", "item.completed", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(deliveryColumns).
+				AddRow(delivery_id, webhook_id, "item.completed", "pending", 0, createdAt, 0, "", createdAt, nil, `{"event":"item.completed"}`).
+				AddRow("other-delivery", "other-webhook", "item.completed", "pending", 0, createdAt, 0, "", createdAt, nil, `{"event":"item.completed"}`))
+
+		item := entity.TodoItem{Id: item_id, UserId: user_id, Status: entity.StatusDone}
+		deliveries, err := repo.EnqueueWebhookDeliveries("item.completed", user_id, item)
+
+		assert.NoError(t, err)
+		assert.Len(t, deliveries, 2)
+		assert.Equal(t, &createdAt, deliveries[0].NextAttemptAt)
+		assert.JSONEq(t, `{"event":"item.completed"}`, string(deliveries[0].Payload))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Enqueue event without webhooks", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM webhooks WHERE \$1 = ANY\(events\)`).
+			WithArgs("item.created", user_id, workspace_id).
+			WillReturnRows(mock.NewRows([]string{"id"}))
+
+		item := entity.TodoItem{Id: item_id, UserId: user_id, WorkspaceId: workspace_id}
+		deliveries, err := repo.EnqueueWebhookDeliveries("item.created", user_id, item)
+
+		assert.NoError(t, err)
+		assert.Empty(t, deliveries)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Claim due deliveries", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE webhook_deliveries SET next_attempt_at=\$2 FROM webhooks .+ FOR UPDATE SKIP LOCKED`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 50).
+			WillReturnRows(mock.NewRows([]string{"id", "webhook_id", "event", "attempts", "payload", "url", "secret"}).
+				AddRow(delivery_id, webhook_id, "item.completed", 2, `{"event":"item.completed"}`, "https://ci.example.com/hooks/todo", "4f9d2c7a1e8b3f6d"))
+
+		jobs, err := repo.ClaimWebhookDeliveries(50, time.Minute)
+
+		assert.NoError(t, err)
+		assert.Len(t, jobs, 1)
+		assert.Equal(t, 2, jobs[0].Delivery.Attempts)
+		assert.Equal(t, "https://ci.example.com/hooks/todo", jobs[0].Url)
+		assert.Equal(t, "4f9d2c7a1e8b3f6d", jobs[0].Secret)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Record failed attempt", func(t *testing.T) {
+		attempt := entity.WebhookAttempt{AttemptedAt: createdAt, StatusCode: 502, Error: "Webhook was rejected with status 502", DurationMs: 120}
+		next := createdAt.Add(time.Minute)
+
+		mock.ExpectExec(`WITH attempt AS \(INSERT INTO webhook_attempts .+\) UPDATE webhook_deliveries SET attempts=attempts\+1`).
+			WithArgs(delivery_id, createdAt, 502, "Webhook was rejected with status 502", int64(120), entity.WebhookDeliveryPending, &next).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.RecordWebhookAttempt(delivery_id, attempt, entity.WebhookDeliveryPending, &next)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Find delivery with its log", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM webhook_deliveries WHERE id=\$1 AND webhook_id=\$2`).
+			WithArgs(delivery_id, webhook_id, user_id).
+			WillReturnRows(mock.NewRows(deliveryColumns).
+				AddRow(delivery_id, webhook_id, "item.completed", "dead", 2, createdAt, 502, "Webhook was rejected with status 502", createdAt, nil, `{"event":"item.completed"}`))
+		mock.ExpectQuery(`SELECT attempted_at,status_code,error,duration_ms FROM webhook_attempts WHERE delivery_id=\$1`).
+			WithArgs(delivery_id).
+			WillReturnRows(mock.NewRows([]string{"attempted_at", "status_code", "error", "duration_ms"}).
+				AddRow(createdAt, 0, "connection refused", 3).
+				AddRow(createdAt.Add(time.Minute), 502, "Webhook was rejected with status 502", 120))
+
+		delivery, err := repo.FindWebhookDelivery(delivery_id, webhook_id, user_id)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.WebhookDeliveryDead, delivery.Status)
+		assert.Nil(t, delivery.NextAttemptAt)
+		assert.Len(t, delivery.Log, 2)
+		assert.Equal(t, "connection refused", delivery.Log[0].Error)
+		assert.Equal(t, 502, delivery.Log[1].StatusCode)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Redeliver missing delivery", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE webhook_deliveries SET status='pending', attempts=0`).
+			WithArgs(delivery_id, webhook_id, user_id, sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.RedeliverWebhookDelivery(delivery_id, webhook_id, user_id)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoItemEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	eventColumns := []string{"id", "item_id", "event", "actor_id", "payload", "created_at"}
+
+	t.Run("Test Create event and notify the instances", func(t *testing.T) {
+		item := entity.TodoItem{Id: item_id, UserId: user_id, AssigneeId: "assignee", Watchers: []string{user_id}}
+
+		mock.ExpectQuery(`INSERT INTO item_events \(item_id,workspace_id,recipients,event,actor_id,payload,created_at\)`).
+			WithArgs(item_id, "", sqlmock.AnyArg(), "item.updated", "assignee", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows([]string{"id", "recipients"}).AddRow(42, "{"+user_id+",assignee,shared}"))
+		mock.ExpectExec(`SELECT pg_notify\(\$1, \$2\);`).
+			WithArgs(ItemEventsChannel, `{"id":42,"recipients":["`+user_id+`","assignee","shared"]}`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		notice, err := repo.CreateItemEvent("item.updated", "assignee", item)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), notice.Id)
+		assert.Equal(t, []string{user_id, "assignee", "shared"}, notice.Recipients)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get events after an id", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id,item_id,event,actor_id,payload,created_at FROM item_events WHERE id > \$2`).
+			WithArgs(user_id, int64(41), 100).
+			WillReturnRows(mock.NewRows(eventColumns).
+				AddRow(42, item_id, "item.completed", user_id, `{"id":"`+item_id+`","status":"done"}`, createdAt))
+
+		events, err := repo.GetItemEvents(user_id, 41, 100)
+
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, int64(42), events[0].Id)
+		assert.Equal(t, entity.StatusDone, events[0].Item.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Bounds of an empty log", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COALESCE\(MIN\(id\), 0\), COALESCE\(MAX\(id\), 0\) FROM item_events`).
+			WillReturnRows(mock.NewRows([]string{"min", "max"}).AddRow(0, 0))
+
+		first, last, err := repo.GetItemEventBounds()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), first)
+		assert.Equal(t, int64(0), last)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Purge expired events", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM item_events WHERE created_at < \$1;`).
+			WithArgs(createdAt).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		purged, err := repo.PurgeItemEvents(createdAt)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), purged)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoItemChanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	purged_id := "b6c3bb51-8a62-4c37-9e4f-55b0f4f0a0a1"
+	dueDate := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	t.Run("Test Get items and tombstones after a change", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\('todo_items_change_seq'\)\);`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT .+,change_seq FROM todo_items WHERE change_seq > \$3 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\) AND \(\$2 <> '' OR user_id=\$1\) ORDER BY change_seq LIMIT \$4`).
+			WithArgs(user_id, "", int64(40), 501).
+			WillReturnRows(mock.NewRows(append(todoItemColumns, "change_seq")).
+				AddRow(item_id, "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, false, false, user_id, nil, "todo", nil, nil, 3, "finance", 0, "", "", "", "", false, "", "", 41))
+		mock.ExpectQuery(`SELECT item_id,change_seq,deleted_at FROM item_tombstones WHERE change_seq > \$3`).
+			WithArgs(user_id, "", int64(40), 501).
+			WillReturnRows(mock.NewRows([]string{"item_id", "change_seq", "deleted_at"}).AddRow(purged_id, 42, dueDate))
+		mock.ExpectCommit()
+
+		changes, tombstones, err := repo.GetItemChanges(user_id, 40, 501)
+
+		assert.NoError(t, err)
+		assert.Len(t, changes, 1)
+		assert.Equal(t, int64(41), changes[0].ChangeSeq)
+		assert.Equal(t, item_id, changes[0].Item.Id)
+		assert.Equal(t, []string{"finance"}, changes[0].Item.Labels)
+		assert.Equal(t, []entity.ItemTombstone{{Id: purged_id, ChangeSeq: 42, DeletedAt: dueDate, Purged: true}}, tombstones)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoGraphQL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	other_id := "8c3e5f7a-9b1d-4e2f-a4c6-d8e0f2a4b6c8"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	dueDate := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	t.Run("Test Get a page of the items selected by a filter", func(t *testing.T) {
+		filter, err := ParseFilter("priority = high")
+		assert.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE .+ AND COALESCE\(priority, ''\) = \$5 ORDER BY rank, created_at, id LIMIT \$3 OFFSET \$4`).
+			WithArgs(user_id, "", 21, 40, "HIGH").
+			WillReturnRows(mock.NewRows(todoItemColumns).
+				AddRow(item_id, "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", ""))
+
+		items, err := repo.GetItemsPage(filter, 21, 40, user_id)
+
+		assert.NoError(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, item_id, items[0].Id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get a page of all the items", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE .+ AND true ORDER BY rank, created_at, id LIMIT \$3 OFFSET \$4`).
+			WithArgs(user_id, "", 21, 0).
+			WillReturnRows(mock.NewRows(todoItemColumns))
+
+		items, err := repo.GetItemsPage(nil, 21, 0, user_id)
+
+		assert.NoError(t, err)
+		assert.Empty(t, items)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get the comments of items", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM item_comments WHERE item_id = ANY\(\$1\) ORDER BY created_at, id`).
+			WillReturnRows(mock.NewRows([]string{"id", "item_id", "parent_id", "user_id", "body", "created_at", "edited_at", "is_deleted"}).
+				AddRow("c1", item_id, nil, user_id, "First", dueDate, nil, false).
+				AddRow("c2", item_id, "c1", other_id, "Reply", dueDate, nil, false))
+
+		comments, err := repo.GetCommentsOfItems([]string{item_id, "other"})
+
+		assert.NoError(t, err)
+		assert.Len(t, comments[item_id], 2)
+		assert.Equal(t, "c1", comments[item_id][1].ParentId)
+		assert.NotContains(t, comments, "other")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Find users by ids", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id,email FROM users WHERE id = ANY\(\$1\)`).
+			WillReturnRows(mock.NewRows([]string{"id", "email"}).AddRow(user_id, "example@gmail.com"))
+
+		users, err := repo.FindUsersByIds([]string{user_id, other_id})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]entity.User{user_id: {Id: user_id, Email: "example@gmail.com"}}, users)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiRepoWorkspaces(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := ApiRepository{
+		DB: db,
+	}
+
+	workspace_id := "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	lockQuery := `SELECT COUNT\(\*\), COALESCE\(BOOL_OR\(user_id=\$2\), false\) FROM \(SELECT user_id FROM workspace_members\s+WHERE workspace_id=\$1 AND role = 'owner' AND status = 'accepted' ORDER BY id FOR UPDATE\) AS owners`
+	deleteQuery := `WITH member AS \(DELETE FROM workspace_members WHERE workspace_id=\$1 AND user_id=\$2 .+`
+
+	t.Run("Test Delete workspace member", func(t *testing.T) {
+		t.Run("Owner with another owner", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lockQuery).WithArgs(workspace_id, user_id).
+				WillReturnRows(mock.NewRows([]string{"count", "bool_or"}).AddRow(2, true))
+			mock.ExpectExec(deleteQuery).WithArgs(workspace_id, user_id).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			err := repo.DeleteWorkspaceMember(workspace_id, user_id)
+
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Last owner", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lockQuery).WithArgs(workspace_id, user_id).
+				WillReturnRows(mock.NewRows([]string{"count", "bool_or"}).AddRow(1, true))
+			mock.ExpectRollback()
+
+			err := repo.DeleteWorkspaceMember(workspace_id, user_id)
+
+			assert.ErrorIs(t, err, ErrLastWorkspaceOwner)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Member of a workspace with one owner", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(lockQuery).WithArgs(workspace_id, user_id).
+				WillReturnRows(mock.NewRows([]string{"count", "bool_or"}).AddRow(1, false))
+			mock.ExpectExec(deleteQuery).WithArgs(workspace_id, user_id).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			err := repo.DeleteWorkspaceMember(workspace_id, user_id)
+
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
@@ -1,8 +1,16 @@
 package api
 
 import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/golang-jwt/jwt/v5"
@@ -11,12 +19,17 @@ import (
 	"todo-project/authentication"
 	"todo-project/constants"
 	"todo-project/entity"
+	"todo-project/notification"
+	"todo-project/storage"
 )
 
 type Service interface {
 	//Create an item
 	CreateTodoItem(item *entity.TodoItemDto, userId string) (todoItem entity.TodoItem, err error)
 
+	//Create an item with an id chosen by the client
+	CreateTodoItemWithId(id string, item *entity.TodoItemDto, userId string) (todoItem entity.TodoItem, err error)
+
 	//Find a todo item by id
 	FindItemById(id string) (todoItem entity.TodoItem, err error)
 
@@ -24,17 +37,341 @@ type Service interface {
 	GetTodoListItems(limit int, userId string) (todoItemList []entity.TodoItem, err error)
 
 	//Update an todo list item
-	UpdateTodoItem(item *entity.TodoItemDetailsDto, id string) (todoItem entity.TodoItem, err error)
+	UpdateTodoItem(item *entity.TodoItemDetailsDto, id string, version int) (todoItem entity.TodoItem, err error)
 
 	//Delete an todo Item
-	DeleteTodoItem(id string) error
+	DeleteTodoItem(id string, version int) error
 
 	//Update status to Complete by Id
-	SetItemStatusToComplete(id string) (todoItem entity.TodoItem, err error)
+	SetItemStatusToComplete(id string, version int) (todoItem entity.TodoItem, err error)
+
+	//Update only the changed columns of a todo item
+	PatchTodoItem(id string, changes map[string]interface{}, version int) (todoItem entity.TodoItem, err error)
+
+	//Find the todo items the user has a role on by ids, with the role of the user on each of them
+	FindItemsOfUser(ids []string, userId string) (todoItems map[string]entity.TodoItem, roles map[string]string, err error)
+
+	//Add and remove labels of a todo item
+	SetItemLabels(id string, add, remove []string) (todoItem entity.TodoItem, err error)
+
+	//Change the status of a todo item
+	SetItemStatus(id, status string, version int) (todoItem entity.TodoItem, err error)
+
+	//Get the deleted todo items
+	GetTrashItems(limit int, userId string) (todoItemList []entity.TodoItem, err error)
+
+	//Restore a deleted todo item
+	RestoreTodoItem(id string) (todoItem entity.TodoItem, err error)
+
+	//Permanently delete a todo item in trash
+	PurgeTodoItem(id string) error
+
+	//Permanently delete all the todo items in trash
+	EmptyTrash(userId string) (int64, error)
+
+	//Record a change of a todo item
+	CreateItemRevision(item entity.TodoItem, userId, action string) error
+
+	//Get the revisions of a todo item, oldest first
+	GetItemRevisions(itemId string) (revisions []entity.ItemRevision, err error)
+
+	//Find a revision of a todo item
+	FindItemRevision(itemId string, revisionId int64) (revision entity.ItemRevision, err error)
+
+	//Add a comment to a todo item
+	CreateComment(itemId, userId string, comment *entity.CommentDto) (entity.Comment, error)
+
+	//Get all the comments of a todo item, including the deleted ones
+	GetItemComments(itemId string) (comments []entity.Comment, err error)
+
+	//Find a comment of a todo item
+	FindCommentById(itemId, commentId string) (comment entity.Comment, err error)
+
+	//Edit the body of a comment
+	UpdateComment(commentId, body string) (comment entity.Comment, err error)
+
+	//Soft delete a comment
+	DeleteComment(commentId string) error
+
+	//Store the metadata of an attachment when the attachments of its user fit in the quota
+	CreateAttachment(attachment entity.Attachment, quota int64) error
+
+	//Get the attachments of a todo item
+	GetItemAttachments(itemId string) (attachments []entity.Attachment, err error)
+
+	//Find an attachment of a todo item
+	FindAttachmentById(itemId, attachmentId string) (attachment entity.Attachment, err error)
+
+	//Total size of the attachments uploaded by the user
+	GetStorageUsage(userId string) (int64, error)
+
+	//Delete the metadata of an attachment
+	DeleteAttachment(attachmentId string) error
+
+	//Storage keys of the attachments of a todo item
+	GetItemAttachmentKeys(itemId string) (keys []string, err error)
+
+	//Storage keys of the attachments of the todo items in trash of the user
+	GetTrashAttachmentKeys(userId string) (keys []string, err error)
+
+	//Storage keys of the attachments of the todo items in trash for longer than the retention days
+	GetExpiredAttachmentKeys(retentionDays int) (keys []string, err error)
+
+	//Role of the user on a todo item, empty when the user has no access to it
+	GetItemRole(id, userId string) (string, error)
+
+	//Find the id of a registered user by email
+	FindUserIdByEmail(email string) (string, error)
+
+	//Share a todo item with a user, the share is pending until the user accepts it
+	ShareItem(itemId, userId, role, invitedBy string) (share entity.Share, err error)
+
+	//Get the shares of a todo item
+	GetItemShares(itemId string) (shares []entity.Share, err error)
+
+	//Find a share of a todo item
+	FindShareById(itemId, shareId string) (share entity.Share, err error)
+
+	//Revoke a share
+	DeleteShare(shareId string) error
+
+	//Get the pending invitations of the user
+	GetInvitations(userId string) (shares []entity.Share, err error)
+
+	//Accept or decline a pending invitation of the user
+	RespondToShare(shareId, userId, status string) (share entity.Share, err error)
+
+	//Get the todo items shared with the user
+	GetSharedItems(limit int, userId string) (items []entity.SharedItem, err error)
+
+	//Get the todo items assigned to the user, only the ones the user created when createdByMe is set
+	GetAssignedItems(limit int, userId string, createdByMe bool) (todoItemList []entity.TodoItem, err error)
+
+	//Assign a todo item to a user, an empty assignee unassigns it
+	SetItemAssignee(id, assigneeId string) (todoItem entity.TodoItem, err error)
+
+	//Add the user to the watchers of a todo item
+	AddItemWatcher(id, userId string) (todoItem entity.TodoItem, err error)
+
+	//Remove the user from the watchers of a todo item
+	RemoveItemWatcher(id, userId string) (todoItem entity.TodoItem, err error)
+
+	//Set the recurrence rule of a todo item, an empty rule stops it from recurring
+	SetItemRecurrence(id, rule string) (todoItem entity.TodoItem, err error)
+
+	//Change the rank of a todo item within its list
+	SetItemRank(id, rank string, version int) (todoItem entity.TodoItem, err error)
+
+	//Give the items of the list sharing the rank distinct ranks, keeping their order
+	SpreadRankTie(userId, rank string) error
+
+	//Get all the todo items of the list by rank
+	GetBoardItems(userId string) (todoItemList []entity.TodoItem, err error)
+
+	//Make a todo item wait on another one, failing when the link would form a cycle
+	AddItemDependency(id, blockerId string) (todoItem entity.TodoItem, err error)
+
+	//Remove the link of a todo item to one of its blockers
+	RemoveItemDependency(id, blockerId string) (todoItem entity.TodoItem, err error)
+
+	//Get the items a todo item waits on and the items waiting on it
+	GetItemDependencies(id, userId string) (dependencies entity.ItemDependencies, err error)
+
+	//Save a named filter of the user
+	CreateSavedFilter(userId string, filter *entity.SavedFilterDto) (savedFilter entity.SavedFilter, err error)
+
+	//Get the saved filters of the user by name
+	GetSavedFilters(userId string) (savedFilters []entity.SavedFilter, err error)
+
+	//Find a saved filter of the user
+	FindSavedFilter(id, userId string) (savedFilter entity.SavedFilter, err error)
+
+	//Rename or change the expression of a saved filter of the user
+	UpdateSavedFilter(id, userId string, filter *entity.SavedFilterDto) (savedFilter entity.SavedFilter, err error)
+
+	//Delete a saved filter of the user
+	DeleteSavedFilter(id, userId string) error
+
+	//Get the todo items of the list selected by a filter, by rank
+	GetFilteredItems(filter *Filter, limit int, userId string) (todoItemList []entity.TodoItem, err error)
+
+	//Make a todo item wait on other items, without checking for cycles
+	LinkItemDependencies(id string, blockerIds []string) (todoItem entity.TodoItem, err error)
+
+	//Save a template of the user
+	CreateTemplate(userId string, template *entity.TemplateDto) (savedTemplate entity.Template, err error)
+
+	//Get the templates of the user by name
+	GetTemplates(userId string) (templates []entity.Template, err error)
+
+	//Find a template of the user
+	FindTemplate(id, userId string) (template entity.Template, err error)
+
+	//Delete a template of the user
+	DeleteTemplate(id, userId string) error
+
+	//Call fn with each todo item of the list by rank
+	ForEachListItem(userId string, fn func(item entity.TodoItem) error) error
+
+	//Find the todo items of the list by ids, other ids are left out
+	FindListItems(ids []string, userId string) (todoItems map[string]entity.TodoItem, err error)
+
+	//Tag changing whenever an item of the list is added, removed or modified
+	GetListChangeTag(userId string) (string, error)
+
+	//Insert imported todo items in batches inside one transaction
+	ImportTodoItems(items []entity.TodoItem, userId string) (imported []entity.TodoItem, err error)
+
+	//Find the items imported into the list from tasks of an app by the ids of the tasks
+	FindItemSources(source string, sourceIds []string, userId string) (todoItems map[string]entity.TodoItem, err error)
+
+	//Save the ids of the tasks of an app the items were imported from
+	CreateItemSources(source string, sourceIds map[string]string, userId string) error
+
+	//Save a webhook of the personal list of the user or of the workspace
+	CreateWebhook(url, secret string, events []string, userId string) (entity.Webhook, error)
+
+	//Get the webhooks of the user in all of their lists
+	GetWebhooks(userId string) (webhooks []entity.Webhook, err error)
+
+	//Find a webhook of the user
+	FindWebhook(id, userId string) (webhook entity.Webhook, err error)
+
+	//Delete a webhook of the user with its deliveries
+	DeleteWebhook(id, userId string) error
+
+	//Queue a delivery of an event to each webhook
+	CreateWebhookDeliveries(webhookIds []string, event, actorId string, item *entity.TodoItem) (deliveries []entity.WebhookDelivery, err error)
+
+	//Queue a delivery of an event of an item to the subscribed webhooks of its list
+	EnqueueWebhookDeliveries(event, actorId string, item entity.TodoItem) (deliveries []entity.WebhookDelivery, err error)
+
+	//Claim the pending deliveries that are due
+	ClaimWebhookDeliveries(limit int, lease time.Duration) (jobs []entity.WebhookJob, err error)
+
+	//Log an attempt to send a delivery and set its status
+	RecordWebhookAttempt(deliveryId string, attempt entity.WebhookAttempt, status string, nextAttemptAt *time.Time) error
+
+	//Get the latest deliveries of the webhooks of the user
+	GetWebhookDeliveries(userId, webhookId, status string, limit int) (deliveries []entity.WebhookDelivery, err error)
+
+	//Find a delivery of a webhook of the user with the log of its attempts
+	FindWebhookDelivery(id, webhookId, userId string) (delivery entity.WebhookDelivery, err error)
+
+	//Queue a delivery of a webhook of the user again
+	RedeliverWebhookDelivery(id, webhookId, userId string) (delivery entity.WebhookDelivery, err error)
+
+	//Log an event of an item and notify the streams of every instance
+	CreateItemEvent(event, actorId string, item entity.TodoItem) (notice entity.ItemEventNotice, err error)
+
+	//Get the events after the id the user gets
+	GetItemEvents(userId string, afterId int64, limit int) (events []entity.ItemEvent, err error)
+
+	//Get the ids of the oldest and the latest events in the log
+	GetItemEventBounds() (first, last int64, err error)
+
+	//Purge the events logged before the time
+	PurgeItemEvents(createdBefore time.Time) (int64, error)
+
+	//Get the items and tombstones of the list changed after a number of the change sequence, in its order
+	GetItemChanges(userId string, after int64, limit int) (changes []entity.ItemChange, tombstones []entity.ItemTombstone, err error)
+
+	//Get a page of the todo items of the list by rank, only the ones selected by the filter when there is one
+	GetItemsPage(filter *Filter, limit, offset int, userId string) (todoItemList []entity.TodoItem, err error)
+
+	//Get the comments of todo items by the id of their item, oldest first
+	GetCommentsOfItems(itemIds []string) (comments map[string][]entity.Comment, err error)
+
+	//Find registered users by ids, other ids are left out
+	FindUsersByIds(ids []string) (users map[string]entity.User, err error)
+
+	//Save the hash of the calendar feed token of the list, replacing the previous token
+	CreateCalendarFeed(userId, tokenHash string) (feed entity.CalendarFeed, err error)
+
+	//Find the calendar feed of a token hash
+	FindCalendarFeed(tokenHash string) (feed entity.CalendarFeed, err error)
+
+	//Delete the calendar feed of the list
+	DeleteCalendarFeed(userId string) error
+
+	//Role of the user in a workspace, empty when the user is not a member of it
+	GetWorkspaceRole(workspaceId, userId string) (string, error)
+
+	//Create a workspace owned by the user
+	CreateWorkspace(name, userId string) (workspace entity.Workspace, err error)
+
+	//Get the workspaces the user is a member of
+	GetWorkspacesOfUser(userId string) (workspaces []entity.Workspace, err error)
+
+	//Get the members of a workspace, including the pending invitations
+	GetWorkspaceMembers(workspaceId string) (members []entity.WorkspaceMember, err error)
+
+	//Find the membership of a user in a workspace
+	FindWorkspaceMember(workspaceId, userId string) (member entity.WorkspaceMember, err error)
+
+	//Invite a user to a workspace, the membership is pending until the user accepts it
+	InviteWorkspaceMember(workspaceId, userId, role, invitedBy string) (member entity.WorkspaceMember, err error)
+
+	//Remove a user from a workspace, the last owner of the workspace cannot be removed
+	DeleteWorkspaceMember(workspaceId, userId string) error
+
+	//Get the pending workspace invitations of the user
+	GetWorkspaceInvitations(userId string) (members []entity.WorkspaceMember, err error)
+
+	//Accept or decline a pending workspace invitation of the user
+	RespondToWorkspaceInvitation(memberId, userId, status string) (member entity.WorkspaceMember, err error)
 }
 
 type ApiService struct {
-	R ApiRepository
+	R              ApiRepository
+	Transitions    StatusTransitions
+	RequireIfMatch bool
+	Blobs          storage.BlobStore
+	Attachments    AttachmentLimits
+	Notifier       notification.Notifier
+	Webhooks       *WebhookSettings
+	Events         *EventHub
+}
+
+// Allowed status transitions, the defaults are used when none are configured
+func (as ApiService) statusTransitions() StatusTransitions {
+	if as.Transitions == nil {
+		return DefaultStatusTransitions
+	}
+
+	return as.Transitions
+}
+
+// Expected version of the item from the If-Match header, 0 means any version
+func (as ApiService) ifMatchVersion(c echo.Context) (int, error) {
+	header := c.Request().Header.Get(HeaderIfMatch)
+	if header == "" && as.RequireIfMatch {
+		return 0, ErrIfMatchRequired
+	}
+
+	return parseIfMatch(header)
+}
+
+// Responds to a missing or malformed If-Match header
+func ifMatchError(c echo.Context, err error) error {
+	if errors.Is(err, ErrIfMatchRequired) {
+		return c.String(http.StatusPreconditionRequired, constants.IF_MATCH_REQUIRED)
+	}
+
+	errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+	return c.String(http.StatusBadRequest, errMessage)
+}
+
+// Responds with the current item when the If-Match version is stale
+func (as ApiService) versionMismatch(c echo.Context, id string) error {
+	item, err := as.repo(c).FindItemById(id)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.FIND_ITEM_BY_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusPreconditionFailed, item, " ")
 }
 
 // Create an item to do
@@ -48,6 +385,7 @@ type ApiService struct {
 // @Success 201 {object} entity.TodoItem
 // @Failure 400 string constants.BAD_REQUEST
 // @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_WORKSPACE_ROLE
 // @Failure 500 string constants.INTERNAL_SERVER_ERROR
 // @Router /item/create [post]
 func(as ApiService) CreateTodoItem(c echo.Context) error {
@@ -77,12 +415,20 @@ func(as ApiService) CreateTodoItem(c echo.Context) error {
 		return c.String(http.StatusBadRequest, errMessage)
 	}
 
-	item, err := as.R.CreateTodoItem(t, userId)
+	allowed, err := as.authorizeCreate(c)
+	if !allowed {
+		return err
+	}
+
+	item, err := as.withRevision(as.repo(c), userId, entity.RevisionCreated, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.CreateTodoItem(t, userId)
+	})
 	if err != nil {
 		errMessage := fmt.Sprintf(constants.CREATE_ITEM_ERROR, err)
 		return c.String(http.StatusBadRequest, errMessage)
 	}
 
+	c.Response().Header().Set(HeaderETag, itemETag(item))
 	return c.JSONPretty(http.StatusCreated, item, " ")
 }
 
@@ -93,7 +439,9 @@ func(as ApiService) CreateTodoItem(c echo.Context) error {
 // @Security JWT
 // @Param Authorization header string false "Bearer"
 // @Param id path string true "item Id"
+// @Param If-None-Match header string false "ETag of the cached item"
 // @Success 200 {object} entity.TodoItem
+// @Success 304 "Not Modified"
 // @Failure 400 string constants.BAD_REQUEST
 // @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
 // @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
@@ -113,22 +461,23 @@ func(as ApiService) FindById(c echo.Context) error {
 
 	param:= c.Param("id")
 
-	item, err := as.R.FindItemById(param)
+	item, err := as.repo(c).FindItemById(param)
 
 	if err != nil {
 		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
 		return c.String(http.StatusNotFound, errMessage)
 	}
 
-	isCurrentUser, err := as.R.IsItemOftheUser(param, userId)
-
-	if err != nil {
-		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
-		return c.String(http.StatusInternalServerError, errMessage)
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleViewer)
+	if !allowed {
+		return err
 	}
 
-	if !isCurrentUser {
-		return c.String(http.StatusForbidden, constants.DOES_NOT_BELONG_TO_USER)
+	etag := itemETag(item)
+	c.Response().Header().Set(HeaderETag, etag)
+
+	if !noneMatch(c.Request().Header.Get(HeaderIfNoneMatch), etag) {
+		return c.NoContent(http.StatusNotModified)
 	}
 
 	return c.JSONPretty(http.StatusOK, item, " ")
@@ -136,13 +485,16 @@ func(as ApiService) FindById(c echo.Context) error {
 
 // Find all items to do by user_id
 // @Summary find all todo items by user_id
+// @Description Lists the items the user created. With assigned_to_me the items assigned to the user are listed instead, and created_by_me then keeps only the ones the user created.
 // @Tags Item
 // @Accept json
 // @Produce json
 // @Security JWT
 // @Param Authorization header string false "Bearer"
 // @Param dto body entity.GetListDto true "Item List Criteria"
+// @Param If-None-Match header string false "ETag of the cached list"
 // @Success 200 {array} entity.TodoItem
+// @Success 304 "Not Modified"
 // @Failure 400 string constants.BAD_REQUEST
 // @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
 // @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
@@ -165,14 +517,26 @@ func (as ApiService) GetAllItems(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, errMessage)
 	}
 
-	
-	items, err := as.R.GetTodoListItems(getListBody.Limit, userId)
+	var items []entity.TodoItem
+	var err error
+	if getListBody.AssignedToMe {
+		items, err = as.repo(c).GetAssignedItems(getListBody.Limit, userId, getListBody.CreatedByMe)
+	} else {
+		items, err = as.repo(c).GetTodoListItems(getListBody.Limit, userId)
+	}
 
 	if err != nil {
 		errMessage := fmt.Sprintf(constants.GET_ALL_ITEMS_ERROR, err)
 		return c.String(http.StatusBadRequest, errMessage)
 	}
 
+	etag := listETag(items)
+	c.Response().Header().Set(HeaderETag, etag)
+
+	if !noneMatch(c.Request().Header.Get(HeaderIfNoneMatch), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	return c.JSONPretty(http.StatusOK, items, " ")
 }
 
@@ -185,10 +549,13 @@ func (as ApiService) GetAllItems(c echo.Context) error {
 // @Param Authorization header string false "Bearer"
 // @Param id path string true "item Id"
 // @Param dto body entity.TodoItemDetailsDto true "Item update criteria"
+// @Param If-Match header string false "ETag of the item being updated"
 // @Success 200 {object} entity.TodoItem
 // @Failure 400 string constants.BAD_REQUEST
 // @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
 // @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 412 {object} entity.TodoItem
+// @Failure 428 string constants.IF_MATCH_REQUIRED
 // @Failure 500 string constants.INTERNAL_SERVER_ERROR
 // @Router /item/update/{id} [put]
 func (as ApiService) UpdateItemById(c echo.Context) error {
@@ -205,15 +572,14 @@ func (as ApiService) UpdateItemById(c echo.Context) error {
 		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
 	}
 
-	isCurrentUser, err := as.R.IsItemOftheUser(param, userId)
-
+	version, err := as.ifMatchVersion(c)
 	if err != nil {
-		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
-		return c.String(http.StatusInternalServerError, errMessage)
+		return ifMatchError(c, err)
 	}
 
-	if !isCurrentUser {
-		return c.String(http.StatusForbidden, constants.DOES_NOT_BELONG_TO_USER)
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleEditor)
+	if !allowed {
+		return err
 	}
 
 	u := new(entity.TodoItemDetailsDto)
@@ -230,12 +596,19 @@ func (as ApiService) UpdateItemById(c echo.Context) error {
 		return c.String(http.StatusBadRequest, errMessage)
 	}
 
-	item, err := as.R.UpdateTodoItem(u, param)
+	item, err := as.withRevision(as.repo(c), userId, entity.RevisionUpdated, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.UpdateTodoItem(u, param, version)
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return as.versionMismatch(c, param)
+	}
+
 	if err != nil {
 		errMessage := fmt.Sprintf(constants.UPDATE_ITEM_ERROR, err)
 		return c.String(http.StatusInternalServerError, errMessage)
 	}
 
+	c.Response().Header().Set(HeaderETag, itemETag(item))
 	return c.JSONPretty(http.StatusOK, item, " ")
 }
 
@@ -247,10 +620,13 @@ func (as ApiService) UpdateItemById(c echo.Context) error {
 // @Security JWT
 // @Param Authorization header string false "Bearer"
 // @Param id path string true "item Id"
+// @Param If-Match header string false "ETag of the item being deleted"
 // @Success 200 string constants.DELETE_ITEM_SUCCESSFULL
 // @Failure 400 string constants.BAD_REQUEST
 // @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
 // @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 412 {object} entity.TodoItem
+// @Failure 428 string constants.IF_MATCH_REQUIRED
 // @Failure 500 string constants.INTERNAL_SERVER_ERROR
 // @Router /item/delete/{id} [delete]
 func (as ApiService) DeleteById(c echo.Context) error {
@@ -266,18 +642,26 @@ func (as ApiService) DeleteById(c echo.Context) error {
 
 	param := c.Param("id")
 
-	isCurrentUser, err := as.R.IsItemOftheUser(param, userId)
-
+	version, err := as.ifMatchVersion(c)
 	if err != nil {
-		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
-		return c.String(http.StatusInternalServerError, errMessage)
+		return ifMatchError(c, err)
 	}
 
-	if !isCurrentUser {
-		return c.String(http.StatusForbidden, constants.DOES_NOT_BELONG_TO_USER)
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleOwner)
+	if !allowed {
+		return err
 	}
 
-	err = as.R.DeleteTodoItem(param)
+	_, err = as.withRevision(as.repo(c), userId, entity.RevisionDeleted, func(r ApiRepository) (entity.TodoItem, error) {
+		if err := r.DeleteTodoItem(param, version); err != nil {
+			return entity.TodoItem{}, err
+		}
+
+		return r.FindItemById(param)
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return as.versionMismatch(c, param)
+	}
 
 	if err != nil {
 		errMessage := fmt.Sprintf(constants.DELETE_ITEM_ERROR, err)
@@ -289,16 +673,21 @@ func (as ApiService) DeleteById(c echo.Context) error {
 
 // Sets the item status to complete by id
 // @Summary sets the item status to complete by id
+// @Description An item waiting on blockers that are not done or cancelled cannot be completed, the response is then 409.
 // @Tags Item
 // @Accept json
 // @Produce json
 // @Security JWT
 // @Param Authorization header string false "Bearer"
 // @Param id path string true "item Id"
+// @Param If-Match header string false "ETag of the item being completed"
 // @Success 200 {object} entity.TodoItem
 // @Failure 400 string constants.BAD_REQUEST
 // @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
 // @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 409 string constants.INVALID_STATUS_TRANSITION
+// @Failure 412 {object} entity.TodoItem
+// @Failure 428 string constants.IF_MATCH_REQUIRED
 // @Failure 500 string constants.INTERNAL_SERVER_ERROR
 // @Router /item/complete/{id} [patch]
 func (as ApiService) UpdateStatustoCompleted(c echo.Context) error {
@@ -314,22 +703,693 @@ func (as ApiService) UpdateStatustoCompleted(c echo.Context) error {
 		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
 	}
 
-	isCurrentUser, err := as.R.IsItemOftheUser(param, userId)
+	version, err := as.ifMatchVersion(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleEditor)
+	if !allowed {
+		return err
+	}
 
+	current, err := as.repo(c).FindItemById(param)
 	if err != nil {
-		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		errMessage := fmt.Sprintf(constants.FIND_ITEM_BY_ITEM_ERROR, err)
 		return c.String(http.StatusInternalServerError, errMessage)
 	}
 
-	if !isCurrentUser {
-		return c.String(http.StatusForbidden, constants.DOES_NOT_BELONG_TO_USER)
+	if version != 0 && version != current.Version {
+		c.Response().Header().Set(HeaderETag, itemETag(current))
+		return c.JSONPretty(http.StatusPreconditionFailed, current, " ")
+	}
+
+	if !as.statusTransitions().CanTransition(current.Status, entity.StatusDone) {
+		errMessage := fmt.Sprintf(constants.INVALID_STATUS_TRANSITION, current.Status, entity.StatusDone)
+		return c.String(http.StatusConflict, errMessage)
+	}
+
+	if waitsOnBlockers(current, entity.StatusDone) {
+		return c.String(http.StatusConflict, constants.ITEM_BLOCKED)
+	}
+
+	item, err := as.withRevision(as.repo(c), userId, entity.RevisionCompleted, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.SetItemStatusToComplete(param, current.Version)
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return as.versionMismatch(c, param)
 	}
 
-	item, err := as.R.SetItemStatusToComplete(param)
 	if err != nil {
 		errMessage := fmt.Sprintf(constants.SET_STATUS_ITEM_ERROR, err)
 		return c.String(http.StatusInternalServerError, errMessage)
 	}
 
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusOK, item, " ")
+}
+
+// Find the deleted items of the user
+// @Summary lists the todo items in trash
+// @Tags Trash
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param limit query int false "Maximum number of items" default(50)
+// @Success 200 {array} entity.TodoItem
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.INTERNAL_SERVER_ERROR
+// @Router /item/trash [get]
+func (as ApiService) GetTrashItems(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	limit := 50
+	if param := c.QueryParam("limit"); param != "" {
+		value, err := strconv.Atoi(param)
+		if err != nil || value <= 0 {
+			errMessage := fmt.Sprintf(constants.BAD_REQUEST, "limit must be a positive number")
+			return c.String(http.StatusBadRequest, errMessage)
+		}
+		limit = value
+	}
+
+	items, err := as.repo(c).GetTrashItems(limit, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_TRASH_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, items, " ")
+}
+
+// Restores a deleted item by id
+// @Summary restores a todo item from trash by id
+// @Tags Trash
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Success 200 {object} entity.TodoItem
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 404 string constants.ITEM_NOT_IN_TRASH
+// @Failure 500 string constants.INTERNAL_SERVER_ERROR
+// @Router /item/restore/{id} [patch]
+func (as ApiService) RestoreById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleOwner)
+	if !allowed {
+		return err
+	}
+
+	item, err := as.withRevision(as.repo(c), userId, entity.RevisionRestored, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.RestoreTodoItem(param)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.ITEM_NOT_IN_TRASH)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.RESTORE_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusOK, item, " ")
+}
+
+// Permanently deletes an item in trash by id
+// @Summary permanently deletes a todo item in trash by id
+// @Tags Trash
+// @Produce plain
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Success 200 string constants.PURGE_ITEM_SUCCESSFULL
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 404 string constants.ITEM_NOT_IN_TRASH
+// @Failure 500 string constants.INTERNAL_SERVER_ERROR
+// @Router /item/purge/{id} [delete]
+func (as ApiService) PurgeById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleOwner)
+	if !allowed {
+		return err
+	}
+
+	var keys []string
+	if as.Blobs != nil {
+		keys, err = as.repo(c).GetItemAttachmentKeys(param)
+		if err != nil {
+			errMessage := fmt.Sprintf(constants.PURGE_ITEM_ERROR, err)
+			return c.String(http.StatusInternalServerError, errMessage)
+		}
+	}
+
+	err = as.repo(c).PurgeTodoItem(param)
+	if errors.Is(err, ErrItemNotInTrash) {
+		return c.String(http.StatusNotFound, constants.ITEM_NOT_IN_TRASH)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.PURGE_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	deleteBlobs(as.Blobs, keys)
+	return c.String(http.StatusOK, constants.PURGE_ITEM_SUCCESSFULL)
+}
+
+// Permanently deletes all the items in trash of the user
+// @Summary empties the trash of the user
+// @Tags Trash
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Success 200 {object} entity.TrashPurgeResponseDto
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.INTERNAL_SERVER_ERROR
+// @Router /item/trash [delete]
+func (as ApiService) EmptyTrash(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	var keys []string
+	if as.Blobs != nil {
+		var err error
+		keys, err = as.repo(c).GetTrashAttachmentKeys(userId)
+		if err != nil {
+			errMessage := fmt.Sprintf(constants.EMPTY_TRASH_ERROR, err)
+			return c.String(http.StatusInternalServerError, errMessage)
+		}
+	}
+
+	purged, err := as.repo(c).EmptyTrash(userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.EMPTY_TRASH_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	deleteBlobs(as.Blobs, keys)
+
+	return c.JSONPretty(http.StatusOK, entity.TrashPurgeResponseDto{
+		Response: constants.EMPTY_TRASH_SUCCESSFULL,
+		Purged:   purged,
+	}, " ")
+}
+
+// Changes the status of an item after checking the version and that the transition is allowed
+func (as ApiService) changeItemStatus(c echo.Context, id, userId, status string, version int) error {
+	current, err := as.repo(c).FindItemById(id)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.FIND_ITEM_BY_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if version != 0 && version != current.Version {
+		c.Response().Header().Set(HeaderETag, itemETag(current))
+		return c.JSONPretty(http.StatusPreconditionFailed, current, " ")
+	}
+
+	if !as.statusTransitions().CanTransition(current.Status, status) {
+		errMessage := fmt.Sprintf(constants.INVALID_STATUS_TRANSITION, current.Status, status)
+		return c.String(http.StatusConflict, errMessage)
+	}
+
+	if waitsOnBlockers(current, status) {
+		return c.String(http.StatusConflict, constants.ITEM_BLOCKED)
+	}
+
+	item, err := as.withRevision(as.repo(c), userId, entity.RevisionStatusChanged, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.SetItemStatus(id, status, current.Version)
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return as.versionMismatch(c, id)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CHANGE_STATUS_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusOK, item, " ")
+}
+
+// Changes the status of an item by id
+// @Summary changes the status of a todo item by id
+// @Description An item waiting on blockers that are not done or cancelled cannot be moved to done, the response is then 409.
+// @Tags Item
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param dto body entity.ItemStatusDto true "New status"
+// @Param If-Match header string false "ETag of the item being changed"
+// @Success 200 {object} entity.TodoItem
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 409 string constants.INVALID_STATUS_TRANSITION
+// @Failure 412 {object} entity.TodoItem
+// @Failure 428 string constants.IF_MATCH_REQUIRED
+// @Failure 500 string constants.INTERNAL_SERVER_ERROR
+// @Router /item/status/{id} [patch]
+func (as ApiService) ChangeStatusById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	s := new(entity.ItemStatusDto)
+	if err := c.Bind(s); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(s)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	version, err := as.ifMatchVersion(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleEditor)
+	if !allowed {
+		return err
+	}
+
+	return as.changeItemStatus(c, param, userId, s.Status, version)
+}
+
+// Reopens a completed or cancelled item by id
+// @Summary moves a todo item back to the todo status by id
+// @Tags Item
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param If-Match header string false "ETag of the item being reopened"
+// @Success 200 {object} entity.TodoItem
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 409 string constants.INVALID_STATUS_TRANSITION
+// @Failure 412 {object} entity.TodoItem
+// @Failure 428 string constants.IF_MATCH_REQUIRED
+// @Failure 500 string constants.INTERNAL_SERVER_ERROR
+// @Router /item/reopen/{id} [patch]
+func (as ApiService) ReopenById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	version, err := as.ifMatchVersion(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleEditor)
+	if !allowed {
+		return err
+	}
+
+	return as.changeItemStatus(c, param, userId, entity.StatusTodo, version)
+}
+
+// Partially updates an item to do by id
+// @Summary partially updates a todo item by id with a JSON Merge Patch or a JSON Patch
+// @Tags Item
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param dto body entity.TodoItemDto true "Fields of the item to change, a null value removes the field"
+// @Param If-Match header string false "ETag of the item being patched"
+// @Success 200 {object} entity.TodoItem
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 412 {object} entity.TodoItem
+// @Failure 428 string constants.IF_MATCH_REQUIRED
+// @Failure 415 string constants.UNSUPPORTED_PATCH_TYPE
+// @Failure 500 string constants.INTERNAL_SERVER_ERROR
+// @Router /item/{id} [patch]
+func (as ApiService) PatchItemById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+	applyPatch := MergePatch
+
+	switch {
+	case strings.HasPrefix(contentType, MIMEJSONPatch):
+		applyPatch = ApplyJSONPatch
+	case strings.HasPrefix(contentType, MIMEMergePatch), strings.HasPrefix(contentType, echo.MIMEApplicationJSON):
+	default:
+		errMessage := fmt.Sprintf(constants.UNSUPPORTED_PATCH_TYPE, contentType)
+		return c.String(http.StatusUnsupportedMediaType, errMessage)
+	}
+
+	patch, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	version, err := as.ifMatchVersion(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleEditor)
+	if !allowed {
+		return err
+	}
+
+	current, err := as.repo(c).FindItemById(param)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.FIND_ITEM_BY_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if version != 0 && version != current.Version {
+		c.Response().Header().Set(HeaderETag, itemETag(current))
+		return c.JSONPretty(http.StatusPreconditionFailed, current, " ")
+	}
+
+	document, err := json.Marshal(current.Item)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INTERNAL_SERVER_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	patched, err := applyPatch(document, patch)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	u := new(entity.TodoItemDto)
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(u); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err = validate.Struct(u)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	changes := changedItemColumns(current.Item, *u)
+	if len(changes) == 0 {
+		c.Response().Header().Set(HeaderETag, itemETag(current))
+		return c.JSONPretty(http.StatusOK, current, " ")
+	}
+
+	item, err := as.withRevision(as.repo(c), userId, entity.RevisionUpdated, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.PatchTodoItem(param, changes, current.Version)
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return as.versionMismatch(c, param)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.PATCH_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
+	return c.JSONPretty(http.StatusOK, item, " ")
+}
+
+// Runs several item operations in one transaction
+// @Summary runs create, update, complete, delete, move and label operations on items in one transaction
+// @Description Each operation gets its own result. In the atomic mode the first failure rolls back the whole batch
+// @Description and the response is 422, otherwise only the failed operations are rolled back.
+// @Description Later operations of the batch refer to the item created by the operation at index n with the id $ref:n.
+// @Tags Item
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param dto body entity.BulkRequestDto true "Operations"
+// @Success 200 {object} entity.BulkResponseDto
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_WORKSPACE_ROLE
+// @Failure 422 {object} entity.BulkResponseDto
+// @Failure 500 string constants.BULK_ITEMS_ERROR
+// @Router /item/bulk [post]
+func (as ApiService) BulkItems(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	request := new(entity.BulkRequestDto)
+	if err := c.Bind(request); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(request)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	for _, operation := range request.Operations {
+		if operation.Op == "create" {
+			allowed, err := as.authorizeCreate(c)
+			if !allowed {
+				return err
+			}
+			break
+		}
+	}
+
+	response, err := as.runBulkOperations(as.repo(c), *request, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BULK_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if !response.Committed {
+		return c.JSONPretty(http.StatusUnprocessableEntity, response, " ")
+	}
+
+	return c.JSONPretty(http.StatusOK, response, " ")
+}
+
+// Lists the revisions of an item with the fields changed by each of them
+// @Summary lists the change history of a todo item, oldest first
+// @Tags History
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Success 200 {array} entity.ItemRevision
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 500 string constants.GET_ITEM_HISTORY_ERROR
+// @Router /item/{id}/history [get]
+func (as ApiService) GetItemHistory(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleViewer)
+	if !allowed {
+		return err
+	}
+
+	revisions, err := as.repo(c).GetItemRevisions(param)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_ITEM_HISTORY_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, revisions, " ")
+}
+
+// Reverts the name, description, due date and priority of an item to a revision
+// @Summary reverts a todo item to a prior revision
+// @Tags History
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param revision path int true "revision Id"
+// @Param If-Match header string false "ETag of the item being reverted"
+// @Success 200 {object} entity.TodoItem
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 404 string constants.REVISION_NOT_FOUND
+// @Failure 412 {object} entity.TodoItem
+// @Failure 428 string constants.IF_MATCH_REQUIRED
+// @Failure 500 string constants.REVERT_ITEM_ERROR
+// @Router /item/{id}/history/{revision}/revert [post]
+func (as ApiService) RevertItemById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	revisionId, err := strconv.ParseInt(c.Param("revision"), 10, 64)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	version, err := as.ifMatchVersion(c)
+	if err != nil {
+		return ifMatchError(c, err)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleEditor)
+	if !allowed {
+		return err
+	}
+
+	revision, err := as.repo(c).FindItemRevision(param, revisionId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.REVISION_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.REVERT_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	current, err := as.repo(c).FindItemById(param)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.FIND_ITEM_BY_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if version != 0 && version != current.Version {
+		c.Response().Header().Set(HeaderETag, itemETag(current))
+		return c.JSONPretty(http.StatusPreconditionFailed, current, " ")
+	}
+
+	changes := changedItemColumns(current.Item, revision.Item.Item)
+	if len(changes) == 0 {
+		c.Response().Header().Set(HeaderETag, itemETag(current))
+		return c.JSONPretty(http.StatusOK, current, " ")
+	}
+
+	item, err := as.withRevision(as.repo(c), userId, entity.RevisionReverted, func(r ApiRepository) (entity.TodoItem, error) {
+		return r.PatchTodoItem(param, changes, current.Version)
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return as.versionMismatch(c, param)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.REVERT_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	c.Response().Header().Set(HeaderETag, itemETag(item))
 	return c.JSONPretty(http.StatusOK, item, " ")
 }
@@ -1,11 +1,21 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -13,10 +23,13 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 
 	"todo-project/constants"
 	"todo-project/entity"
+	"todo-project/notification"
+	"todo-project/storage"
 )
 
 func createJwtToken(username, id string) (*jwt.Token, string) {
@@ -227,8 +240,8 @@ func TestApiService(t *testing.T) {
 		})
 
 		t.Run("Create item success", func(t *testing.T) {
-			rows := mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+			rows := mock.NewRows(todoItemColumns).
+				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
 
 			var item = &entity.TodoItemDto{
 				Name: "Todo list item 1",
@@ -239,9 +252,14 @@ func TestApiService(t *testing.T) {
 				},
 			}
 
+			mock.ExpectBegin()
 			mock.ExpectQuery("INSERT INTO todo_items").
-				WithArgs(sqlmock.AnyArg(), item.Name, item.Details.Description, sqlmock.AnyArg(), item.Details.Priority, sqlmock.AnyArg(), sqlmock.AnyArg(), user_id).
+				WithArgs(sqlmock.AnyArg(), item.Name, item.Details.Description, sqlmock.AnyArg(), item.Details.Priority, sqlmock.AnyArg(), sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg()).
 				WillReturnRows(rows)
+			mock.ExpectExec("INSERT INTO item_revisions").
+				WithArgs("3a35452e-957c-4588-8d40-c88f370067d2", user_id, entity.RevisionCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
 
 			req := httptest.NewRequest(http.MethodPost, "/item/create",
 				strings.NewReader(`{
@@ -281,6 +299,7 @@ func TestApiService(t *testing.T) {
 
 			want := entity.TodoItem{
 				Id: "3a35452e-957c-4588-8d40-c88f370067d2",
+				UserId: "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
 				Item: entity.TodoItemDto{
 					Name: "Todo list item 1",
 					Details: entity.TodoItemDetailsDto{
@@ -290,9 +309,11 @@ func TestApiService(t *testing.T) {
 					},
 				},
 				IsCompleted: true,
+				Status:      "done",
 				IsDeleted:   false,
 				CreatedAt:   createdTime,
 				UpdatedAt:   updatedAt,
+				Version:     1,
 			}
 
 			assert.Equal(t, want, result)
@@ -309,9 +330,11 @@ func TestApiService(t *testing.T) {
 				},
 			}
 
+			mock.ExpectBegin()
 			mock.ExpectQuery("INSERT INTO todo_items").
-				WithArgs(sqlmock.AnyArg(), item.Name, item.Details.Description, sqlmock.AnyArg(), item.Details.Priority, sqlmock.AnyArg(), sqlmock.AnyArg(), user_id).
+				WithArgs(sqlmock.AnyArg(), item.Name, item.Details.Description, sqlmock.AnyArg(), item.Details.Priority, sqlmock.AnyArg(), sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg()).
 				WillReturnError(errors.New("bad request"))
+			mock.ExpectRollback()
 
 			mock.ExpectQuery("SELECT").WillReturnError(errors.New("bad request"))
 
@@ -400,10 +423,10 @@ func TestApiService(t *testing.T) {
 		})
 
 		t.Run("Internal Server error - Cannot find the item belongs to user", func(t *testing.T) {
-			rows := mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+			rows := mock.NewRows(todoItemColumns).
+				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
 
-			mock.ExpectQuery(`SELECT \* FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2'`).
+			mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).
 				WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", nil)
@@ -427,14 +450,14 @@ func TestApiService(t *testing.T) {
 		})
 
 		t.Run("Forbidden", func(t *testing.T) {
-			rows := mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+			rows := mock.NewRows(todoItemColumns).
+				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
 
-			mock.ExpectQuery(`SELECT \* FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2'`).
+			mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).
 				WillReturnRows(rows)
 
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows = mock.NewRows([]string{"is_current_user"}).AddRow(false)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows = mock.NewRows([]string{"role"}).AddRow("")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", nil)
@@ -457,14 +480,14 @@ func TestApiService(t *testing.T) {
 			assert.Equal(t, http.StatusForbidden, rec.Code)
 		})
 		t.Run("Success", func(t *testing.T) {
-			rows := mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+			rows := mock.NewRows(todoItemColumns).
+				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
 
-			mock.ExpectQuery(`SELECT \* FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2'`).
+			mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).
 				WillReturnRows(rows)
 
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows = mock.NewRows([]string{"is_current_user"}).AddRow(true)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows = mock.NewRows([]string{"role"}).AddRow("owner")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", nil)
@@ -493,6 +516,7 @@ func TestApiService(t *testing.T) {
 
 			want := entity.TodoItem{
 				Id: "3a35452e-957c-4588-8d40-c88f370067d2",
+				UserId: "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
 				Item: entity.TodoItemDto{
 					Name: "Todo list item 1",
 					Details: entity.TodoItemDetailsDto{
@@ -502,9 +526,11 @@ func TestApiService(t *testing.T) {
 					},
 				},
 				IsCompleted: true,
+				Status:      "done",
 				IsDeleted:   false,
 				CreatedAt:   createdTime,
 				UpdatedAt:   updatedAt,
+				Version:     1,
 			}
 
 			assert.Equal(t, want, result)
@@ -592,9 +618,9 @@ func TestApiService(t *testing.T) {
 		})
 
 		t.Run("Success", func(t *testing.T) {
-			rows := mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
-			GetItemsQuery := `SELECT \* FROM todo_items WHERE is_deleted = false AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67' LIMIT 1`
+			rows := mock.NewRows(todoItemColumns).
+				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+			GetItemsQuery := `SELECT .+ FROM todo_items WHERE is_deleted = false AND workspace_id IS NULL AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67' ORDER BY rank, created_at, id LIMIT 1`
 			mock.ExpectQuery(GetItemsQuery).WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodPost, "/item/list", strings.NewReader(
@@ -626,6 +652,7 @@ func TestApiService(t *testing.T) {
 
 			item := entity.TodoItem{
 				Id: "3a35452e-957c-4588-8d40-c88f370067d2",
+				UserId: "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
 				Item: entity.TodoItemDto{
 					Name: "Todo list item 1",
 					Details: entity.TodoItemDetailsDto{
@@ -635,9 +662,11 @@ func TestApiService(t *testing.T) {
 					},
 				},
 				IsCompleted: true,
+				Status:      "done",
 				IsDeleted:   false,
 				CreatedAt:   createdTime,
 				UpdatedAt:   updatedAt,
+				Version:     1,
 			}
 
 			assert.Len(t, result, 1)
@@ -695,8 +724,8 @@ func TestApiService(t *testing.T) {
 			assert.Equal(t, http.StatusInternalServerError, rec.Code)
 		})
 		t.Run("Forbidden", func(t *testing.T) {
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows := mock.NewRows([]string{"is_current_user"}).AddRow(false)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows := mock.NewRows([]string{"role"}).AddRow("")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", nil)
@@ -719,8 +748,8 @@ func TestApiService(t *testing.T) {
 			assert.Equal(t, http.StatusForbidden, rec.Code)
 		})
 		t.Run("Bad Request - bind error", func(t *testing.T) {
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows := mock.NewRows([]string{"is_current_user"}).AddRow(true)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows := mock.NewRows([]string{"role"}).AddRow("owner")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", strings.NewReader(`{
@@ -749,8 +778,8 @@ func TestApiService(t *testing.T) {
 		})
 
 		t.Run("Bad Request - validation error", func(t *testing.T) {
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows := mock.NewRows([]string{"is_current_user"}).AddRow(true)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows := mock.NewRows([]string{"role"}).AddRow("owner")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", nil)
@@ -773,8 +802,8 @@ func TestApiService(t *testing.T) {
 			assert.Equal(t, http.StatusBadRequest, rec.Code)
 		})
 		t.Run("Internal server error", func(t *testing.T) {
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows := mock.NewRows([]string{"is_current_user"}).AddRow(true)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows := mock.NewRows([]string{"role"}).AddRow("owner")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", strings.NewReader(`{
@@ -801,20 +830,25 @@ func TestApiService(t *testing.T) {
 			assert.Equal(t, http.StatusInternalServerError, rec.Code)
 		})
 		t.Run("Success", func(t *testing.T) {
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows := mock.NewRows([]string{"is_current_user"}).AddRow(true)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows := mock.NewRows([]string{"role"}).AddRow("owner")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			newUpdatedAtString := time.Now().Format("2006-01-02T15:04:05Z07:00")
 			newUpdatedDate, _ := time.Parse(newUpdatedAtString, "2006-01-02T15:04:05Z07:00")
 
-			rows = mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is description for the todo list item.", dueDate, "HIGH", createdTime, newUpdatedDate, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
-			newUpdatedQuery := `UPDATE todo_items SET description=\$1, due_date=\$2, priority=\$3, updated_at=\$4 WHERE id=\$5 RETURNING \*;`
+			rows = mock.NewRows(todoItemColumns).
+				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is description for the todo list item.", dueDate, "HIGH", createdTime, newUpdatedDate, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+			newUpdatedQuery := `UPDATE todo_items SET description=\$1, due_date=\$2, priority=\$3, updated_at=\$4 WHERE id=\$5 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$7, ''\) AND \(\$6 = 0 OR version=\$6\) RETURNING .+;`
 
+			mock.ExpectBegin()
 			mock.ExpectQuery(newUpdatedQuery).
-				WithArgs("This is description for the todo list item.", dueDate, "HIGH", newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2").
+				WithArgs("This is description for the todo list item.", dueDate, "HIGH", newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2", 0, "").
 				WillReturnRows(rows)
+			mock.ExpectExec("INSERT INTO item_revisions").
+				WithArgs("3a35452e-957c-4588-8d40-c88f370067d2", user_id, entity.RevisionUpdated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", strings.NewReader(`{
 					  "description": "This is description for the todo list item.",
@@ -840,6 +874,7 @@ func TestApiService(t *testing.T) {
 
 			want := entity.TodoItem{
 				Id: "3a35452e-957c-4588-8d40-c88f370067d2",
+				UserId: "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
 				Item: entity.TodoItemDto{
 					Name: "Todo list item 1",
 					Details: entity.TodoItemDetailsDto{
@@ -849,9 +884,11 @@ func TestApiService(t *testing.T) {
 					},
 				},
 				IsCompleted: true,
+				Status:      "done",
 				IsDeleted:   false,
 				CreatedAt:   createdTime,
 				UpdatedAt:   newUpdatedDate,
+				Version:     1,
 			}
 
 			var result entity.TodoItem
@@ -917,8 +954,8 @@ func TestApiService(t *testing.T) {
 		})
 
 		t.Run("Forbidden", func(t *testing.T) {
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows := mock.NewRows([]string{"is_current_user"}).AddRow(false)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows := mock.NewRows([]string{"role"}).AddRow("")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", nil)
@@ -941,8 +978,8 @@ func TestApiService(t *testing.T) {
 			assert.Equal(t, http.StatusForbidden, rec.Code)
 		})
 		t.Run("Internal server error-Delete Todo Item", func(t *testing.T) {
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows := mock.NewRows([]string{"is_current_user"}).AddRow(true)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows := mock.NewRows([]string{"role"}).AddRow("owner")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", nil)
@@ -965,15 +1002,23 @@ func TestApiService(t *testing.T) {
 			assert.Equal(t, http.StatusInternalServerError, rec.Code)
 		})
 		t.Run("Success", func(t *testing.T) {
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows := mock.NewRows([]string{"is_current_user"}).AddRow(true)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows := mock.NewRows([]string{"role"}).AddRow("owner")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			newUpdatedAtString := time.Now().Format("2006-01-02T15:04:05Z07:00")
-			newDeleteQuery := `UPDATE todo_items SET is_deleted = 'true', updated_at=\$1 WHERE id=\$2;`
+			newDeleteQuery := `UPDATE todo_items SET is_deleted = 'true', updated_at=\$1, deleted_at=\$1 WHERE id=\$2 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$4, ''\) AND \(\$3 = 0 OR version=\$3\);`
 
-			mock.ExpectExec(newDeleteQuery).WithArgs(newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2").
+			mock.ExpectBegin()
+			mock.ExpectExec(newDeleteQuery).WithArgs(newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2", 0, "").
 				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).
+				WillReturnRows(mock.NewRows(todoItemColumns).
+					AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, false, true, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", updatedAt, "todo", nil, nil, 2, "", 0, "", "", "", "", false, "", ""))
+			mock.ExpectExec("INSERT INTO item_revisions").
+				WithArgs("3a35452e-957c-4588-8d40-c88f370067d2", user_id, entity.RevisionDeleted, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", nil)
 
@@ -1049,8 +1094,8 @@ func TestApiService(t *testing.T) {
 		})
 
 		t.Run("Forbidden", func(t *testing.T) {
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows := mock.NewRows([]string{"is_current_user"}).AddRow(false)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows := mock.NewRows([]string{"role"}).AddRow("")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", nil)
@@ -1073,8 +1118,8 @@ func TestApiService(t *testing.T) {
 			assert.Equal(t, http.StatusForbidden, rec.Code)
 		})
 		t.Run("Internal server error-Delete Todo Item", func(t *testing.T) {
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows := mock.NewRows([]string{"is_current_user"}).AddRow(true)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows := mock.NewRows([]string{"role"}).AddRow("owner")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", nil)
@@ -1097,17 +1142,27 @@ func TestApiService(t *testing.T) {
 			assert.Equal(t, http.StatusInternalServerError, rec.Code)
 		})
 		t.Run("Success", func(t *testing.T) {
-			expectedQuery := `SELECT EXISTS \(SELECT 1 FROM todo_items WHERE id='3a35452e-957c-4588-8d40-c88f370067d2' AND user_id='ed6caeda-1fa9-442e-a41d-dd2b135cea67'\) AS is_current_user`
-			rows := mock.NewRows([]string{"is_current_user"}).AddRow(true)
+			expectedQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+			rows := mock.NewRows([]string{"role"}).AddRow("owner")
 			mock.ExpectQuery(expectedQuery).WillReturnRows(rows)
 
-			rows = mock.NewRows([]string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id"}).
-				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+			rows = mock.NewRows(todoItemColumns).
+				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, false, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "in_progress", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+			mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).
+				WillReturnRows(rows)
+
+			rows = mock.NewRows(todoItemColumns).
+				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", dueDate, "HIGH", createdTime, updatedAt, true, false, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", nil, "done", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
 			newUpdatedAtString := time.Now().Format("2006-01-02T15:04:05Z07:00")
-			newCompleteQuery := `UPDATE todo_items SET is_completed = 'true', updated_at=\$1 WHERE id=\$2 RETURNING \*;`
+			newCompleteQuery := `UPDATE todo_items SET is_completed = 'true', status = 'done', completed_at=COALESCE\(completed_at, \$1\), updated_at=\$1 WHERE id=\$2 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$4, ''\) AND \(\$3 = 0 OR version=\$3\) RETURNING .+;`
 
-			mock.ExpectQuery(newCompleteQuery).WithArgs(newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2").
+			mock.ExpectBegin()
+			mock.ExpectQuery(newCompleteQuery).WithArgs(newUpdatedAtString, "3a35452e-957c-4588-8d40-c88f370067d2", 1, "").
 				WillReturnRows(rows)
+			mock.ExpectExec("INSERT INTO item_revisions").
+				WithArgs("3a35452e-957c-4588-8d40-c88f370067d2", user_id, entity.RevisionCompleted, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
 
 			req := httptest.NewRequest(http.MethodGet, "/item/3a35452e-957c-4588-8d40-c88f370067d2", nil)
 
@@ -1135,6 +1190,7 @@ func TestApiService(t *testing.T) {
 
 			want := entity.TodoItem{
 				Id: "3a35452e-957c-4588-8d40-c88f370067d2",
+				UserId: "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
 				Item: entity.TodoItemDto{
 					Name: "Todo list item 1",
 					Details: entity.TodoItemDetailsDto{
@@ -1144,9 +1200,11 @@ func TestApiService(t *testing.T) {
 					},
 				},
 				IsCompleted: true,
+				Status:      "done",
 				IsDeleted:   false,
 				CreatedAt:   createdTime,
 				UpdatedAt:   updatedAt,
+				Version:     1,
 			}
 
 			assert.Equal(t, want, result)
@@ -1155,3 +1213,4458 @@ func TestApiService(t *testing.T) {
 		})
 	})
 }
+
+func TestApiServiceTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	dueDate, err := time.Parse("2006-01-02 15:04:05", "2023-10-15 09:38:24")
+	if err != nil {
+		t.Fatalf("Failed to parse due_date: %v", err)
+	}
+
+	e := echo.New()
+
+	username := "example@gmail.com"
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	ownerQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+
+	newContext := func(method, target string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, target, nil)
+		token, rawToken := createJwtToken(username, user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+
+		return ctx, rec
+	}
+
+	t.Run("Test Get trash items", func(t *testing.T) {
+		t.Run("Cannot get jwt token", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/item/trash", nil)
+			rec := httptest.NewRecorder()
+
+			_ = as.GetTrashItems(e.NewContext(req, rec))
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		})
+
+		t.Run("Invalid limit", func(t *testing.T) {
+			ctx, rec := newContext(http.MethodGet, "/item/trash?limit=abc")
+
+			_ = as.GetTrashItems(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			rows := mock.NewRows(todoItemColumns).
+				AddRow(item_id, "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, false, true, user_id, dueDate, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+			mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = true`).WithArgs(user_id, 5, "").WillReturnRows(rows)
+
+			ctx, rec := newContext(http.MethodGet, "/item/trash?limit=5")
+
+			err := as.GetTrashItems(ctx)
+			assert.NoError(t, err)
+
+			var result []entity.TodoItem
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Len(t, result, 1)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+
+		t.Run("Internal server error", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = true`).WithArgs(user_id, 50, "").WillReturnError(errors.New("internal server error"))
+
+			ctx, rec := newContext(http.MethodGet, "/item/trash")
+
+			_ = as.GetTrashItems(ctx)
+
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		})
+	})
+
+	t.Run("Test Restore by id", func(t *testing.T) {
+		t.Run("Forbidden", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow(""))
+
+			ctx, rec := newContext(http.MethodPatch, "/item/restore/"+item_id)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(item_id)
+
+			_ = as.RestoreById(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+
+		t.Run("Not in trash", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE todo_items SET is_deleted = 'false'`).WillReturnRows(mock.NewRows(todoItemColumns))
+			mock.ExpectRollback()
+
+			ctx, rec := newContext(http.MethodPatch, "/item/restore/"+item_id)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(item_id)
+
+			_ = as.RestoreById(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			rows := mock.NewRows(todoItemColumns).
+				AddRow(item_id, "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE todo_items SET is_deleted = 'false'`).WillReturnRows(rows)
+			mock.ExpectExec("INSERT INTO item_revisions").
+				WithArgs(item_id, user_id, entity.RevisionRestored, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			ctx, rec := newContext(http.MethodPatch, "/item/restore/"+item_id)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(item_id)
+
+			err := as.RestoreById(ctx)
+			assert.NoError(t, err)
+
+			var result entity.TodoItem
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.False(t, result.IsDeleted)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	})
+
+	t.Run("Test Purge by id", func(t *testing.T) {
+		t.Run("Not in trash", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectExec(`DELETE FROM todo_items WHERE id=\$1`).WithArgs(item_id, "").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			ctx, rec := newContext(http.MethodDelete, "/item/purge/"+item_id)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(item_id)
+
+			_ = as.PurgeById(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectExec(`DELETE FROM todo_items WHERE id=\$1`).WithArgs(item_id, "").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			ctx, rec := newContext(http.MethodDelete, "/item/purge/"+item_id)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(item_id)
+
+			_ = as.PurgeById(ctx)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, constants.PURGE_ITEM_SUCCESSFULL, rec.Body.String())
+		})
+	})
+
+	t.Run("Test Empty trash", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectExec(`DELETE FROM todo_items WHERE user_id=\$1 AND is_deleted = true AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\);`).WithArgs(user_id, "").WillReturnResult(sqlmock.NewResult(0, 4))
+
+			ctx, rec := newContext(http.MethodDelete, "/item/trash")
+
+			err := as.EmptyTrash(ctx)
+			assert.NoError(t, err)
+
+			var result entity.TrashPurgeResponseDto
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, int64(4), result.Purged)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+
+		t.Run("Internal server error", func(t *testing.T) {
+			mock.ExpectExec(`DELETE FROM todo_items WHERE user_id=\$1 AND is_deleted = true AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\);`).WithArgs(user_id, "").WillReturnError(errors.New("internal server error"))
+
+			ctx, rec := newContext(http.MethodDelete, "/item/trash")
+
+			_ = as.EmptyTrash(ctx)
+
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		})
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApiServiceStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	dueDate, err := time.Parse("2006-01-02 15:04:05", "2023-10-15 09:38:24")
+	if err != nil {
+		t.Fatalf("Failed to parse due_date: %v", err)
+	}
+
+	e := echo.New()
+
+	username := "example@gmail.com"
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	ownerQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	findQuery := `SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`
+
+	newContext := func(method, target, body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		token, rawToken := createJwtToken(username, user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(item_id)
+
+		return ctx, rec
+	}
+
+	itemRow := func(status string, isCompleted bool) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, isCompleted, false, user_id, nil, status, nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+	}
+
+	t.Run("Test Change status by id", func(t *testing.T) {
+		t.Run("Cannot get jwt token", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/item/status/"+item_id, nil)
+			rec := httptest.NewRecorder()
+
+			_ = as.ChangeStatusById(e.NewContext(req, rec))
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		})
+
+		t.Run("Validation error", func(t *testing.T) {
+			ctx, rec := newContext(http.MethodPatch, "/item/status/"+item_id, `{"status": "finished"}`)
+
+			_ = as.ChangeStatusById(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+
+		t.Run("Forbidden", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow(""))
+
+			ctx, rec := newContext(http.MethodPatch, "/item/status/"+item_id, `{"status": "in_progress"}`)
+
+			_ = as.ChangeStatusById(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+
+		t.Run("Transition not allowed", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findQuery).WillReturnRows(itemRow("cancelled", false))
+
+			ctx, rec := newContext(http.MethodPatch, "/item/status/"+item_id, `{"status": "done"}`)
+
+			_ = as.ChangeStatusById(ctx)
+
+			assert.Equal(t, http.StatusConflict, rec.Code)
+		})
+
+		t.Run("Configured transitions", func(t *testing.T) {
+			configured := ApiService{
+				R:           as.R,
+				Transitions: StatusTransitions{"todo": {"done"}},
+			}
+
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findQuery).WillReturnRows(itemRow("todo", false))
+
+			ctx, rec := newContext(http.MethodPatch, "/item/status/"+item_id, `{"status": "in_progress"}`)
+
+			_ = configured.ChangeStatusById(ctx)
+
+			assert.Equal(t, http.StatusConflict, rec.Code)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findQuery).WillReturnRows(itemRow("todo", false))
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE todo_items SET status=\$1::text`).
+				WithArgs("in_progress", sqlmock.AnyArg(), item_id, 1, "").
+				WillReturnRows(itemRow("in_progress", false))
+			mock.ExpectExec("INSERT INTO item_revisions").
+				WithArgs(item_id, user_id, entity.RevisionStatusChanged, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			ctx, rec := newContext(http.MethodPatch, "/item/status/"+item_id, `{"status": "in_progress"}`)
+
+			err := as.ChangeStatusById(ctx)
+			assert.NoError(t, err)
+
+			var result entity.TodoItem
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, "in_progress", result.Status)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	})
+
+	t.Run("Test Reopen by id", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findQuery).WillReturnRows(itemRow("done", true))
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE todo_items SET status=\$1::text`).
+				WithArgs("todo", sqlmock.AnyArg(), item_id, 1, "").
+				WillReturnRows(itemRow("todo", false))
+			mock.ExpectExec("INSERT INTO item_revisions").
+				WithArgs(item_id, user_id, entity.RevisionStatusChanged, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			ctx, rec := newContext(http.MethodPatch, "/item/reopen/"+item_id, "")
+
+			err := as.ReopenById(ctx)
+			assert.NoError(t, err)
+
+			var result entity.TodoItem
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, "todo", result.Status)
+			assert.False(t, result.IsCompleted)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+
+		t.Run("Internal server error", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findQuery).WillReturnError(errors.New("internal server error"))
+
+			ctx, rec := newContext(http.MethodPatch, "/item/reopen/"+item_id, "")
+
+			_ = as.ReopenById(ctx)
+
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		})
+	})
+
+	t.Run("Test Complete a cancelled item", func(t *testing.T) {
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(findQuery).WillReturnRows(itemRow("cancelled", false))
+
+		ctx, rec := newContext(http.MethodPatch, "/item/complete/"+item_id, "")
+
+		_ = as.UpdateStatustoCompleted(ctx)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApiServicePatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	dueDate, err := time.Parse("2006-01-02 15:04:05", "2023-10-15 09:38:24")
+	if err != nil {
+		t.Fatalf("Failed to parse due_date: %v", err)
+	}
+
+	e := echo.New()
+
+	username := "example@gmail.com"
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	ownerQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	findQuery := `SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`
+
+	newContext := func(contentType, body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPatch, "/item/"+item_id, strings.NewReader(body))
+		token, rawToken := createJwtToken(username, user_id)
+
+		req.Header.Set(echo.HeaderContentType, contentType)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(item_id)
+
+		return ctx, rec
+	}
+
+	itemRow := func(name, priority string) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, name, "This is item 1", dueDate, priority, dueDate, dueDate, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+	}
+
+	t.Run("Cannot get jwt token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPatch, "/item/"+item_id, nil)
+		rec := httptest.NewRecorder()
+
+		_ = as.PatchItemById(e.NewContext(req, rec))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Unsupported content type", func(t *testing.T) {
+		ctx, rec := newContext(echo.MIMETextPlain, `name=Todo`)
+
+		_ = as.PatchItemById(ctx)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow(""))
+
+		ctx, rec := newContext(MIMEMergePatch, `{"name": "Todo list item 2"}`)
+
+		_ = as.PatchItemById(ctx)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Validation error", func(t *testing.T) {
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(findQuery).WillReturnRows(itemRow("Todo list item 1", "HIGH"))
+
+		ctx, rec := newContext(MIMEMergePatch, `{"name": null}`)
+
+		_ = as.PatchItemById(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Unknown field", func(t *testing.T) {
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(findQuery).WillReturnRows(itemRow("Todo list item 1", "HIGH"))
+
+		ctx, rec := newContext(MIMEMergePatch, `{"colour": "red"}`)
+
+		_ = as.PatchItemById(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("No changes", func(t *testing.T) {
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(findQuery).WillReturnRows(itemRow("Todo list item 1", "HIGH"))
+
+		ctx, rec := newContext(MIMEMergePatch, `{"name": "Todo list item 1"}`)
+
+		_ = as.PatchItemById(ctx)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Merge patch success", func(t *testing.T) {
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(findQuery).WillReturnRows(itemRow("Todo list item 1", "HIGH"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE todo_items SET name=\$1, priority=\$2, updated_at=\$3 WHERE id=\$4`).
+			WithArgs("Todo list item 2", "LOW", sqlmock.AnyArg(), item_id, 1, "").
+			WillReturnRows(itemRow("Todo list item 2", "LOW"))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(item_id, user_id, entity.RevisionUpdated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(MIMEMergePatch, `{"name": "Todo list item 2", "details": {"priority": "LOW"}}`)
+
+		err := as.PatchItemById(ctx)
+		assert.NoError(t, err)
+
+		var result entity.TodoItem
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Todo list item 2", result.Item.Name)
+		assert.Equal(t, "LOW", result.Item.Details.Priority)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("JSON patch success", func(t *testing.T) {
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(findQuery).WillReturnRows(itemRow("Todo list item 1", "HIGH"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE todo_items SET priority=\$1, updated_at=\$2 WHERE id=\$3`).
+			WithArgs("MEDIUM", sqlmock.AnyArg(), item_id, 1, "").
+			WillReturnRows(itemRow("Todo list item 1", "MEDIUM"))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(item_id, user_id, entity.RevisionUpdated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(MIMEJSONPatch, `[{"op": "replace", "path": "/details/priority", "value": "MEDIUM"}]`)
+
+		_ = as.PatchItemById(ctx)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApiServiceETag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	dueDate, err := time.Parse("2006-01-02 15:04:05", "2023-10-15 09:38:24")
+	if err != nil {
+		t.Fatalf("Failed to parse due_date: %v", err)
+	}
+
+	e := echo.New()
+
+	username := "example@gmail.com"
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	ownerQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	findQuery := `SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`
+	body := `{"description": "This is item 2", "due_date": "2023-05-22T09:38:24.405027Z", "priority": "HIGH"}`
+
+	newContext := func(method, body string, headers map[string]string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, "/item/"+item_id, strings.NewReader(body))
+		token, rawToken := createJwtToken(username, user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(item_id)
+
+		return ctx, rec
+	}
+
+	itemRow := func(version int) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, false, false, user_id, nil, "todo", nil, nil, version, "", 0, "", "", "", "", false, "", "")
+	}
+
+	t.Run("Test Find by id", func(t *testing.T) {
+		var etag string
+
+		t.Run("Returns the etag", func(t *testing.T) {
+			mock.ExpectQuery(findQuery).WillReturnRows(itemRow(3))
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+
+			ctx, rec := newContext(http.MethodGet, "", nil)
+
+			_ = as.FindById(ctx)
+
+			etag = rec.Header().Get(HeaderETag)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Regexp(t, `^"3-[0-9a-f]{16}"$`, etag)
+		})
+
+		t.Run("Not modified", func(t *testing.T) {
+			mock.ExpectQuery(findQuery).WillReturnRows(itemRow(3))
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+
+			ctx, rec := newContext(http.MethodGet, "", map[string]string{HeaderIfNoneMatch: etag})
+
+			_ = as.FindById(ctx)
+
+			assert.Equal(t, http.StatusNotModified, rec.Code)
+			assert.Empty(t, rec.Body.String())
+		})
+	})
+
+	t.Run("Test Get all items not modified", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false`).WillReturnRows(itemRow(3))
+
+		ctx, rec := newContext(http.MethodPost, `{"limit": 1}`, nil)
+
+		_ = as.GetAllItems(ctx)
+
+		etag := rec.Header().Get(HeaderETag)
+		assert.Regexp(t, `^W/"[0-9a-f]{16}"$`, etag)
+
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false`).WillReturnRows(itemRow(3))
+
+		ctx, rec = newContext(http.MethodPost, `{"limit": 1}`, map[string]string{HeaderIfNoneMatch: etag})
+
+		_ = as.GetAllItems(ctx)
+
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+	})
+
+	t.Run("Test Update by id", func(t *testing.T) {
+		t.Run("Invalid If-Match", func(t *testing.T) {
+			ctx, rec := newContext(http.MethodPut, body, map[string]string{HeaderIfMatch: "3"})
+
+			_ = as.UpdateItemById(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+
+		t.Run("If-Match required", func(t *testing.T) {
+			required := ApiService{
+				R:              as.R,
+				RequireIfMatch: true,
+			}
+
+			ctx, rec := newContext(http.MethodPut, body, nil)
+
+			_ = required.UpdateItemById(ctx)
+
+			assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+		})
+
+		t.Run("Stale version", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE todo_items SET description=\$1`).WillReturnRows(mock.NewRows(todoItemColumns))
+			mock.ExpectRollback()
+			mock.ExpectQuery(findQuery).WillReturnRows(itemRow(4))
+
+			ctx, rec := newContext(http.MethodPut, body, map[string]string{HeaderIfMatch: `"3"`})
+
+			_ = as.UpdateItemById(ctx)
+
+			var result entity.TodoItem
+			err := json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+			assert.Regexp(t, `^"4-[0-9a-f]{16}"$`, rec.Header().Get(HeaderETag))
+			assert.Equal(t, 4, result.Version)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE todo_items SET description=\$1`).
+				WithArgs("This is item 2", sqlmock.AnyArg(), "HIGH", sqlmock.AnyArg(), item_id, 3, "").
+				WillReturnRows(itemRow(4))
+			mock.ExpectExec("INSERT INTO item_revisions").
+				WithArgs(item_id, user_id, entity.RevisionUpdated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			ctx, rec := newContext(http.MethodPut, body, map[string]string{HeaderIfMatch: `"3"`})
+
+			_ = as.UpdateItemById(ctx)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Regexp(t, `^"4-[0-9a-f]{16}"$`, rec.Header().Get(HeaderETag))
+		})
+	})
+
+	t.Run("Test Delete by id with a stale version", func(t *testing.T) {
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE todo_items SET is_deleted = 'true'`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		mock.ExpectQuery(findQuery).WillReturnRows(itemRow(4))
+
+		ctx, rec := newContext(http.MethodDelete, "", map[string]string{HeaderIfMatch: `"3"`})
+
+		_ = as.DeleteById(ctx)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	})
+
+	t.Run("Test Complete with a stale version", func(t *testing.T) {
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(findQuery).WillReturnRows(itemRow(4))
+
+		ctx, rec := newContext(http.MethodPatch, "", map[string]string{HeaderIfMatch: `"3"`})
+
+		_ = as.UpdateStatustoCompleted(ctx)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		assert.Regexp(t, `^"4-[0-9a-f]{16}"$`, rec.Header().Get(HeaderETag))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApiServiceBulk(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	dueDate, err := time.Parse("2006-01-02 15:04:05", "2023-10-15 09:38:24")
+	if err != nil {
+		t.Fatalf("Failed to parse due_date: %v", err)
+	}
+
+	e := echo.New()
+
+	username := "example@gmail.com"
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	other_id := "b6c3bb51-8a62-4c37-9e4f-55b0f4f0a0a1"
+	findQuery := `SELECT \* FROM \(SELECT .+ FROM todo_items\s+WHERE id = ANY\(\$1\) AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\)\) AS items WHERE role <> ''`
+
+	newContext := func(body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPost, "/item/bulk", strings.NewReader(body))
+		token, rawToken := createJwtToken(username, user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+
+		return ctx, rec
+	}
+
+	itemRows := func(status string, version int) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, status == entity.StatusDone, false, user_id, nil, status, nil, nil, version, "", 0, "", "", "", "", false, "", "")
+	}
+
+	ownedRows := func(status string, version int) *sqlmock.Rows {
+		return mock.NewRows(append(todoItemColumns, "role")).
+			AddRow(item_id, "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, status == entity.StatusDone, false, user_id, nil, status, nil, nil, version, "", 0, "", "", "", "", false, "", "", entity.RoleOwner)
+	}
+
+	t.Run("Invalid request", func(t *testing.T) {
+		ctx, rec := newContext(`{"operations": []}`)
+
+		_ = as.BulkItems(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Per operation results", func(t *testing.T) {
+		body := `{"operations": [
+			{"op": "complete", "id": "` + item_id + `"},
+			{"op": "delete", "id": "` + other_id + `"},
+			{"op": "move", "id": "` + item_id + `", "status": "unknown"}
+		]}`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findQuery).WithArgs(sqlmock.AnyArg(), user_id, "").WillReturnRows(ownedRows(entity.StatusTodo, 1))
+		mock.ExpectExec(`SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`UPDATE todo_items SET status=\$1::text`).
+			WithArgs(entity.StatusDone, sqlmock.AnyArg(), item_id, 1, "").
+			WillReturnRows(itemRows(entity.StatusDone, 2))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(item_id, user_id, entity.RevisionCompleted, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(body)
+
+		_ = as.BulkItems(ctx)
+
+		var got entity.BulkResponseDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, got.Committed)
+		assert.Len(t, got.Results, 3)
+		assert.Equal(t, http.StatusOK, got.Results[0].Status)
+		assert.Equal(t, 2, got.Results[0].Item.Version)
+		assert.Equal(t, http.StatusForbidden, got.Results[1].Status)
+		assert.Equal(t, constants.DOES_NOT_BELONG_TO_USER, got.Results[1].Error)
+		assert.Equal(t, http.StatusBadRequest, got.Results[2].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("All or nothing", func(t *testing.T) {
+		body := `{"atomic": true, "operations": [
+			{"op": "label", "id": "` + item_id + `", "add": ["finance"]},
+			{"op": "delete", "id": "` + item_id + `", "version": 1},
+			{"op": "complete", "id": "` + item_id + `"}
+		]}`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findQuery).WithArgs(sqlmock.AnyArg(), user_id, "").WillReturnRows(ownedRows(entity.StatusTodo, 1))
+		mock.ExpectExec(`INSERT INTO item_labels`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1`).WillReturnRows(itemRows(entity.StatusTodo, 2))
+		mock.ExpectExec("INSERT INTO item_revisions").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectRollback()
+
+		ctx, rec := newContext(body)
+
+		_ = as.BulkItems(ctx)
+
+		var got entity.BulkResponseDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.False(t, got.Committed)
+		assert.Equal(t, http.StatusOK, got.Results[0].Status)
+		assert.Equal(t, http.StatusPreconditionFailed, got.Results[1].Status)
+		assert.Equal(t, http.StatusFailedDependency, got.Results[2].Status)
+		assert.Equal(t, constants.BULK_OPERATION_NOT_EXECUTED, got.Results[2].Error)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Create then update the new item", func(t *testing.T) {
+		new_id := "5f0e8c2a-7b1d-4e3f-9a6b-2c4d6e8f0a1b"
+		body := `{"atomic": true, "operations": [
+			{"op": "create", "item": {"name": "Pay rent", "details": {"description": "Monthly rent", "due_date": "2023-10-15T09:38:24Z", "priority": "HIGH"}}},
+			{"op": "update", "id": "$ref:0", "details": {"description": "Rent of October", "due_date": "2023-10-15T09:38:24Z", "priority": "HIGH"}}
+		]}`
+
+		newRows := func(description string, version int) *sqlmock.Rows {
+			return mock.NewRows(todoItemColumns).
+				AddRow(new_id, "Pay rent", description, dueDate, "HIGH", dueDate, dueDate, false, false, user_id, nil, entity.StatusTodo, nil, nil, version, "", 0, "", "", "", "", false, "", "")
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findQuery).WithArgs(sqlmock.AnyArg(), user_id, "").WillReturnRows(mock.NewRows(append(todoItemColumns, "role")))
+		mock.ExpectQuery("INSERT INTO todo_items").
+			WithArgs(sqlmock.AnyArg(), "Pay rent", "Monthly rent", sqlmock.AnyArg(), "HIGH", sqlmock.AnyArg(), sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg()).
+			WillReturnRows(newRows("Monthly rent", 1))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(new_id, user_id, entity.RevisionCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET description=\$1`).
+			WithArgs("Rent of October", sqlmock.AnyArg(), "HIGH", sqlmock.AnyArg(), new_id, 1, "").
+			WillReturnRows(newRows("Rent of October", 2))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(new_id, user_id, entity.RevisionUpdated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(body)
+
+		_ = as.BulkItems(ctx)
+
+		var got entity.BulkResponseDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, got.Committed)
+		assert.Equal(t, http.StatusCreated, got.Results[0].Status)
+		assert.Equal(t, new_id, got.Results[0].Id)
+		assert.Equal(t, http.StatusOK, got.Results[1].Status)
+		assert.Equal(t, new_id, got.Results[1].Id)
+		assert.Equal(t, "Rent of October", got.Results[1].Item.Item.Details.Description)
+		assert.Equal(t, 2, got.Results[1].Item.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("References to items not created", func(t *testing.T) {
+		body := `{"operations": [
+			{"op": "create", "id": "` + item_id + `", "item": {"name": "Pay rent", "details": {"description": "Monthly rent", "due_date": "2023-10-15T09:38:24Z", "priority": "HIGH"}}},
+			{"op": "complete", "id": "$ref:2"},
+			{"op": "create", "item": {"name": "Pay rent", "details": {"description": "Monthly rent", "due_date": "2023-10-15T09:38:24Z", "priority": "HIGH"}}},
+			{"op": "complete", "id": "$ref:2"},
+			{"op": "complete", "id": "$ref:1"}
+		]}`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findQuery).WithArgs(sqlmock.AnyArg(), user_id, "").WillReturnRows(mock.NewRows(append(todoItemColumns, "role")))
+		for i := 0; i < 5; i++ {
+			mock.ExpectExec(`SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+			if i == 2 {
+				mock.ExpectQuery("INSERT INTO todo_items").WillReturnError(errors.New("connection refused"))
+			}
+			mock.ExpectExec(`ROLLBACK TO SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(body)
+
+		_ = as.BulkItems(ctx)
+
+		var got entity.BulkResponseDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+		assert.Equal(t, http.StatusBadRequest, got.Results[0].Status)
+		assert.Contains(t, got.Results[0].Error, "id is generated by the server")
+		assert.Equal(t, http.StatusBadRequest, got.Results[1].Status)
+		assert.Contains(t, got.Results[1].Error, "id refers to no earlier create operation")
+		assert.Equal(t, http.StatusInternalServerError, got.Results[2].Status)
+		assert.Equal(t, http.StatusFailedDependency, got.Results[3].Status)
+		assert.Equal(t, constants.BULK_OPERATION_NOT_EXECUTED, got.Results[3].Error)
+		assert.Equal(t, http.StatusBadRequest, got.Results[4].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Transaction error", func(t *testing.T) {
+		mock.ExpectBegin().WillReturnError(errors.New("connection refused"))
+
+		ctx, rec := newContext(`{"operations": [{"op": "complete", "id": "` + item_id + `"}]}`)
+
+		_ = as.BulkItems(ctx)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	dueDate, err := time.Parse("2006-01-02 15:04:05", "2023-10-15 09:38:24")
+	if err != nil {
+		t.Fatalf("Failed to parse due_date: %v", err)
+	}
+
+	e := echo.New()
+
+	username := "example@gmail.com"
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	ownerQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	findQuery := `SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`
+	revisionQuery := `SELECT id,item_id,user_id,action,snapshot,created_at FROM item_revisions WHERE item_id=\$1 AND id=\$2`
+	revisionColumns := []string{"id", "item_id", "user_id", "action", "snapshot", "created_at"}
+
+	newContext := func(revision string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPost, "/item/"+item_id+"/history", nil)
+		token, rawToken := createJwtToken(username, user_id)
+
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id", "revision")
+		ctx.SetParamValues(item_id, revision)
+
+		return ctx, rec
+	}
+
+	itemRow := func(name string, version int) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, name, "This is item 1", dueDate, "HIGH", dueDate, dueDate, false, false, user_id, nil, "todo", nil, nil, version, "", 0, "", "", "", "", false, "", "")
+	}
+
+	snapshot := []byte(`{"id": "` + item_id + `", "item": {"name": "Todo list item 1", "details": {"description": "This is item 1", "due_date": "2023-10-15T09:38:24Z", "priority": "HIGH"}}}`)
+
+	t.Run("Test Get item history", func(t *testing.T) {
+		t.Run("Forbidden", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow(""))
+
+			ctx, rec := newContext("")
+
+			_ = as.GetItemHistory(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(`SELECT id,item_id,user_id,action,snapshot,created_at FROM item_revisions WHERE item_id=\$1 ORDER BY id`).
+				WithArgs(item_id).
+				WillReturnRows(mock.NewRows(revisionColumns).AddRow(1, item_id, user_id, entity.RevisionCreated, snapshot, dueDate))
+
+			ctx, rec := newContext("")
+
+			err := as.GetItemHistory(ctx)
+			assert.NoError(t, err)
+
+			var result []entity.ItemRevision
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Len(t, result, 1)
+			assert.Equal(t, entity.RevisionCreated, result[0].Action)
+		})
+	})
+
+	t.Run("Test Revert item", func(t *testing.T) {
+		t.Run("Invalid revision", func(t *testing.T) {
+			ctx, rec := newContext("first")
+
+			_ = as.RevertItemById(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+
+		t.Run("Revision not found", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(revisionQuery).WithArgs(item_id, 9).WillReturnRows(mock.NewRows(revisionColumns))
+
+			ctx, rec := newContext("9")
+
+			_ = as.RevertItemById(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(revisionQuery).WithArgs(item_id, 1).
+				WillReturnRows(mock.NewRows(revisionColumns).AddRow(1, item_id, user_id, entity.RevisionCreated, snapshot, dueDate))
+			mock.ExpectQuery(findQuery).WillReturnRows(itemRow("Todo list item 2", 2))
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE todo_items SET name=\$1, updated_at=\$2 WHERE id=\$3`).
+				WithArgs("Todo list item 1", sqlmock.AnyArg(), item_id, 2, "").
+				WillReturnRows(itemRow("Todo list item 1", 3))
+			mock.ExpectExec("INSERT INTO item_revisions").
+				WithArgs(item_id, user_id, entity.RevisionReverted, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(2, 1))
+			mock.ExpectCommit()
+
+			ctx, rec := newContext("1")
+
+			err := as.RevertItemById(ctx)
+			assert.NoError(t, err)
+
+			var result entity.TodoItem
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "Todo list item 1", result.Item.Name)
+			assert.Regexp(t, `^"3-[0-9a-f]{16}"$`, rec.Header().Get(HeaderETag))
+		})
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApiServiceComments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	username := "example@gmail.com"
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	comment_id := "9b2f1c8e-4d7a-4f43-b0b5-2d6a7c1e5f10"
+	ownerQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	findCommentQuery := `SELECT .+ FROM item_comments WHERE item_id=\$1 AND id=\$2 AND is_deleted = false`
+	commentColumns := []string{"id", "item_id", "parent_id", "user_id", "body", "created_at", "edited_at", "is_deleted"}
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	newContext := func(method, body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, "/item/"+item_id+"/comments", strings.NewReader(body))
+		token, rawToken := createJwtToken(username, user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id", "commentId")
+		ctx.SetParamValues(item_id, comment_id)
+
+		return ctx, rec
+	}
+
+	commentRow := func(author, body string) *sqlmock.Rows {
+		return mock.NewRows(commentColumns).AddRow(comment_id, item_id, nil, author, body, createdAt, nil, false)
+	}
+
+	t.Run("Test Get comments", func(t *testing.T) {
+		t.Run("Forbidden", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow(""))
+
+			ctx, rec := newContext(http.MethodGet, "")
+
+			_ = as.GetComments(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(`SELECT .+ FROM item_comments WHERE item_id=\$1 ORDER BY created_at, id`).
+				WillReturnRows(commentRow(user_id, "first").
+					AddRow("5c1d2e3f-6a7b-4c8d-9e0f-1a2b3c4d5e6f", item_id, comment_id, user_id, "reply", createdAt, nil, false))
+
+			ctx, rec := newContext(http.MethodGet, "")
+
+			err := as.GetComments(ctx)
+			assert.NoError(t, err)
+
+			var result []entity.Comment
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Len(t, result, 1)
+			assert.Equal(t, "reply", result[0].Replies[0].Body)
+		})
+	})
+
+	t.Run("Test Create comment", func(t *testing.T) {
+		t.Run("Validation error", func(t *testing.T) {
+			ctx, rec := newContext(http.MethodPost, `{"body": ""}`)
+
+			_ = as.CreateComment(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+
+		t.Run("Parent not found", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findCommentQuery).WithArgs(item_id, "missing").WillReturnRows(mock.NewRows(commentColumns))
+
+			ctx, rec := newContext(http.MethodPost, `{"body": "reply", "parent_id": "missing"}`)
+
+			_ = as.CreateComment(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(`INSERT INTO item_comments`).
+				WithArgs(sqlmock.AnyArg(), item_id, nil, user_id, "Waiting for the **invoice**", sqlmock.AnyArg()).
+				WillReturnRows(commentRow(user_id, "Waiting for the **invoice**"))
+
+			ctx, rec := newContext(http.MethodPost, `{"body": "Waiting for the **invoice**"}`)
+
+			err := as.CreateComment(ctx)
+			assert.NoError(t, err)
+
+			var result entity.Comment
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, user_id, result.UserId)
+		})
+	})
+
+	t.Run("Test Update comment", func(t *testing.T) {
+		t.Run("Not the author", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findCommentQuery).WithArgs(item_id, comment_id).
+				WillReturnRows(commentRow("b6c3bb51-8a62-4c37-9e4f-55b0f4f0a0a1", "first"))
+
+			ctx, rec := newContext(http.MethodPatch, `{"body": "edited"}`)
+
+			_ = as.UpdateCommentById(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, constants.NOT_COMMENT_AUTHOR, rec.Body.String())
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findCommentQuery).WithArgs(item_id, comment_id).WillReturnRows(commentRow(user_id, "first"))
+			mock.ExpectQuery(`UPDATE item_comments SET body=\$1`).
+				WithArgs("edited", sqlmock.AnyArg(), comment_id).
+				WillReturnRows(mock.NewRows(commentColumns).AddRow(comment_id, item_id, nil, user_id, "edited", createdAt, createdAt, false))
+
+			ctx, rec := newContext(http.MethodPatch, `{"body": "edited"}`)
+
+			err := as.UpdateCommentById(ctx)
+			assert.NoError(t, err)
+
+			var result entity.Comment
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "edited", result.Body)
+			assert.NotNil(t, result.EditedAt)
+		})
+	})
+
+	t.Run("Test Delete comment", func(t *testing.T) {
+		t.Run("Not found", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findCommentQuery).WithArgs(item_id, comment_id).WillReturnRows(mock.NewRows(commentColumns))
+
+			ctx, rec := newContext(http.MethodDelete, "")
+
+			_ = as.DeleteCommentById(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findCommentQuery).WithArgs(item_id, comment_id).WillReturnRows(commentRow(user_id, "first"))
+			mock.ExpectExec(`UPDATE item_comments SET is_deleted = true`).
+				WithArgs(sqlmock.AnyArg(), comment_id).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			ctx, rec := newContext(http.MethodDelete, "")
+
+			_ = as.DeleteCommentById(ctx)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, constants.DELETE_COMMENT_SUCCESSFULL, rec.Body.String())
+		})
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApiServiceAttachments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	blobs := storage.LocalStore{Root: t.TempDir()}
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+		Blobs: blobs,
+		Attachments: AttachmentLimits{
+			MaxSize:      64,
+			AllowedTypes: []string{"image/png", "text/plain"},
+			Quota:        1024,
+		},
+	}
+
+	e := echo.New()
+
+	username := "example@gmail.com"
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	attachment_id := "6f0c2b7e-8a1d-4e5f-9a3b-2c4d6e8f0a1b"
+	storage_key := "items/" + item_id + "/" + attachment_id
+	ownerQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	findItemQuery := `SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`
+	findAttachmentQuery := `SELECT .+ FROM item_attachments WHERE item_id=\$1 AND id=\$2`
+	usageQuery := `SELECT COALESCE\(SUM\(size\), 0\) FROM item_attachments WHERE user_id=\$1`
+	lockUsageQuery := `SELECT pg_advisory_xact_lock\(hashtext\('item_attachments:' \|\| \$1::text\)\)`
+	attachmentColumns := []string{"id", "item_id", "user_id", "file_name", "content_type", "size", "storage_key", "created_at"}
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	png := "\x89PNG\r\n\x1a\n" + "screenshot"
+
+	newContext := func(method, target string, body io.Reader, contentType string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, target, body)
+		token, rawToken := createJwtToken(username, user_id)
+
+		req.Header.Set(echo.HeaderContentType, contentType)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id", "attachmentId")
+		ctx.SetParamValues(item_id, attachment_id)
+
+		return ctx, rec
+	}
+
+	newUpload := func(fileName, content string) (echo.Context, *httptest.ResponseRecorder) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("file", fileName)
+		_, _ = part.Write([]byte(content))
+		_ = writer.Close()
+
+		return newContext(http.MethodPost, "/item/"+item_id+"/attachments", body, writer.FormDataContentType())
+	}
+
+	itemRow := func(isDeleted bool) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, isDeleted, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+	}
+
+	attachmentRow := func(size int64) *sqlmock.Rows {
+		return mock.NewRows(attachmentColumns).
+			AddRow(attachment_id, item_id, user_id, "screenshot.png", "image/png", size, storage_key, createdAt)
+	}
+
+	t.Run("Test Upload attachment", func(t *testing.T) {
+		t.Run("Not configured", func(t *testing.T) {
+			ctx, rec := newUpload("screenshot.png", png)
+
+			_ = ApiService{R: as.R}.UploadAttachment(ctx)
+
+			assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		})
+
+		t.Run("Item in trash", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findItemQuery).WillReturnRows(itemRow(true))
+
+			ctx, rec := newUpload("screenshot.png", png)
+
+			_ = as.UploadAttachment(ctx)
+
+			assert.Equal(t, http.StatusConflict, rec.Code)
+		})
+
+		t.Run("Too large", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findItemQuery).WillReturnRows(itemRow(false))
+
+			ctx, rec := newUpload("screenshot.png", png+strings.Repeat("x", 64))
+
+			_ = as.UploadAttachment(ctx)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		})
+
+		t.Run("Body over the limit", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findItemQuery).WillReturnRows(itemRow(false))
+
+			ctx, rec := newUpload("screenshot.png", png+strings.Repeat("x", 1<<20))
+
+			_ = as.UploadAttachment(ctx)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+			assert.Equal(t, fmt.Sprintf(constants.ATTACHMENT_TOO_LARGE, 64), rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Type not allowed", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findItemQuery).WillReturnRows(itemRow(false))
+
+			ctx, rec := newUpload("screenshot.png", "%PDF-1.7 renamed")
+
+			_ = as.UploadAttachment(ctx)
+
+			assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+			assert.Contains(t, rec.Body.String(), "application/pdf")
+		})
+
+		t.Run("Quota exceeded", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findItemQuery).WillReturnRows(itemRow(false))
+			mock.ExpectBegin()
+			mock.ExpectExec(lockUsageQuery).WithArgs(user_id).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(usageQuery).WithArgs(user_id).WillReturnRows(mock.NewRows([]string{"coalesce"}).AddRow(1020))
+			mock.ExpectRollback()
+
+			ctx, rec := newUpload("screenshot.png", png)
+
+			_ = as.UploadAttachment(ctx)
+
+			assert.Equal(t, http.StatusInsufficientStorage, rec.Code)
+			assert.Equal(t, fmt.Sprintf(constants.STORAGE_QUOTA_EXCEEDED, 1024), rec.Body.String())
+
+			stored, _ := filepath.Glob(filepath.Join(blobs.Root, "items", item_id, "*"))
+			assert.Empty(t, stored)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findItemQuery).WillReturnRows(itemRow(false))
+			mock.ExpectBegin()
+			mock.ExpectExec(lockUsageQuery).WithArgs(user_id).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(usageQuery).WithArgs(user_id).WillReturnRows(mock.NewRows([]string{"coalesce"}).AddRow(0))
+			mock.ExpectExec(`INSERT INTO item_attachments`).
+				WithArgs(sqlmock.AnyArg(), item_id, user_id, "screenshot.png", "image/png", int64(len(png)), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			ctx, rec := newUpload("screenshot.png", png)
+
+			err := as.UploadAttachment(ctx)
+			assert.NoError(t, err)
+
+			var result entity.Attachment
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, "image/png", result.ContentType)
+			assert.NotContains(t, rec.Body.String(), "storage_key")
+
+			blob, err := blobs.Open("items/" + item_id + "/" + result.Id)
+			assert.NoError(t, err)
+			content, _ := io.ReadAll(blob)
+			blob.Close()
+			assert.Equal(t, png, string(content))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Get attachments", func(t *testing.T) {
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(`SELECT .+ FROM item_attachments WHERE item_id=\$1 ORDER BY created_at, id`).
+			WithArgs(item_id).
+			WillReturnRows(attachmentRow(int64(len(png))))
+
+		ctx, rec := newContext(http.MethodGet, "/item/"+item_id+"/attachments", nil, echo.MIMEApplicationJSON)
+
+		err := as.GetAttachments(ctx)
+		assert.NoError(t, err)
+
+		var result []entity.Attachment
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("Test Download attachment", func(t *testing.T) {
+		err := blobs.Put(storage_key, strings.NewReader(png), int64(len(png)), "image/png")
+		assert.NoError(t, err)
+
+		t.Run("Not found", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findAttachmentQuery).WillReturnRows(mock.NewRows(attachmentColumns))
+
+			ctx, rec := newContext(http.MethodGet, "/item/"+item_id+"/attachments/"+attachment_id, nil, echo.MIMEApplicationJSON)
+
+			_ = as.DownloadAttachment(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+
+		t.Run("Whole file", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findAttachmentQuery).WillReturnRows(attachmentRow(int64(len(png))))
+
+			ctx, rec := newContext(http.MethodGet, "/item/"+item_id+"/attachments/"+attachment_id, nil, echo.MIMEApplicationJSON)
+
+			err := as.DownloadAttachment(ctx)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+			assert.Equal(t, `attachment; filename=screenshot.png`, rec.Header().Get(echo.HeaderContentDisposition))
+			assert.Equal(t, png, rec.Body.String())
+		})
+
+		t.Run("Range", func(t *testing.T) {
+			mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findAttachmentQuery).WillReturnRows(attachmentRow(int64(len(png))))
+
+			ctx, rec := newContext(http.MethodGet, "/item/"+item_id+"/attachments/"+attachment_id, nil, echo.MIMEApplicationJSON)
+			ctx.Request().Header.Set("Range", "bytes=8-")
+
+			err := as.DownloadAttachment(ctx)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusPartialContent, rec.Code)
+			assert.Equal(t, "screenshot", rec.Body.String())
+		})
+	})
+
+	t.Run("Test Delete attachment", func(t *testing.T) {
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(findAttachmentQuery).WillReturnRows(attachmentRow(int64(len(png))))
+		mock.ExpectExec(`DELETE FROM item_attachments WHERE id=\$1;`).WithArgs(attachment_id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		ctx, rec := newContext(http.MethodDelete, "/item/"+item_id+"/attachments/"+attachment_id, nil, echo.MIMEApplicationJSON)
+
+		err := as.DeleteAttachmentById(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		_, err = blobs.Open(storage_key)
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	})
+
+	t.Run("Test Purge deletes the attachments", func(t *testing.T) {
+		err := blobs.Put(storage_key, strings.NewReader(png), int64(len(png)), "image/png")
+		assert.NoError(t, err)
+
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(`SELECT storage_key FROM item_attachments WHERE item_id=\$1`).
+			WithArgs(item_id).
+			WillReturnRows(mock.NewRows([]string{"storage_key"}).AddRow(storage_key))
+		mock.ExpectExec(`DELETE FROM todo_items WHERE id=\$1`).WithArgs(item_id, "").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		ctx, rec := newContext(http.MethodDelete, "/item/purge/"+item_id, nil, echo.MIMEApplicationJSON)
+
+		err = as.PurgeById(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		_, err = blobs.Open(storage_key)
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceSharing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	colleague_id := "8c3e5f7a-9b1d-4e2f-a4c6-d8e0f2a4b6c8"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	share_id := "1d9c7a4e-2b3f-4c5d-8e6f-7a8b9c0d1e2f"
+	roleQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	findShareQuery := `SELECT .+ FROM item_shares WHERE item_id=\$1 AND id=\$2`
+	shareColumns := []string{"id", "item_id", "user_id", "email", "role", "status", "invited_by", "created_at", "responded_at"}
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	newContext := func(method, body, userId string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, "/item/"+item_id+"/shares", strings.NewReader(body))
+		token, rawToken := createJwtToken("example@gmail.com", userId)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id", "shareId")
+		ctx.SetParamValues(item_id, share_id)
+
+		return ctx, rec
+	}
+
+	roleRow := func(role string) *sqlmock.Rows {
+		return mock.NewRows([]string{"role"}).AddRow(role)
+	}
+
+	shareRow := func(status string) *sqlmock.Rows {
+		return mock.NewRows(shareColumns).
+			AddRow(share_id, item_id, colleague_id, "colleague@gmail.com", "viewer", status, user_id, createdAt, nil)
+	}
+
+	t.Run("Test Share item", func(t *testing.T) {
+		t.Run("Invalid role", func(t *testing.T) {
+			ctx, rec := newContext(http.MethodPost, `{"email": "colleague@gmail.com", "role": "owner"}`, user_id)
+
+			_ = as.ShareItemById(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+
+		t.Run("Editor cannot share", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, colleague_id, "").WillReturnRows(roleRow(entity.RoleEditor))
+
+			ctx, rec := newContext(http.MethodPost, `{"email": "other@gmail.com", "role": "viewer"}`, colleague_id)
+
+			_ = as.ShareItemById(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "The owner role on the item is required", rec.Body.String())
+		})
+
+		t.Run("Email not registered", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WillReturnRows(roleRow(entity.RoleOwner))
+			mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).WillReturnRows(mock.NewRows([]string{"id"}))
+
+			ctx, rec := newContext(http.MethodPost, `{"email": "nobody@gmail.com", "role": "viewer"}`, user_id)
+
+			_ = as.ShareItemById(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, constants.EMAIL_NOT_REGISTERED, rec.Body.String())
+		})
+
+		t.Run("Share with the owner", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WillReturnRows(roleRow(entity.RoleOwner))
+			mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(user_id))
+
+			ctx, rec := newContext(http.MethodPost, `{"email": "example@gmail.com", "role": "viewer"}`, user_id)
+
+			_ = as.ShareItemById(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, constants.CANNOT_SHARE_WITH_OWNER, rec.Body.String())
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WillReturnRows(roleRow(entity.RoleOwner))
+			mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(colleague_id))
+			mock.ExpectQuery(`INSERT INTO item_shares`).
+				WithArgs(sqlmock.AnyArg(), item_id, colleague_id, entity.RoleViewer, user_id, sqlmock.AnyArg()).
+				WillReturnRows(shareRow(entity.ShareStatusPending))
+
+			ctx, rec := newContext(http.MethodPost, `{"email": "colleague@gmail.com", "role": "viewer"}`, user_id)
+
+			err := as.ShareItemById(ctx)
+			assert.NoError(t, err)
+
+			var result entity.Share
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, entity.ShareStatusPending, result.Status)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Viewer permissions", func(t *testing.T) {
+		t.Run("Viewer can read", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).
+				WillReturnRows(mock.NewRows(todoItemColumns).
+					AddRow(item_id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", ""))
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, colleague_id, "").WillReturnRows(roleRow(entity.RoleViewer))
+
+			ctx, rec := newContext(http.MethodGet, "", colleague_id)
+
+			_ = as.FindById(ctx)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+
+		t.Run("Viewer cannot update", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, colleague_id, "").WillReturnRows(roleRow(entity.RoleViewer))
+
+			ctx, rec := newContext(http.MethodPut, `{"description": "Changed", "due_date": "2023-10-15T09:38:24Z", "priority": "LOW"}`, colleague_id)
+
+			_ = as.UpdateItemById(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "The editor role on the item is required", rec.Body.String())
+		})
+
+		t.Run("Editor cannot delete", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, colleague_id, "").WillReturnRows(roleRow(entity.RoleEditor))
+
+			ctx, rec := newContext(http.MethodDelete, "", colleague_id)
+
+			_ = as.DeleteById(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+
+		t.Run("No access", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, colleague_id, "").WillReturnRows(roleRow(""))
+
+			ctx, rec := newContext(http.MethodGet, "", colleague_id)
+
+			_ = as.GetComments(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, constants.DOES_NOT_BELONG_TO_USER, rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Invitations", func(t *testing.T) {
+		t.Run("List", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT .+ FROM item_shares WHERE user_id=\$1 AND status = 'pending'`).
+				WithArgs(colleague_id).
+				WillReturnRows(shareRow(entity.ShareStatusPending))
+
+			ctx, rec := newContext(http.MethodGet, "", colleague_id)
+
+			err := as.GetInvitations(ctx)
+			assert.NoError(t, err)
+
+			var result []entity.Share
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Len(t, result, 1)
+		})
+
+		t.Run("Accept", func(t *testing.T) {
+			mock.ExpectQuery(`UPDATE item_shares SET status=\$1`).
+				WithArgs(entity.ShareStatusAccepted, sqlmock.AnyArg(), share_id, colleague_id).
+				WillReturnRows(shareRow(entity.ShareStatusAccepted))
+
+			ctx, rec := newContext(http.MethodPost, "", colleague_id)
+
+			err := as.AcceptInvitation(ctx)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"status": "accepted"`)
+		})
+
+		t.Run("Decline an answered invitation", func(t *testing.T) {
+			mock.ExpectQuery(`UPDATE item_shares SET status=\$1`).
+				WithArgs(entity.ShareStatusDeclined, sqlmock.AnyArg(), share_id, colleague_id).
+				WillReturnRows(mock.NewRows(shareColumns))
+
+			ctx, rec := newContext(http.MethodPost, "", colleague_id)
+
+			_ = as.DeclineInvitation(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Shared items", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false AND id IN \(SELECT item_id FROM item_shares`).
+			WithArgs(colleague_id, 50, "").
+			WillReturnRows(mock.NewRows(append(todoItemColumns, "role", "owner_email")).
+				AddRow(item_id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", "", "viewer", "example@gmail.com"))
+
+		ctx, rec := newContext(http.MethodGet, "", colleague_id)
+
+		err := as.GetSharedItems(ctx)
+		assert.NoError(t, err)
+
+		var result []entity.SharedItem
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, result, 1)
+		assert.Equal(t, item_id, result[0].Id)
+		assert.Equal(t, entity.RoleViewer, result[0].Role)
+		assert.Equal(t, "example@gmail.com", result[0].OwnerEmail)
+	})
+
+	t.Run("Test Revoke share", func(t *testing.T) {
+		t.Run("Not found", func(t *testing.T) {
+			mock.ExpectQuery(findShareQuery).WillReturnRows(mock.NewRows(shareColumns))
+
+			ctx, rec := newContext(http.MethodDelete, "", user_id)
+
+			_ = as.RevokeShareById(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+
+		t.Run("Other user cannot revoke", func(t *testing.T) {
+			other_id := "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+			mock.ExpectQuery(findShareQuery).WillReturnRows(shareRow(entity.ShareStatusAccepted))
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, other_id, "").WillReturnRows(roleRow(entity.RoleEditor))
+
+			ctx, rec := newContext(http.MethodDelete, "", other_id)
+
+			_ = as.RevokeShareById(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+
+		t.Run("Shared user leaves", func(t *testing.T) {
+			mock.ExpectQuery(findShareQuery).WillReturnRows(shareRow(entity.ShareStatusAccepted))
+			mock.ExpectExec(`WITH share AS \(DELETE FROM item_shares WHERE id=\$1 .+\) UPDATE todo_items SET assignee_id = NULL .+;`).WithArgs(share_id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			ctx, rec := newContext(http.MethodDelete, "", colleague_id)
+
+			err := as.RevokeShareById(ctx)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, constants.REVOKE_SHARE_SUCCESSFULL, rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Bulk delete of a shared item", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM \(SELECT .+ FROM todo_items\s+WHERE id = ANY\(\$1\) AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$3, ''\)\) AS items WHERE role <> ''`).
+			WithArgs(sqlmock.AnyArg(), colleague_id, "").
+			WillReturnRows(mock.NewRows(append(todoItemColumns, "role")).
+				AddRow(item_id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", "", entity.RoleEditor))
+		mock.ExpectRollback()
+
+		response, err := as.runBulkOperations(as.R, entity.BulkRequestDto{
+			Atomic:     true,
+			Operations: []entity.BulkOperationDto{{Op: "delete", Id: item_id}},
+		}, colleague_id)
+
+		assert.NoError(t, err)
+		assert.False(t, response.Committed)
+		assert.Equal(t, http.StatusForbidden, response.Results[0].Status)
+		assert.Equal(t, fmt.Sprintf(constants.INSUFFICIENT_ROLE, entity.RoleOwner), response.Results[0].Error)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceWorkspaces(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	colleague_id := "8c3e5f7a-9b1d-4e2f-a4c6-d8e0f2a4b6c8"
+	workspace_id := "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"
+	other_workspace_id := "0b6a3c1e-9f4d-4a57-8e2b-5d1c7f3a9e60"
+	member_id := "1d9c7a4e-2b3f-4c5d-8e6f-7a8b9c0d1e2f"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	workspaceRoleQuery := `SELECT role FROM workspace_members WHERE workspace_id=\$1 AND user_id=\$2 AND status = 'accepted'`
+	findMemberQuery := `SELECT .+ FROM workspace_members WHERE workspace_id=\$1 AND user_id=\$2`
+	lockOwnersQuery := `SELECT COUNT\(\*\), COALESCE\(BOOL_OR\(user_id=\$2\), false\) FROM \(SELECT user_id FROM workspace_members`
+	deleteMemberQuery := `WITH member AS \(DELETE FROM workspace_members WHERE workspace_id=\$1 AND user_id=\$2 .+`
+	respondQuery := `UPDATE workspace_members SET status=\$1, responded_at=\$2 WHERE id=\$3 AND user_id=\$4 AND status = 'pending' RETURNING .+;`
+	roleQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	memberColumns := []string{"id", "workspace_id", "user_id", "email", "role", "status", "invited_by", "created_at", "responded_at"}
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	newContext := func(method, body, userId string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, "/workspaces/"+workspace_id+"/members", strings.NewReader(body))
+		token, rawToken := createJwtToken("example@gmail.com", userId)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+
+		return ctx, rec
+	}
+
+	roleRow := func(role string) *sqlmock.Rows {
+		return mock.NewRows([]string{"role"}).AddRow(role)
+	}
+
+	memberRow := func(userId, role string) *sqlmock.Rows {
+		return mock.NewRows(memberColumns).
+			AddRow(member_id, workspace_id, userId, "colleague@gmail.com", role, entity.ShareStatusAccepted, user_id, createdAt, createdAt)
+	}
+
+	ownersRow := func(owners int, isOwner bool) *sqlmock.Rows {
+		return mock.NewRows([]string{"count", "bool_or"}).AddRow(owners, isOwner)
+	}
+
+	t.Run("Test Workspace scope", func(t *testing.T) {
+		next := func(c echo.Context) error {
+			return c.String(http.StatusOK, workspaceOf(c))
+		}
+
+		t.Run("Not a member", func(t *testing.T) {
+			mock.ExpectQuery(workspaceRoleQuery).WithArgs(other_workspace_id, user_id).WillReturnRows(mock.NewRows([]string{"role"}))
+
+			ctx, rec := newContext(http.MethodGet, "", user_id)
+			ctx.Request().Header.Set(HeaderWorkspaceId, other_workspace_id)
+
+			_ = as.WorkspaceScope(next)(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, constants.NOT_A_WORKSPACE_MEMBER, rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Member", func(t *testing.T) {
+			mock.ExpectQuery(workspaceRoleQuery).WithArgs(workspace_id, user_id).WillReturnRows(roleRow(entity.RoleViewer))
+
+			ctx, rec := newContext(http.MethodGet, "", user_id)
+			ctx.Request().Header.Set(HeaderWorkspaceId, workspace_id)
+
+			_ = as.WorkspaceScope(next)(ctx)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, workspace_id, rec.Body.String())
+			assert.Equal(t, entity.RoleViewer, ctx.Get(workspaceRoleKey))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Invite member", func(t *testing.T) {
+		t.Run("Viewer cannot invite", func(t *testing.T) {
+			mock.ExpectQuery(workspaceRoleQuery).WithArgs(workspace_id, colleague_id).WillReturnRows(roleRow(entity.RoleViewer))
+
+			ctx, rec := newContext(http.MethodPost, `{"email": "other@gmail.com", "role": "viewer"}`, colleague_id)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(workspace_id)
+
+			_ = as.InviteWorkspaceMember(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, "The owner role in the workspace is required", rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Cannot invite yourself", func(t *testing.T) {
+			mock.ExpectQuery(workspaceRoleQuery).WithArgs(workspace_id, user_id).WillReturnRows(roleRow(entity.RoleOwner))
+			mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).WithArgs("example@gmail.com").
+				WillReturnRows(mock.NewRows([]string{"id"}).AddRow(user_id))
+
+			ctx, rec := newContext(http.MethodPost, `{"email": "example@gmail.com", "role": "editor"}`, user_id)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(workspace_id)
+
+			_ = as.InviteWorkspaceMember(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, constants.CANNOT_INVITE_YOURSELF, rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Remove member", func(t *testing.T) {
+		t.Run("Last owner", func(t *testing.T) {
+			mock.ExpectQuery(findMemberQuery).WithArgs(workspace_id, user_id).WillReturnRows(memberRow(user_id, entity.RoleOwner))
+			mock.ExpectBegin()
+			mock.ExpectQuery(lockOwnersQuery).WithArgs(workspace_id, user_id).WillReturnRows(ownersRow(1, true))
+			mock.ExpectRollback()
+
+			ctx, rec := newContext(http.MethodDelete, "", user_id)
+			ctx.SetParamNames("id", "userId")
+			ctx.SetParamValues(workspace_id, user_id)
+
+			_ = as.RemoveWorkspaceMember(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, constants.LAST_WORKSPACE_OWNER, rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Member leaves", func(t *testing.T) {
+			mock.ExpectQuery(findMemberQuery).WithArgs(workspace_id, colleague_id).WillReturnRows(memberRow(colleague_id, entity.RoleEditor))
+			mock.ExpectBegin()
+			mock.ExpectQuery(lockOwnersQuery).WithArgs(workspace_id, colleague_id).WillReturnRows(ownersRow(1, false))
+			mock.ExpectExec(deleteMemberQuery).WithArgs(workspace_id, colleague_id).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			ctx, rec := newContext(http.MethodDelete, "", colleague_id)
+			ctx.SetParamNames("id", "userId")
+			ctx.SetParamValues(workspace_id, colleague_id)
+
+			_ = as.RemoveWorkspaceMember(ctx)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, constants.REMOVE_MEMBER_SUCCESSFULL, rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Editor cannot remove another member", func(t *testing.T) {
+			mock.ExpectQuery(workspaceRoleQuery).WithArgs(workspace_id, colleague_id).WillReturnRows(roleRow(entity.RoleEditor))
+
+			ctx, rec := newContext(http.MethodDelete, "", colleague_id)
+			ctx.SetParamNames("id", "userId")
+			ctx.SetParamValues(workspace_id, user_id)
+
+			_ = as.RemoveWorkspaceMember(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Respond to invitation of another user", func(t *testing.T) {
+		for status, respond := range map[string]echo.HandlerFunc{
+			entity.ShareStatusAccepted: as.AcceptWorkspaceInvitation,
+			entity.ShareStatusDeclined: as.DeclineWorkspaceInvitation,
+		} {
+			mock.ExpectQuery(respondQuery).WithArgs(status, sqlmock.AnyArg(), member_id, colleague_id).WillReturnRows(mock.NewRows(memberColumns))
+
+			ctx, rec := newContext(http.MethodPost, "", colleague_id)
+			ctx.SetParamNames("memberId")
+			ctx.SetParamValues(member_id)
+
+			_ = respond(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code, status)
+			assert.Equal(t, constants.INVITATION_NOT_FOUND, rec.Body.String(), status)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("Test Item of another workspace", func(t *testing.T) {
+		t.Run("Read", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).
+				WithArgs(item_id, workspace_id).
+				WillReturnRows(mock.NewRows(todoItemColumns))
+
+			ctx, rec := newContext(http.MethodGet, "", user_id)
+			ctx.Set(workspaceKey, workspace_id)
+			ctx.Set(workspaceRoleKey, entity.RoleOwner)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(item_id)
+
+			_ = as.FindById(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Delete", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, workspace_id).WillReturnRows(mock.NewRows([]string{"role"}))
+
+			ctx, rec := newContext(http.MethodDelete, "", user_id)
+			ctx.Set(workspaceKey, workspace_id)
+			ctx.Set(workspaceRoleKey, entity.RoleOwner)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(item_id)
+
+			_ = as.DeleteById(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Equal(t, constants.DOES_NOT_BELONG_TO_USER, rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
+
+func TestApiServiceAssignment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	var notifications []notification.Notification
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+		Notifier: notification.NotifierFunc(func(n notification.Notification) error {
+			notifications = append(notifications, n)
+			return nil
+		}),
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	assignee_id := "8c3e5f7a-9b1d-4e2f-a4c6-d8e0f2a4b6c8"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	roleQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	assignQuery := `UPDATE todo_items SET assignee_id=NULLIF\(\$1, ''\)`
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	newContext := func(method, body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, "/item/"+item_id+"/assignee", strings.NewReader(body))
+		token, rawToken := createJwtToken("example@gmail.com", user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(item_id)
+
+		return ctx, rec
+	}
+
+	itemRow := func(assignee, watchers string) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 2, "", 0, assignee, watchers, "", "", false, "", "")
+	}
+
+	t.Run("Test Assign item", func(t *testing.T) {
+		t.Run("Assignee without access", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(assignee_id))
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, assignee_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("viewer"))
+
+			ctx, rec := newContext(http.MethodPut, `{"email": "colleague@gmail.com"}`)
+
+			_ = as.AssignItemById(ctx)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Equal(t, constants.ASSIGNEE_WITHOUT_ACCESS, rec.Body.String())
+			assert.Empty(t, notifications)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).WillReturnRows(mock.NewRows([]string{"id"}).AddRow(assignee_id))
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, assignee_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("editor"))
+			mock.ExpectBegin()
+			mock.ExpectQuery(assignQuery).WithArgs(assignee_id, sqlmock.AnyArg(), item_id, "").WillReturnRows(itemRow(assignee_id, ""))
+			mock.ExpectExec("INSERT INTO item_revisions").
+				WithArgs(item_id, user_id, entity.RevisionAssigned, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			ctx, rec := newContext(http.MethodPut, `{"email": "colleague@gmail.com"}`)
+
+			err := as.AssignItemById(ctx)
+			assert.NoError(t, err)
+
+			var result entity.TodoItem
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, assignee_id, result.AssigneeId)
+			assert.Len(t, notifications, 1)
+			assert.Equal(t, notification.EventAssigned, notifications[0].Event)
+			assert.Equal(t, assignee_id, notifications[0].Recipient)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Unassign item", func(t *testing.T) {
+		notifications = nil
+
+		mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).WillReturnRows(itemRow(assignee_id, ""))
+		mock.ExpectBegin()
+		mock.ExpectQuery(assignQuery).WithArgs("", sqlmock.AnyArg(), item_id, "").WillReturnRows(itemRow("", ""))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(item_id, user_id, entity.RevisionUnassigned, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(http.MethodDelete, "")
+
+		err := as.UnassignItemById(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, notifications, 1)
+		assert.Equal(t, notification.EventUnassigned, notifications[0].Event)
+		assert.Equal(t, assignee_id, notifications[0].Recipient)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Watch item", func(t *testing.T) {
+		mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectExec(`INSERT INTO item_watchers`).WithArgs(item_id, user_id).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2`).WillReturnRows(itemRow("", user_id))
+
+		ctx, rec := newContext(http.MethodPost, "")
+
+		err := as.WatchItemById(ctx)
+		assert.NoError(t, err)
+
+		var result entity.TodoItem
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []string{user_id}, result.Watchers)
+		assert.NotEmpty(t, rec.Header().Get(HeaderETag))
+	})
+
+	t.Run("Test List items assigned to me", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false AND assignee_id=\$1`).
+			WithArgs(user_id, true, 5, "").
+			WillReturnRows(itemRow(user_id, ""))
+
+		ctx, rec := newContext(http.MethodPost, `{"limit": 5, "assigned_to_me": true, "created_by_me": true}`)
+
+		err := as.GetAllItems(ctx)
+		assert.NoError(t, err)
+
+		var result []entity.TodoItem
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, result, 1)
+		assert.Equal(t, user_id, result[0].AssigneeId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceDependencies(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	blocker_id := "7c1f2e3d-4b5a-4c6d-8e7f-9a0b1c2d3e4f"
+	roleQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	newContext := func(method, body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, "/item/"+item_id+"/dependencies", strings.NewReader(body))
+		token, rawToken := createJwtToken("example@gmail.com", user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id", "blockerId")
+		ctx.SetParamValues(item_id, blocker_id)
+
+		return ctx, rec
+	}
+
+	itemRow := func(blockedBy string, blocked bool) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 2, "", 0, "", "", "", blockedBy, blocked, "", "")
+	}
+
+	t.Run("Test Add dependency", func(t *testing.T) {
+		t.Run("Validation error", func(t *testing.T) {
+			ctx, rec := newContext(http.MethodPost, `{}`)
+
+			_ = as.AddItemDependency(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+
+		t.Run("Blocker not visible", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(roleQuery).WithArgs(blocker_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}))
+
+			ctx, rec := newContext(http.MethodPost, `{"blocker_id": "`+blocker_id+`"}`)
+
+			_ = as.AddItemDependency(ctx)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Cycle", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(roleQuery).WithArgs(blocker_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectBegin()
+			mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs("").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(`WITH RECURSIVE blockers`).WithArgs(blocker_id, item_id).WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(true))
+			mock.ExpectRollback()
+
+			ctx, rec := newContext(http.MethodPost, `{"blocker_id": "`+blocker_id+`"}`)
+
+			_ = as.AddItemDependency(ctx)
+
+			assert.Equal(t, http.StatusConflict, rec.Code)
+			assert.Equal(t, constants.DEPENDENCY_CYCLE, rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(roleQuery).WithArgs(blocker_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("viewer"))
+			mock.ExpectBegin()
+			mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs("").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(`WITH RECURSIVE blockers`).WithArgs(blocker_id, item_id).WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(false))
+			mock.ExpectExec(`INSERT INTO item_dependencies`).WithArgs(item_id, blocker_id, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2`).WillReturnRows(itemRow(blocker_id, true))
+			mock.ExpectCommit()
+
+			ctx, rec := newContext(http.MethodPost, `{"blocker_id": "`+blocker_id+`"}`)
+
+			err := as.AddItemDependency(ctx)
+			assert.NoError(t, err)
+
+			var result entity.TodoItem
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.True(t, result.Blocked)
+			assert.Equal(t, []string{blocker_id}, result.BlockedBy)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Complete a blocked item", func(t *testing.T) {
+		mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).WillReturnRows(itemRow(blocker_id, true))
+
+		ctx, rec := newContext(http.MethodPatch, "")
+
+		_ = as.UpdateStatustoCompleted(ctx)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, constants.ITEM_BLOCKED, rec.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Remove dependency", func(t *testing.T) {
+		t.Run("Not found", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("editor"))
+			mock.ExpectExec(`DELETE FROM item_dependencies`).WithArgs(item_id, blocker_id).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			ctx, rec := newContext(http.MethodDelete, "")
+
+			_ = as.RemoveItemDependency(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, constants.DEPENDENCY_NOT_FOUND, rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("editor"))
+			mock.ExpectExec(`DELETE FROM item_dependencies`).WithArgs(item_id, blocker_id).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2`).WillReturnRows(itemRow("", false))
+
+			ctx, rec := newContext(http.MethodDelete, "")
+
+			err := as.RemoveItemDependency(ctx)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NotEmpty(t, rec.Header().Get(HeaderETag))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
+
+func TestApiServiceBoard(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	after_id := "5c1d2e3f-6a7b-4c8d-9e0f-1a2b3c4d5e6f"
+	before_id := "7c1f2e3d-4b5a-4c6d-8e7f-9a0b1c2d3e4f"
+	roleQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	newContext := func(method, target, body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		token, rawToken := createJwtToken("example@gmail.com", user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(item_id)
+
+		return ctx, rec
+	}
+
+	itemRow := func(id, status, owner, rank string) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(id, "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, status == entity.StatusDone, false, owner, nil, status, nil, nil, 2, "", 0, "", "", "", "", false, rank, "")
+	}
+
+	findQuery := `SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`
+
+	t.Run("Test Get board", func(t *testing.T) {
+		t.Run("Invalid columns", func(t *testing.T) {
+			ctx, rec := newContext(http.MethodGet, "/item/board?columns=todo,later", "")
+
+			_ = as.GetBoard(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), "later")
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			rows := itemRow(item_id, entity.StatusTodo, user_id, "4").
+				AddRow(before_id, "Todo list item 2", "This is item 2", createdAt, "HIGH", createdAt, createdAt, true, false, user_id, nil, entity.StatusDone, nil, nil, 1, "", 0, "", "", "", "", false, "8", "")
+			mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false .+ ORDER BY rank, created_at, id`).
+				WithArgs(user_id, "").
+				WillReturnRows(rows)
+
+			ctx, rec := newContext(http.MethodGet, "/item/board?columns=todo,done", "")
+
+			err := as.GetBoard(ctx)
+			assert.NoError(t, err)
+
+			var result entity.Board
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, BoardGroupByStatus, result.GroupBy)
+			assert.Len(t, result.Columns, 2)
+			assert.Equal(t, item_id, result.Columns[0].Items[0].Id)
+			assert.Equal(t, before_id, result.Columns[1].Items[0].Id)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Move item", func(t *testing.T) {
+		t.Run("Neighbour in another list", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findQuery).WithArgs(item_id, "").WillReturnRows(itemRow(item_id, entity.StatusTodo, user_id, "2"))
+			mock.ExpectQuery(findQuery).WithArgs(after_id, "").WillReturnRows(itemRow(after_id, entity.StatusTodo, "someone else", "4"))
+
+			ctx, rec := newContext(http.MethodPost, "/item/"+item_id+"/move", `{"after_id": "`+after_id+`"}`)
+
+			_ = as.MoveItemById(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), after_id)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Reorder", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findQuery).WithArgs(item_id, "").WillReturnRows(itemRow(item_id, entity.StatusTodo, user_id, "2"))
+			mock.ExpectQuery(findQuery).WithArgs(after_id, "").WillReturnRows(itemRow(after_id, entity.StatusTodo, user_id, "4"))
+			mock.ExpectQuery(findQuery).WithArgs(before_id, "").WillReturnRows(itemRow(before_id, entity.StatusTodo, user_id, "8"))
+			mock.ExpectQuery(`UPDATE todo_items SET rank=\$1`).
+				WithArgs("6", sqlmock.AnyArg(), item_id, 2, "").
+				WillReturnRows(itemRow(item_id, entity.StatusTodo, user_id, "6"))
+
+			ctx, rec := newContext(http.MethodPost, "/item/"+item_id+"/move", `{"after_id": "`+after_id+`", "before_id": "`+before_id+`"}`)
+
+			err := as.MoveItemById(ctx)
+			assert.NoError(t, err)
+
+			var result entity.TodoItem
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "6", result.Rank)
+			assert.NotEmpty(t, rec.Header().Get(HeaderETag))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Neighbours sharing a rank", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findQuery).WithArgs(item_id, "").WillReturnRows(itemRow(item_id, entity.StatusTodo, user_id, "2"))
+			mock.ExpectQuery(findQuery).WithArgs(after_id, "").WillReturnRows(itemRow(after_id, entity.StatusTodo, user_id, "4"))
+			mock.ExpectQuery(findQuery).WithArgs(before_id, "").WillReturnRows(itemRow(before_id, entity.StatusTodo, user_id, "4"))
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id FROM todo_items WHERE rank=\$3`).
+				WithArgs(user_id, "", "4").
+				WillReturnRows(mock.NewRows([]string{"id"}).AddRow(after_id).AddRow(before_id))
+			mock.ExpectQuery(`SELECT COALESCE\(MIN\(rank\), ''\) FROM todo_items WHERE rank > \$3`).
+				WithArgs(user_id, "", "4").
+				WillReturnRows(mock.NewRows([]string{"rank"}).AddRow("8"))
+			mock.ExpectQuery(`UPDATE todo_items SET rank=\$1`).
+				WithArgs("6", sqlmock.AnyArg(), before_id, 0, "").
+				WillReturnRows(itemRow(before_id, entity.StatusTodo, user_id, "6"))
+			mock.ExpectCommit()
+			mock.ExpectQuery(findQuery).WithArgs(after_id, "").WillReturnRows(itemRow(after_id, entity.StatusTodo, user_id, "4"))
+			mock.ExpectQuery(findQuery).WithArgs(before_id, "").WillReturnRows(itemRow(before_id, entity.StatusTodo, user_id, "6"))
+			mock.ExpectQuery(`UPDATE todo_items SET rank=\$1`).
+				WithArgs("5", sqlmock.AnyArg(), item_id, 2, "").
+				WillReturnRows(itemRow(item_id, entity.StatusTodo, user_id, "5"))
+
+			ctx, rec := newContext(http.MethodPost, "/item/"+item_id+"/move", `{"after_id": "`+after_id+`", "before_id": "`+before_id+`"}`)
+
+			err := as.MoveItemById(ctx)
+			assert.NoError(t, err)
+
+			var result entity.TodoItem
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "5", result.Rank)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Neighbours out of order", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findQuery).WithArgs(item_id, "").WillReturnRows(itemRow(item_id, entity.StatusTodo, user_id, "2"))
+			mock.ExpectQuery(findQuery).WithArgs(after_id, "").WillReturnRows(itemRow(after_id, entity.StatusTodo, user_id, "8"))
+			mock.ExpectQuery(findQuery).WithArgs(before_id, "").WillReturnRows(itemRow(before_id, entity.StatusTodo, user_id, "4"))
+
+			ctx, rec := newContext(http.MethodPost, "/item/"+item_id+"/move", `{"after_id": "`+after_id+`", "before_id": "`+before_id+`"}`)
+
+			_ = as.MoveItemById(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Invalid status transition", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findQuery).WithArgs(item_id, "").WillReturnRows(itemRow(item_id, entity.StatusCancelled, user_id, "2"))
+
+			ctx, rec := newContext(http.MethodPost, "/item/"+item_id+"/move", `{"column": "done"}`)
+
+			_ = as.MoveItemById(ctx)
+
+			assert.Equal(t, http.StatusConflict, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("To another column", func(t *testing.T) {
+			mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+			mock.ExpectQuery(findQuery).WithArgs(item_id, "").WillReturnRows(itemRow(item_id, entity.StatusTodo, user_id, "2"))
+			mock.ExpectQuery(findQuery).WithArgs(before_id, "").WillReturnRows(itemRow(before_id, entity.StatusInProgress, user_id, "8"))
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE todo_items SET status=\$1::text`).
+				WithArgs(entity.StatusInProgress, sqlmock.AnyArg(), item_id, 2, "").
+				WillReturnRows(itemRow(item_id, entity.StatusInProgress, user_id, "2"))
+			mock.ExpectQuery(`UPDATE todo_items SET rank=\$1`).
+				WithArgs("4", sqlmock.AnyArg(), item_id, 0, "").
+				WillReturnRows(itemRow(item_id, entity.StatusInProgress, user_id, "4"))
+			mock.ExpectExec("INSERT INTO item_revisions").
+				WithArgs(item_id, user_id, entity.RevisionStatusChanged, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			ctx, rec := newContext(http.MethodPost, "/item/"+item_id+"/move", `{"column": "in_progress", "before_id": "`+before_id+`"}`)
+
+			err := as.MoveItemById(ctx)
+			assert.NoError(t, err)
+
+			var result entity.TodoItem
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, entity.StatusInProgress, result.Status)
+			assert.Equal(t, "4", result.Rank)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+}
+
+func TestApiServiceFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	filter_id := "2e7d4c1b-9a8f-4b6e-a5d3-c1b2a3f4e5d6"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	filterColumns := []string{"id", "user_id", "name", "expression", "created_at", "updated_at"}
+
+	newContext := func(method, target, body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		token, rawToken := createJwtToken("example@gmail.com", user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(filter_id)
+
+		return ctx, rec
+	}
+
+	t.Run("Test Create saved filter", func(t *testing.T) {
+		t.Run("Invalid expression", func(t *testing.T) {
+			ctx, rec := newContext(http.MethodPost, "/item/filters", `{"name": "Urgent", "expression": "priority=urgent"}`)
+
+			_ = as.CreateSavedFilter(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), `invalid priority "urgent"`)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(`INSERT INTO saved_filters`).
+				WithArgs(sqlmock.AnyArg(), user_id, "Urgent", "priority=HIGH", sqlmock.AnyArg()).
+				WillReturnRows(mock.NewRows(filterColumns).AddRow(filter_id, user_id, "Urgent", "priority=HIGH", createdAt, createdAt))
+
+			ctx, rec := newContext(http.MethodPost, "/item/filters", `{"name": "Urgent", "expression": "priority=HIGH"}`)
+
+			err := as.CreateSavedFilter(ctx)
+			assert.NoError(t, err)
+
+			var result entity.SavedFilter
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, filter_id, result.Id)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Update saved filter of another user", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE saved_filters SET name=\$1, expression=\$2, updated_at=\$3 WHERE id=\$4 AND user_id=\$5`).
+			WithArgs("Urgent", "priority=HIGH", sqlmock.AnyArg(), filter_id, user_id).
+			WillReturnRows(mock.NewRows(filterColumns))
+
+		ctx, rec := newContext(http.MethodPut, "/item/filters/"+filter_id, `{"name": "Urgent", "expression": "priority=HIGH"}`)
+
+		_ = as.UpdateSavedFilterById(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, constants.FILTER_NOT_FOUND, rec.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get filtered items", func(t *testing.T) {
+		t.Run("Not found", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT .+ FROM saved_filters WHERE id=\$1 AND user_id=\$2`).
+				WithArgs(filter_id, user_id).
+				WillReturnRows(mock.NewRows(filterColumns))
+
+			ctx, rec := newContext(http.MethodGet, "/item/filters/"+filter_id+"/items", "")
+
+			_ = as.GetFilteredItems(ctx)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			mock.ExpectQuery(`SELECT .+ FROM saved_filters WHERE id=\$1 AND user_id=\$2`).
+				WithArgs(filter_id, user_id).
+				WillReturnRows(mock.NewRows(filterColumns).AddRow(filter_id, user_id, "Open", "status!=done", createdAt, createdAt))
+			mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE .+ AND status <> \$4 ORDER BY rank, created_at, id LIMIT \$3`).
+				WithArgs(user_id, "", 5, "done").
+				WillReturnRows(mock.NewRows(todoItemColumns).
+					AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Todo list item 1", "This is item 1", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", ""))
+
+			ctx, rec := newContext(http.MethodGet, "/item/filters/"+filter_id+"/items?limit=5", "")
+
+			err := as.GetFilteredItems(ctx)
+			assert.NoError(t, err)
+
+			var result []entity.TodoItem
+			err = json.Unmarshal(rec.Body.Bytes(), &result)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Len(t, result, 1)
+			assert.NotEmpty(t, rec.Header().Get(HeaderETag))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
+	t.Run("Test Delete saved filter", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM saved_filters`).
+			WithArgs(filter_id, user_id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		ctx, rec := newContext(http.MethodDelete, "/item/filters/"+filter_id, "")
+
+		_ = as.DeleteSavedFilterById(ctx)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, constants.DELETE_SAVED_FILTER_SUCCESSFULL, rec.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceQuickAdd(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	newContext := func(body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPost, "/item/quick", strings.NewReader(body))
+		token, rawToken := createJwtToken("example@gmail.com", user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+
+		return ctx, rec
+	}
+
+	itemRow := func(labels, recurrence string) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Pay rent", "", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, labels, 0, "", "", "", "", false, "", recurrence)
+	}
+
+	t.Run("Test Invalid timezone", func(t *testing.T) {
+		ctx, rec := newContext(`{"text": "Pay rent tomorrow", "timezone": "Mars/Olympus"}`)
+
+		_ = as.QuickAddItem(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Mars/Olympus")
+	})
+
+	t.Run("Test Name too short", func(t *testing.T) {
+		ctx, rec := newContext(`{"text": "Go tomorrow !high"}`)
+
+		_ = as.QuickAddItem(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Test Preview", func(t *testing.T) {
+		ctx, rec := newContext(`{"text": "Pay rent tomorrow 9am !high #finance", "timezone": "Europe/Berlin", "preview": true}`)
+
+		err := as.QuickAddItem(ctx)
+		assert.NoError(t, err)
+
+		var result entity.QuickAddResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Nil(t, result.Item)
+		assert.Equal(t, "Pay rent", result.Parsed.Name)
+		assert.Equal(t, 9, result.Parsed.DueDate.Hour())
+		assert.Len(t, result.Tokens, 4)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Create", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO todo_items").
+			WithArgs(sqlmock.AnyArg(), "Pay rent", "", sqlmock.AnyArg(), "HIGH", sqlmock.AnyArg(), sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg()).
+			WillReturnRows(itemRow("", ""))
+		mock.ExpectExec(`INSERT INTO item_labels`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2`).
+			WillReturnRows(itemRow("finance", ""))
+		mock.ExpectQuery(`UPDATE todo_items SET recurrence=NULLIF\(\$1, ''\)`).
+			WithArgs("FREQ=MONTHLY", sqlmock.AnyArg(), item_id, "").
+			WillReturnRows(itemRow("finance", "FREQ=MONTHLY"))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(item_id, user_id, entity.RevisionCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(`{"text": "Pay rent tomorrow 9am !high #finance every month"}`)
+
+		err := as.QuickAddItem(ctx)
+		assert.NoError(t, err)
+
+		var result entity.QuickAddResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, item_id, result.Item.Id)
+		assert.Equal(t, []string{"finance"}, result.Item.Labels)
+		assert.Equal(t, "FREQ=MONTHLY", result.Item.Recurrence)
+		assert.NotEmpty(t, rec.Header().Get(HeaderETag))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceTemplates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	template_id := "9b2e4c6a-1d3f-4a5b-8c7d-0e1f2a3b4c5d"
+	first_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	second_id := "7c1f2e3d-4b5a-4c6d-8e7f-9a0b1c2d3e4f"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	templateColumns := []string{"id", "user_id", "name", "items", "created_at"}
+	items := `[{"name":"Collect receipts","priority":"MEDIUM","labels":["finance"],"due_in_days":0},
+		{"name":"Reconcile accounts","priority":"HIGH","due_in_days":2,"due_time":"17:00","blocked_by":[0]}]`
+
+	newContext := func(method, body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, "/templates", strings.NewReader(body))
+		token, rawToken := createJwtToken("example@gmail.com", user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(template_id)
+
+		return ctx, rec
+	}
+
+	itemRow := func(id, name string, labels, blockedBy string) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(id, name, "", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, labels, 0, "", "", "", blockedBy, blockedBy != "", "", "")
+	}
+
+	t.Run("Test Create template with a cycle", func(t *testing.T) {
+		ctx, rec := newContext(http.MethodPost, `{"name": "Monthly close", "items": [
+			{"name": "Collect receipts", "priority": "MEDIUM", "blocked_by": [1]},
+			{"name": "Reconcile accounts", "priority": "HIGH", "blocked_by": [0]}]}`)
+
+		_ = as.CreateTemplate(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "cycle")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Create template", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO item_templates").
+			WithArgs(sqlmock.AnyArg(), user_id, "Monthly close", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(templateColumns).AddRow(template_id, user_id, "Monthly close", []byte(items), createdAt))
+
+		ctx, rec := newContext(http.MethodPost, `{"name": "Monthly close", "items": `+items+`}`)
+
+		err := as.CreateTemplate(ctx)
+		assert.NoError(t, err)
+
+		var result entity.Template
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Len(t, result.Items, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Instantiate with an invalid base date", func(t *testing.T) {
+		ctx, rec := newContext(http.MethodPost, `{"base_date": "01.11.2023"}`)
+
+		_ = as.InstantiateTemplate(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Instantiate a template of another user", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM item_templates WHERE id=\$1 AND user_id=\$2`).
+			WithArgs(template_id, user_id).
+			WillReturnRows(mock.NewRows(templateColumns))
+
+		ctx, rec := newContext(http.MethodPost, `{"base_date": "2023-11-01"}`)
+
+		_ = as.InstantiateTemplate(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Instantiate", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM item_templates WHERE id=\$1 AND user_id=\$2`).
+			WithArgs(template_id, user_id).
+			WillReturnRows(mock.NewRows(templateColumns).AddRow(template_id, user_id, "Monthly close", []byte(items), createdAt))
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO todo_items").
+			WithArgs(sqlmock.AnyArg(), "Collect receipts", "", "2023-11-01T22:59:59Z", "MEDIUM", sqlmock.AnyArg(), sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg()).
+			WillReturnRows(itemRow(first_id, "Collect receipts", "", ""))
+		mock.ExpectExec(`INSERT INTO item_labels`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2`).
+			WillReturnRows(itemRow(first_id, "Collect receipts", "finance", ""))
+		mock.ExpectQuery("INSERT INTO todo_items").
+			WithArgs(sqlmock.AnyArg(), "Reconcile accounts", "", "2023-11-03T16:00:00Z", "HIGH", sqlmock.AnyArg(), sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg()).
+			WillReturnRows(itemRow(second_id, "Reconcile accounts", "", ""))
+		mock.ExpectExec(`INSERT INTO item_dependencies`).
+			WithArgs(second_id, first_id, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2`).
+			WithArgs(sqlmock.AnyArg(), second_id, "").
+			WillReturnRows(itemRow(second_id, "Reconcile accounts", "", first_id))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(first_id, user_id, entity.RevisionCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(second_id, user_id, entity.RevisionCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(http.MethodPost, `{"base_date": "2023-11-01", "timezone": "Europe/Berlin"}`)
+
+		err := as.InstantiateTemplate(ctx)
+		assert.NoError(t, err)
+
+		var result []entity.TodoItem
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Len(t, result, 2)
+		assert.Equal(t, []string{"finance"}, result[0].Labels)
+		assert.Equal(t, []string{first_id}, result[1].BlockedBy)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceImportExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	newContext := func(method, target string, body io.Reader, contentType string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, target, body)
+		token, rawToken := createJwtToken("example@gmail.com", user_id)
+
+		req.Header.Set(echo.HeaderContentType, contentType)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+
+		return ctx, rec
+	}
+
+	newImport := func(content string, fields map[string]string) (echo.Context, *httptest.ResponseRecorder) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for name, value := range fields {
+			_ = writer.WriteField(name, value)
+		}
+		part, _ := writer.CreateFormFile("file", "items.csv")
+		_, _ = part.Write([]byte(content))
+		_ = writer.Close()
+
+		return newContext(http.MethodPost, "/item/import", body, writer.FormDataContentType())
+	}
+
+	itemRow := func() *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Pay rent", "Flat, March", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", "")
+	}
+
+	t.Run("Test Export unsupported format", func(t *testing.T) {
+		ctx, rec := newContext(http.MethodGet, "/item/export?format=xlsx", nil, echo.MIMEApplicationJSON)
+
+		_ = as.ExportItems(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Test Export", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false`).
+			WithArgs(user_id, "").
+			WillReturnRows(itemRow())
+
+		ctx, rec := newContext(http.MethodGet, "/item/export?format=csv", nil, echo.MIMEApplicationJSON)
+
+		err := as.ExportItems(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, "name,description,due_date,priority,status,created_at,updated_at,started_at,completed_at\n"+
+			"Pay rent,\"Flat, March\",2023-10-15T09:38:24Z,HIGH,todo,2023-10-15T09:38:24Z,2023-10-15T09:38:24Z,,\n", rec.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import with a missing column", func(t *testing.T) {
+		ctx, rec := newImport("Title,description,due_date,priority\nPay rent,Flat,2023-10-15,HIGH\n", nil)
+
+		_ = as.ImportItems(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Invalid column mapping")
+	})
+
+	t.Run("Test Import reports the invalid rows", func(t *testing.T) {
+		ctx, rec := newImport("Title,description,due_date,priority\nPay rent,Flat,2023-10-15,HIGH\nGo,Gym,2023-10-16,HIGH\nCall mom,Phone,someday,LOW\n",
+			map[string]string{"mapping": `{"name": "Title"}`})
+
+		err := as.ImportItems(ctx)
+		assert.NoError(t, err)
+
+		var result entity.ImportResultDto
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, 3, result.Rows)
+		assert.Equal(t, 0, result.Imported)
+		assert.Len(t, result.Errors, 2)
+		assert.Equal(t, 3, result.Errors[0].Row)
+		assert.Equal(t, 4, result.Errors[1].Row)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import dry run", func(t *testing.T) {
+		ctx, rec := newImport("name,description,due_date,priority\nPay rent,Flat,2023-10-15,HIGH\n", map[string]string{"dry_run": "true"})
+
+		err := as.ImportItems(ctx)
+		assert.NoError(t, err)
+
+		var result entity.ImportResultDto
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, 1, result.Imported)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO todo_items`).
+			WithArgs(sqlmock.AnyArg(), "Pay rent", "Flat, March", "2023-10-15T07:38:00Z", "HIGH", sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg(), entity.StatusTodo).
+			WillReturnRows(itemRow())
+		mock.ExpectExec(`INSERT INTO item_revisions`).
+			WithArgs(item_id, user_id, entity.RevisionCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newImport("name,description,due_date,priority\nPay rent,\"Flat, March\",2023-10-15 09:38,high\n", map[string]string{"timezone": "Europe/Berlin"})
+
+		err := as.ImportItems(ctx)
+		assert.NoError(t, err)
+
+		var result entity.ImportResultDto
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, 1, result.Imported)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("Test Export todo.txt", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false`).
+			WithArgs(user_id, "").
+			WillReturnRows(itemRow())
+
+		ctx, rec := newContext(http.MethodGet, "/item/export?format=txt", nil, echo.MIMEApplicationJSON)
+
+		err := as.ExportItems(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), "todo.txt")
+		assert.Equal(t, "(A) 2023-10-15 Pay rent due:2023-10-15\n", rec.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import unsupported format", func(t *testing.T) {
+		ctx, rec := newImport("Pay rent", map[string]string{"format": "xlsx"})
+
+		_ = as.ImportItems(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "Unsupported import format: xlsx", rec.Body.String())
+	})
+
+	t.Run("Test Import todo.txt with a mapping", func(t *testing.T) {
+		ctx, rec := newImport("Pay rent", map[string]string{"format": "txt", "mapping": `{"name": "Title"}`})
+
+		_ = as.ImportItems(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Invalid column mapping")
+	})
+
+	t.Run("Test Import Markdown reports the invalid lines", func(t *testing.T) {
+		ctx, rec := newImport("# Chores\n- [ ] Pay rent\n- [x] Go\n", map[string]string{"format": "md", "dry_run": "true"})
+
+		err := as.ImportItems(ctx)
+		assert.NoError(t, err)
+
+		var result entity.ImportResultDto
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, 2, result.Rows)
+		assert.Len(t, result.Errors, 1)
+		assert.Equal(t, 3, result.Errors[0].Row)
+	})
+
+	t.Run("Test Import todo.txt", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO todo_items`).
+			WithArgs(sqlmock.AnyArg(), "Pay rent", "", "2023-10-15T21:59:59Z", "HIGH", sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg(), entity.StatusDone).
+			WillReturnRows(itemRow())
+		mock.ExpectExec(`INSERT INTO item_labels`).
+			WithArgs(sqlmock.AnyArg(), "home").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO item_revisions`).
+			WithArgs(item_id, user_id, entity.RevisionCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newImport("x 2023-10-16 2023-10-01 Pay rent +home due:2023-10-15 pri:A\n", map[string]string{"format": "txt", "timezone": "Europe/Berlin"})
+
+		err := as.ImportItems(ctx)
+		assert.NoError(t, err)
+
+		var result entity.ImportResultDto
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, 1, result.Imported)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	newAppImport := func(source, content string, fields map[string]string) (echo.Context, *httptest.ResponseRecorder) {
+		ctx, rec := newImport(content, fields)
+		ctx.SetPath("/item/import/:source")
+		ctx.SetParamNames("source")
+		ctx.SetParamValues(source)
+
+		return ctx, rec
+	}
+
+	backup := func(priority int) string {
+		return fmt.Sprintf(`{"projects": [], "items": [
+			{"id": "6Jf8VQXxpwv56VQ7", "content": "Pay rent", "description": "Flat, March", "priority": %d, "due": {"date": "2023-10-15T09:38:24Z"}},
+			{"id": "2995104339", "content": "Call mom", "priority": 1, "due": {"date": "2023-10-16"}}
+		]}`, priority)
+	}
+
+	expectSources := func() {
+		mock.ExpectQuery(`SELECT item_id, source_id FROM item_sources`).
+			WithArgs("todoist", sqlmock.AnyArg(), user_id, "").
+			WillReturnRows(mock.NewRows([]string{"item_id", "source_id"}).AddRow(item_id, "6Jf8VQXxpwv56VQ7"))
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id = ANY\(\$1\)`).
+			WillReturnRows(itemRow())
+	}
+
+	t.Run("Test Import from an unsupported app", func(t *testing.T) {
+		ctx, rec := newAppImport("trello", "{}", nil)
+
+		_ = as.ImportAppItems(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "Unsupported import source: trello", rec.Body.String())
+	})
+
+	t.Run("Test Import an invalid Todoist backup", func(t *testing.T) {
+		ctx, rec := newAppImport("todoist", "Pay rent", nil)
+
+		_ = as.ImportAppItems(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Invalid todoist export")
+	})
+
+	t.Run("Test Import from Todoist dry run", func(t *testing.T) {
+		expectSources()
+
+		ctx, rec := newAppImport("todoist", backup(2), map[string]string{"dry_run": "true"})
+
+		err := as.ImportAppItems(ctx)
+		assert.NoError(t, err)
+
+		var result entity.AppImportResultDto
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "todoist", result.Source)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, 1, result.Created)
+		assert.Equal(t, 1, result.Updated)
+		assert.Equal(t, entity.ImportActionUpdate, result.Changes[0].Action)
+		assert.Equal(t, item_id, result.Changes[0].ItemId)
+		assert.Equal(t, []entity.AppImportField{{Field: "priority", From: "HIGH", To: "LOW"}}, result.Changes[0].Fields)
+		assert.Equal(t, entity.ImportActionCreate, result.Changes[1].Action)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import from Todoist again leaves unchanged items alone", func(t *testing.T) {
+		expectSources()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO todo_items`).
+			WithArgs(sqlmock.AnyArg(), "Call mom", "", "2023-10-16T23:59:59Z", "LOW", sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg(), entity.StatusTodo).
+			WillReturnRows(itemRow())
+		mock.ExpectExec(`INSERT INTO item_revisions`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO item_sources`).
+			WithArgs(sqlmock.AnyArg(), user_id, "", "todoist", "2995104339", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newAppImport("todoist", backup(4), nil)
+
+		err := as.ImportAppItems(ctx)
+		assert.NoError(t, err)
+
+		var result entity.AppImportResultDto
+		err = json.Unmarshal(rec.Body.Bytes(), &result)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, 1, result.Created)
+		assert.Equal(t, 1, result.Unchanged)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import from Todoist updates the items imported before", func(t *testing.T) {
+		expectSources()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO todo_items`).
+			WillReturnRows(itemRow())
+		mock.ExpectExec(`INSERT INTO item_revisions`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO item_sources`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET priority=\$1`).
+			WithArgs("MEDIUM", sqlmock.AnyArg(), item_id, 1, "").
+			WillReturnRows(itemRow())
+		mock.ExpectExec(`INSERT INTO item_revisions`).
+			WithArgs(item_id, user_id, entity.RevisionUpdated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newAppImport("todoist", backup(3), nil)
+
+		err := as.ImportAppItems(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceCalendar(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	workspace_id := "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	feed_id := "4d8e2f1a-6b3c-4a5d-9e7f-0a1b2c3d4e5f"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	feedColumns := []string{"id", "user_id", "workspace_id", "created_at"}
+
+	newContext := func(method, target string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, target, nil)
+		token, rawToken := createJwtToken("example@gmail.com", user_id)
+
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+
+		return ctx, rec
+	}
+
+	newFeedContext := func(target, token string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("token")
+		ctx.SetParamValues(token)
+
+		return ctx, rec
+	}
+
+	t.Run("Test Create calendar feed", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO calendar_feeds`).
+			WithArgs(sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(feedColumns).AddRow(feed_id, user_id, "", createdAt))
+
+		ctx, rec := newContext(http.MethodPost, "/item/calendar")
+
+		err := as.CreateCalendarFeed(ctx)
+		assert.NoError(t, err)
+
+		var response entity.CalendarFeedResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Len(t, response.Token, 43)
+		assert.Equal(t, "http://example.com/calendar/"+response.Token+".ics", response.Url)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Delete missing calendar feed", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM calendar_feeds`).
+			WithArgs(user_id, "").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		ctx, rec := newContext(http.MethodDelete, "/item/calendar")
+
+		_ = as.DeleteCalendarFeed(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get calendar feed without the ics suffix", func(t *testing.T) {
+		ctx, rec := newFeedContext("/calendar/secret", "secret")
+
+		_ = as.GetCalendarFeed(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Test Get calendar feed of an unknown token", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM calendar_feeds WHERE token_hash=\$1`).
+			WithArgs(calendarTokenHash("secret")).
+			WillReturnRows(mock.NewRows(feedColumns))
+
+		ctx, rec := newFeedContext("/calendar/secret.ics", "secret.ics")
+
+		_ = as.GetCalendarFeed(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get calendar feed of a workspace the user left", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM calendar_feeds WHERE token_hash=\$1`).
+			WithArgs(calendarTokenHash("secret")).
+			WillReturnRows(mock.NewRows(feedColumns).AddRow(feed_id, user_id, workspace_id, createdAt))
+		mock.ExpectQuery(`SELECT role FROM workspace_members`).
+			WithArgs(workspace_id, user_id).
+			WillReturnRows(mock.NewRows([]string{"role"}))
+
+		ctx, rec := newFeedContext("/calendar/secret.ics", "secret.ics")
+
+		_ = as.GetCalendarFeed(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get calendar feed", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM calendar_feeds WHERE token_hash=\$1`).
+			WithArgs(calendarTokenHash("secret")).
+			WillReturnRows(mock.NewRows(feedColumns).AddRow(feed_id, user_id, "", createdAt))
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false`).
+			WithArgs(user_id, "").
+			WillReturnRows(mock.NewRows(todoItemColumns).
+				AddRow(item_id, "Pay rent", "Flat, March", createdAt, "MEDIUM", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, "", 0, "", "", "", "", false, "", ""))
+
+		ctx, rec := newFeedContext("/calendar/secret.ics", "secret.ics")
+
+		err := as.GetCalendarFeed(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+		assert.Contains(t, rec.Body.String(), "SUMMARY:Pay rent\r\n")
+		assert.Contains(t, rec.Body.String(), "PRIORITY:5\r\n")
+		assert.Contains(t, rec.Body.String(), "STATUS:NEEDS-ACTION\r\n")
+		assert.True(t, strings.HasSuffix(rec.Body.String(), "END:VCALENDAR\r\n"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceCalDAV(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	workspace_id := "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	ownerQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	listItemsQuery := `SELECT .+ FROM todo_items WHERE is_deleted = false AND .+ AND id = ANY\(\$3\)`
+	changeTagQuery := `SELECT COUNT\(\*\), COALESCE\(SUM\(version\), 0\), MAX\(updated_at\) FROM todo_items`
+
+	newContext := func(method, target, body string, params ...string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		token, _ := createJwtToken("example@gmail.com", user_id)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+		ctx.SetParamNames("calendar", "name")
+		ctx.SetParamValues(append(params, "", "")[:2]...)
+
+		return ctx, rec
+	}
+
+	item := entity.TodoItem{
+		Id:        item_id,
+		UserId:    user_id,
+		Item:      entity.TodoItemDto{Name: "Pay rent", Details: entity.TodoItemDetailsDto{DueDate: createdAt, Priority: "HIGH"}},
+		Status:    entity.StatusTodo,
+		Version:   1,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	_, etag := calDAVObject(item)
+
+	itemRow := func(status string, labels string) *sqlmock.Rows {
+		return mock.NewRows(todoItemColumns).
+			AddRow(item_id, "Pay rent", "", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, status, nil, nil, 1, labels, 0, "", "", "", "", false, "", "")
+	}
+
+	todo := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:" + item_id + "\r\nSUMMARY:Pay rent\r\nDUE:20231015T093824Z\r\nPRIORITY:1\r\nCATEGORIES:home\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+
+	t.Run("Test Options", func(t *testing.T) {
+		ctx, rec := newContext(http.MethodOptions, "/caldav/personal/", "", "personal")
+
+		err := as.CalDAVCalendar(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "1, 3, calendar-access", rec.Header().Get("DAV"))
+		assert.Contains(t, rec.Header().Get(echo.HeaderAllow), "REPORT")
+	})
+
+	t.Run("Test Home with its calendars", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT workspaces.id,workspaces.name`).
+			WithArgs(user_id).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "owner_id", "role", "created_at"}).AddRow(workspace_id, "Team", user_id, "viewer", createdAt))
+		mock.ExpectQuery(changeTagQuery).
+			WithArgs(user_id, "").
+			WillReturnRows(mock.NewRows([]string{"count", "sum", "max"}).AddRow(1, 1, createdAt))
+		mock.ExpectQuery(changeTagQuery).
+			WithArgs(user_id, workspace_id).
+			WillReturnRows(mock.NewRows([]string{"count", "sum", "max"}).AddRow(0, 0, nil))
+
+		ctx, rec := newContext(echo.PROPFIND, "/caldav/", "")
+		ctx.Request().Header.Set("Depth", "1")
+
+		err := as.CalDAVHome(ctx)
+		assert.NoError(t, err)
+
+		var response davMultistatus
+		assert.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &response))
+
+		assert.Equal(t, http.StatusMultiStatus, rec.Code)
+		assert.Len(t, response.Responses, 3)
+		assert.Equal(t, "/caldav/personal/", response.Responses[1].Href)
+		assert.Equal(t, "/caldav/"+workspace_id+"/", response.Responses[2].Href)
+		assert.Contains(t, rec.Body.String(), `<getctag xmlns="http://calendarserver.org/ns/">&#34;1-1-1697362704&#34;</getctag>`)
+		assert.Equal(t, 1, strings.Count(rec.Body.String(), "<write-content"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Calendar of a workspace the user is not a member of", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT role FROM workspace_members`).
+			WithArgs(workspace_id, user_id).
+			WillReturnRows(mock.NewRows([]string{"role"}))
+
+		ctx, rec := newContext(echo.PROPFIND, "/caldav/"+workspace_id+"/", "", workspace_id)
+
+		_ = as.CalDAVCalendar(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Calendar with its tasks", func(t *testing.T) {
+		mock.ExpectQuery(changeTagQuery).
+			WithArgs(user_id, "").
+			WillReturnRows(mock.NewRows([]string{"count", "sum", "max"}).AddRow(1, 1, createdAt))
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false`).
+			WithArgs(user_id, "").
+			WillReturnRows(itemRow("todo", ""))
+
+		ctx, rec := newContext(echo.PROPFIND, "/caldav/personal/", `<d:propfind xmlns:d="DAV:"><d:prop><d:getetag/></d:prop></d:propfind>`, "personal")
+		ctx.Request().Header.Set("Depth", "1")
+
+		err := as.CalDAVCalendar(ctx)
+		assert.NoError(t, err)
+
+		var response davMultistatus
+		assert.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &response))
+
+		assert.Equal(t, http.StatusMultiStatus, rec.Code)
+		assert.Len(t, response.Responses, 2)
+		assert.Equal(t, "/caldav/personal/"+item_id+".ics", response.Responses[1].Href)
+		assert.Equal(t, etag, response.Responses[1].Propstats[0].Prop.Props[0].Text)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Multiget", func(t *testing.T) {
+		mock.ExpectQuery(listItemsQuery).
+			WillReturnRows(itemRow("todo", ""))
+
+		ctx, rec := newContext(echo.REPORT, "/caldav/personal/", `<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+			<d:prop><d:getetag/><c:calendar-data/></d:prop>
+			<d:href>/caldav/personal/`+item_id+`.ics</d:href>
+			<d:href>/caldav/personal/missing.ics</d:href>
+			<d:href>/caldav/`+workspace_id+`/`+item_id+`.ics</d:href>
+			</c:calendar-multiget>`, "personal")
+
+		err := as.CalDAVCalendar(ctx)
+		assert.NoError(t, err)
+
+		var response davMultistatus
+		assert.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &response))
+
+		assert.Equal(t, http.StatusMultiStatus, rec.Code)
+		assert.Len(t, response.Responses, 3)
+		assert.Contains(t, response.Responses[0].Propstats[0].Prop.Props[1].Text, "UID:"+item_id+"\r\n")
+		assert.Equal(t, davStatusNotFound, response.Responses[1].Status)
+		assert.Equal(t, davStatusNotFound, response.Responses[2].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Unsupported report", func(t *testing.T) {
+		ctx, rec := newContext(echo.REPORT, "/caldav/personal/", `<d:sync-collection xmlns:d="DAV:"/>`, "personal")
+
+		_ = as.CalDAVCalendar(ctx)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Test Get task", func(t *testing.T) {
+		mock.ExpectQuery(listItemsQuery).
+			WillReturnRows(itemRow("todo", ""))
+
+		ctx, rec := newContext(http.MethodGet, "/caldav/personal/"+item_id+".ics", "", "personal", item_id+".ics")
+
+		err := as.CalDAVObject(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, etag, rec.Header().Get(HeaderETag))
+		assert.Contains(t, rec.Body.String(), "BEGIN:VTODO\r\nUID:"+item_id+"\r\n")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get unchanged task", func(t *testing.T) {
+		mock.ExpectQuery(listItemsQuery).
+			WillReturnRows(itemRow("todo", ""))
+
+		ctx, rec := newContext(http.MethodGet, "/caldav/personal/"+item_id+".ics", "", "personal", item_id+".ics")
+		ctx.Request().Header.Set(HeaderIfNoneMatch, etag)
+
+		_ = as.CalDAVObject(ctx)
+
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Get task with an invalid name", func(t *testing.T) {
+		ctx, rec := newContext(http.MethodGet, "/caldav/personal/x'.ics", "", "personal", "x'.ics")
+
+		_ = as.CalDAVObject(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Test Put invalid calendar data", func(t *testing.T) {
+		mock.ExpectQuery(listItemsQuery).
+			WillReturnRows(mock.NewRows(todoItemColumns))
+
+		ctx, rec := newContext(http.MethodPut, "/caldav/personal/"+item_id+".ics", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n", "personal", item_id+".ics")
+
+		_ = as.CalDAVObject(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "VEVENT")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Put new task", func(t *testing.T) {
+		mock.ExpectQuery(listItemsQuery).
+			WillReturnRows(mock.NewRows(todoItemColumns))
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO todo_items").
+			WithArgs(item_id, "Pay rent", "", sqlmock.AnyArg(), "HIGH", sqlmock.AnyArg(), sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg()).
+			WillReturnRows(itemRow("todo", ""))
+		mock.ExpectExec(`INSERT INTO item_labels`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`UPDATE todo_items SET updated_at=\$1 WHERE id=\$2`).
+			WillReturnRows(itemRow("todo", "home"))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(item_id, user_id, entity.RevisionCreated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(http.MethodPut, "/caldav/personal/"+item_id+".ics", todo, "personal", item_id+".ics")
+		ctx.Request().Header.Set(HeaderIfNoneMatch, "*")
+
+		err := as.CalDAVObject(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(HeaderETag))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Put task that was changed", func(t *testing.T) {
+		mock.ExpectQuery(listItemsQuery).
+			WillReturnRows(itemRow("todo", ""))
+
+		ctx, rec := newContext(http.MethodPut, "/caldav/personal/"+item_id+".ics", todo, "personal", item_id+".ics")
+		ctx.Request().Header.Set(HeaderIfMatch, `"0000000000000000"`)
+
+		_ = as.CalDAVObject(ctx)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Put completed task", func(t *testing.T) {
+		mock.ExpectQuery(listItemsQuery).
+			WillReturnRows(itemRow("todo", "home"))
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE todo_items SET status=\$1::text`).
+			WithArgs(entity.StatusDone, sqlmock.AnyArg(), item_id, 1, "").
+			WillReturnRows(itemRow("done", "home"))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(item_id, user_id, entity.RevisionStatusChanged, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(http.MethodPut, "/caldav/personal/"+item_id+".ics", strings.Replace(todo, "END:VTODO", "STATUS:COMPLETED\r\nEND:VTODO", 1), "personal", item_id+".ics")
+
+		err := as.CalDAVObject(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Delete task", func(t *testing.T) {
+		mock.ExpectQuery(listItemsQuery).
+			WillReturnRows(itemRow("todo", ""))
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE todo_items SET is_deleted = 'true'`).
+			WithArgs(sqlmock.AnyArg(), item_id, 1, "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).
+			WillReturnRows(itemRow("todo", ""))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(item_id, user_id, entity.RevisionDeleted, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(http.MethodDelete, "/caldav/personal/"+item_id+".ics", "", "personal", item_id+".ics")
+		ctx.Request().Header.Set(HeaderIfMatch, etag)
+
+		err := as.CalDAVObject(ctx)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Delete task as a viewer of the workspace", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT role FROM workspace_members`).
+			WithArgs(workspace_id, user_id).
+			WillReturnRows(mock.NewRows([]string{"role"}).AddRow("viewer"))
+		mock.ExpectQuery(listItemsQuery).
+			WillReturnRows(itemRow("todo", ""))
+		mock.ExpectQuery(ownerQuery).WillReturnRows(mock.NewRows([]string{"role"}).AddRow("viewer"))
+
+		ctx, rec := newContext(http.MethodDelete, "/caldav/"+workspace_id+"/"+item_id+".ics", "", workspace_id, item_id+".ics")
+
+		_ = as.CalDAVObject(ctx)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceWebhooks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+		Webhooks: &WebhookSettings{
+			MaxAttempts:     3,
+			Backoff:         time.Minute,
+			AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")},
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	webhook_id := "9b2e4c6a-8d1f-4a3b-9c5e-7f0a2b4c6d8e"
+	delivery_id := "1c3e5a7b-9d2f-4b6a-8c0e-2d4f6a8b0c1e"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	webhookColumns := []string{"id", "user_id", "workspace_id", "url", "secret", "events", "created_at"}
+	deliveryColumns := []string{"id", "webhook_id", "event", "status", "attempts", "next_attempt_at", "last_status_code", "last_error", "created_at", "delivered_at", "payload"}
+
+	newContext := func(method, target string, body io.Reader, names ...string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, target, body)
+		token, rawToken := createJwtToken("example@gmail.com", user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+
+		var values []string
+		for i := 0; i < len(names); i += 2 {
+			ctx.SetParamNames(append(ctx.ParamNames(), names[i])...)
+			values = append(values, names[i+1])
+		}
+		ctx.SetParamValues(values...)
+
+		return ctx, rec
+	}
+
+	t.Run("Test Create webhook with an unknown event", func(t *testing.T) {
+		ctx, rec := newContext(http.MethodPost, "/webhooks", strings.NewReader(`{"url": "https://ci.example.com/hooks/todo", "events": ["item.archived"]}`))
+
+		_ = as.CreateWebhook(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Unknown webhook event item.archived")
+	})
+
+	t.Run("Test Create webhook with a generated secret", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO webhooks`).
+			WithArgs(sqlmock.AnyArg(), user_id, "", "https://ci.example.com/hooks/todo", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(webhookColumns).
+				AddRow(webhook_id, user_id, "", "https://ci.example.com/hooks/todo", "4f9d2c7a1e8b3f6d0a5c9e2b7d4f1a8c", "{item.completed}", createdAt))
+
+		ctx, rec := newContext(http.MethodPost, "/webhooks", strings.NewReader(`{"url": "https://ci.example.com/hooks/todo", "events": ["item.completed"]}`))
+
+		err := as.CreateWebhook(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var webhook entity.Webhook
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &webhook))
+		assert.Equal(t, "4f9d2c7a1e8b3f6d0a5c9e2b7d4f1a8c", webhook.Secret)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test List webhooks without their secrets", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM webhooks WHERE user_id=\$1`).
+			WithArgs(user_id).
+			WillReturnRows(mock.NewRows(webhookColumns).
+				AddRow(webhook_id, user_id, "", "https://ci.example.com/hooks/todo", "4f9d2c7a1e8b3f6d0a5c9e2b7d4f1a8c", "{item.completed}", createdAt))
+
+		ctx, rec := newContext(http.MethodGet, "/webhooks", nil)
+
+		err := as.GetWebhooks(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "4f9d2c7a1e8b3f6d0a5c9e2b7d4f1a8c")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Send test event to a receiver", func(t *testing.T) {
+		var event string
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			event = r.Header.Get(HeaderWebhookEvent)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+
+		mock.ExpectQuery(`SELECT .+ FROM webhooks WHERE id=\$1 AND user_id=\$2`).
+			WithArgs(webhook_id, user_id).
+			WillReturnRows(mock.NewRows(webhookColumns).
+				AddRow(webhook_id, user_id, "", receiver.URL, "4f9d2c7a1e8b3f6d0a5c9e2b7d4f1a8c", "{item.completed}", createdAt))
+		mock.ExpectQuery(`INSERT INTO webhook_deliveries`).
+			WithArgs(sqlmock.AnyArg(), webhook_id, WebhookTestEvent, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(deliveryColumns).
+				AddRow(delivery_id, webhook_id, WebhookTestEvent, "pending", 0, createdAt, 0, "", createdAt, nil, `{"event":"ping"}`))
+		mock.ExpectExec(`WITH attempt AS \(INSERT INTO webhook_attempts`).
+			WithArgs(delivery_id, sqlmock.AnyArg(), http.StatusOK, "", sqlmock.AnyArg(), entity.WebhookDeliveryDelivered, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT .+ FROM webhook_deliveries WHERE id=\$1`).
+			WithArgs(delivery_id, webhook_id, user_id).
+			WillReturnRows(mock.NewRows(deliveryColumns).
+				AddRow(delivery_id, webhook_id, WebhookTestEvent, "delivered", 1, nil, 200, "", createdAt, createdAt, `{"event":"ping"}`))
+		mock.ExpectQuery(`SELECT attempted_at,status_code,error,duration_ms FROM webhook_attempts`).
+			WithArgs(delivery_id).
+			WillReturnRows(mock.NewRows([]string{"attempted_at", "status_code", "error", "duration_ms"}).AddRow(createdAt, 200, "", 4))
+
+		ctx, rec := newContext(http.MethodPost, "/webhooks/"+webhook_id+"/test", nil, "id", webhook_id)
+
+		err := as.SendTestWebhook(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, WebhookTestEvent, event)
+
+		var delivery entity.WebhookDelivery
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &delivery))
+		assert.Equal(t, entity.WebhookDeliveryDelivered, delivery.Status)
+		assert.Len(t, delivery.Log, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Send test event to a missing webhook", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM webhooks WHERE id=\$1 AND user_id=\$2`).
+			WithArgs(webhook_id, user_id).
+			WillReturnError(sql.ErrNoRows)
+
+		ctx, rec := newContext(http.MethodPost, "/webhooks/"+webhook_id+"/test", nil, "id", webhook_id)
+
+		_ = as.SendTestWebhook(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Deliveries with an unknown status", func(t *testing.T) {
+		ctx, rec := newContext(http.MethodGet, "/webhooks/"+webhook_id+"/deliveries?status=lost", nil, "id", webhook_id)
+
+		_ = as.GetWebhookDeliveries(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Test Dead letters", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT .+ FROM webhook_deliveries WHERE webhook_id IN`).
+			WithArgs(user_id, "", entity.WebhookDeliveryDead, webhookLogLimit).
+			WillReturnRows(mock.NewRows(deliveryColumns).
+				AddRow(delivery_id, webhook_id, "item.completed", "dead", 3, createdAt, 502, "Webhook was rejected with status 502", createdAt, nil, `{"event":"item.completed"}`))
+
+		ctx, rec := newContext(http.MethodGet, "/webhooks/dead-letters", nil)
+
+		err := as.GetDeadWebhookDeliveries(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var deliveries []entity.WebhookDelivery
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &deliveries))
+		assert.Len(t, deliveries, 1)
+		assert.Nil(t, deliveries[0].NextAttemptAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Redeliver missing delivery", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE webhook_deliveries SET status='pending'`).
+			WithArgs(delivery_id, webhook_id, user_id, sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+
+		ctx, rec := newContext(http.MethodPost, "/webhooks/"+webhook_id+"/deliveries/"+delivery_id+"/redeliver", nil, "id", webhook_id, "deliveryId", delivery_id)
+
+		_ = as.RedeliverWebhookById(ctx)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Change of an item queues its event with the revision", func(t *testing.T) {
+		item := entity.TodoItem{Id: item_id, UserId: user_id, Status: entity.StatusDone}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO item_revisions`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT id FROM webhooks WHERE \$1 = ANY\(events\)`).
+			WithArgs("item.completed", user_id, "").
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(webhook_id))
+		mock.ExpectQuery(`INSERT INTO webhook_deliveries`).
+			WithArgs(sqlmock.AnyArg(), webhook_id, "item.completed", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(deliveryColumns).
+				AddRow(delivery_id, webhook_id, "item.completed", "pending", 0, createdAt, 0, "", createdAt, nil, `{"event":"item.completed"}`))
+		mock.ExpectCommit()
+
+		_, err := as.withRevision(as.R, user_id, entity.RevisionStatusChanged, func(r ApiRepository) (entity.TodoItem, error) {
+			return item, nil
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+		Events: NewEventHub(),
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	workspaceColumns := []string{"id", "name", "owner_id", "role", "created_at"}
+	eventColumns := []string{"id", "item_id", "event", "actor_id", "payload", "created_at"}
+
+	// The request is already cancelled, so the stream sends the events in the log and ends
+	newStream := func(lastEventId string) (echo.Context, *httptest.ResponseRecorder) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+		if lastEventId != "" {
+			req.Header.Set(HeaderLastEventId, lastEventId)
+		}
+		token, _ := createJwtToken("example@gmail.com", user_id)
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("user", token)
+
+		return c, rec
+	}
+
+	t.Run("Test Stream when real-time updates are disabled", func(t *testing.T) {
+		c, rec := newStream("")
+
+		_ = ApiService{R: as.R}.StreamItemEvents(c)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Test Stream with an invalid Last-Event-ID", func(t *testing.T) {
+		c, rec := newStream("latest")
+
+		_ = as.StreamItemEvents(c)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Test Stream resumes after the last event", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT workspaces.id,workspaces.name`).
+			WithArgs(user_id).
+			WillReturnRows(mock.NewRows(workspaceColumns))
+		mock.ExpectQuery(`SELECT COALESCE\(MIN\(id\), 0\), COALESCE\(MAX\(id\), 0\) FROM item_events`).
+			WillReturnRows(mock.NewRows([]string{"min", "max"}).AddRow(40, 45))
+		mock.ExpectQuery(`SELECT id,item_id,event,actor_id,payload,created_at FROM item_events`).
+			WithArgs(user_id, int64(41), itemEventBatchSize).
+			WillReturnRows(mock.NewRows(eventColumns).
+				AddRow(42, item_id, "item.completed", user_id, `{"id":"`+item_id+`","status":"done"}`, createdAt))
+
+		c, rec := newStream("41")
+
+		err := as.StreamItemEvents(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+		assert.Contains(t, rec.Body.String(), "id: 42\nevent: item.completed\ndata: {\"id\":42,")
+		assert.Empty(t, as.Events.subscribers)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Stream resuming after purged events is reset", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT workspaces.id,workspaces.name`).
+			WithArgs(user_id).
+			WillReturnRows(mock.NewRows(workspaceColumns))
+		mock.ExpectQuery(`SELECT COALESCE\(MIN\(id\), 0\), COALESCE\(MAX\(id\), 0\) FROM item_events`).
+			WillReturnRows(mock.NewRows([]string{"min", "max"}).AddRow(40, 45))
+		mock.ExpectQuery(`SELECT id,item_id,event,actor_id,payload,created_at FROM item_events`).
+			WithArgs(user_id, int64(45), itemEventBatchSize).
+			WillReturnRows(mock.NewRows(eventColumns))
+
+		c, rec := newStream("5")
+
+		err := as.StreamItemEvents(c)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(rec.Body.String(), "id: 45\nevent: reset\n"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Change of an item is logged for the streams", func(t *testing.T) {
+		item := entity.TodoItem{Id: item_id, UserId: user_id, Status: entity.StatusTodo}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO item_revisions`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO item_events`).
+			WithArgs(item_id, "", sqlmock.AnyArg(), "item.created", user_id, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows([]string{"id", "recipients"}).AddRow(46, "{"+user_id+"}"))
+		mock.ExpectExec(`SELECT pg_notify`).
+			WithArgs(ItemEventsChannel, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		_, err := as.withRevision(as.R, user_id, entity.RevisionCreated, func(r ApiRepository) (entity.TodoItem, error) {
+			return item, nil
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceSync(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	dueDate := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	e := echo.New()
+
+	username := "example@gmail.com"
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	purged_id := "b6c3bb51-8a62-4c37-9e4f-55b0f4f0a0a1"
+	findQuery := `SELECT .+ FROM todo_items WHERE is_deleted = false AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\) AND \(\$2 <> '' OR user_id=\$1\) AND id = ANY\(\$3\)`
+	changesQuery := `SELECT .+,change_seq FROM todo_items WHERE change_seq > \$3`
+	tombstonesQuery := `SELECT item_id,change_seq,deleted_at FROM item_tombstones WHERE change_seq > \$3`
+	tombstoneColumns := []string{"item_id", "change_seq", "deleted_at"}
+
+	newContext := func(body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPost, "/sync", strings.NewReader(body))
+		token, rawToken := createJwtToken(username, user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+
+		return ctx, rec
+	}
+
+	itemRow := func(rows *sqlmock.Rows, name, priority string, version int, deleted bool, extra ...driver.Value) *sqlmock.Rows {
+		values := []driver.Value{item_id, name, "This is item 1", dueDate, priority, dueDate, dueDate, false, deleted, user_id, nil, entity.StatusTodo, nil, nil, version, "", 0, "", "", "", "", false, "", ""}
+		return rows.AddRow(append(values, extra...)...)
+	}
+
+	snapshot := func(name, priority string, version int) []byte {
+		item := entity.TodoItem{Id: item_id, Item: entity.TodoItemDto{Name: name, Details: entity.TodoItemDetailsDto{Description: "This is item 1", DueDate: dueDate, Priority: priority}}, Status: entity.StatusTodo, Version: version}
+		data, _ := json.Marshal(item)
+		return data
+	}
+
+	t.Run("Invalid token", func(t *testing.T) {
+		ctx, rec := newContext(`{"token": "latest"}`)
+
+		_ = as.SyncItems(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, `Invalid sync token "latest"`, rec.Body.String())
+	})
+
+	t.Run("Report a conflict and apply the other fields", func(t *testing.T) {
+		body := `{"token": "40", "changes": [
+			{"id": "` + item_id + `", "op": "update", "base_version": 2, "changed_at": "2023-10-16T08:00:00Z", "fields": {"name": "Renamed item", "priority": "LOW"}}
+		]}`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findQuery).
+			WithArgs(user_id, "", sqlmock.AnyArg()).
+			WillReturnRows(itemRow(mock.NewRows(todoItemColumns), "Todo list item 1", "HIGH", 3, false))
+		mock.ExpectExec(`SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT id,item_id,user_id,action,snapshot,created_at FROM item_revisions WHERE item_id=\$1 ORDER BY id`).
+			WithArgs(item_id).
+			WillReturnRows(mock.NewRows([]string{"id", "item_id", "user_id", "action", "snapshot", "created_at"}).
+				AddRow(1, item_id, user_id, entity.RevisionCreated, snapshot("Todo list item 1", "LOW", 1), dueDate).
+				AddRow(2, item_id, user_id, entity.RevisionUpdated, snapshot("Todo list item 1", "LOW", 2), dueDate).
+				AddRow(3, item_id, user_id, entity.RevisionUpdated, snapshot("Todo list item 1", "HIGH", 3), dueDate.Add(time.Hour)))
+		mock.ExpectQuery(`UPDATE todo_items SET name=\$1, updated_at=\$2 WHERE id=\$3`).
+			WithArgs("Renamed item", sqlmock.AnyArg(), item_id, 3, "").
+			WillReturnRows(itemRow(mock.NewRows(todoItemColumns), "Renamed item", "HIGH", 4, false))
+		mock.ExpectExec("INSERT INTO item_revisions").
+			WithArgs(item_id, user_id, entity.RevisionUpdated, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(4, 1))
+		mock.ExpectExec(`RELEASE SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(changesQuery).
+			WithArgs(user_id, "", int64(40), syncBatchSize+1).
+			WillReturnRows(itemRow(mock.NewRows(append(todoItemColumns, "change_seq")), "Renamed item", "HIGH", 4, false, 45))
+		mock.ExpectQuery(tombstonesQuery).
+			WithArgs(user_id, "", int64(40), syncBatchSize+1).
+			WillReturnRows(mock.NewRows(tombstoneColumns).AddRow(purged_id, 44, dueDate))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(body)
+
+		_ = as.SyncItems(ctx)
+
+		var got entity.SyncResponseDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, got.Results, 1)
+		assert.Equal(t, entity.SyncConflict, got.Results[0].Status)
+		assert.Equal(t, []entity.SyncConflictDto{{Field: "priority", Client: "LOW", Server: "HIGH", Resolution: entity.SyncResolutionServer}}, got.Results[0].Conflicts)
+		assert.Equal(t, "Renamed item", got.Results[0].Item.Item.Name)
+		assert.Equal(t, "45", got.Token)
+		assert.False(t, got.HasMore)
+		assert.Len(t, got.Changes, 1)
+		assert.Equal(t, []entity.ItemTombstone{{Id: purged_id, ChangeSeq: 44, DeletedAt: dueDate, Purged: true}}, got.Tombstones)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("First sync deletes an item no longer in the list and gets no tombstones", func(t *testing.T) {
+		body := `{"changes": [{"id": "` + purged_id + `", "op": "delete", "base_version": 1}]}`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findQuery).
+			WithArgs(user_id, "", sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(todoItemColumns))
+		mock.ExpectExec(`SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`RELEASE SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(changesQuery).
+			WithArgs(user_id, "", int64(0), syncBatchSize+1).
+			WillReturnRows(itemRow(mock.NewRows(append(todoItemColumns, "change_seq")), "Todo list item 1", "HIGH", 2, true, 3))
+		mock.ExpectQuery(tombstonesQuery).
+			WithArgs(user_id, "", int64(0), syncBatchSize+1).
+			WillReturnRows(mock.NewRows(tombstoneColumns).AddRow(purged_id, 5, dueDate))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(body)
+
+		_ = as.SyncItems(ctx)
+
+		var got entity.SyncResponseDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, entity.SyncApplied, got.Results[0].Status)
+		assert.Empty(t, got.Changes)
+		assert.Empty(t, got.Tombstones)
+		assert.Equal(t, "5", got.Token)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Create with an invalid id or the id of another item", func(t *testing.T) {
+		taken_id := "c2d6e4f8-1a3b-4c5d-9e7f-0a1b2c3d4e5f"
+		body := `{"token": "40", "changes": [
+			{"id": "x'; DROP TABLE todo_items; --", "op": "create", "fields": {"name": "Pay rent", "description": "Monthly rent", "due_date": "2023-10-15T09:38:24Z", "priority": "HIGH"}},
+			{"id": "` + taken_id + `", "op": "create", "fields": {"name": "Pay rent", "description": "Monthly rent", "due_date": "2023-10-15T09:38:24Z", "priority": "HIGH"}}
+		]}`
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(findQuery).
+			WithArgs(user_id, "", sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(todoItemColumns))
+		mock.ExpectExec(`SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO todo_items .+ ON CONFLICT \(id\) DO NOTHING RETURNING .+;`).
+			WithArgs(taken_id, "Pay rent", "Monthly rent", sqlmock.AnyArg(), "HIGH", sqlmock.AnyArg(), sqlmock.AnyArg(), user_id, "", sqlmock.AnyArg()).
+			WillReturnRows(mock.NewRows(todoItemColumns))
+		mock.ExpectExec(`RELEASE SAVEPOINT bulk_operation;`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(changesQuery).
+			WithArgs(user_id, "", int64(40), syncBatchSize+1).
+			WillReturnRows(mock.NewRows(append(todoItemColumns, "change_seq")))
+		mock.ExpectQuery(tombstonesQuery).
+			WithArgs(user_id, "", int64(40), syncBatchSize+1).
+			WillReturnRows(mock.NewRows(tombstoneColumns))
+		mock.ExpectCommit()
+
+		ctx, rec := newContext(body)
+
+		_ = as.SyncItems(ctx)
+
+		var got entity.SyncResponseDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, got.Results, 2)
+		assert.Equal(t, entity.SyncFailed, got.Results[0].Status)
+		assert.Equal(t, "Bad request: Invalid create change: id is invalid", got.Results[0].Error)
+		assert.Equal(t, entity.SyncConflict, got.Results[1].Status)
+		assert.Equal(t, []entity.SyncConflictDto{{Field: "id", Client: taken_id, Resolution: entity.SyncResolutionServer}}, got.Results[1].Conflicts)
+		assert.Equal(t, constants.ITEM_ID_TAKEN, got.Results[1].Error)
+		assert.Nil(t, got.Results[1].Item)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiServiceGraphQL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	as := ApiService{
+		R: ApiRepository{
+			DB: db,
+		},
+	}
+
+	e := echo.New()
+
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	other_id := "8c3e5f7a-9b1d-4e2f-a4c6-d8e0f2a4b6c8"
+	item_id := "3a35452e-957c-4588-8d40-c88f370067d2"
+	other_item_id := "b6c3bb51-8a62-4c37-9e4f-55b0f4f0a0a1"
+	dueDate := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	pageQuery := `SELECT .+ FROM todo_items WHERE .+ ORDER BY rank, created_at, id LIMIT \$3 OFFSET \$4`
+	usersQuery := `SELECT id,email FROM users WHERE id = ANY\(\$1\)`
+	roleQuery := `SELECT CASE WHEN user_id=\$2 THEN 'owner' .+ AS role FROM todo_items WHERE id=\$1`
+	commentColumns := []string{"id", "item_id", "parent_id", "user_id", "body", "created_at", "edited_at", "is_deleted"}
+
+	newContext := func(body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		token, rawToken := createJwtToken("example@gmail.com", user_id)
+
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer"+" "+rawToken)
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.Set("user", token)
+
+		return ctx, rec
+	}
+
+	itemRow := func(rows *sqlmock.Rows, id, owner, blockedBy string, blocked bool) *sqlmock.Rows {
+		return rows.AddRow(id, "Todo list item 1", "This is item 1", dueDate, "HIGH", dueDate, dueDate, false, false, owner, nil, "todo", nil, nil, 2, "", 0, "", "", "", blockedBy, blocked, "", "")
+	}
+
+	t.Run("Items load their owners with one query", func(t *testing.T) {
+		rows := mock.NewRows(todoItemColumns)
+		itemRow(rows, item_id, user_id, "", false)
+		itemRow(rows, other_item_id, other_id, "", false)
+		itemRow(rows, "c7d8e9f0-1a2b-4c3d-8e4f-5a6b7c8d9e0f", user_id, "", false)
+
+		mock.ExpectQuery(pageQuery).WithArgs(user_id, "", 3, 0).WillReturnRows(rows)
+		mock.ExpectQuery(usersQuery).
+			WithArgs(pq.Array([]string{user_id, other_id})).
+			WillReturnRows(mock.NewRows([]string{"id", "email"}).AddRow(user_id, "example@gmail.com").AddRow(other_id, "colleague@gmail.com"))
+
+		ctx, rec := newContext(`{"query": "{ items(first: 2) { nodes { id owner { email } } pageInfo { hasNextPage endCursor } } }"}`)
+
+		_ = as.GraphQL(ctx)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"data": {"items": {
+			"nodes": [
+				{"id": "`+item_id+`", "owner": {"email": "example@gmail.com"}},
+				{"id": "`+other_item_id+`", "owner": {"email": "colleague@gmail.com"}}
+			],
+			"pageInfo": {"hasNextPage": true, "endCursor": "`+graphqlCursor(2)+`"}
+		}}}`, rec.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Comments of the items and their authors load with one query each", func(t *testing.T) {
+		rows := mock.NewRows(todoItemColumns)
+		itemRow(rows, item_id, user_id, "", false)
+		itemRow(rows, other_item_id, user_id, "", false)
+
+		mock.ExpectQuery(pageQuery).WithArgs(user_id, "", 21, 0).WillReturnRows(rows)
+		mock.ExpectQuery(`SELECT .+ FROM item_comments WHERE item_id = ANY\(\$1\)`).
+			WithArgs(pq.Array([]string{item_id, other_item_id})).
+			WillReturnRows(mock.NewRows(commentColumns).
+				AddRow("c1", item_id, nil, user_id, "First", dueDate, nil, false).
+				AddRow("c2", other_item_id, nil, other_id, "Second", dueDate, nil, false))
+		mock.ExpectQuery(usersQuery).
+			WithArgs(pq.Array([]string{user_id, other_id})).
+			WillReturnRows(mock.NewRows([]string{"id", "email"}).AddRow(user_id, "example@gmail.com").AddRow(other_id, "colleague@gmail.com"))
+
+		ctx, rec := newContext(`{"query": "{ items { nodes { comments { body author { email } } } } }"}`)
+
+		_ = as.GraphQL(ctx)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"data": {"items": {"nodes": [
+			{"comments": [{"body": "First", "author": {"email": "example@gmail.com"}}]},
+			{"comments": [{"body": "Second", "author": {"email": "colleague@gmail.com"}}]}
+		]}}}`, rec.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Item of another user", func(t *testing.T) {
+		mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}))
+
+		ctx, rec := newContext(`{"query": "query($id: ID!) { item(id: $id) { id } }", "variables": {"id": "` + item_id + `"}}`)
+
+		_ = as.GraphQL(ctx)
+
+		var got entity.GraphQLResponseDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, map[string]interface{}{"item": nil}, got.Data)
+		assert.Equal(t, constants.DOES_NOT_BELONG_TO_USER, got.Errors[0].Message)
+		assert.Equal(t, []interface{}{"item"}, got.Errors[0].Path)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Completing an item waiting on blockers", func(t *testing.T) {
+		mock.ExpectQuery(roleQuery).WithArgs(item_id, user_id, "").WillReturnRows(mock.NewRows([]string{"role"}).AddRow("owner"))
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE id=\$1 AND workspace_id IS NOT DISTINCT FROM NULLIF\(\$2, ''\)`).
+			WithArgs(item_id, "").
+			WillReturnRows(itemRow(mock.NewRows(todoItemColumns), item_id, user_id, other_item_id, true))
+
+		ctx, rec := newContext(`{"query": "mutation { completeItem(id: \"` + item_id + `\") { status } }"}`)
+
+		_ = as.GraphQL(ctx)
+
+		var got entity.GraphQLResponseDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, constants.ITEM_BLOCKED, got.Errors[0].Message)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		ctx, rec := newContext(`{"query": "{ items { nodes { password } } }"}`)
+
+		_ = as.GraphQL(ctx)
+
+		var got entity.GraphQLResponseDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Nil(t, got.Data)
+		assert.Contains(t, got.Errors[0].Message, `Cannot query field "password" on type "TodoItem"`)
+	})
+
+	t.Run("Query too complex", func(t *testing.T) {
+		ctx, rec := newContext(`{"query": "{ items(first: 100) { nodes { comments { replies { author { email } } } } } }"}`)
+
+		_ = as.GraphQL(ctx)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, `{"errors": [{"message": "Query complexity 21201 exceeds the limit of 5000"}]}`, rec.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,353 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Each role is allowed everything the roles ranked below it are allowed
+var roleRanks = map[string]int{
+	entity.RoleViewer: 1,
+	entity.RoleEditor: 2,
+	entity.RoleOwner:  3,
+}
+
+// Reports whether the role grants the required role
+func roleAllows(role, required string) bool {
+	return role != "" && roleRanks[role] >= roleRanks[required]
+}
+
+// Checks the user has at least the required role on the item, responding with 403 when they do not
+func (as ApiService) authorizeItem(c echo.Context, id, userId, required string) (bool, error) {
+	role, err := as.repo(c).GetItemRole(id, userId)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return false, c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if role == "" {
+		return false, c.String(http.StatusForbidden, constants.DOES_NOT_BELONG_TO_USER)
+	}
+
+	if !roleAllows(role, required) {
+		return false, c.String(http.StatusForbidden, fmt.Sprintf(constants.INSUFFICIENT_ROLE, required))
+	}
+
+	return true, nil
+}
+
+// Shares an item with another registered user
+// @Summary shares a todo item with a user by email
+// @Description The user is invited with the role and gets access once they accept the invitation. Sharing again changes the role.
+// @Tags Sharing
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param dto body entity.ShareDto true "Share"
+// @Success 201 {object} entity.Share
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_ROLE
+// @Failure 404 string constants.EMAIL_NOT_REGISTERED
+// @Failure 422 string constants.SHARE_OUTSIDE_WORKSPACE
+// @Failure 500 string constants.SHARE_ITEM_ERROR
+// @Router /item/{id}/shares [post]
+func (as ApiService) ShareItemById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	share := new(entity.ShareDto)
+	if err := c.Bind(share); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(share)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleOwner)
+	if !allowed {
+		return err
+	}
+
+	shareUserId, err := as.repo(c).FindUserIdByEmail(share.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.EMAIL_NOT_REGISTERED)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.SHARE_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if shareUserId == userId {
+		return c.String(http.StatusBadRequest, constants.CANNOT_SHARE_WITH_OWNER)
+	}
+
+	if workspaceId := workspaceOf(c); workspaceId != "" {
+		role, err := as.R.GetWorkspaceRole(workspaceId, shareUserId)
+		if err != nil {
+			errMessage := fmt.Sprintf(constants.SHARE_ITEM_ERROR, err)
+			return c.String(http.StatusInternalServerError, errMessage)
+		}
+
+		if role == "" {
+			return c.String(http.StatusUnprocessableEntity, constants.SHARE_OUTSIDE_WORKSPACE)
+		}
+	}
+
+	created, err := as.repo(c).ShareItem(param, shareUserId, share.Role, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.SHARE_ITEM_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, created, " ")
+}
+
+// Lists the users an item is shared with
+// @Summary lists the shares of a todo item
+// @Tags Sharing
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Success 200 {array} entity.Share
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 500 string constants.GET_SHARES_ERROR
+// @Router /item/{id}/shares [get]
+func (as ApiService) GetItemShares(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeItem(c, param, userId, entity.RoleViewer)
+	if !allowed {
+		return err
+	}
+
+	shares, err := as.repo(c).GetItemShares(param)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_SHARES_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, shares, " ")
+}
+
+// Revokes a share, the owner can revoke any share and a user can leave an item shared with them
+// @Summary revokes a share of a todo item
+// @Tags Sharing
+// @Produce plain
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "item Id"
+// @Param shareId path string true "share Id"
+// @Success 200 string constants.REVOKE_SHARE_SUCCESSFULL
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_ROLE
+// @Failure 404 string constants.SHARE_NOT_FOUND
+// @Failure 500 string constants.REVOKE_SHARE_ERROR
+// @Router /item/{id}/shares/{shareId} [delete]
+func (as ApiService) RevokeShareById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	share, err := as.repo(c).FindShareById(param, c.Param("shareId"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.SHARE_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.REVOKE_SHARE_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if share.UserId != userId {
+		allowed, err := as.authorizeItem(c, param, userId, entity.RoleOwner)
+		if !allowed {
+			return err
+		}
+	}
+
+	if err := as.repo(c).DeleteShare(share.Id); err != nil {
+		errMessage := fmt.Sprintf(constants.REVOKE_SHARE_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.String(http.StatusOK, constants.REVOKE_SHARE_SUCCESSFULL)
+}
+
+// Lists the invitations the user has not answered yet
+// @Summary lists the pending invitations of the user
+// @Tags Sharing
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Success 200 {array} entity.Share
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.GET_INVITATIONS_ERROR
+// @Router /item/invitations [get]
+func (as ApiService) GetInvitations(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	invitations, err := as.repo(c).GetInvitations(userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_INVITATIONS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, invitations, " ")
+}
+
+func (as ApiService) respondToInvitation(c echo.Context, status string) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	share, err := as.repo(c).RespondToShare(c.Param("shareId"), userId, status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.INVITATION_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.RESPOND_INVITATION_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, share, " ")
+}
+
+// Accepts an invitation, the item is then shared with the user
+// @Summary accepts an invitation to a shared todo item
+// @Tags Sharing
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param shareId path string true "share Id"
+// @Success 200 {object} entity.Share
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.INVITATION_NOT_FOUND
+// @Failure 500 string constants.RESPOND_INVITATION_ERROR
+// @Router /item/invitations/{shareId}/accept [post]
+func (as ApiService) AcceptInvitation(c echo.Context) error {
+	return as.respondToInvitation(c, entity.ShareStatusAccepted)
+}
+
+// Declines an invitation, the owner can invite the user again
+// @Summary declines an invitation to a shared todo item
+// @Tags Sharing
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param shareId path string true "share Id"
+// @Success 200 {object} entity.Share
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.INVITATION_NOT_FOUND
+// @Failure 500 string constants.RESPOND_INVITATION_ERROR
+// @Router /item/invitations/{shareId}/decline [post]
+func (as ApiService) DeclineInvitation(c echo.Context) error {
+	return as.respondToInvitation(c, entity.ShareStatusDeclined)
+}
+
+// Find the items other users shared with the user
+// @Summary lists the todo items shared with the user
+// @Tags Sharing
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param limit query int false "Maximum number of items" default(50)
+// @Success 200 {array} entity.SharedItem
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.GET_SHARED_ITEMS_ERROR
+// @Router /item/shared [get]
+func (as ApiService) GetSharedItems(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	limit := 50
+	if param := c.QueryParam("limit"); param != "" {
+		value, err := strconv.Atoi(param)
+		if err != nil || value <= 0 {
+			errMessage := fmt.Sprintf(constants.BAD_REQUEST, "limit must be a positive number")
+			return c.String(http.StatusBadRequest, errMessage)
+		}
+		limit = value
+	}
+
+	items, err := as.repo(c).GetSharedItems(limit, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_SHARED_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, items, " ")
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestSharing(t *testing.T) {
+	t.Run("Test Role allows", func(t *testing.T) {
+		assert.True(t, roleAllows(entity.RoleOwner, entity.RoleOwner))
+		assert.True(t, roleAllows(entity.RoleOwner, entity.RoleViewer))
+		assert.True(t, roleAllows(entity.RoleEditor, entity.RoleEditor))
+		assert.True(t, roleAllows(entity.RoleEditor, entity.RoleViewer))
+		assert.False(t, roleAllows(entity.RoleEditor, entity.RoleOwner))
+		assert.False(t, roleAllows(entity.RoleViewer, entity.RoleEditor))
+		assert.False(t, roleAllows("", entity.RoleViewer))
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"todo-project/entity"
+)
+
+// Allowed status changes, keyed by the current status of the item
+type StatusTransitions map[string][]string
+
+var DefaultStatusTransitions = StatusTransitions{
+	entity.StatusTodo:       {entity.StatusInProgress, entity.StatusBlocked, entity.StatusDone, entity.StatusCancelled},
+	entity.StatusInProgress: {entity.StatusTodo, entity.StatusBlocked, entity.StatusDone, entity.StatusCancelled},
+	entity.StatusBlocked:    {entity.StatusTodo, entity.StatusInProgress, entity.StatusCancelled},
+	entity.StatusDone:       {entity.StatusTodo, entity.StatusInProgress},
+	entity.StatusCancelled:  {entity.StatusTodo},
+}
+
+var statuses = []string{entity.StatusTodo, entity.StatusInProgress, entity.StatusBlocked, entity.StatusDone, entity.StatusCancelled}
+
+func isStatus(status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Parses transitions written as "todo=in_progress|done;done=todo",
+// an empty config returns the default transitions
+func ParseStatusTransitions(config string) (StatusTransitions, error) {
+	if strings.TrimSpace(config) == "" {
+		return DefaultStatusTransitions, nil
+	}
+
+	transitions := StatusTransitions{}
+
+	for _, rule := range strings.Split(config, ";") {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+
+		from, to, found := strings.Cut(rule, "=")
+		from = strings.TrimSpace(from)
+		if !found || !isStatus(from) {
+			return nil, fmt.Errorf("invalid rule %q", rule)
+		}
+
+		for _, status := range strings.Split(to, "|") {
+			status = strings.TrimSpace(status)
+			if !isStatus(status) {
+				return nil, fmt.Errorf("invalid status %q in rule %q", status, rule)
+			}
+
+			transitions[from] = append(transitions[from], status)
+		}
+	}
+
+	return transitions, nil
+}
+
+// Checks whether an item can move from one status to another, staying in the same status is always allowed
+func (t StatusTransitions) CanTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+
+	for _, status := range t[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
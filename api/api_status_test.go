@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestStatusTransitions(t *testing.T) {
+	t.Run("Empty config returns the default transitions", func(t *testing.T) {
+		got, err := ParseStatusTransitions("")
+
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultStatusTransitions, got)
+	})
+
+	t.Run("Parses the config", func(t *testing.T) {
+		got, err := ParseStatusTransitions("todo=in_progress|done; in_progress=done")
+
+		assert.NoError(t, err)
+		assert.Equal(t, StatusTransitions{
+			entity.StatusTodo:       {entity.StatusInProgress, entity.StatusDone},
+			entity.StatusInProgress: {entity.StatusDone},
+		}, got)
+	})
+
+	t.Run("Invalid config", func(t *testing.T) {
+		_, err := ParseStatusTransitions("todo=finished")
+		assert.Error(t, err)
+
+		_, err = ParseStatusTransitions("todo")
+		assert.Error(t, err)
+	})
+
+	t.Run("Can transition", func(t *testing.T) {
+		assert.True(t, DefaultStatusTransitions.CanTransition(entity.StatusTodo, entity.StatusInProgress))
+		assert.True(t, DefaultStatusTransitions.CanTransition(entity.StatusDone, entity.StatusTodo))
+		assert.True(t, DefaultStatusTransitions.CanTransition(entity.StatusDone, entity.StatusDone))
+		assert.False(t, DefaultStatusTransitions.CanTransition(entity.StatusCancelled, entity.StatusDone))
+		assert.False(t, DefaultStatusTransitions.CanTransition(entity.StatusBlocked, entity.StatusDone))
+	})
+}
@@ -0,0 +1,562 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Most items and tombstones returned by a single sync
+const syncBatchSize = 500
+
+// Fields a client syncs, named like the changes of diffItems
+var syncFields = []string{"name", "description", "due_date", "priority", "status", "labels"}
+
+// Number of the change sequence of a sync token, an empty token is a client syncing for the first time
+func parseSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	after, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || after < 0 {
+		return 0, fmt.Errorf(constants.INVALID_SYNC_TOKEN, token)
+	}
+
+	return after, nil
+}
+
+// Checks the fields each kind of change needs, the field values are checked with the validator tags
+func validateSyncChange(change entity.SyncChangeDto) error {
+	fields := change.Fields
+
+	if !calDAVResourceId.MatchString(change.Id) {
+		return fmt.Errorf(constants.INVALID_SYNC_CHANGE, change.Op, "id is invalid")
+	}
+
+	if change.Op == entity.SyncOpCreate {
+		if fields == nil || fields.Name == nil || fields.Description == nil || fields.DueDate == nil || fields.Priority == nil {
+			return fmt.Errorf(constants.INVALID_SYNC_CHANGE, change.Op, "name, description, due_date and priority are required")
+		}
+	}
+
+	if fields != nil && fields.Status != nil && !isStatus(*fields.Status) {
+		return fmt.Errorf(constants.INVALID_SYNC_CHANGE, change.Op, "status is invalid")
+	}
+
+	return nil
+}
+
+// The item with the fields the client changed
+func syncedItem(current entity.TodoItem, fields *entity.SyncFieldsDto) entity.TodoItem {
+	wanted := current
+	if fields == nil {
+		return wanted
+	}
+
+	if fields.Name != nil {
+		wanted.Item.Name = *fields.Name
+	}
+
+	if fields.Description != nil {
+		wanted.Item.Details.Description = *fields.Description
+	}
+
+	if fields.DueDate != nil {
+		wanted.Item.Details.DueDate = *fields.DueDate
+	}
+
+	if fields.Priority != nil {
+		wanted.Item.Details.Priority = *fields.Priority
+	}
+
+	if fields.Status != nil {
+		wanted.Status = *fields.Status
+	}
+
+	if fields.Labels != nil {
+		wanted.Labels = []string{}
+		for _, label := range fields.Labels {
+			if !containsString(wanted.Labels, label) {
+				wanted.Labels = append(wanted.Labels, label)
+			}
+		}
+		sort.Strings(wanted.Labels)
+	}
+
+	return wanted
+}
+
+// Times the fields of the item were last changed on the server after the base version a client changed it
+// from, read from the revisions of the item. Without the revision of the base version every field is taken as
+// changed at the last update of the item
+func (as ApiService) serverChanges(r ApiRepository, current entity.TodoItem, baseVersion int) (map[string]time.Time, error) {
+	changed := map[string]time.Time{}
+	if baseVersion >= current.Version {
+		return changed, nil
+	}
+
+	revisions, err := r.GetItemRevisions(current.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	based := false
+	for _, revision := range revisions {
+		if revision.Item.Version < baseVersion {
+			continue
+		}
+
+		if revision.Item.Version == baseVersion {
+			based = true
+			continue
+		}
+
+		for _, change := range revision.Changes {
+			changed[change.Field] = revision.CreatedAt
+		}
+	}
+
+	if !based {
+		for _, field := range syncFields {
+			changed[field] = current.UpdatedAt
+		}
+	}
+
+	return changed, nil
+}
+
+// Resolves a field both the client and the server changed, the client wins with last_writer_wins when it
+// changed the field after the server did
+func resolveSyncConflict(change entity.SyncChangeDto, strategy string, serverChangedAt time.Time) string {
+	if strategy == entity.SyncStrategyLastWriterWins && change.ChangedAt.After(serverChangedAt) {
+		return entity.SyncResolutionClient
+	}
+
+	return entity.SyncResolutionServer
+}
+
+func syncError(result entity.SyncResultDto, message string) entity.SyncResultDto {
+	result.Status = entity.SyncFailed
+	result.Error = message
+	return result
+}
+
+// Creates the item of a change with the id the client gave it, the change conflicts with the server when
+// another item has the id
+func (as ApiService) createSyncedItem(r ApiRepository, change entity.SyncChangeDto, userId string) (item entity.TodoItem, conflicts []entity.SyncConflictDto, message string) {
+	fields := change.Fields
+	dto := entity.TodoItemDto{
+		Name: *fields.Name,
+		Details: entity.TodoItemDetailsDto{
+			Description: *fields.Description,
+			DueDate:     *fields.DueDate,
+			Priority:    *fields.Priority,
+		},
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(dto); err != nil {
+		return item, nil, fmt.Sprintf(constants.BAD_REQUEST, err)
+	}
+
+	wanted := syncedItem(entity.TodoItem{Status: entity.StatusTodo}, fields)
+	if wanted.Status != entity.StatusTodo && !as.statusTransitions().CanTransition(entity.StatusTodo, wanted.Status) {
+		return item, nil, fmt.Sprintf(constants.INVALID_STATUS_TRANSITION, entity.StatusTodo, wanted.Status)
+	}
+
+	item, err := r.CreateTodoItemWithId(change.Id, &dto, userId)
+	if errors.Is(err, ErrItemIdTaken) {
+		return item, []entity.SyncConflictDto{{Field: "id", Client: change.Id, Resolution: entity.SyncResolutionServer}}, ""
+	}
+
+	if err == nil && wanted.Status != entity.StatusTodo {
+		item, err = r.SetItemStatus(item.Id, wanted.Status, item.Version)
+	}
+
+	if err == nil && len(wanted.Labels) > 0 {
+		item, err = r.SetItemLabels(item.Id, wanted.Labels, nil)
+	}
+
+	if err == nil {
+		err = as.recordRevision(r, item, userId, entity.RevisionCreated)
+	}
+
+	if err != nil {
+		return item, nil, fmt.Sprintf(constants.CREATE_ITEM_ERROR, err)
+	}
+
+	return item, nil, ""
+}
+
+// Applies the fields of a change the server did not change since the base version, and the conflicting ones
+// the client wins
+func (as ApiService) updateSyncedItem(r ApiRepository, change entity.SyncChangeDto, strategy string, current entity.TodoItem, userId string) (item entity.TodoItem, conflicts []entity.SyncConflictDto, message string) {
+	wanted := syncedItem(current, change.Fields)
+
+	changed, err := as.serverChanges(r, current, change.BaseVersion)
+	if err != nil {
+		return current, nil, fmt.Sprintf(constants.UPDATE_ITEM_ERROR, err)
+	}
+
+	resolved := current
+	for _, field := range diffItems(current, wanted) {
+		if changedAt, ok := changed[field.Field]; ok {
+			resolution := resolveSyncConflict(change, strategy, changedAt)
+			conflicts = append(conflicts, entity.SyncConflictDto{Field: field.Field, Client: field.To, Server: field.From, Resolution: resolution})
+
+			if resolution == entity.SyncResolutionServer {
+				continue
+			}
+		}
+
+		switch field.Field {
+		case "name":
+			resolved.Item.Name = wanted.Item.Name
+		case "description":
+			resolved.Item.Details.Description = wanted.Item.Details.Description
+		case "due_date":
+			resolved.Item.Details.DueDate = wanted.Item.Details.DueDate
+		case "priority":
+			resolved.Item.Details.Priority = wanted.Item.Details.Priority
+		case "status":
+			resolved.Status = wanted.Status
+		case "labels":
+			resolved.Labels = wanted.Labels
+		}
+	}
+
+	if resolved.Status != current.Status {
+		if !as.statusTransitions().CanTransition(current.Status, resolved.Status) {
+			return current, conflicts, fmt.Sprintf(constants.INVALID_STATUS_TRANSITION, current.Status, resolved.Status)
+		}
+
+		if waitsOnBlockers(current, resolved.Status) {
+			return current, conflicts, constants.ITEM_BLOCKED
+		}
+	}
+
+	columns := changedItemColumns(current.Item, resolved.Item)
+	add, remove := labelChanges(current.Labels, resolved.Labels)
+
+	item = current
+	if len(columns) > 0 {
+		item, err = r.PatchTodoItem(item.Id, columns, item.Version)
+	}
+
+	if err == nil && resolved.Status != current.Status {
+		item, err = r.SetItemStatus(item.Id, resolved.Status, item.Version)
+	}
+
+	if err == nil && (len(add) > 0 || len(remove) > 0) {
+		item, err = r.SetItemLabels(item.Id, add, remove)
+	}
+
+	if err == nil && item.Version != current.Version {
+		action := entity.RevisionUpdated
+		if len(columns) == 0 && len(add) == 0 && len(remove) == 0 {
+			action = entity.RevisionStatusChanged
+		}
+
+		err = as.recordRevision(r, item, userId, action)
+	}
+
+	if errors.Is(err, ErrVersionMismatch) {
+		return current, conflicts, constants.ITEM_VERSION_MISMATCH
+	}
+
+	if err != nil {
+		return current, conflicts, fmt.Sprintf(constants.UPDATE_ITEM_ERROR, err)
+	}
+
+	return item, conflicts, ""
+}
+
+// Moves the item of a change to trash, unless the server changed it since the base version and wins
+func (as ApiService) deleteSyncedItem(r ApiRepository, change entity.SyncChangeDto, strategy string, current entity.TodoItem, userId string) (item entity.TodoItem, conflicts []entity.SyncConflictDto, message string) {
+	role, err := r.GetItemRole(current.Id, userId)
+	if err != nil {
+		return current, nil, fmt.Sprintf(constants.DELETE_ITEM_ERROR, err)
+	}
+
+	if !roleAllows(role, entity.RoleOwner) {
+		return current, nil, fmt.Sprintf(constants.INSUFFICIENT_ROLE, entity.RoleOwner)
+	}
+
+	changed, err := as.serverChanges(r, current, change.BaseVersion)
+	if err != nil {
+		return current, nil, fmt.Sprintf(constants.DELETE_ITEM_ERROR, err)
+	}
+
+	if len(changed) > 0 {
+		var changedAt time.Time
+		for _, at := range changed {
+			if at.After(changedAt) {
+				changedAt = at
+			}
+		}
+
+		resolution := resolveSyncConflict(change, strategy, changedAt)
+		conflicts = append(conflicts, entity.SyncConflictDto{Field: "is_deleted", Client: true, Server: false, Resolution: resolution})
+
+		if resolution == entity.SyncResolutionServer {
+			return current, conflicts, ""
+		}
+	}
+
+	err = r.DeleteTodoItem(current.Id, current.Version)
+	item = current
+	item.IsDeleted = true
+
+	if err == nil {
+		err = as.recordRevision(r, item, userId, entity.RevisionDeleted)
+	}
+
+	if errors.Is(err, ErrVersionMismatch) {
+		return current, conflicts, constants.ITEM_VERSION_MISMATCH
+	}
+
+	if err != nil {
+		return current, conflicts, fmt.Sprintf(constants.DELETE_ITEM_ERROR, err)
+	}
+
+	return item, conflicts, ""
+}
+
+// Applies a single change of a client, items holds the current state of the items of the list. Creating an
+// item that exists already updates it, as the client did not get the response of an earlier sync, and
+// deleting an item that is no longer in the list is done already
+func (as ApiService) applySyncChange(r ApiRepository, change entity.SyncChangeDto, strategy string, items map[string]entity.TodoItem, userId string) entity.SyncResultDto {
+	result := entity.SyncResultDto{
+		Id: change.Id,
+		Op: change.Op,
+	}
+
+	if err := validateSyncChange(change); err != nil {
+		return syncError(result, fmt.Sprintf(constants.BAD_REQUEST, err))
+	}
+
+	current, ok := items[change.Id]
+
+	var item entity.TodoItem
+	var message string
+
+	switch {
+	case change.Op == entity.SyncOpCreate && !ok:
+		item, result.Conflicts, message = as.createSyncedItem(r, change, userId)
+		if len(result.Conflicts) > 0 {
+			result.Status = entity.SyncConflict
+			result.Error = constants.ITEM_ID_TAKEN
+			return result
+		}
+	case change.Op == entity.SyncOpDelete && !ok:
+		result.Status = entity.SyncApplied
+		return result
+	case !ok:
+		return syncError(result, constants.DOES_NOT_BELONG_TO_USER)
+	case change.Op == entity.SyncOpDelete:
+		item, result.Conflicts, message = as.deleteSyncedItem(r, change, strategy, current, userId)
+	default:
+		if change.Op == entity.SyncOpCreate && change.BaseVersion == 0 {
+			change.BaseVersion = 1
+		}
+
+		item, result.Conflicts, message = as.updateSyncedItem(r, change, strategy, current, userId)
+	}
+
+	if message != "" {
+		return syncError(result, message)
+	}
+
+	if item.IsDeleted {
+		delete(items, item.Id)
+	} else {
+		items[item.Id] = item
+	}
+
+	result.Status = entity.SyncApplied
+	if len(result.Conflicts) > 0 {
+		result.Status = entity.SyncConflict
+	}
+
+	result.Item = &item
+	return result
+}
+
+// Applies the changes of a client in one transaction, each in a savepoint so only the failed ones are rolled back
+func (as ApiService) applySyncChanges(r ApiRepository, request entity.SyncRequestDto, userId string) ([]entity.SyncResultDto, error) {
+	results := make([]entity.SyncResultDto, len(request.Changes))
+	if len(request.Changes) == 0 {
+		return results, nil
+	}
+
+	strategy := request.Strategy
+	if strategy == "" {
+		strategy = entity.SyncStrategyReport
+	}
+
+	ids := make([]string, len(request.Changes))
+	for i, change := range request.Changes {
+		ids[i] = change.Id
+	}
+
+	err := r.WithTx(func(tx ApiRepository) error {
+		items, err := tx.FindListItems(ids, userId)
+		if err != nil {
+			return err
+		}
+
+		for i, change := range request.Changes {
+			if _, err := tx.DB.Exec(SavepointQuery); err != nil {
+				return err
+			}
+
+			results[i] = as.applySyncChange(tx, change, strategy, items, userId)
+
+			if results[i].Status != entity.SyncFailed {
+				if _, err := tx.DB.Exec(ReleaseSavepointQuery); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if _, err := tx.DB.Exec(RollbackToSavepointQuery); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return results, err
+}
+
+// Merges the items and the tombstones changed after the number of the token in the order of the change
+// sequence, up to limit of them. Items in trash are sent as tombstones, and a client syncing for the first
+// time gets no tombstones as it has none of the deleted items
+func syncResponse(after int64, changes []entity.ItemChange, tombstones []entity.ItemTombstone, limit int) entity.SyncResponseDto {
+	response := entity.SyncResponseDto{
+		Changes:    []entity.ItemChange{},
+		Tombstones: []entity.ItemTombstone{},
+	}
+
+	last := after
+	i, j := 0, 0
+
+	for n := 0; n < limit && (i < len(changes) || j < len(tombstones)); n++ {
+		if j == len(tombstones) || i < len(changes) && changes[i].ChangeSeq < tombstones[j].ChangeSeq {
+			change := changes[i]
+			i++
+			last = change.ChangeSeq
+
+			if !change.Item.IsDeleted {
+				response.Changes = append(response.Changes, change)
+				continue
+			}
+
+			if after > 0 {
+				deletedAt := change.Item.UpdatedAt
+				if change.Item.DeletedAt != nil {
+					deletedAt = *change.Item.DeletedAt
+				}
+
+				response.Tombstones = append(response.Tombstones, entity.ItemTombstone{Id: change.Item.Id, ChangeSeq: change.ChangeSeq, DeletedAt: deletedAt})
+			}
+			continue
+		}
+
+		tombstone := tombstones[j]
+		j++
+		last = tombstone.ChangeSeq
+
+		if after > 0 {
+			response.Tombstones = append(response.Tombstones, tombstone)
+		}
+	}
+
+	response.Token = strconv.FormatInt(last, 10)
+	response.HasMore = i < len(changes) || j < len(tombstones)
+
+	return response
+}
+
+// Syncs the items of an offline client
+// @Summary applies the changes of an offline client and returns the changes of the server since its last sync
+// @Description The token is the one of the previous response, a client syncing for the first time sends none and gets every item. Each change creates, updates or deletes an item from its base_version, the version of the item the client changed. A field both the client and the server changed since the base version is a conflict: with report the server keeps its value, with last_writer_wins the one changed last wins, by the changed_at of the change. The other fields are applied either way. Ids of created items are up to 100 letters, digits and @._- characters, creating an item with the id of another item is a conflict on the id and the client creates it again with a new id. Each change gets a result, a failed change is rolled back alone. The response lists the items changed after the token, including the ones the changes applied, and tombstones for the items moved to trash or purged, in the order of the change sequence. Apply them in that order and keep the new token; with has_more sync again right away.
+// @Tags Sync
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param dto body entity.SyncRequestDto true "Token and changes of the client"
+// @Success 200 {object} entity.SyncResponseDto
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_WORKSPACE_ROLE
+// @Failure 500 string constants.SYNC_ITEMS_ERROR
+// @Router /sync [post]
+func (as ApiService) SyncItems(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	request := new(entity.SyncRequestDto)
+	if err := c.Bind(request); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(request)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	after, err := parseSyncToken(request.Token)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	if len(request.Changes) > 0 {
+		allowed, err := as.authorizeCreate(c)
+		if !allowed {
+			return err
+		}
+	}
+
+	results, err := as.applySyncChanges(as.repo(c), *request, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.SYNC_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	changes, tombstones, err := as.repo(c).GetItemChanges(userId, after, syncBatchSize+1)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.SYNC_ITEMS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	response := syncResponse(after, changes, tombstones, syncBatchSize)
+	response.Results = results
+
+	return c.JSONPretty(http.StatusOK, response, " ")
+}
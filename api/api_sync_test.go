@@ -0,0 +1,91 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestSync(t *testing.T) {
+	changedAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+	t.Run("Test Parse a sync token", func(t *testing.T) {
+		after, err := parseSyncToken("")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), after)
+
+		after, err = parseSyncToken("1042")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1042), after)
+
+		_, err = parseSyncToken("-1")
+		assert.EqualError(t, err, `Invalid sync token "-1"`)
+	})
+
+	t.Run("Test Validate a change", func(t *testing.T) {
+		id := "3a35452e-957c-4588-8d40-c88f370067d2"
+		name := "Todo list item 1"
+		status := "archived"
+
+		assert.NoError(t, validateSyncChange(entity.SyncChangeDto{Id: id, Op: entity.SyncOpUpdate, Fields: &entity.SyncFieldsDto{Name: &name}}))
+		assert.EqualError(t, validateSyncChange(entity.SyncChangeDto{Id: id, Op: entity.SyncOpCreate, Fields: &entity.SyncFieldsDto{Name: &name}}), "Invalid create change: name, description, due_date and priority are required")
+		assert.EqualError(t, validateSyncChange(entity.SyncChangeDto{Id: id, Op: entity.SyncOpUpdate, Fields: &entity.SyncFieldsDto{Status: &status}}), "Invalid update change: status is invalid")
+		assert.EqualError(t, validateSyncChange(entity.SyncChangeDto{Id: "x'; DROP TABLE todo_items; --", Op: entity.SyncOpDelete}), "Invalid delete change: id is invalid")
+		assert.EqualError(t, validateSyncChange(entity.SyncChangeDto{Id: strings.Repeat("a", 101), Op: entity.SyncOpDelete}), "Invalid delete change: id is invalid")
+	})
+
+	t.Run("Test Fields of a change replace the ones of the item", func(t *testing.T) {
+		current := entity.TodoItem{Item: entity.TodoItemDto{Name: "Todo list item 1"}, Status: entity.StatusTodo, Labels: []string{"home"}}
+		status := entity.StatusDone
+
+		wanted := syncedItem(current, &entity.SyncFieldsDto{Status: &status, Labels: []string{"work", "finance", "work"}})
+
+		assert.Equal(t, "Todo list item 1", wanted.Item.Name)
+		assert.Equal(t, entity.StatusDone, wanted.Status)
+		assert.Equal(t, []string{"finance", "work"}, wanted.Labels)
+		assert.Equal(t, []string{"home"}, current.Labels)
+	})
+
+	t.Run("Test Resolve a conflict", func(t *testing.T) {
+		change := entity.SyncChangeDto{ChangedAt: changedAt}
+
+		assert.Equal(t, entity.SyncResolutionServer, resolveSyncConflict(change, entity.SyncStrategyReport, changedAt.Add(-time.Hour)))
+		assert.Equal(t, entity.SyncResolutionClient, resolveSyncConflict(change, entity.SyncStrategyLastWriterWins, changedAt.Add(-time.Hour)))
+		assert.Equal(t, entity.SyncResolutionServer, resolveSyncConflict(change, entity.SyncStrategyLastWriterWins, changedAt))
+	})
+
+	t.Run("Test Merge items and tombstones in the order of the changes", func(t *testing.T) {
+		changes := []entity.ItemChange{
+			{ChangeSeq: 41, Item: entity.TodoItem{Id: "a"}},
+			{ChangeSeq: 43, Item: entity.TodoItem{Id: "b", IsDeleted: true, DeletedAt: &changedAt}},
+			{ChangeSeq: 45, Item: entity.TodoItem{Id: "c"}},
+		}
+		tombstones := []entity.ItemTombstone{{Id: "d", ChangeSeq: 42, Purged: true}}
+
+		response := syncResponse(40, changes, tombstones, 3)
+
+		assert.Equal(t, "43", response.Token)
+		assert.True(t, response.HasMore)
+		assert.Equal(t, []entity.ItemChange{changes[0]}, response.Changes)
+		assert.Equal(t, []entity.ItemTombstone{tombstones[0], {Id: "b", ChangeSeq: 43, DeletedAt: changedAt}}, response.Tombstones)
+
+		response = syncResponse(43, changes[2:], nil, 3)
+
+		assert.Equal(t, "45", response.Token)
+		assert.False(t, response.HasMore)
+		assert.Len(t, response.Changes, 1)
+	})
+
+	t.Run("Test Token stays when nothing changed", func(t *testing.T) {
+		response := syncResponse(45, nil, nil, 3)
+
+		assert.Equal(t, "45", response.Token)
+		assert.False(t, response.HasMore)
+		assert.Empty(t, response.Changes)
+		assert.Empty(t, response.Tombstones)
+	})
+}
@@ -0,0 +1,525 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Checks the due times and the dependencies of the items of a template, an item cannot wait on itself
+// or on an item waiting on it
+func validateTemplateItems(items []entity.TemplateItem) error {
+	for i, item := range items {
+		if item.DueTime != "" {
+			if _, err := time.Parse("15:04", item.DueTime); err != nil {
+				return fmt.Errorf("item %d: due_time must be like 17:00", i)
+			}
+		}
+
+		for _, blocker := range item.BlockedBy {
+			if blocker < 0 || blocker >= len(items) {
+				return fmt.Errorf("item %d: blocked_by %d is not an item of the template", i, blocker)
+			}
+
+			if blocker == i {
+				return fmt.Errorf("item %d: an item cannot wait on itself", i)
+			}
+		}
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	state := make([]int, len(items))
+
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		if state[i] == visiting {
+			return false
+		}
+
+		if state[i] == visited {
+			return true
+		}
+
+		state[i] = visiting
+		for _, blocker := range items[i].BlockedBy {
+			if !visit(blocker) {
+				return false
+			}
+		}
+		state[i] = visited
+
+		return true
+	}
+
+	for i := range items {
+		if !visit(i) {
+			return fmt.Errorf("item %d: the dependencies form a cycle", i)
+		}
+	}
+
+	return nil
+}
+
+// Days are counted on the calendar so daylight saving changes do not move an item to another day
+func daysBetween(from, to time.Time) int {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// Template of existing items, the due dates become offsets from the earliest due date and the dependencies
+// between the items are kept. Times are taken in location, an item due at the end of the day has no due time
+func templateFromItems(items []entity.TodoItem, location *time.Location) []entity.TemplateItem {
+	var base time.Time
+	positions := map[string]int{}
+
+	for i, item := range items {
+		due := item.Item.Details.DueDate.In(location)
+		if i == 0 || due.Before(base) {
+			base = due
+		}
+
+		positions[item.Id] = i
+	}
+
+	templateItems := make([]entity.TemplateItem, len(items))
+	for i, item := range items {
+		due := item.Item.Details.DueDate.In(location)
+
+		templateItem := entity.TemplateItem{
+			Name:        item.Item.Name,
+			Description: item.Item.Details.Description,
+			Priority:    item.Item.Details.Priority,
+			Labels:      item.Labels,
+			Recurrence:  item.Recurrence,
+			DueInDays:   daysBetween(base, due),
+		}
+
+		if due.Hour() != 23 || due.Minute() != 59 {
+			templateItem.DueTime = due.Format("15:04")
+		}
+
+		for _, blockerId := range item.BlockedBy {
+			if blocker, ok := positions[blockerId]; ok {
+				templateItem.BlockedBy = append(templateItem.BlockedBy, blocker)
+			}
+		}
+
+		templateItems[i] = templateItem
+	}
+
+	return templateItems
+}
+
+// Due date of an item of a template instantiated on the day base, at the end of the day without a due time
+func templateDueDate(item entity.TemplateItem, base time.Time) time.Time {
+	hour, minute, second := 23, 59, 59
+	if item.DueTime != "" {
+		due, _ := time.Parse("15:04", item.DueTime)
+		hour, minute, second = due.Hour(), due.Minute(), 0
+	}
+
+	return time.Date(base.Year(), base.Month(), base.Day()+item.DueInDays, hour, minute, second, 0, base.Location())
+}
+
+// Creates the items of a template in one transaction, each with a created revision. The items are
+// created first so the dependencies can link them by their new ids
+func (as ApiService) instantiateTemplate(r ApiRepository, template entity.Template, base time.Time, userId string) ([]entity.TodoItem, error) {
+	items := make([]entity.TodoItem, len(template.Items))
+
+	err := r.WithTx(func(tx ApiRepository) error {
+		for i, templateItem := range template.Items {
+			t := &entity.TodoItemDto{
+				Name: templateItem.Name,
+				Details: entity.TodoItemDetailsDto{
+					Description: templateItem.Description,
+					DueDate:     templateDueDate(templateItem, base).UTC(),
+					Priority:    templateItem.Priority,
+				},
+			}
+
+			item, err := tx.CreateTodoItem(t, userId)
+			if err != nil {
+				return err
+			}
+
+			if len(templateItem.Labels) > 0 {
+				if item, err = tx.SetItemLabels(item.Id, templateItem.Labels, nil); err != nil {
+					return err
+				}
+			}
+
+			if templateItem.Recurrence != "" {
+				if item, err = tx.SetItemRecurrence(item.Id, templateItem.Recurrence); err != nil {
+					return err
+				}
+			}
+
+			items[i] = item
+		}
+
+		for i, templateItem := range template.Items {
+			if len(templateItem.BlockedBy) == 0 {
+				continue
+			}
+
+			blockerIds := make([]string, len(templateItem.BlockedBy))
+			for j, blocker := range templateItem.BlockedBy {
+				blockerIds[j] = items[blocker].Id
+			}
+
+			item, err := tx.LinkItemDependencies(items[i].Id, blockerIds)
+			if err != nil {
+				return err
+			}
+
+			items[i] = item
+		}
+
+		for _, item := range items {
+			if err := as.recordRevision(tx, item, userId, entity.RevisionCreated); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return items, err
+}
+
+// Lists the templates of the user
+// @Summary lists the templates of the user
+// @Tags Templates
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Success 200 {array} entity.Template
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.GET_TEMPLATES_ERROR
+// @Router /templates [get]
+func (as ApiService) GetTemplates(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	templates, err := as.repo(c).GetTemplates(userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_TEMPLATES_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, templates, " ")
+}
+
+// Finds a template of the user
+// @Summary finds a template
+// @Tags Templates
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "template Id"
+// @Success 200 {object} entity.Template
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.TEMPLATE_NOT_FOUND
+// @Failure 500 string constants.GET_TEMPLATES_ERROR
+// @Router /templates/{id} [get]
+func (as ApiService) GetTemplateById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	template, err := as.repo(c).FindTemplate(param, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.TEMPLATE_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_TEMPLATES_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, template, " ")
+}
+
+// Saves a template of the user
+// @Summary saves a template
+// @Description Each item is due due_in_days after the base date the template is instantiated with, at due_time or at the end of the day. blocked_by lists the positions of the items of the template the item waits on.
+// @Tags Templates
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param dto body entity.TemplateDto true "Template"
+// @Success 201 {object} entity.Template
+// @Failure 400 string constants.INVALID_TEMPLATE
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.SAVE_TEMPLATE_ERROR
+// @Router /templates [post]
+func (as ApiService) CreateTemplate(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	template := new(entity.TemplateDto)
+	if err := c.Bind(template); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(template)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	if err := validateTemplateItems(template.Items); err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_TEMPLATE, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	saved, err := as.repo(c).CreateTemplate(userId, template)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.SAVE_TEMPLATE_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, saved, " ")
+}
+
+// Saves existing items as a template of the user
+// @Summary saves todo items as a template
+// @Description The items keep their names, descriptions, priorities, labels and recurrences. Their due dates become offsets from the earliest due date, with the times of day in the timezone, and the dependencies between the items are kept.
+// @Tags Templates
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param X-Workspace-Id header string false "Workspace of the items"
+// @Param dto body entity.TemplateFromItemsDto true "Items"
+// @Success 201 {object} entity.Template
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.DOES_NOT_BELONG_TO_USER
+// @Failure 500 string constants.SAVE_TEMPLATE_ERROR
+// @Router /templates/from-items [post]
+func (as ApiService) CreateTemplateFromItems(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	fromItems := new(entity.TemplateFromItemsDto)
+	if err := c.Bind(fromItems); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(fromItems)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	location, err := time.LoadLocation(fromItems.Timezone)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_TIMEZONE, fromItems.Timezone)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	items := make([]entity.TodoItem, 0, len(fromItems.ItemIds))
+	seen := map[string]bool{}
+
+	for _, id := range fromItems.ItemIds {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		allowed, err := as.authorizeItem(c, id, userId, entity.RoleViewer)
+		if !allowed {
+			return err
+		}
+
+		item, err := as.repo(c).FindItemById(id)
+		if err != nil {
+			errMessage := fmt.Sprintf(constants.FIND_ITEM_BY_ITEM_ERROR, err)
+			return c.String(http.StatusInternalServerError, errMessage)
+		}
+
+		items = append(items, item)
+	}
+
+	template := &entity.TemplateDto{Name: fromItems.Name, Items: templateFromItems(items, location)}
+
+	saved, err := as.repo(c).CreateTemplate(userId, template)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.SAVE_TEMPLATE_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, saved, " ")
+}
+
+// Deletes a template of the user, the items created from it are kept
+// @Summary deletes a template
+// @Tags Templates
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "template Id"
+// @Success 200 string constants.DELETE_TEMPLATE_SUCCESSFULL
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.TEMPLATE_NOT_FOUND
+// @Failure 500 string constants.DELETE_TEMPLATE_ERROR
+// @Router /templates/{id} [delete]
+func (as ApiService) DeleteTemplateById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	err := as.repo(c).DeleteTemplate(param, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.TEMPLATE_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.DELETE_TEMPLATE_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.String(http.StatusOK, constants.DELETE_TEMPLATE_SUCCESSFULL)
+}
+
+// Creates the items of a template with due dates relative to a base date
+// @Summary creates the todo items of a template
+// @Description The items are created in the personal list of the user, or in the workspace selected by the X-Workspace-Id header. Each item is due due_in_days after base_date, at its due time in the timezone.
+// @Tags Templates
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param X-Workspace-Id header string false "Workspace to create the items in"
+// @Param id path string true "template Id"
+// @Param dto body entity.InstantiateTemplateDto true "Base date"
+// @Success 201 {array} entity.TodoItem
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_WORKSPACE_ROLE
+// @Failure 404 string constants.TEMPLATE_NOT_FOUND
+// @Failure 500 string constants.INSTANTIATE_TEMPLATE_ERROR
+// @Router /templates/{id}/instantiate [post]
+func (as ApiService) InstantiateTemplate(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	instantiate := new(entity.InstantiateTemplateDto)
+	if err := c.Bind(instantiate); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(instantiate)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	location, err := time.LoadLocation(instantiate.Timezone)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVALID_TIMEZONE, instantiate.Timezone)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	base, err := time.ParseInLocation("2006-01-02", instantiate.BaseDate, location)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, "base_date must be like 2023-11-01")
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	allowed, err := as.authorizeCreate(c)
+	if !allowed {
+		return err
+	}
+
+	template, err := as.repo(c).FindTemplate(param, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.TEMPLATE_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INSTANTIATE_TEMPLATE_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	items, err := as.instantiateTemplate(as.repo(c), template, base, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INSTANTIATE_TEMPLATE_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, items, " ")
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestTemplates(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	t.Run("Test Validate template items", func(t *testing.T) {
+		items := []entity.TemplateItem{
+			{Name: "Collect receipts"},
+			{Name: "Reconcile accounts", BlockedBy: []int{0}, DueTime: "17:00"},
+			{Name: "File the report", BlockedBy: []int{0, 1}},
+		}
+		assert.NoError(t, validateTemplateItems(items))
+
+		assert.Error(t, validateTemplateItems([]entity.TemplateItem{{Name: "Collect receipts", DueTime: "5pm"}}))
+		assert.Error(t, validateTemplateItems([]entity.TemplateItem{{Name: "Collect receipts", BlockedBy: []int{1}}}))
+		assert.Error(t, validateTemplateItems([]entity.TemplateItem{{Name: "Collect receipts", BlockedBy: []int{0}}}))
+
+		cycle := []entity.TemplateItem{
+			{Name: "Collect receipts", BlockedBy: []int{2}},
+			{Name: "Reconcile accounts", BlockedBy: []int{0}},
+			{Name: "File the report", BlockedBy: []int{1}},
+		}
+		assert.Error(t, validateTemplateItems(cycle))
+	})
+
+	t.Run("Test Due dates relative to the base date", func(t *testing.T) {
+		// The clocks change on the 29th
+		base := time.Date(2023, 10, 27, 0, 0, 0, 0, berlin)
+
+		assert.Equal(t, time.Date(2023, 10, 27, 23, 59, 59, 0, berlin), templateDueDate(entity.TemplateItem{}, base))
+		assert.Equal(t, time.Date(2023, 10, 30, 17, 0, 0, 0, berlin), templateDueDate(entity.TemplateItem{DueInDays: 3, DueTime: "17:00"}, base))
+		assert.Equal(t, time.Date(2023, 10, 26, 23, 59, 59, 0, berlin), templateDueDate(entity.TemplateItem{DueInDays: -1}, base))
+	})
+
+	t.Run("Test Template from items", func(t *testing.T) {
+		item := func(id string, due time.Time, blockedBy ...string) entity.TodoItem {
+			return entity.TodoItem{
+				Id:        id,
+				Item:      entity.TodoItemDto{Name: "Item " + id, Details: entity.TodoItemDetailsDto{DueDate: due.UTC(), Priority: "HIGH"}},
+				Labels:    []string{"finance"},
+				BlockedBy: blockedBy,
+			}
+		}
+
+		items := []entity.TodoItem{
+			item("1", time.Date(2023, 10, 30, 17, 0, 0, 0, berlin), "2", "outside"),
+			item("2", time.Date(2023, 10, 28, 23, 59, 59, 0, berlin)),
+		}
+
+		templateItems := templateFromItems(items, berlin)
+
+		assert.Equal(t, entity.TemplateItem{Name: "Item 1", Priority: "HIGH", Labels: []string{"finance"}, DueInDays: 2, DueTime: "17:00", BlockedBy: []int{1}}, templateItems[0])
+		assert.Equal(t, entity.TemplateItem{Name: "Item 2", Priority: "HIGH", Labels: []string{"finance"}}, templateItems[1])
+		assert.NoError(t, validateTemplateItems(templateItems))
+	})
+}
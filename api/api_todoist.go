@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"todo-project/entity"
+)
+
+// Backup of Todoist with the projects and the items of the account like the sync API returns them
+type todoistExport struct {
+	Projects []todoistProject `json:"projects"`
+	Items    []todoistItem    `json:"items"`
+}
+
+type todoistProject struct {
+	Id           appId   `json:"id"`
+	Name         string  `json:"name"`
+	InboxProject appFlag `json:"inbox_project"`
+}
+
+type todoistItem struct {
+	Id          appId       `json:"id"`
+	ProjectId   appId       `json:"project_id"`
+	Content     string      `json:"content"`
+	Description string      `json:"description"`
+	Priority    int         `json:"priority"`
+	Due         *todoistDue `json:"due"`
+	Labels      []string    `json:"labels"`
+	Checked     appFlag     `json:"checked"`
+	IsDeleted   appFlag     `json:"is_deleted"`
+}
+
+// Due date of an item, a day like 2023-10-15, a floating time like 2023-10-15T09:00:00 or a time in UTC like
+// 2023-10-15T07:00:00Z. A floating time with a timezone is fixed to it
+type todoistDue struct {
+	Date     string `json:"date"`
+	Timezone string `json:"timezone"`
+}
+
+// Priority of an item, Todoist shows priority 4 as p1, the most urgent one
+func todoistPriority(priority int) string {
+	switch priority {
+	case 4:
+		return "HIGH"
+	case 3:
+		return "MEDIUM"
+	}
+
+	return "LOW"
+}
+
+func todoistDueDate(due todoistDue, location *time.Location) (time.Time, error) {
+	if timezone, err := time.LoadLocation(due.Timezone); due.Timezone != "" && err == nil {
+		location = timezone
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02T15:04:05", due.Date, location); err == nil {
+		return date.UTC(), nil
+	}
+
+	date, err := parseCSVDueDate(due.Date, location)
+	if err != nil {
+		return date, fmt.Errorf("due date %q must be a date like 2023-10-15 or 2023-10-15T09:00:00", due.Date)
+	}
+
+	return date.UTC(), nil
+}
+
+// Reads the items of a Todoist backup. The project of an item is a label unless it is the inbox, deleted
+// items are left out and a checked item is done
+func readTodoistTasks(file io.Reader, location *time.Location) ([]appTask, error) {
+	var export todoistExport
+	if err := json.NewDecoder(file).Decode(&export); err != nil {
+		return nil, err
+	}
+
+	if export.Items == nil {
+		return nil, errors.New("the backup has no items")
+	}
+
+	projects := map[appId]string{}
+	for _, project := range export.Projects {
+		if !project.InboxProject {
+			projects[project.Id] = project.Name
+		}
+	}
+
+	tasks := []appTask{}
+	for _, todoistItem := range export.Items {
+		if todoistItem.IsDeleted {
+			continue
+		}
+
+		if todoistItem.Id == "" {
+			return nil, errors.New("an item has no id")
+		}
+
+		task := appTask{sourceId: string(todoistItem.Id)}
+		task.item.Item.Name = strings.TrimSpace(todoistItem.Content)
+		task.item.Item.Details.Description = strings.TrimSpace(todoistItem.Description)
+		task.item.Item.Details.Priority = todoistPriority(todoistItem.Priority)
+		task.item.Labels = addAppLabels(nil, projects[todoistItem.ProjectId])
+		task.item.Labels = addAppLabels(task.item.Labels, todoistItem.Labels...)
+
+		task.item.Status = entity.StatusTodo
+		if todoistItem.Checked {
+			task.item.Status = entity.StatusDone
+		}
+
+		if todoistItem.Due != nil && todoistItem.Due.Date != "" {
+			due, err := todoistDueDate(*todoistItem.Due, location)
+			if err != nil {
+				return nil, fmt.Errorf("item %s: %v", todoistItem.Id, err)
+			}
+
+			task.item.Item.Details.DueDate = due
+			task.hasDue = true
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"todo-project/entity"
+)
+
+var (
+	todoTxtPriority = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todoTxtDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// Priority of a todo.txt letter, A is high, B medium and C to Z low
+func priorityOfLetter(letter string) string {
+	switch letter {
+	case "A":
+		return "HIGH"
+	case "B":
+		return "MEDIUM"
+	}
+
+	return "LOW"
+}
+
+func letterOfPriority(priority string) string {
+	switch priority {
+	case "HIGH":
+		return "A"
+	case "LOW":
+		return "C"
+	}
+
+	return "B"
+}
+
+// Writes the items as todo.txt, one line for each item
+type todoTxtExporter struct {
+	writer *bufio.Writer
+}
+
+func (e *todoTxtExporter) contentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (e *todoTxtExporter) fileName() string {
+	return "todo.txt"
+}
+
+func (e *todoTxtExporter) begin() error {
+	return nil
+}
+
+func (e *todoTxtExporter) write(item entity.TodoItem) error {
+	_, err := e.writer.WriteString(todoTxtLine(item) + "\n")
+	return err
+}
+
+func (e *todoTxtExporter) flush() error {
+	return e.writer.Flush()
+}
+
+func (e *todoTxtExporter) end() error {
+	return e.flush()
+}
+
+// Line of an item like (A) 2023-10-01 Pay rent +home due:2023-10-15. todo.txt only knows open and completed
+// tasks, so cancelled items are completed too and keep their priority as pri:. Labels are written as projects
+// and the due date is the day in UTC
+func todoTxtLine(item entity.TodoItem) string {
+	var words []string
+
+	closed := item.Status == entity.StatusDone || item.Status == entity.StatusCancelled
+	if closed {
+		completedAt := item.UpdatedAt
+		if item.CompletedAt != nil {
+			completedAt = *item.CompletedAt
+		}
+		words = append(words, "x", completedAt.UTC().Format("2006-01-02"))
+	} else {
+		words = append(words, "("+letterOfPriority(item.Item.Details.Priority)+")")
+	}
+
+	if !item.CreatedAt.IsZero() {
+		words = append(words, item.CreatedAt.UTC().Format("2006-01-02"))
+	}
+
+	words = append(words, strings.Fields(item.Item.Name)...)
+	for _, label := range item.Labels {
+		words = append(words, "+"+strings.Join(strings.Fields(label), "_"))
+	}
+
+	words = append(words, "due:"+item.Item.Details.DueDate.UTC().Format("2006-01-02"))
+	if closed {
+		words = append(words, "pri:"+letterOfPriority(item.Item.Details.Priority))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Item of a line of todo.txt. Projects and contexts become labels, due: is the due date and pri: the priority
+// of a completed task, any other key:value stays in the name. The completion and creation dates are not kept,
+// the item is created and completed by the import
+func todoTxtItem(line string, location *time.Location) (entity.TodoItem, error) {
+	item := entity.TodoItem{Status: entity.StatusTodo}
+	item.Item.Details.Priority = "MEDIUM"
+
+	words := strings.Fields(line)
+	dates := 1
+	if len(words) > 0 && words[0] == "x" {
+		item.Status = entity.StatusDone
+		words = words[1:]
+		dates = 2
+	}
+
+	if len(words) > 0 && todoTxtPriority.MatchString(words[0]) {
+		item.Item.Details.Priority = priorityOfLetter(words[0][1:2])
+		words = words[1:]
+	}
+
+	for ; dates > 0 && len(words) > 0 && todoTxtDate.MatchString(words[0]); dates-- {
+		words = words[1:]
+	}
+
+	var name []string
+	due := ""
+
+	for _, word := range words {
+		switch {
+		case len(word) > 1 && (word[0] == '+' || word[0] == '@'):
+			if !containsString(item.Labels, word[1:]) {
+				item.Labels = append(item.Labels, word[1:])
+			}
+		case strings.HasPrefix(word, "due:") && len(word) > len("due:"):
+			due = word[len("due:"):]
+		case strings.HasPrefix(word, "pri:") && todoTxtPriority.MatchString("("+word[len("pri:"):]+")"):
+			item.Item.Details.Priority = priorityOfLetter(word[len("pri:"):])
+		default:
+			name = append(name, word)
+		}
+	}
+
+	item.Item.Name = strings.Join(name, " ")
+
+	dueDate, err := textDueDate(due, location)
+	if err != nil {
+		return item, err
+	}
+	item.Item.Details.DueDate = dueDate
+
+	return item, validateTextItem(item)
+}
+
+// Reads a todo.txt file, every line that is not blank is an item. Rows are the numbers of the lines
+func readTodoTxtItems(file io.Reader, location *time.Location) (result entity.ImportResultDto, items []entity.TodoItem, err error) {
+	result.Errors = []entity.ImportRowError{}
+
+	scanner := bufio.NewScanner(file)
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		result.Rows++
+		if result.Rows > maxImportRows {
+			return result, nil, errTooManyRows
+		}
+
+		item, err := todoTxtItem(line, location)
+		if err != nil {
+			result.Errors = append(result.Errors, entity.ImportRowError{Row: row, Error: err.Error()})
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	return result, items, scanner.Err()
+}
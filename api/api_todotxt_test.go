@@ -0,0 +1,106 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestTodoTxt(t *testing.T) {
+	createdAt := time.Date(2023, 10, 1, 9, 38, 24, 0, time.UTC)
+	dueDate := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	t.Run("Test Line of an open item", func(t *testing.T) {
+		item := entity.TodoItem{
+			Item:      entity.TodoItemDto{Name: "Pay rent", Details: entity.TodoItemDetailsDto{Description: "Flat", DueDate: dueDate, Priority: "HIGH"}},
+			Status:    entity.StatusInProgress,
+			Labels:    []string{"home", "monthly bills"},
+			CreatedAt: createdAt,
+		}
+
+		assert.Equal(t, "(A) 2023-10-01 Pay rent +home +monthly_bills due:2023-10-15", todoTxtLine(item))
+	})
+
+	t.Run("Test Line of a cancelled item", func(t *testing.T) {
+		updatedAt := createdAt.AddDate(0, 0, 3)
+		item := entity.TodoItem{
+			Item:      entity.TodoItemDto{Name: "Call\nmom", Details: entity.TodoItemDetailsDto{DueDate: dueDate, Priority: "LOW"}},
+			Status:    entity.StatusCancelled,
+			CreatedAt: createdAt,
+			UpdatedAt: updatedAt,
+		}
+
+		assert.Equal(t, "x 2023-10-04 2023-10-01 Call mom due:2023-10-15 pri:C", todoTxtLine(item))
+	})
+
+	t.Run("Test Item of a line", func(t *testing.T) {
+		item, err := todoTxtItem("(A) 2023-10-01 Pay rent +home @bank rec:1m due:2023-10-15", berlin)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Pay rent rec:1m", item.Item.Name)
+		assert.Equal(t, "HIGH", item.Item.Details.Priority)
+		assert.Equal(t, entity.StatusTodo, item.Status)
+		assert.Equal(t, []string{"home", "bank"}, item.Labels)
+		assert.Equal(t, time.Date(2023, 10, 15, 21, 59, 59, 0, time.UTC), item.Item.Details.DueDate)
+	})
+
+	t.Run("Test Item of a completed line", func(t *testing.T) {
+		item, err := todoTxtItem("x 2023-10-04 2023-10-01 Call mom due:2023-10-15T09:38:24Z pri:D", time.UTC)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Call mom", item.Item.Name)
+		assert.Equal(t, "LOW", item.Item.Details.Priority)
+		assert.Equal(t, entity.StatusDone, item.Status)
+		assert.Equal(t, dueDate, item.Item.Details.DueDate)
+	})
+
+	t.Run("Test Item without a priority and a due date", func(t *testing.T) {
+		item, err := todoTxtItem("2023-10-01 Water the plants", time.UTC)
+		assert.NoError(t, err)
+
+		now := time.Now().UTC()
+		assert.Equal(t, "Water the plants", item.Item.Name)
+		assert.Equal(t, "MEDIUM", item.Item.Details.Priority)
+		assert.Equal(t, time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.UTC), item.Item.Details.DueDate)
+	})
+
+	t.Run("Test Invalid lines", func(t *testing.T) {
+		_, err := todoTxtItem("(B) Go +gym", time.UTC)
+		assert.Error(t, err)
+
+		_, err = todoTxtItem("Pay rent due:someday", time.UTC)
+		assert.EqualError(t, err, "due:someday must be a date like 2023-10-15")
+	})
+
+	t.Run("Test Read the lines", func(t *testing.T) {
+		result, items, err := readTodoTxtItems(strings.NewReader("(A) Pay rent due:2023-10-15\n\nGo\nx Call mom due:2023-10-15\n"), time.UTC)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 3, result.Rows)
+		assert.Len(t, items, 2)
+		assert.Len(t, result.Errors, 1)
+		assert.Equal(t, 3, result.Errors[0].Row)
+	})
+
+	t.Run("Test Export and import again", func(t *testing.T) {
+		item := entity.TodoItem{
+			Item:      entity.TodoItemDto{Name: "Pay rent", Details: entity.TodoItemDetailsDto{DueDate: time.Date(2023, 10, 15, 23, 59, 59, 0, time.UTC), Priority: "LOW"}},
+			Status:    entity.StatusDone,
+			Labels:    []string{"home"},
+			CreatedAt: createdAt,
+		}
+
+		imported, err := todoTxtItem(todoTxtLine(item), time.UTC)
+		assert.NoError(t, err)
+
+		assert.Equal(t, item.Item, imported.Item)
+		assert.Equal(t, item.Status, imported.Status)
+		assert.Equal(t, item.Labels, imported.Labels)
+	})
+}
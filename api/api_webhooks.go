@@ -0,0 +1,700 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+const (
+	HeaderWebhookId        = "X-Webhook-Id"
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// Event sent by the send test event endpoint, it has no item
+const WebhookTestEvent = "ping"
+
+// Events a webhook can subscribe to, one for each action recorded in the history of an item
+var WebhookEvents = []string{
+	"item." + entity.RevisionCreated,
+	"item." + entity.RevisionUpdated,
+	"item." + entity.RevisionCompleted,
+	"item." + entity.RevisionStatusChanged,
+	"item." + entity.RevisionDeleted,
+	"item." + entity.RevisionRestored,
+	"item." + entity.RevisionReverted,
+	"item." + entity.RevisionAssigned,
+	"item." + entity.RevisionUnassigned,
+}
+
+// Deliveries sent by the worker at once, and the time a claimed delivery is left to the worker sending it
+const (
+	webhookBatchSize = 50
+	webhookLease     = time.Minute
+	webhookMaxDelay  = 6 * time.Hour
+	webhookLogLimit  = 100
+)
+
+// How the deliveries are sent, a delivery failing MaxAttempts times is dead. The delay before the next
+// attempt starts at Backoff and doubles with each failed attempt. Loopback, private and link-local addresses
+// cannot receive deliveries unless they are in AllowedNetworks, like a receiver running next to the server
+type WebhookSettings struct {
+	MaxAttempts     int
+	Backoff         time.Duration
+	AllowedNetworks []netip.Prefix
+	Client          *http.Client
+}
+
+var DefaultWebhookSettings = WebhookSettings{
+	MaxAttempts: 8,
+	Backoff:     30 * time.Second,
+}
+
+// Parses the settings from their configuration, an empty value keeps the default of that setting. The allowed
+// networks are comma separated addresses or CIDR prefixes
+func ParseWebhookSettings(maxAttempts, backoff, allowedNetworks string) (WebhookSettings, error) {
+	settings := DefaultWebhookSettings
+
+	if strings.TrimSpace(maxAttempts) != "" {
+		attempts, err := strconv.Atoi(strings.TrimSpace(maxAttempts))
+		if err != nil || attempts <= 0 {
+			return settings, fmt.Errorf("invalid maximum attempts %q", maxAttempts)
+		}
+		settings.MaxAttempts = attempts
+	}
+
+	if strings.TrimSpace(backoff) != "" {
+		delay, err := time.ParseDuration(strings.TrimSpace(backoff))
+		if err != nil || delay <= 0 {
+			return settings, fmt.Errorf("invalid backoff %q", backoff)
+		}
+		settings.Backoff = delay
+	}
+
+	for _, network := range strings.Split(allowedNetworks, ",") {
+		network = strings.TrimSpace(network)
+		if network == "" {
+			continue
+		}
+
+		var prefix netip.Prefix
+		addr, err := netip.ParseAddr(network)
+		if err == nil {
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		} else {
+			prefix, err = netip.ParsePrefix(network)
+		}
+
+		if err != nil {
+			return settings, fmt.Errorf("invalid allowed network %q", network)
+		}
+		settings.AllowedNetworks = append(settings.AllowedNetworks, prefix.Masked())
+	}
+
+	return settings, nil
+}
+
+// The client does not follow redirects and only dials public addresses or the allowed networks, the address is
+// checked when it is dialed so a host resolving to an internal address later on is refused as well
+func (s WebhookSettings) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return s.checkAddress(address)
+		},
+	}
+
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:       dialer.DialContext,
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Refuses the loopback, private, link-local and other internal addresses that are not in the allowed networks
+func (s WebhookSettings) checkAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf(constants.WEBHOOK_ADDRESS_NOT_ALLOWED, host)
+	}
+	addr = addr.Unmap()
+
+	for _, network := range s.AllowedNetworks {
+		if network.Contains(addr) {
+			return nil
+		}
+	}
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf(constants.WEBHOOK_ADDRESS_NOT_ALLOWED, addr)
+	}
+
+	return nil
+}
+
+// Delay before the attempt following the failed attempts, capped so a delivery is tried at least a few times a day
+func (s WebhookSettings) backoff(attempts int) time.Duration {
+	delay := s.Backoff
+	for i := 1; i < attempts && delay < webhookMaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > webhookMaxDelay {
+		return webhookMaxDelay
+	}
+
+	return delay
+}
+
+// Status of a delivery after its attempts, with the time of the next attempt while it is pending
+func (s WebhookSettings) outcome(attempts int, attempt entity.WebhookAttempt) (string, *time.Time) {
+	if attempt.Error == "" {
+		return entity.WebhookDeliveryDelivered, nil
+	}
+
+	if attempts >= s.MaxAttempts {
+		return entity.WebhookDeliveryDead, nil
+	}
+
+	next := attempt.AttemptedAt.Add(s.backoff(attempts))
+	return entity.WebhookDeliveryPending, &next
+}
+
+// Settings of the service, the test event is sent with the defaults when webhooks are not enabled
+func (as ApiService) webhookSettings() WebhookSettings {
+	if as.Webhooks == nil {
+		return DefaultWebhookSettings
+	}
+
+	return *as.Webhooks
+}
+
+func validateWebhookEvents(events []string) error {
+	for _, event := range events {
+		if !containsString(WebhookEvents, event) {
+			return fmt.Errorf(constants.INVALID_WEBHOOK_EVENT, event)
+		}
+	}
+
+	return nil
+}
+
+// Only http and https URLs can receive deliveries, a URL naming an internal address is refused as soon as it is
+// registered. Host names are resolved and checked again each time a delivery is sent
+func (s WebhookSettings) validateUrl(rawUrl string) error {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return err
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("url must be an http or https URL")
+	}
+
+	host := parsed.Hostname()
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		host = "127.0.0.1"
+	}
+
+	if _, err := netip.ParseAddr(host); err == nil {
+		return s.checkAddress(host)
+	}
+
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// Signature of a payload sent at timestamp, the receiver computes the HMAC-SHA256 of "<timestamp>.<body>" with
+// the secret of the webhook and compares it with the X-Webhook-Signature header
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Posts the payload of a delivery to its webhook, any response but a 2xx one is a failed attempt
+func (s WebhookSettings) send(job entity.WebhookJob) entity.WebhookAttempt {
+	start := time.Now()
+	attempt := entity.WebhookAttempt{AttemptedAt: start.UTC()}
+
+	req, err := http.NewRequest(http.MethodPost, job.Url, bytes.NewReader(job.Delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(HeaderWebhookId, job.Delivery.Id)
+	req.Header.Set(HeaderWebhookEvent, job.Delivery.Event)
+	req.Header.Set(HeaderWebhookTimestamp, strconv.FormatInt(start.Unix(), 10))
+	req.Header.Set(HeaderWebhookSignature, signWebhook(job.Secret, start.Unix(), job.Delivery.Payload))
+
+	res, err := s.client().Do(req)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	attempt.StatusCode = res.StatusCode
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		attempt.Error = fmt.Sprintf(constants.WEBHOOK_REJECTED, res.StatusCode)
+	}
+
+	return attempt
+}
+
+// Sends a claimed delivery once and records the attempt
+func (r ApiRepository) sendWebhookDelivery(settings WebhookSettings, job entity.WebhookJob) error {
+	attempt := settings.send(job)
+	status, next := settings.outcome(job.Delivery.Attempts+1, attempt)
+
+	return r.RecordWebhookAttempt(job.Delivery.Id, attempt, status, next)
+}
+
+// Sends the deliveries that are due, the deliveries of a batch are sent concurrently so a slow receiver does
+// not hold up the others. Returns the number of deliveries that were sent
+func (r ApiRepository) DeliverWebhooks(settings WebhookSettings) (int, error) {
+	jobs, err := r.ClaimWebhookDeliveries(webhookBatchSize, webhookLease)
+	if err != nil {
+		return 0, err
+	}
+
+	errs := make([]error, len(jobs))
+
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job entity.WebhookJob) {
+			defer wg.Done()
+			errs[i] = r.sendWebhookDelivery(settings, job)
+		}(i, job)
+	}
+	wg.Wait()
+
+	return len(jobs), errors.Join(errs...)
+}
+
+// Lists the webhooks of the user
+// @Summary lists the webhooks of the user
+// @Description The secrets of the webhooks are only returned when they are created.
+// @Tags Webhooks
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Success 200 {array} entity.Webhook
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.GET_WEBHOOKS_ERROR
+// @Router /webhooks [get]
+func (as ApiService) GetWebhooks(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	webhooks, err := as.repo(c).GetWebhooks(userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_WEBHOOKS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return c.JSONPretty(http.StatusOK, webhooks, " ")
+}
+
+// Registers a webhook of the user for events of the items of their list or of the workspace
+// @Summary registers a webhook
+// @Description Each event is posted as JSON to the URL with the headers X-Webhook-Id, X-Webhook-Event, X-Webhook-Timestamp and X-Webhook-Signature. The signature is sha256= followed by the hex HMAC-SHA256 of "<timestamp>.<body>" with the secret. A secret is generated when none is given. Failed deliveries are retried with exponential backoff until they are dead. Redirects are not followed and URLs on loopback, private or link-local addresses are refused unless they are allowed by the configuration. Events: item.created, item.updated, item.completed, item.status_changed, item.deleted, item.restored, item.reverted, item.assigned and item.unassigned.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param X-Workspace-Id header string false "Workspace of the items"
+// @Param dto body entity.WebhookDto true "Webhook"
+// @Success 201 {object} entity.Webhook
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_WORKSPACE_ROLE
+// @Failure 500 string constants.CREATE_WEBHOOK_ERROR
+// @Router /webhooks [post]
+func (as ApiService) CreateWebhook(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeCreate(c)
+	if !allowed {
+		return err
+	}
+
+	webhook := new(entity.WebhookDto)
+	if err := c.Bind(webhook); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err = validate.Struct(webhook)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	if err := as.webhookSettings().validateUrl(webhook.Url); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	if err := validateWebhookEvents(webhook.Events); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	secret := webhook.Secret
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			errMessage := fmt.Sprintf(constants.CREATE_WEBHOOK_ERROR, err)
+			return c.String(http.StatusInternalServerError, errMessage)
+		}
+	}
+
+	saved, err := as.repo(c).CreateWebhook(webhook.Url, secret, webhook.Events, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CREATE_WEBHOOK_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, saved, " ")
+}
+
+// Deletes a webhook of the user, the deliveries still pending are not sent
+// @Summary deletes a webhook
+// @Tags Webhooks
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "webhook Id"
+// @Success 200 string constants.DELETE_WEBHOOK_SUCCESSFULL
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.WEBHOOK_NOT_FOUND
+// @Failure 500 string constants.DELETE_WEBHOOK_ERROR
+// @Router /webhooks/{id} [delete]
+func (as ApiService) DeleteWebhookById(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	err := as.repo(c).DeleteWebhook(param, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.WEBHOOK_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.DELETE_WEBHOOK_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.String(http.StatusOK, constants.DELETE_WEBHOOK_SUCCESSFULL)
+}
+
+// Sends a test event to a webhook of the user right away
+// @Summary sends a test event to a webhook
+// @Description A ping event without an item is delivered once while the request waits, the delivery is returned with its log. A failed test event is retried like any other delivery.
+// @Tags Webhooks
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "webhook Id"
+// @Success 200 {object} entity.WebhookDelivery
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.WEBHOOK_NOT_FOUND
+// @Failure 500 string constants.SEND_WEBHOOK_ERROR
+// @Router /webhooks/{id}/test [post]
+func (as ApiService) SendTestWebhook(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	r := as.repo(c)
+
+	webhook, err := r.FindWebhook(param, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.WEBHOOK_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.SEND_WEBHOOK_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	deliveries, err := r.CreateWebhookDeliveries([]string{webhook.Id}, WebhookTestEvent, userId, nil)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.SEND_WEBHOOK_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	job := entity.WebhookJob{Delivery: deliveries[0], Url: webhook.Url, Secret: webhook.Secret}
+	if err := r.sendWebhookDelivery(as.webhookSettings(), job); err != nil {
+		errMessage := fmt.Sprintf(constants.SEND_WEBHOOK_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	delivery, err := r.FindWebhookDelivery(job.Delivery.Id, webhook.Id, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.SEND_WEBHOOK_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, delivery, " ")
+}
+
+// Lists the latest deliveries of a webhook of the user
+// @Summary lists the deliveries of a webhook
+// @Description The 100 latest deliveries, newest first. status filters them by pending, delivered or dead.
+// @Tags Webhooks
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "webhook Id"
+// @Param status query string false "pending, delivered or dead"
+// @Success 200 {array} entity.WebhookDelivery
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.WEBHOOK_NOT_FOUND
+// @Failure 500 string constants.GET_WEBHOOK_DELIVERIES_ERROR
+// @Router /webhooks/{id}/deliveries [get]
+func (as ApiService) GetWebhookDeliveries(c echo.Context) error {
+	param := c.Param("id")
+	status := c.QueryParam("status")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	if status != "" && status != entity.WebhookDeliveryPending && status != entity.WebhookDeliveryDelivered && status != entity.WebhookDeliveryDead {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, fmt.Errorf("status must be pending, delivered or dead"))
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	r := as.repo(c)
+
+	_, err := r.FindWebhook(param, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.WEBHOOK_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_WEBHOOK_DELIVERIES_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	deliveries, err := r.GetWebhookDeliveries(userId, param, status, webhookLogLimit)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_WEBHOOK_DELIVERIES_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, deliveries, " ")
+}
+
+// Lists the dead deliveries of all the webhooks of the user
+// @Summary lists the dead letters of the webhooks
+// @Description The 100 latest deliveries that failed every attempt, newest first. They can be redelivered once the receiver is fixed.
+// @Tags Webhooks
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Success 200 {array} entity.WebhookDelivery
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.GET_WEBHOOK_DELIVERIES_ERROR
+// @Router /webhooks/dead-letters [get]
+func (as ApiService) GetDeadWebhookDeliveries(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	deliveries, err := as.repo(c).GetWebhookDeliveries(userId, "", entity.WebhookDeliveryDead, webhookLogLimit)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_WEBHOOK_DELIVERIES_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, deliveries, " ")
+}
+
+// Finds a delivery of a webhook of the user with the log of its attempts
+// @Summary finds a delivery of a webhook
+// @Tags Webhooks
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "webhook Id"
+// @Param deliveryId path string true "delivery Id"
+// @Success 200 {object} entity.WebhookDelivery
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.WEBHOOK_DELIVERY_NOT_FOUND
+// @Failure 500 string constants.GET_WEBHOOK_DELIVERIES_ERROR
+// @Router /webhooks/{id}/deliveries/{deliveryId} [get]
+func (as ApiService) GetWebhookDeliveryById(c echo.Context) error {
+	param := c.Param("id")
+	deliveryId := c.Param("deliveryId")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	delivery, err := as.repo(c).FindWebhookDelivery(deliveryId, param, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.WEBHOOK_DELIVERY_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_WEBHOOK_DELIVERIES_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, delivery, " ")
+}
+
+// Queues a delivery of a webhook of the user again, e.g. a dead letter once the receiver is fixed
+// @Summary redelivers a delivery of a webhook
+// @Description The delivery is pending again with a fresh set of attempts and is sent by the next run of the worker. Its log is kept.
+// @Tags Webhooks
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "webhook Id"
+// @Param deliveryId path string true "delivery Id"
+// @Success 200 {object} entity.WebhookDelivery
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.WEBHOOK_DELIVERY_NOT_FOUND
+// @Failure 500 string constants.REDELIVER_WEBHOOK_ERROR
+// @Router /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (as ApiService) RedeliverWebhookById(c echo.Context) error {
+	param := c.Param("id")
+	deliveryId := c.Param("deliveryId")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	delivery, err := as.repo(c).RedeliverWebhookDelivery(deliveryId, param, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.WEBHOOK_DELIVERY_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.REDELIVER_WEBHOOK_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, delivery, " ")
+}
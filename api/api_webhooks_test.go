@@ -0,0 +1,204 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestWebhooks(t *testing.T) {
+	t.Run("Test Sign a payload", func(t *testing.T) {
+		signature := signWebhook("secret", 1697362704, []byte(`{"event":"ping"}`))
+
+		assert.Equal(t, signWebhook("secret", 1697362704, []byte(`{"event":"ping"}`)), signature)
+		assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+		assert.NotEqual(t, signature, signWebhook("other", 1697362704, []byte(`{"event":"ping"}`)))
+		assert.NotEqual(t, signature, signWebhook("secret", 1697362705, []byte(`{"event":"ping"}`)))
+	})
+
+	t.Run("Test Parse settings", func(t *testing.T) {
+		settings, err := ParseWebhookSettings("", "", "")
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultWebhookSettings, settings)
+
+		settings, err = ParseWebhookSettings(" 3 ", "1m", "127.0.0.1, 10.1.2.3/16,::1")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, settings.MaxAttempts)
+		assert.Equal(t, time.Minute, settings.Backoff)
+		assert.Equal(t, []netip.Prefix{
+			netip.MustParsePrefix("127.0.0.1/32"),
+			netip.MustParsePrefix("10.1.0.0/16"),
+			netip.MustParsePrefix("::1/128"),
+		}, settings.AllowedNetworks)
+
+		_, err = ParseWebhookSettings("0", "", "")
+		assert.Error(t, err)
+
+		_, err = ParseWebhookSettings("", "soon", "")
+		assert.Error(t, err)
+
+		_, err = ParseWebhookSettings("", "", "localhost")
+		assert.Error(t, err)
+	})
+
+	t.Run("Test Check the dialed address", func(t *testing.T) {
+		settings := WebhookSettings{AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}}
+
+		assert.NoError(t, settings.checkAddress("93.184.216.34:443"))
+		assert.NoError(t, settings.checkAddress("[2606:2800:220:1:248:1893:25c8:1946]:443"))
+		assert.NoError(t, settings.checkAddress("10.1.2.3:8080"))
+
+		assert.EqualError(t, settings.checkAddress("127.0.0.1:5432"), "Webhooks cannot be sent to the internal address 127.0.0.1")
+		assert.Error(t, settings.checkAddress("169.254.169.254:80"))
+		assert.Error(t, settings.checkAddress("10.2.0.1:80"))
+		assert.Error(t, settings.checkAddress("192.168.1.1:80"))
+		assert.Error(t, settings.checkAddress("0.0.0.0:80"))
+		assert.Error(t, settings.checkAddress("[::1]:80"))
+		assert.Error(t, settings.checkAddress("[::ffff:127.0.0.1]:80"))
+		assert.Error(t, settings.checkAddress("[fe80::1]:80"))
+		assert.Error(t, settings.checkAddress("[fd00::1]:80"))
+	})
+
+	t.Run("Test Backoff doubles up to the cap", func(t *testing.T) {
+		settings := WebhookSettings{MaxAttempts: 20, Backoff: 30 * time.Second}
+
+		assert.Equal(t, 30*time.Second, settings.backoff(1))
+		assert.Equal(t, time.Minute, settings.backoff(2))
+		assert.Equal(t, 4*time.Minute, settings.backoff(4))
+		assert.Equal(t, 6*time.Hour, settings.backoff(15))
+	})
+
+	t.Run("Test Outcome of an attempt", func(t *testing.T) {
+		settings := WebhookSettings{MaxAttempts: 3, Backoff: time.Minute}
+		attemptedAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+		status, next := settings.outcome(1, entity.WebhookAttempt{AttemptedAt: attemptedAt, StatusCode: 204})
+		assert.Equal(t, entity.WebhookDeliveryDelivered, status)
+		assert.Nil(t, next)
+
+		status, next = settings.outcome(2, entity.WebhookAttempt{AttemptedAt: attemptedAt, Error: "connection refused"})
+		assert.Equal(t, entity.WebhookDeliveryPending, status)
+		assert.Equal(t, attemptedAt.Add(2*time.Minute), *next)
+
+		status, next = settings.outcome(3, entity.WebhookAttempt{AttemptedAt: attemptedAt, Error: "connection refused"})
+		assert.Equal(t, entity.WebhookDeliveryDead, status)
+		assert.Nil(t, next)
+	})
+
+	t.Run("Test Event of an action", func(t *testing.T) {
+		assert.Equal(t, "item.created", itemEvent(entity.RevisionCreated, entity.TodoItem{}))
+		assert.Equal(t, "item.completed", itemEvent(entity.RevisionStatusChanged, entity.TodoItem{Status: entity.StatusDone}))
+		assert.Equal(t, "item.status_changed", itemEvent(entity.RevisionStatusChanged, entity.TodoItem{Status: entity.StatusBlocked}))
+	})
+
+	t.Run("Test Validate events and URL", func(t *testing.T) {
+		assert.NoError(t, validateWebhookEvents([]string{"item.created", "item.deleted"}))
+		assert.EqualError(t, validateWebhookEvents([]string{"item.created", "item.archived"}), "Unknown webhook event item.archived")
+
+		assert.NoError(t, DefaultWebhookSettings.validateUrl("https://ci.example.com/hooks"))
+		assert.Error(t, DefaultWebhookSettings.validateUrl("ftp://example.com/hooks"))
+		assert.Error(t, DefaultWebhookSettings.validateUrl("http://localhost:8080/hooks"))
+		assert.Error(t, DefaultWebhookSettings.validateUrl("http://169.254.169.254/latest/meta-data"))
+		assert.Error(t, DefaultWebhookSettings.validateUrl("http://[::1]:5432/"))
+
+		local := WebhookSettings{AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")}}
+		assert.NoError(t, local.validateUrl("http://localhost:8080/hooks"))
+	})
+
+	local := WebhookSettings{
+		MaxAttempts:     DefaultWebhookSettings.MaxAttempts,
+		Backoff:         DefaultWebhookSettings.Backoff,
+		AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")},
+	}
+
+	t.Run("Test Send a delivery to a receiver", func(t *testing.T) {
+		payload := []byte(`{"id":"1c3e5a7b","event":"item.completed"}`)
+
+		var received *http.Request
+		var body []byte
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		job := entity.WebhookJob{
+			Delivery: entity.WebhookDelivery{Id: "1c3e5a7b", Event: "item.completed", Payload: payload},
+			Url:      receiver.URL,
+			Secret:   "secret",
+		}
+
+		attempt := local.send(job)
+
+		assert.Equal(t, http.StatusNoContent, attempt.StatusCode)
+		assert.Empty(t, attempt.Error)
+		assert.Equal(t, payload, body)
+		assert.Equal(t, "1c3e5a7b", received.Header.Get(HeaderWebhookId))
+		assert.Equal(t, "item.completed", received.Header.Get(HeaderWebhookEvent))
+
+		timestamp, err := strconv.ParseInt(received.Header.Get(HeaderWebhookTimestamp), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, signWebhook("secret", timestamp, payload), received.Header.Get(HeaderWebhookSignature))
+	})
+
+	t.Run("Test Send a delivery to a failing receiver", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+
+		job := entity.WebhookJob{Delivery: entity.WebhookDelivery{Id: "1c3e5a7b", Event: "ping"}, Url: receiver.URL, Secret: "secret"}
+
+		attempt := local.send(job)
+		assert.Equal(t, http.StatusBadGateway, attempt.StatusCode)
+		assert.Equal(t, "Webhook was rejected with status 502", attempt.Error)
+
+		receiver.Close()
+
+		attempt = local.send(job)
+		assert.Equal(t, 0, attempt.StatusCode)
+		assert.NotEmpty(t, attempt.Error)
+	})
+
+	t.Run("Test Send a delivery to an internal receiver", func(t *testing.T) {
+		received := false
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = true
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		job := entity.WebhookJob{Delivery: entity.WebhookDelivery{Id: "1c3e5a7b", Event: "ping"}, Url: receiver.URL, Secret: "secret"}
+
+		attempt := DefaultWebhookSettings.send(job)
+		assert.Equal(t, 0, attempt.StatusCode)
+		assert.Contains(t, attempt.Error, "Webhooks cannot be sent to the internal address 127.0.0.1")
+		assert.False(t, received)
+	})
+
+	t.Run("Test Send a delivery that is redirected", func(t *testing.T) {
+		redirected := false
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			redirected = true
+		}))
+		defer target.Close()
+
+		receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+		defer receiver.Close()
+
+		job := entity.WebhookJob{Delivery: entity.WebhookDelivery{Id: "1c3e5a7b", Event: "ping"}, Url: receiver.URL, Secret: "secret"}
+
+		attempt := local.send(job)
+		assert.Equal(t, http.StatusTemporaryRedirect, attempt.StatusCode)
+		assert.Equal(t, "Webhook was rejected with status 307", attempt.Error)
+		assert.False(t, redirected)
+	})
+}
@@ -0,0 +1,485 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+
+	"todo-project/authentication"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Header selecting the workspace of a request, it takes precedence over the workspace of the token
+const HeaderWorkspaceId = "X-Workspace-Id"
+
+const (
+	workspaceKey     = "workspace"
+	workspaceRoleKey = "workspace_role"
+)
+
+// Workspace selected by the request, empty for the personal space of the user
+func workspaceOf(c echo.Context) string {
+	workspaceId, _ := c.Get(workspaceKey).(string)
+	return workspaceId
+}
+
+// Repository scoped to the workspace selected by the request
+func (as ApiService) repo(c echo.Context) ApiRepository {
+	return as.R.InWorkspace(workspaceOf(c))
+}
+
+// Middleware selecting the workspace of the header or the token for the request, users who are not
+// members of the workspace are rejected before any item of it is read
+func (as ApiService) WorkspaceScope(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token, ok := c.Get("user").(*jwt.Token)
+		if !ok {
+			return next(c)
+		}
+
+		workspaceId := c.Request().Header.Get(HeaderWorkspaceId)
+		if workspaceId == "" {
+			workspaceId = authentication.GetWorkspaceFromToken(token)
+		}
+
+		if workspaceId == "" {
+			return next(c)
+		}
+
+		userId, ok := authentication.GetUserFromToken(token)
+		if !ok {
+			return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+		}
+
+		role, err := as.R.GetWorkspaceRole(workspaceId, userId)
+		if err != nil {
+			errMessage := fmt.Sprintf(constants.GET_WORKSPACE_ROLE_ERROR, err)
+			return c.String(http.StatusInternalServerError, errMessage)
+		}
+
+		if role == "" {
+			return c.String(http.StatusForbidden, constants.NOT_A_WORKSPACE_MEMBER)
+		}
+
+		c.Set(workspaceKey, workspaceId)
+		c.Set(workspaceRoleKey, role)
+
+		return next(c)
+	}
+}
+
+// Checks the user can create items in the selected workspace, which needs the editor role
+func (as ApiService) authorizeCreate(c echo.Context) (bool, error) {
+	if workspaceOf(c) == "" {
+		return true, nil
+	}
+
+	role, _ := c.Get(workspaceRoleKey).(string)
+	if !roleAllows(role, entity.RoleEditor) {
+		return false, c.String(http.StatusForbidden, fmt.Sprintf(constants.INSUFFICIENT_WORKSPACE_ROLE, entity.RoleEditor))
+	}
+
+	return true, nil
+}
+
+// Checks the user has at least the required role in the workspace, responding with 403 when they do not
+func (as ApiService) authorizeWorkspace(c echo.Context, workspaceId, userId, required string) (bool, error) {
+	role, err := as.R.GetWorkspaceRole(workspaceId, userId)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_WORKSPACE_ROLE_ERROR, err)
+		return false, c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if role == "" {
+		return false, c.String(http.StatusForbidden, constants.NOT_A_WORKSPACE_MEMBER)
+	}
+
+	if !roleAllows(role, required) {
+		return false, c.String(http.StatusForbidden, fmt.Sprintf(constants.INSUFFICIENT_WORKSPACE_ROLE, required))
+	}
+
+	return true, nil
+}
+
+// Creates a workspace with the user as its owner
+// @Summary creates a workspace
+// @Tags Workspaces
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param dto body entity.WorkspaceDto true "Workspace"
+// @Success 201 {object} entity.Workspace
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.CREATE_WORKSPACE_ERROR
+// @Router /workspaces [post]
+func (as ApiService) CreateWorkspace(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	workspace := new(entity.WorkspaceDto)
+	if err := c.Bind(workspace); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(workspace)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	created, err := as.R.CreateWorkspace(workspace.Name, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.CREATE_WORKSPACE_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, created, " ")
+}
+
+// Lists the workspaces the user is a member of with the role of the user in each
+// @Summary lists the workspaces of the user
+// @Tags Workspaces
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Success 200 {array} entity.Workspace
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.GET_WORKSPACES_ERROR
+// @Router /workspaces [get]
+func (as ApiService) GetWorkspaces(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	workspaces, err := as.R.GetWorkspacesOfUser(userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_WORKSPACES_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, workspaces, " ")
+}
+
+// Issues a token selecting the workspace, the items of the workspace are used with it instead of the personal ones
+// @Summary selects a workspace
+// @Description The X-Workspace-Id header selects a workspace for a single request instead.
+// @Tags Workspaces
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "workspace Id"
+// @Success 200 {object} authentication.LoginResponseDto
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.NOT_A_WORKSPACE_MEMBER
+// @Failure 500 string constants.CANNOT_PROCESS_THE_REQUEST
+// @Router /workspaces/{id}/token [post]
+func (as ApiService) CreateWorkspaceToken(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeWorkspace(c, param, userId, entity.RoleViewer)
+	if !allowed {
+		return err
+	}
+
+	workspaceToken, err := authentication.CreateWorkspaceJWTToken(authentication.GetNameFromToken(token), userId, param)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, constants.CANNOT_PROCESS_THE_REQUEST)
+	}
+
+	return c.JSONPretty(http.StatusOK, authentication.LoginResponseDto{
+		Response: constants.WORKSPACE_SELECTED_SUCCESSFULL,
+		Token:    workspaceToken,
+	}, " ")
+}
+
+// Lists the members of a workspace, including the invited users
+// @Summary lists the members of a workspace
+// @Tags Workspaces
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "workspace Id"
+// @Success 200 {array} entity.WorkspaceMember
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.NOT_A_WORKSPACE_MEMBER
+// @Failure 500 string constants.GET_WORKSPACE_MEMBERS_ERROR
+// @Router /workspaces/{id}/members [get]
+func (as ApiService) GetWorkspaceMembers(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	allowed, err := as.authorizeWorkspace(c, param, userId, entity.RoleViewer)
+	if !allowed {
+		return err
+	}
+
+	members, err := as.R.GetWorkspaceMembers(param)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_WORKSPACE_MEMBERS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, members, " ")
+}
+
+// Invites a registered user to a workspace by email
+// @Summary invites a user to a workspace
+// @Description The user becomes a member once they accept the invitation. Inviting a member again changes their role.
+// @Tags Workspaces
+// @Accept json
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "workspace Id"
+// @Param dto body entity.WorkspaceMemberDto true "Member"
+// @Success 201 {object} entity.WorkspaceMember
+// @Failure 400 string constants.BAD_REQUEST
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_WORKSPACE_ROLE
+// @Failure 404 string constants.EMAIL_NOT_REGISTERED
+// @Failure 500 string constants.INVITE_MEMBER_ERROR
+// @Router /workspaces/{id}/members [post]
+func (as ApiService) InviteWorkspaceMember(c echo.Context) error {
+	param := c.Param("id")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	member := new(entity.WorkspaceMemberDto)
+	if err := c.Bind(member); err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	validate := validator.New()
+	err := validate.Struct(member)
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.BAD_REQUEST, err)
+		return c.String(http.StatusBadRequest, errMessage)
+	}
+
+	allowed, err := as.authorizeWorkspace(c, param, userId, entity.RoleOwner)
+	if !allowed {
+		return err
+	}
+
+	memberUserId, err := as.R.FindUserIdByEmail(member.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.EMAIL_NOT_REGISTERED)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVITE_MEMBER_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	if memberUserId == userId {
+		return c.String(http.StatusBadRequest, constants.CANNOT_INVITE_YOURSELF)
+	}
+
+	invited, err := as.R.InviteWorkspaceMember(param, memberUserId, member.Role, userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.INVITE_MEMBER_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusCreated, invited, " ")
+}
+
+// Removes a member from a workspace, an owner can remove any member and a member can leave the workspace
+// @Summary removes a member from a workspace
+// @Description The shares, assignments and watches of the member on the items of the workspace are removed as well. The last owner cannot be removed.
+// @Tags Workspaces
+// @Produce plain
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param id path string true "workspace Id"
+// @Param userId path string true "user Id of the member"
+// @Success 200 string constants.REMOVE_MEMBER_SUCCESSFULL
+// @Failure 400 string constants.LAST_WORKSPACE_OWNER
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 403 string constants.INSUFFICIENT_WORKSPACE_ROLE
+// @Failure 404 string constants.WORKSPACE_MEMBER_NOT_FOUND
+// @Failure 500 string constants.REMOVE_MEMBER_ERROR
+// @Router /workspaces/{id}/members/{userId} [delete]
+func (as ApiService) RemoveWorkspaceMember(c echo.Context) error {
+	param := c.Param("id")
+	memberUserId := c.Param("userId")
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	if memberUserId != userId {
+		allowed, err := as.authorizeWorkspace(c, param, userId, entity.RoleOwner)
+		if !allowed {
+			return err
+		}
+	}
+
+	_, err := as.R.FindWorkspaceMember(param, memberUserId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.WORKSPACE_MEMBER_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.REMOVE_MEMBER_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	err = as.R.DeleteWorkspaceMember(param, memberUserId)
+	if errors.Is(err, ErrLastWorkspaceOwner) {
+		return c.String(http.StatusBadRequest, constants.LAST_WORKSPACE_OWNER)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.REMOVE_MEMBER_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.String(http.StatusOK, constants.REMOVE_MEMBER_SUCCESSFULL)
+}
+
+// Lists the workspace invitations the user has not answered yet
+// @Summary lists the pending workspace invitations of the user
+// @Tags Workspaces
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Success 200 {array} entity.WorkspaceMember
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 500 string constants.GET_INVITATIONS_ERROR
+// @Router /workspaces/invitations [get]
+func (as ApiService) GetWorkspaceInvitations(c echo.Context) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	invitations, err := as.R.GetWorkspaceInvitations(userId)
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.GET_INVITATIONS_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, invitations, " ")
+}
+
+func (as ApiService) respondToWorkspaceInvitation(c echo.Context, status string) error {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	userId, ok := authentication.GetUserFromToken(token)
+	if !ok {
+		return c.String(http.StatusUnauthorized, constants.CANNOT_FETCH_THE_USER_ID)
+	}
+
+	member, err := as.R.RespondToWorkspaceInvitation(c.Param("memberId"), userId, status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.String(http.StatusNotFound, constants.INVITATION_NOT_FOUND)
+	}
+
+	if err != nil {
+		errMessage := fmt.Sprintf(constants.RESPOND_INVITATION_ERROR, err)
+		return c.String(http.StatusInternalServerError, errMessage)
+	}
+
+	return c.JSONPretty(http.StatusOK, member, " ")
+}
+
+// Accepts a workspace invitation, the user can then select the workspace
+// @Summary accepts an invitation to a workspace
+// @Tags Workspaces
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param memberId path string true "membership Id"
+// @Success 200 {object} entity.WorkspaceMember
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.INVITATION_NOT_FOUND
+// @Failure 500 string constants.RESPOND_INVITATION_ERROR
+// @Router /workspaces/invitations/{memberId}/accept [post]
+func (as ApiService) AcceptWorkspaceInvitation(c echo.Context) error {
+	return as.respondToWorkspaceInvitation(c, entity.ShareStatusAccepted)
+}
+
+// Declines a workspace invitation, an owner can invite the user again
+// @Summary declines an invitation to a workspace
+// @Tags Workspaces
+// @Produce json
+// @Security JWT
+// @Param Authorization header string false "Bearer"
+// @Param memberId path string true "membership Id"
+// @Success 200 {object} entity.WorkspaceMember
+// @Failure 401 string constants.CANNOT_FETCH_THE_USER_ID
+// @Failure 404 string constants.INVITATION_NOT_FOUND
+// @Failure 500 string constants.RESPOND_INVITATION_ERROR
+// @Router /workspaces/invitations/{memberId}/decline [post]
+func (as ApiService) DeclineWorkspaceInvitation(c echo.Context) error {
+	return as.respondToWorkspaceInvitation(c, entity.ShareStatusDeclined)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestWorkspaces(t *testing.T) {
+	e := echo.New()
+
+	newContext := func() (echo.Context, *httptest.ResponseRecorder) {
+		rec := httptest.NewRecorder()
+		return e.NewContext(httptest.NewRequest(http.MethodPost, "/item/create", nil), rec), rec
+	}
+
+	t.Run("Test Personal space", func(t *testing.T) {
+		ctx, _ := newContext()
+
+		allowed, err := ApiService{}.authorizeCreate(ctx)
+
+		assert.Empty(t, workspaceOf(ctx))
+		assert.True(t, allowed)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Test Editors can create items", func(t *testing.T) {
+		ctx, _ := newContext()
+		ctx.Set(workspaceKey, "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60")
+		ctx.Set(workspaceRoleKey, entity.RoleEditor)
+
+		allowed, err := ApiService{}.authorizeCreate(ctx)
+
+		assert.True(t, allowed)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Test Viewers cannot create items", func(t *testing.T) {
+		ctx, rec := newContext()
+		ctx.Set(workspaceKey, "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60")
+		ctx.Set(workspaceRoleKey, entity.RoleViewer)
+
+		allowed, _ := ApiService{}.authorizeCreate(ctx)
+
+		assert.False(t, allowed)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Test Repository is scoped to the workspace", func(t *testing.T) {
+		ctx, _ := newContext()
+		ctx.Set(workspaceKey, "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60")
+
+		assert.Equal(t, "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60", ApiService{}.repo(ctx).WorkspaceId)
+		assert.Empty(t, ApiRepository{WorkspaceId: "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"}.InWorkspace("").WorkspaceId)
+	})
+}
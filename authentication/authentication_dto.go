@@ -16,8 +16,9 @@ type User struct {
 }
 
 type JwtClaims struct {
-	Name   string `json:"name"`
-	UserId string `json:"user_id"`
+	Name        string `json:"name"`
+	UserId      string `json:"user_id"`
+	WorkspaceId string `json:"workspace_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -2,6 +2,7 @@ package authentication
 
 //GET
 const IfEmailExistsQuery = `SELECT EXISTS (SELECT 1 FROM users WHERE email = '%s') AS email_exists`
+const CredentialsQuery = `SELECT password, id FROM users WHERE email = $1`
 
 //POST
 const CreateUserQuery = "INSERT INTO users (id, email, password) VALUES ($1, $2, $3)"
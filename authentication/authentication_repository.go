@@ -18,6 +18,9 @@ type Repository interface {
 
 	//login using the email and password
 	Login(c echo.Context) error
+
+	//find the password hash and the id of a user by email
+	FindCredentials(email string) (string, string, error)
 }
 
 type AuthRepository struct {
@@ -66,4 +69,16 @@ func (a *AuthRepository) Login(email string) (string,string,error) {
 	}
 
     return hashPassword,userId,nil
-}
\ No newline at end of file
+}
+
+// Password hash and id of the user with the email, sql.ErrNoRows when no user has it
+func (a *AuthRepository) FindCredentials(email string) (string, string, error) {
+	var userId, hashPassword string
+
+	err := a.DB.QueryRow(CredentialsQuery, email).Scan(&hashPassword, &userId)
+	if err != nil {
+		return "", "", err
+	}
+
+	return hashPassword, userId, nil
+}
@@ -232,4 +232,20 @@ func TestAuthenticationRepository(t *testing.T) {
 			}
 		})
 	})
+	t.Run("FindCredentials", func(t *testing.T) {
+		t.Run("Verifies that the credentials are found with a parameter", func(t *testing.T) {
+			rows := mock.NewRows([]string{"password", "id"}).AddRow("hashPassword", "user-id")
+			mock.ExpectQuery(`SELECT password, id FROM users WHERE email = \$1`).WithArgs("guest'@gmail.com").WillReturnRows(rows)
+
+			hashPassword, userId, err := authRepo.FindCredentials("guest'@gmail.com")
+
+			if err != nil {
+				t.Fatalf("Error must not be thrown: %v", err)
+			}
+
+			if hashPassword != "hashPassword" || userId != "user-id" {
+				t.Errorf("error is not expected")
+			}
+		})
+	})
 }
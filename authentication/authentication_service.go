@@ -1,6 +1,7 @@
 package authentication
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
@@ -40,7 +41,39 @@ func GetUserFromToken(token *jwt.Token) (string, bool) {
 	return "", false
 }
 
+// Name and workspace of the user are optional claims, empty when the token does not carry them
+func GetNameFromToken(token *jwt.Token) string {
+	if token == nil {
+		return ""
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		name, _ := claims["name"].(string)
+		return name
+	}
+
+	return ""
+}
+
+func GetWorkspaceFromToken(token *jwt.Token) string {
+	if token == nil {
+		return ""
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		workspaceId, _ := claims["workspace_id"].(string)
+		return workspaceId
+	}
+
+	return ""
+}
+
 func CreateJWTToken(username, user_id string) (string, error) {
+	return CreateWorkspaceJWTToken(username, user_id, "")
+}
+
+// Token selecting a workspace, every item request made with it is scoped to the workspace
+func CreateWorkspaceJWTToken(username, user_id, workspace_id string) (string, error) {
 	if username == "" || user_id == "" {
 		err := errors.New(constants.INVALID_USERNAME_OR_USER_ID)
 		return "", err
@@ -49,6 +82,7 @@ func CreateJWTToken(username, user_id string) (string, error) {
 	claims := JwtClaims{
 		username,
 		user_id,
+		workspace_id,
 		jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour))},
 	}
@@ -166,3 +200,24 @@ func (s AuthService) UserLogin(c echo.Context) error {
 		Token:    token,
 	}, " ")
 }
+
+// Checks the email and password of HTTP Basic authentication, for the clients that cannot log in for a JWT
+// like CalDAV clients. The user is stored in the context like a verified JWT so the handlers read it the same way
+func (s AuthService) BasicAuthValidator(email, password string, c echo.Context) (bool, error) {
+	hashPassword, userId, err := s.R.FindCredentials(email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hashPassword), []byte(password)) != nil {
+		return false, nil
+	}
+
+	c.Set("user", jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{"name": email, "user_id": userId}))
+
+	return true, nil
+}
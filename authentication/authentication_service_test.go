@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 
@@ -273,4 +274,44 @@ func TestAuthenticationService(t *testing.T) {
 			assert.Equal(t, http.StatusInternalServerError, rec.Code)
 		})
 	})
+	t.Run("Basic Auth Validator", func(t *testing.T) {
+		credentialsQuery := `SELECT password, id FROM users WHERE email = \$1`
+
+		t.Run("verifies that the user of the credentials is set", func(t *testing.T) {
+			rows := mock.NewRows([]string{"password", "id"}).AddRow("$2a$10$h1aiikYlNioOFM39E/NmgO8p4QxtVYmQjLqbVJLYeJNUKr/sKU3GG", "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+			mock.ExpectQuery(credentialsQuery).WithArgs("example@gmail.com").WillReturnRows(rows)
+
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/caldav/", nil), httptest.NewRecorder())
+
+			valid, err := as.BasicAuthValidator("example@gmail.com", "JohnD0@2123", c)
+			assert.NoError(t, err)
+			assert.True(t, valid)
+
+			userId, ok := GetUserFromToken(c.Get("user").(*jwt.Token))
+			assert.True(t, ok)
+			assert.Equal(t, "ed6caeda-1fa9-442e-a41d-dd2b135cea67", userId)
+		})
+
+		t.Run("verifies that a wrong password is rejected", func(t *testing.T) {
+			rows := mock.NewRows([]string{"password", "id"}).AddRow("$2a$10$h1aiikYlNioOFM39E/NmgO8p4QxtVYmQjLqbVJLYeJNUKr/sKU3GG", "ed6caeda-1fa9-442e-a41d-dd2b135cea67")
+			mock.ExpectQuery(credentialsQuery).WithArgs("example@gmail.com").WillReturnRows(rows)
+
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/caldav/", nil), httptest.NewRecorder())
+
+			valid, err := as.BasicAuthValidator("example@gmail.com", "wrong", c)
+			assert.NoError(t, err)
+			assert.False(t, valid)
+			assert.Nil(t, c.Get("user"))
+		})
+
+		t.Run("verifies that an unknown email is rejected", func(t *testing.T) {
+			mock.ExpectQuery(credentialsQuery).WithArgs("unknown@gmail.com").WillReturnError(sql.ErrNoRows)
+
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/caldav/", nil), httptest.NewRecorder())
+
+			valid, err := as.BasicAuthValidator("unknown@gmail.com", "JohnD0@2123", c)
+			assert.NoError(t, err)
+			assert.False(t, valid)
+		})
+	})
 }
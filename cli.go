@@ -0,0 +1,228 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"todo-project/api"
+	"todo-project/constants"
+	"todo-project/entity"
+)
+
+// Commands run instead of the server, e.g. todo-project export -user john@example.com -format txt
+var commands = map[string]func(r api.ApiRepository, args []string, stdin io.Reader, stdout, stderr io.Writer) error{
+	"export": exportCommand,
+	"import": importCommand,
+}
+
+// Runs the command of the arguments and returns its exit code, 2 when the command or its flags are wrong
+func runCommand(r api.ApiRepository, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q, the commands are export and import\n", args[0])
+		return 2
+	}
+
+	err := command(r, args[1:], stdin, stdout, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	var usage usageError
+	if errors.As(err, &usage) {
+		if usage.message != "" {
+			fmt.Fprintln(stderr, usage.message)
+		}
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// Flags of a command that are missing or wrong, the flag package already printed its own errors
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// Parses the flags of a command, the flag package prints the errors and the usage itself
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return usageError{}
+	}
+
+	return err
+}
+
+// Flags selecting the list of a user, the personal list or a workspace
+type listFlags struct {
+	user      string
+	workspace string
+	format    string
+}
+
+func newFlagSet(name string, stderr io.Writer, list *listFlags) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	flags.StringVar(&list.user, "user", "", "Email of the user")
+	flags.StringVar(&list.workspace, "workspace", "", "Id of the workspace, the personal list of the user when empty")
+	flags.StringVar(&list.format, "format", "csv", "Format of the file, csv, txt or md, or ics for an export")
+
+	return flags
+}
+
+// Repository and id of the user of the list, the user needs at least the required role in the workspace
+func (f listFlags) open(r api.ApiRepository, required string) (api.ApiRepository, string, error) {
+	if f.user == "" {
+		return r, "", usageError{"-user is required"}
+	}
+
+	userId, err := r.FindUserIdByEmail(f.user)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, "", errors.New(constants.EMAIL_NOT_REGISTERED)
+	}
+
+	if err != nil {
+		return r, "", err
+	}
+
+	if f.workspace == "" {
+		return r, userId, nil
+	}
+
+	role, err := r.GetWorkspaceRole(f.workspace, userId)
+	if err != nil {
+		return r, "", fmt.Errorf(constants.GET_WORKSPACE_ROLE_ERROR, err)
+	}
+
+	if role == "" {
+		return r, "", errors.New(constants.NOT_A_WORKSPACE_MEMBER)
+	}
+
+	if required == entity.RoleEditor && role == entity.RoleViewer {
+		return r, "", fmt.Errorf(constants.INSUFFICIENT_WORKSPACE_ROLE, required)
+	}
+
+	return r.InWorkspace(f.workspace), userId, nil
+}
+
+// Writes the items of a list to a file or to the standard output
+func exportCommand(r api.ApiRepository, args []string, stdin io.Reader, stdout, stderr io.Writer) (err error) {
+	var list listFlags
+	flags := newFlagSet("export", stderr, &list)
+	output := flags.String("o", "", "File to write, the standard output when empty")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	r, userId, err := list.open(r, entity.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return api.ExportList(r, stdout, userId, list.format)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	return api.ExportList(r, file, userId, list.format)
+}
+
+// Imports the items of a file or of the standard input into a list. Nothing is imported when any row is
+// invalid, the invalid rows are listed on the standard error
+func importCommand(r api.ApiRepository, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var list listFlags
+	flags := newFlagSet("import", stderr, &list)
+	mappingFlag := flags.String("mapping", "", "Columns of the fields of a csv file as a JSON object")
+	timezone := flags.String("timezone", "UTC", "Timezone of the due dates without one")
+	dryRun := flags.Bool("dry-run", false, "Only check the rows")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		return usageError{"at most one file can be imported"}
+	}
+
+	mapping := map[string]string{}
+	if *mappingFlag != "" {
+		if err := json.Unmarshal([]byte(*mappingFlag), &mapping); err != nil {
+			return fmt.Errorf(constants.INVALID_IMPORT_MAPPING, err)
+		}
+	}
+
+	location, err := time.LoadLocation(*timezone)
+	if err != nil {
+		return fmt.Errorf(constants.INVALID_TIMEZONE, *timezone)
+	}
+
+	r, userId, err := list.open(r, entity.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	file := stdin
+	if flags.NArg() == 1 && flags.Arg(0) != "-" {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		file = f
+	}
+
+	result, items, err := api.ReadImport(file, list.format, mapping, location)
+	if err != nil {
+		return err
+	}
+
+	for _, rowErr := range result.Errors {
+		fmt.Fprintf(stderr, "row %d: %s\n", rowErr.Row, rowErr.Error)
+	}
+
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%d of %d rows are invalid, nothing was imported", len(result.Errors), result.Rows)
+	}
+
+	if *dryRun {
+		fmt.Fprintf(stdout, "%d items can be imported\n", len(items))
+		return nil
+	}
+
+	imported, err := r.ImportTodoItems(items, userId)
+	if err != nil {
+		return fmt.Errorf(constants.IMPORT_ITEMS_ERROR, err)
+	}
+
+	fmt.Fprintf(stdout, "%d items imported\n", len(imported))
+	return nil
+}
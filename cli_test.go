@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/api"
+)
+
+func TestCommands(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	repo := api.ApiRepository{
+		DB: db,
+	}
+
+	email := "example@gmail.com"
+	user_id := "ed6caeda-1fa9-442e-a41d-dd2b135cea67"
+	workspace_id := "5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"
+	createdAt := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+	itemColumns := []string{"id", "name", "description", "due_date", "priority", "created_at", "updated_at", "is_completed", "is_deleted", "user_id", "deleted_at", "status", "started_at", "completed_at", "version", "labels", "comment_count", "assignee_id", "watchers", "workspace_id", "blocked_by", "blocked", "rank", "recurrence"}
+
+	run := func(stdin string, args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := runCommand(repo, args, strings.NewReader(stdin), &stdout, &stderr)
+
+		return code, stdout.String(), stderr.String()
+	}
+
+	t.Run("Test Unknown command", func(t *testing.T) {
+		code, _, stderr := run("", "serve")
+
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, `unknown command "serve"`)
+	})
+
+	t.Run("Test Missing user", func(t *testing.T) {
+		code, _, stderr := run("", "export", "-format", "txt")
+
+		assert.Equal(t, 2, code)
+		assert.Equal(t, "-user is required\n", stderr)
+	})
+
+	t.Run("Test Help", func(t *testing.T) {
+		code, _, stderr := run("", "import", "-h")
+
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stderr, "-dry-run")
+	})
+
+	t.Run("Test Export", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).
+			WithArgs(email).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(user_id))
+		mock.ExpectQuery(`SELECT .+ FROM todo_items WHERE is_deleted = false`).
+			WithArgs(user_id, "").
+			WillReturnRows(mock.NewRows(itemColumns).
+				AddRow("3a35452e-957c-4588-8d40-c88f370067d2", "Pay rent", "Flat", createdAt, "HIGH", createdAt, createdAt, false, false, user_id, nil, "todo", nil, nil, 1, "home", 0, "", "", "", "", false, "", ""))
+
+		code, stdout, _ := run("", "export", "-user", email, "-format", "md")
+
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "- [ ] Pay rent !high #home due:2023-10-15\n  Flat\n", stdout)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Export unsupported format", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).
+			WithArgs(email).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(user_id))
+
+		code, _, stderr := run("", "export", "-user", email, "-format", "xlsx")
+
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "Unsupported export format: xlsx\n", stderr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import into a workspace as a viewer", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).
+			WithArgs(email).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(user_id))
+		mock.ExpectQuery(`SELECT role FROM workspace_members`).
+			WithArgs(workspace_id, user_id).
+			WillReturnRows(mock.NewRows([]string{"role"}).AddRow("viewer"))
+
+		code, _, stderr := run("- [ ] Pay rent\n", "import", "-user", email, "-workspace", workspace_id, "-format", "md")
+
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "The editor role in the workspace is required\n", stderr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import reports the invalid rows", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).
+			WithArgs(email).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(user_id))
+
+		code, _, stderr := run("(A) Pay rent\nGo\n", "import", "-user", email, "-format", "txt")
+
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "row 2: the name must be at least 3 characters\n1 of 2 rows are invalid, nothing was imported\n", stderr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Test Import dry run", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id FROM users WHERE email=\$1`).
+			WithArgs(email).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(user_id))
+
+		code, stdout, _ := run("(A) Pay rent\n(B) Call mom\n", "import", "-user", email, "-format", "txt", "-dry-run")
+
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "2 items can be imported\n", stdout)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
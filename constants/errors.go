@@ -1,22 +1,153 @@
 package constants
 
 const (
+	ASSIGNEE_WITHOUT_ACCESS      = `The assignee needs the editor role on the item`
+	ASSIGN_ITEM_ERROR            = `Cannot assign the todo item: %v`
+	ATTACHMENT_NOT_FOUND         = `Attachment not found for the todo item`
+	ATTACHMENT_OVER_QUOTA        = `Attachment does not fit in the storage quota`
+	ATTACHMENT_TOO_LARGE         = `Attachment is larger than the limit of %d bytes`
+	ATTACHMENT_TYPE_NOT_ALLOWED  = `Attachments of type %s are not allowed`
+	ATTACHMENTS_NOT_CONFIGURED   = `Attachment storage is not configured`
 	BAD_REQUEST                  = `Bad request: %v`
+	BLOB_NOT_FOUND               = `Blob not found`
+	BULK_ITEMS_ERROR             = `Cannot run the bulk operations: %v`
+	BULK_OPERATION_NOT_EXECUTED  = `Not executed because an earlier operation failed`
+	CALDAV_ERROR                 = `Cannot process the CalDAV request: %v`
+	CALDAV_NOT_FOUND             = `Calendar or task not found`
+	CALENDAR_FEED_ERROR          = `Cannot change the calendar feed: %v`
+	CALENDAR_FEED_NOT_FOUND      = `Calendar feed not found`
 	CANNOT_CHECK_IF_EMAIL_EXISTS = `Email validation failed: %v`
 	CANNOT_CREATE_TABLE_ERROR    = `Cannot create the table`
 	CANNOT_FETCH_THE_USER_ID     = `Cannot find the user id`
+	CANNOT_INVITE_YOURSELF       = `Cannot invite yourself to the workspace`
 	CANNOT_PROCESS_THE_REQUEST   = `Cannot process the request`
+	CANNOT_SHARE_WITH_OWNER      = `Cannot share an item with its owner`
+	CHANGE_STATUS_ITEM_ERROR     = `Cannot change the status of the todo item: %v`
+	COMMENT_NOT_FOUND            = `Comment not found for the todo item`
+	CREATE_COMMENT_ERROR         = `Cannot create the comment: %v`
 	CREATE_ITEM_ERROR            = `Cannot create the todo item: %v`
+	CREATE_WEBHOOK_ERROR         = `Cannot create the webhook: %v`
+	CREATE_WORKSPACE_ERROR       = `Cannot create the workspace: %v`
 	DATABASE_CONNECTION_ERROR    = `Cannot connect to data base: %v`
+	DELETE_ATTACHMENT_ERROR      = `Cannot delete the attachment: %v`
+	DELETE_BLOB_ERROR            = `Cannot delete the attachment blob %s: %v`
+	DELETE_COMMENT_ERROR         = `Cannot delete the comment: %v`
 	DELETE_ITEM_ERROR            = `Cannot delete the todo item: %v`
+	DELETE_SAVED_FILTER_ERROR    = `Cannot delete the saved filter: %v`
+	DELETE_TEMPLATE_ERROR        = `Cannot delete the template: %v`
+	DELETE_WEBHOOK_ERROR         = `Cannot delete the webhook: %v`
+	DELIVER_WEBHOOKS_ERROR       = `Cannot deliver the webhooks: %v`
+	DEPENDENCY_CYCLE             = `The dependency would make the item wait on itself`
+	DEPENDENCY_ERROR             = `Cannot change the dependencies of the todo item: %v`
+	DEPENDENCY_NOT_FOUND         = `The item does not wait on the blocker`
 	DOES_NOT_BELONG_TO_USER      = `Item does not belong to the current user`
+	EMPTY_TRASH_ERROR            = `Cannot empty the trash: %v`
 	EMAIL_ADDRESS_ALREADY_EXISTS = `User with the email id already exists`
 	EMAIL_NOT_REGISTERED         = `Email id not registered`
+	EVENTS_DISABLED              = `Real-time updates are not enabled`
+	EXPORT_ITEMS_ERROR           = `Cannot export the todo items: %v`
+	FILTER_NOT_FOUND             = `Saved filter not found for the user`
 	FIND_ITEM_BY_ITEM_ERROR      = `Cannot find the item: %v`
 	GET_ALL_ITEMS_ERROR          = `Cannot fetch todo item list: %v`
+	GET_ATTACHMENTS_ERROR        = `Cannot fetch the attachments of the todo item: %v`
+	GET_BOARD_ERROR              = `Cannot fetch the board: %v`
+	GET_COMMENTS_ERROR           = `Cannot fetch the comments of the todo item: %v`
+	GET_DEPENDENCIES_ERROR       = `Cannot fetch the dependencies of the todo item: %v`
+	GET_FILTERED_ITEMS_ERROR     = `Cannot fetch the items of the saved filter: %v`
+	GET_INVITATIONS_ERROR        = `Cannot fetch the invitations of the user: %v`
+	GET_ITEM_EVENTS_ERROR        = `Cannot fetch the events of the todo items: %v`
+	GET_ITEM_HISTORY_ERROR       = `Cannot fetch the history of the todo item: %v`
+	GET_SAVED_FILTERS_ERROR      = `Cannot fetch the saved filters: %v`
+	GET_SHARED_ITEMS_ERROR       = `Cannot fetch the items shared with the user: %v`
+	GET_SHARES_ERROR             = `Cannot fetch the shares of the todo item: %v`
+	GET_TEMPLATES_ERROR          = `Cannot fetch the templates: %v`
+	GET_TRASH_ITEMS_ERROR        = `Cannot fetch the deleted todo items: %v`
+	GET_WEBHOOKS_ERROR           = `Cannot fetch the webhooks: %v`
+	GET_WEBHOOK_DELIVERIES_ERROR = `Cannot fetch the deliveries of the webhook: %v`
+	GET_WORKSPACE_MEMBERS_ERROR  = `Cannot get the members of the workspace: %v`
+	GET_WORKSPACE_ROLE_ERROR     = `Cannot check the membership of the workspace: %v`
+	GET_WORKSPACES_ERROR         = `Cannot get the workspaces: %v`
+	GRAPHQL_QUERY_TOO_COMPLEX    = `Query complexity %d exceeds the limit of %d`
+	GRAPHQL_QUERY_TOO_DEEP       = `Query depth %d exceeds the limit of %d`
+	GRAPHQL_VERSION_REQUIRED     = `The version of the item is required`
+	IF_MATCH_REQUIRED            = `If-Match header is required`
+	IMPORT_ITEMS_ERROR           = `Cannot import the todo items: %v`
+	INSTANTIATE_TEMPLATE_ERROR   = `Cannot create the items of the template: %v`
+	INSUFFICIENT_ROLE            = `The %s role on the item is required`
+	INSUFFICIENT_WORKSPACE_ROLE  = `The %s role in the workspace is required`
 	INTERNAL_SERVER_ERROR        = `Internal server error: %v`
+	INVALID_IF_MATCH             = `If-Match must be a single entity tag or *`
+	INVALID_APP_EXPORT           = `Invalid %s export: %v`
+	INVALID_ATTACHMENT_LIMITS    = `Invalid attachment limits configuration: %v`
+	INVALID_BOARD_COLUMNS        = `Invalid board columns: %s`
+	INVALID_BLOB_KEY             = `Invalid blob key`
+	INVALID_BULK_OPERATION       = `Invalid %s operation: %s`
+	INVALID_CALENDAR_DATA        = `Invalid calendar data: %v`
+	INVALID_CURSOR               = `Invalid cursor %q`
+	INVALID_FILTER               = `Invalid filter expression: %v`
+	INVALID_IMPORT_MAPPING       = `Invalid column mapping: %v`
+	INVALID_LAST_EVENT_ID        = `Invalid Last-Event-ID %q`
+	INVALID_PAGE_SIZE            = `first must be between 1 and %d`
 	INVALID_PASSWORD             = `Invalid password`
+	INVALID_RANK_RANGE           = `The item placed before must come after the item placed after`
+	INVALID_STATUS_TRANSITION    = `Cannot change the status from %s to %s`
+	INVALID_STATUS_TRANSITIONS   = `Invalid status transitions configuration: %v`
+	INVALID_SYNC_CHANGE          = `Invalid %s change: %s`
+	INVALID_SYNC_TOKEN           = `Invalid sync token %q`
+	INVALID_TEMPLATE             = `Invalid template: %v`
+	INVALID_TIMEZONE             = `Invalid timezone %q`
+	INVALID_WEBHOOK_EVENT        = `Unknown webhook event %s`
+	INVALID_WEBHOOK_SETTINGS     = `Invalid webhook configuration: %v`
+	INVITATION_NOT_FOUND         = `Pending invitation not found for the user`
+	INVITE_MEMBER_ERROR          = `Cannot invite the user to the workspace: %v`
+	ITEM_BLOCKED                 = `Item is waiting on blockers that are not done`
+	ITEM_ID_TAKEN                = `The id is taken, the item has to be created with another id`
+	ITEM_IN_TRASH                = `Item is in the trash`
+	ITEM_NOT_IN_TRASH            = `Item is not in the trash`
+	LAST_WORKSPACE_OWNER         = `Cannot remove the last owner of the workspace`
+	LISTEN_ITEM_EVENTS_ERROR     = `Cannot listen to the events of the todo items: %v`
+	MOVE_ITEM_ERROR              = `Cannot move the todo item: %v`
+	NEIGHBOUR_NOT_FOUND          = `Item %s to place the moved item next to is not found`
+	NOT_A_WORKSPACE_MEMBER       = `The user is not a member of the workspace`
+	NOTIFICATION_REJECTED        = `Notification was rejected with status %d`
+	NOTIFY_ERROR                 = `Cannot notify %s of %s: %v`
+	NOT_COMMENT_AUTHOR           = `Only the author can change the comment`
+	PURGE_EXPIRED_ITEMS_ERROR    = `Cannot purge the expired items in trash: %v`
+	PURGE_ITEM_EVENTS_ERROR      = `Cannot purge the expired events of the todo items: %v`
+	PURGE_ITEM_ERROR             = `Cannot permanently delete the todo item: %v`
+	REDELIVER_WEBHOOK_ERROR      = `Cannot redeliver the webhook: %v`
+	REMOVE_MEMBER_ERROR          = `Cannot remove the member from the workspace: %v`
+	RESPOND_INVITATION_ERROR     = `Cannot respond to the invitation: %v`
+	RESTORE_ITEM_ERROR           = `Cannot restore the todo item: %v`
+	REVERT_ITEM_ERROR            = `Cannot revert the todo item: %v`
+	REVISION_NOT_FOUND           = `Revision not found for the todo item`
+	REVOKE_SHARE_ERROR           = `Cannot revoke the share: %v`
+	SAVE_FILTER_ERROR            = `Cannot save the filter: %v`
+	SAVE_TEMPLATE_ERROR          = `Cannot save the template: %v`
+	SEND_WEBHOOK_ERROR           = `Cannot send the test event: %v`
 	SET_STATUS_ITEM_ERROR        = `Cannot set staus to complete: %v`
+	SHARE_ITEM_ERROR             = `Cannot share the todo item: %v`
+	SHARE_NOT_FOUND              = `Share not found for the todo item`
+	SHARE_OUTSIDE_WORKSPACE      = `Items of a workspace can only be shared with its members`
+	STORAGE_QUOTA_EXCEEDED       = `Storage quota of %d bytes is exceeded`
+	SYNC_ITEMS_ERROR             = `Cannot sync the todo items: %v`
+	TEMPLATE_NOT_FOUND           = `Template not found for the user`
+	TRANSACTION_NOT_SUPPORTED    = `Database connection does not support transactions`
+	UNSUPPORTED_EXPORT_FORMAT    = `Unsupported export format: %s`
+	UNSUPPORTED_IMPORT_FORMAT    = `Unsupported import format: %s`
+	UNSUPPORTED_IMPORT_SOURCE    = `Unsupported import source: %s`
+	UNSUPPORTED_REPORT           = `Unsupported report: %s`
+	UPDATE_COMMENT_ERROR         = `Cannot update the comment: %v`
 	UPDATE_ITEM_ERROR            = `Cannot update the todo item: %v`
+	UPLOAD_ATTACHMENT_ERROR      = `Cannot upload the attachment: %v`
+	WEBHOOK_ADDRESS_NOT_ALLOWED  = `Webhooks cannot be sent to the internal address %s`
+	WEBHOOK_DELIVERY_NOT_FOUND   = `Delivery not found for the webhook`
+	WEBHOOK_NOT_FOUND            = `Webhook not found for the user`
+	WEBHOOK_REJECTED             = `Webhook was rejected with status %d`
 	INVALID_USERNAME_OR_USER_ID  = `invalid username or user_id`
+	ITEM_VERSION_MISMATCH        = `Item was modified by another request`
+	PATCH_ITEM_ERROR             = `Cannot patch the todo item: %v`
+	UNSUPPORTED_PATCH_TYPE       = `Unsupported patch content type: %s`
+	WATCH_ITEM_ERROR             = `Cannot change the watchers of the todo item: %v`
+	WORKSPACE_MEMBER_NOT_FOUND   = `Member not found in the workspace`
 )
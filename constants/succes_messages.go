@@ -1,7 +1,19 @@
 package constants
 
 const (
+	DELETE_ATTACHMENT_SUCCESSFULL = "The attachment is deleted successfully"
+	DELETE_CALENDAR_FEED_SUCCESSFULL = "The calendar feed is deleted successfully"
+	DELETE_COMMENT_SUCCESSFULL = "The comment is deleted successfully"
 	DELETE_ITEM_SUCCESSFULL = "The todo item is deleted successfully"
+	DELETE_SAVED_FILTER_SUCCESSFULL = "The saved filter is deleted successfully"
+	DELETE_TEMPLATE_SUCCESSFULL = "The template is deleted successfully"
+	DELETE_WEBHOOK_SUCCESSFULL = "The webhook is deleted successfully"
+	EMPTY_TRASH_SUCCESSFULL = "The trash is emptied successfully"
+	PURGE_ITEM_SUCCESSFULL = "The todo item is permanently deleted"
+	PURGED_EXPIRED_ITEMS = "Permanently deleted %d expired items from trash"
+	REMOVE_MEMBER_SUCCESSFULL = "The member is removed from the workspace successfully"
+	REVOKE_SHARE_SUCCESSFULL = "The share is revoked successfully"
 	USER_LOGIN_SUCCESSFUL = "User is logged in successfully"
 	USER_REGISTERED_SUCCESSFUL = "User is registered successfully"
+	WORKSPACE_SELECTED_SUCCESSFULL = "The workspace is selected successfully"
 )
\ No newline at end of file
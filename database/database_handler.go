@@ -14,11 +14,8 @@ import (
 
 var DB *sql.DB
 
-func ConnectToDb() *sql.DB {
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
-	}
-
+// Connection string of the database from the environment variables
+func ConnectionString() string {
 	// Get database connection parameters from environment variables.
 	dbUsername := os.Getenv("DB_USER")
 	dbPassword := os.Getenv("DB_PASSWORD")
@@ -27,7 +24,16 @@ func ConnectToDb() *sql.DB {
 	dbPort := os.Getenv("DB_PORT")
 
 	// Create the database connection string.
-	dbConnectionString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", dbHost, dbPort, dbUsername, dbPassword, dbName)
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", dbHost, dbPort, dbUsername, dbPassword, dbName)
+}
+
+func ConnectToDb() *sql.DB {
+	if err := godotenv.Load(); err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+
+	// Create the database connection string.
+	dbConnectionString := ConnectionString()
 
 	// Initialize the database connection.
 	DB, err := sql.Open("postgres", dbConnectionString)
@@ -40,13 +46,11 @@ func ConnectToDb() *sql.DB {
 		log.Fatalf(constants.DATABASE_CONNECTION_ERROR, err)
 	}
 
-	_, err = DB.Exec(UserTableQuery)
-	if err != nil {
-		log.Fatalf(constants.CANNOT_CREATE_TABLE_ERROR)
-	}
-	_, err = DB.Exec(CreateTableIfNotExistsQuery)
-	if err != nil {
-		log.Fatalf(constants.CANNOT_CREATE_TABLE_ERROR)
+	for _, migration := range Migrations {
+		_, err = DB.Exec(migration)
+		if err != nil {
+			log.Fatalf(constants.CANNOT_CREATE_TABLE_ERROR)
+		}
 	}
 
 	return DB
@@ -16,5 +16,393 @@ const CreateTableIfNotExistsQuery = `CREATE TABLE IF NOT EXISTS todo_items (
 	updated_at TIMESTAMP,
 	is_completed BOOLEAN DEFAULT FALSE,
 	is_deleted BOOLEAN DEFAULT FALSE,
-	user_id TEXT REFERENCES users(id)
+	user_id TEXT REFERENCES users(id),
+	deleted_at TIMESTAMP,
+	status TEXT DEFAULT 'todo',
+	started_at TIMESTAMP,
+	completed_at TIMESTAMP,
+	version INTEGER NOT NULL DEFAULT 1,
+	assignee_id TEXT REFERENCES users(id),
+	workspace_id TEXT REFERENCES workspaces(id),
+	rank TEXT COLLATE "C",
+	recurrence TEXT
 );`
+
+const AddDeletedAtColumnQuery = `ALTER TABLE todo_items ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;`
+
+const AddStatusColumnsQuery = `ALTER TABLE todo_items
+	ADD COLUMN IF NOT EXISTS status TEXT DEFAULT 'todo',
+	ADD COLUMN IF NOT EXISTS started_at TIMESTAMP,
+	ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;`
+
+// Items completed before the status column existed are moved to done
+const BackfillStatusQuery = `UPDATE todo_items SET status = 'done', completed_at = COALESCE(completed_at, updated_at) WHERE is_completed = true AND status <> 'done';`
+
+const AddVersionColumnQuery = `ALTER TABLE todo_items ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;`
+
+// Every update of an item bumps its version, which is used as the ETag of the item
+const VersionTriggerFunctionQuery = `CREATE OR REPLACE FUNCTION bump_todo_item_version() RETURNS trigger AS $$
+BEGIN
+	NEW.version := OLD.version + 1;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;`
+
+const VersionTriggerQuery = `DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'todo_items_version') THEN
+		CREATE TRIGGER todo_items_version BEFORE UPDATE ON todo_items
+		FOR EACH ROW EXECUTE PROCEDURE bump_todo_item_version();
+	END IF;
+END
+$$;`
+
+const ItemLabelsTableQuery = `CREATE TABLE IF NOT EXISTS item_labels (
+	item_id TEXT REFERENCES todo_items(id) ON DELETE CASCADE,
+	label TEXT NOT NULL,
+	PRIMARY KEY (item_id, label)
+);`
+
+// Every change of an item is kept as an immutable snapshot of the item, the history stays when the item is purged
+const ItemRevisionsTableQuery = `CREATE TABLE IF NOT EXISTS item_revisions (
+	id BIGSERIAL PRIMARY KEY,
+	item_id TEXT NOT NULL,
+	user_id TEXT REFERENCES users(id),
+	action TEXT NOT NULL,
+	snapshot JSONB NOT NULL,
+	created_at TIMESTAMP
+);`
+
+// The revisions outlive their item, tables created with the earlier cascading reference lose it
+const DropItemRevisionsItemForeignKeyQuery = `ALTER TABLE item_revisions DROP CONSTRAINT IF EXISTS item_revisions_item_id_fkey;`
+
+const ItemRevisionsIndexQuery = `CREATE INDEX IF NOT EXISTS item_revisions_item_id ON item_revisions (item_id, id);`
+
+// Comments are soft deleted so the replies to them stay in their thread
+const ItemCommentsTableQuery = `CREATE TABLE IF NOT EXISTS item_comments (
+	id TEXT PRIMARY KEY,
+	item_id TEXT REFERENCES todo_items(id) ON DELETE CASCADE,
+	parent_id TEXT REFERENCES item_comments(id),
+	user_id TEXT REFERENCES users(id),
+	body TEXT NOT NULL,
+	created_at TIMESTAMP,
+	edited_at TIMESTAMP,
+	is_deleted BOOLEAN DEFAULT FALSE
+);`
+
+const ItemCommentsIndexQuery = `CREATE INDEX IF NOT EXISTS item_comments_item_id ON item_comments (item_id, created_at);`
+
+// Metadata of the attachments, the content is kept in the blob store under storage_key
+const ItemAttachmentsTableQuery = `CREATE TABLE IF NOT EXISTS item_attachments (
+	id TEXT PRIMARY KEY,
+	item_id TEXT REFERENCES todo_items(id) ON DELETE CASCADE,
+	user_id TEXT REFERENCES users(id),
+	file_name TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size BIGINT NOT NULL,
+	storage_key TEXT NOT NULL,
+	created_at TIMESTAMP
+);`
+
+const ItemAttachmentsIndexQuery = `CREATE INDEX IF NOT EXISTS item_attachments_item_id ON item_attachments (item_id);`
+
+// Items shared with other users, a share is a pending invitation until the user accepts it
+const ItemSharesTableQuery = `CREATE TABLE IF NOT EXISTS item_shares (
+	id TEXT PRIMARY KEY,
+	item_id TEXT REFERENCES todo_items(id) ON DELETE CASCADE,
+	user_id TEXT REFERENCES users(id),
+	role TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	invited_by TEXT REFERENCES users(id),
+	created_at TIMESTAMP,
+	responded_at TIMESTAMP,
+	UNIQUE (item_id, user_id)
+);`
+
+const ItemSharesIndexQuery = `CREATE INDEX IF NOT EXISTS item_shares_user_id ON item_shares (user_id, status);`
+
+const AddAssigneeColumnQuery = `ALTER TABLE todo_items ADD COLUMN IF NOT EXISTS assignee_id TEXT REFERENCES users(id);`
+
+const ItemWatchersTableQuery = `CREATE TABLE IF NOT EXISTS item_watchers (
+	item_id TEXT REFERENCES todo_items(id) ON DELETE CASCADE,
+	user_id TEXT REFERENCES users(id),
+	PRIMARY KEY (item_id, user_id)
+);`
+
+// Workspaces isolate the items of a team, items without a workspace are in the personal space of their owner
+const WorkspacesTableQuery = `CREATE TABLE IF NOT EXISTS workspaces (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	owner_id TEXT REFERENCES users(id),
+	created_at TIMESTAMP
+);`
+
+// Members of a workspace, a membership is a pending invitation until the user accepts it
+const WorkspaceMembersTableQuery = `CREATE TABLE IF NOT EXISTS workspace_members (
+	id TEXT PRIMARY KEY,
+	workspace_id TEXT REFERENCES workspaces(id) ON DELETE CASCADE,
+	user_id TEXT REFERENCES users(id),
+	role TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	invited_by TEXT REFERENCES users(id),
+	created_at TIMESTAMP,
+	responded_at TIMESTAMP,
+	UNIQUE (workspace_id, user_id)
+);`
+
+const WorkspaceMembersIndexQuery = `CREATE INDEX IF NOT EXISTS workspace_members_user_id ON workspace_members (user_id, status);`
+
+const AddWorkspaceColumnQuery = `ALTER TABLE todo_items ADD COLUMN IF NOT EXISTS workspace_id TEXT REFERENCES workspaces(id);`
+
+const ItemsWorkspaceIndexQuery = `CREATE INDEX IF NOT EXISTS todo_items_workspace_id ON todo_items (workspace_id);`
+
+// Links an item to the items it waits on, the links never form a cycle
+const ItemDependenciesTableQuery = `CREATE TABLE IF NOT EXISTS item_dependencies (
+	item_id TEXT REFERENCES todo_items(id) ON DELETE CASCADE,
+	blocker_id TEXT REFERENCES todo_items(id) ON DELETE CASCADE,
+	created_at TIMESTAMP,
+	PRIMARY KEY (item_id, blocker_id),
+	CHECK (item_id <> blocker_id)
+);`
+
+const ItemDependenciesIndexQuery = `CREATE INDEX IF NOT EXISTS item_dependencies_blocker_id ON item_dependencies (blocker_id);`
+
+// Ranks order the items of a list, they are compared byte by byte so the C collation is used
+const AddRankColumnQuery = `ALTER TABLE todo_items ADD COLUMN IF NOT EXISTS rank TEXT COLLATE "C";`
+
+// Items created before ranks existed are ranked by their creation time, in the format of the ranks of new items.
+// Items created in the same second are two nanoseconds apart in the order of their ids, so their ranks stay
+// distinct when the last digit 0 is replaced
+const BackfillRankQuery = `UPDATE todo_items SET rank = backfill.rank FROM (SELECT id, regexp_replace(lpad(to_hex((EXTRACT(EPOCH FROM created_at) * 1000000000)::bigint
+	+ 2 * (ROW_NUMBER() OVER (PARTITION BY created_at ORDER BY id) - 1)), 16, '0'), '0$', '1') AS rank FROM todo_items WHERE rank IS NULL) AS backfill
+	WHERE todo_items.id = backfill.id;`
+
+const ItemsRankIndexQuery = `CREATE INDEX IF NOT EXISTS todo_items_rank ON todo_items (user_id, rank);`
+
+// Named filter expressions of a user, evaluated against the items whenever they are listed
+const SavedFiltersTableQuery = `CREATE TABLE IF NOT EXISTS saved_filters (
+	id TEXT PRIMARY KEY,
+	user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	expression TEXT NOT NULL,
+	created_at TIMESTAMP,
+	updated_at TIMESTAMP
+);`
+
+const SavedFiltersIndexQuery = `CREATE INDEX IF NOT EXISTS saved_filters_user_id ON saved_filters (user_id);`
+
+// Recurrence rules of the items, in the RRULE format of iCalendar
+const AddRecurrenceColumnQuery = `ALTER TABLE todo_items ADD COLUMN IF NOT EXISTS recurrence TEXT;`
+
+// Items of a template are kept as a single document, like the snapshots of the revisions
+const TemplatesTableQuery = `CREATE TABLE IF NOT EXISTS item_templates (
+	id TEXT PRIMARY KEY,
+	user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	items JSONB NOT NULL,
+	created_at TIMESTAMP
+);`
+
+const TemplatesIndexQuery = `CREATE INDEX IF NOT EXISTS item_templates_user_id ON item_templates (user_id);`
+
+// Secret tokens of the calendar feeds, one per list of a user. Only a hash of the token is kept
+const CalendarFeedsTableQuery = `CREATE TABLE IF NOT EXISTS calendar_feeds (
+	id TEXT PRIMARY KEY,
+	user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+	workspace_id TEXT REFERENCES workspaces(id) ON DELETE CASCADE,
+	token_hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMP
+);`
+
+const CalendarFeedsIndexQuery = `CREATE UNIQUE INDEX IF NOT EXISTS calendar_feeds_user_id ON calendar_feeds (user_id, (COALESCE(workspace_id, '')));`
+
+// Id of an imported item in the app it was imported from, a later import of the same task updates the item
+const ItemSourcesTableQuery = `CREATE TABLE IF NOT EXISTS item_sources (
+	item_id TEXT PRIMARY KEY REFERENCES todo_items(id) ON DELETE CASCADE,
+	user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+	workspace_id TEXT REFERENCES workspaces(id) ON DELETE CASCADE,
+	source TEXT NOT NULL,
+	source_id TEXT NOT NULL,
+	imported_at TIMESTAMP
+);`
+
+const ItemSourcesIndexQuery = `CREATE UNIQUE INDEX IF NOT EXISTS item_sources_source_id ON item_sources (user_id, (COALESCE(workspace_id, '')), source, source_id);`
+
+// Webhooks of a user are sent the events of the items of their personal list or of a workspace, the secret signs
+// the payloads so it is kept as it is
+const WebhooksTableQuery = `CREATE TABLE IF NOT EXISTS webhooks (
+	id TEXT PRIMARY KEY,
+	user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+	workspace_id TEXT REFERENCES workspaces(id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT[] NOT NULL,
+	created_at TIMESTAMP
+);`
+
+const WebhooksIndexQuery = `CREATE INDEX IF NOT EXISTS webhooks_user_id ON webhooks (user_id);`
+
+// Each event sent to a webhook, a pending delivery is sent again at next_attempt_at until it is delivered or dead
+const WebhookDeliveriesTableQuery = `CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id TEXT PRIMARY KEY,
+	webhook_id TEXT REFERENCES webhooks(id) ON DELETE CASCADE,
+	event TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP,
+	last_status_code INT NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP,
+	delivered_at TIMESTAMP
+);`
+
+const WebhookDeliveriesIndexQuery = `CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';`
+
+const WebhookDeliveriesWebhookIndexQuery = `CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, created_at);`
+
+// Log of the attempts to send a delivery
+const WebhookAttemptsTableQuery = `CREATE TABLE IF NOT EXISTS webhook_attempts (
+	id BIGSERIAL PRIMARY KEY,
+	delivery_id TEXT REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+	attempted_at TIMESTAMP NOT NULL,
+	status_code INT NOT NULL DEFAULT 0,
+	error TEXT NOT NULL DEFAULT '',
+	duration_ms INT NOT NULL DEFAULT 0
+);`
+
+const WebhookAttemptsIndexQuery = `CREATE INDEX IF NOT EXISTS webhook_attempts_delivery_id ON webhook_attempts (delivery_id, id);`
+
+// Bounded log of the changes of items streamed to the clients, the users in recipients and the members of the
+// workspace get the event. The item is not referenced so the events of a purged item stay in the log
+const ItemEventsTableQuery = `CREATE TABLE IF NOT EXISTS item_events (
+	id BIGSERIAL PRIMARY KEY,
+	item_id TEXT NOT NULL,
+	workspace_id TEXT,
+	recipients TEXT[] NOT NULL,
+	event TEXT NOT NULL,
+	actor_id TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	created_at TIMESTAMP
+);`
+
+const ItemEventsIndexQuery = `CREATE INDEX IF NOT EXISTS item_events_created_at ON item_events (created_at);`
+
+// Every insert and update of an item takes the next number of the change sequence, so a client syncing the items
+// gets the ones changed after the last number it saw. The writers hold a shared lock until they commit and a
+// sync reads under the exclusive lock, so it never sees a number before the smaller ones are committed
+const ChangeSeqSequenceQuery = `CREATE SEQUENCE IF NOT EXISTS todo_items_change_seq;`
+
+const AddChangeSeqColumnQuery = `ALTER TABLE todo_items ADD COLUMN IF NOT EXISTS change_seq BIGINT NOT NULL DEFAULT nextval('todo_items_change_seq');`
+
+const ChangeSeqTriggerFunctionQuery = `CREATE OR REPLACE FUNCTION bump_todo_item_change_seq() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock_shared(hashtext('todo_items_change_seq'));
+	NEW.change_seq := nextval('todo_items_change_seq');
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;`
+
+const ChangeSeqTriggerQuery = `DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'todo_items_change_seq') THEN
+		CREATE TRIGGER todo_items_change_seq BEFORE INSERT OR UPDATE ON todo_items
+		FOR EACH ROW EXECUTE PROCEDURE bump_todo_item_change_seq();
+	END IF;
+END
+$$;`
+
+const ItemsChangeSeqIndexQuery = `CREATE INDEX IF NOT EXISTS todo_items_change_seq ON todo_items (change_seq);`
+
+// Items permanently deleted, kept so the clients syncing the list remove them too. The item is not referenced
+// as it is gone
+const ItemTombstonesTableQuery = `CREATE TABLE IF NOT EXISTS item_tombstones (
+	item_id TEXT PRIMARY KEY,
+	user_id TEXT,
+	workspace_id TEXT,
+	change_seq BIGINT NOT NULL,
+	deleted_at TIMESTAMP
+);`
+
+const ItemTombstonesIndexQuery = `CREATE INDEX IF NOT EXISTS item_tombstones_change_seq ON item_tombstones (change_seq);`
+
+const TombstoneTriggerFunctionQuery = `CREATE OR REPLACE FUNCTION record_todo_item_tombstone() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock_shared(hashtext('todo_items_change_seq'));
+	INSERT INTO item_tombstones (item_id, user_id, workspace_id, change_seq, deleted_at)
+	VALUES (OLD.id, OLD.user_id, OLD.workspace_id, nextval('todo_items_change_seq'), LOCALTIMESTAMP)
+	ON CONFLICT (item_id) DO UPDATE SET user_id = EXCLUDED.user_id, workspace_id = EXCLUDED.workspace_id,
+		change_seq = EXCLUDED.change_seq, deleted_at = EXCLUDED.deleted_at;
+	RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;`
+
+const TombstoneTriggerQuery = `DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'todo_items_tombstone') THEN
+		CREATE TRIGGER todo_items_tombstone AFTER DELETE ON todo_items
+		FOR EACH ROW EXECUTE PROCEDURE record_todo_item_tombstone();
+	END IF;
+END
+$$;`
+
+// Schema statements run in order on every start up, each must be idempotent.
+var Migrations = []string{
+	UserTableQuery,
+	WorkspacesTableQuery,
+	CreateTableIfNotExistsQuery,
+	AddDeletedAtColumnQuery,
+	AddStatusColumnsQuery,
+	BackfillStatusQuery,
+	AddVersionColumnQuery,
+	VersionTriggerFunctionQuery,
+	VersionTriggerQuery,
+	ItemLabelsTableQuery,
+	ItemRevisionsTableQuery,
+	DropItemRevisionsItemForeignKeyQuery,
+	ItemRevisionsIndexQuery,
+	ItemCommentsTableQuery,
+	ItemCommentsIndexQuery,
+	ItemAttachmentsTableQuery,
+	ItemAttachmentsIndexQuery,
+	ItemSharesTableQuery,
+	ItemSharesIndexQuery,
+	AddAssigneeColumnQuery,
+	ItemWatchersTableQuery,
+	WorkspaceMembersTableQuery,
+	WorkspaceMembersIndexQuery,
+	AddWorkspaceColumnQuery,
+	ItemsWorkspaceIndexQuery,
+	ItemDependenciesTableQuery,
+	ItemDependenciesIndexQuery,
+	AddRankColumnQuery,
+	BackfillRankQuery,
+	ItemsRankIndexQuery,
+	SavedFiltersTableQuery,
+	SavedFiltersIndexQuery,
+	AddRecurrenceColumnQuery,
+	TemplatesTableQuery,
+	TemplatesIndexQuery,
+	CalendarFeedsTableQuery,
+	CalendarFeedsIndexQuery,
+	ItemSourcesTableQuery,
+	ItemSourcesIndexQuery,
+	WebhooksTableQuery,
+	WebhooksIndexQuery,
+	WebhookDeliveriesTableQuery,
+	WebhookDeliveriesIndexQuery,
+	WebhookDeliveriesWebhookIndexQuery,
+	WebhookAttemptsTableQuery,
+	WebhookAttemptsIndexQuery,
+	ItemEventsTableQuery,
+	ItemEventsIndexQuery,
+	ChangeSeqSequenceQuery,
+	AddChangeSeqColumnQuery,
+	ChangeSeqTriggerFunctionQuery,
+	ChangeSeqTriggerQuery,
+	ItemsChangeSeqIndexQuery,
+	ItemTombstonesTableQuery,
+	ItemTombstonesIndexQuery,
+	TombstoneTriggerFunctionQuery,
+	TombstoneTriggerQuery,
+}
@@ -1,14 +1,100 @@
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusBlocked    = "blocked"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
+const (
+	RevisionCreated       = "created"
+	RevisionUpdated       = "updated"
+	RevisionCompleted     = "completed"
+	RevisionStatusChanged = "status_changed"
+	RevisionDeleted       = "deleted"
+	RevisionRestored      = "restored"
+	RevisionReverted      = "reverted"
+	RevisionAssigned      = "assigned"
+	RevisionUnassigned    = "unassigned"
+)
+
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+const (
+	ShareStatusPending  = "pending"
+	ShareStatusAccepted = "accepted"
+	ShareStatusDeclined = "declined"
+)
+
+const (
+	ImportActionCreate    = "create"
+	ImportActionUpdate    = "update"
+	ImportActionUnchanged = "unchanged"
+	ImportActionSkip      = "skip"
+	ImportActionInvalid   = "invalid"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+const (
+	SyncOpCreate = "create"
+	SyncOpUpdate = "update"
+	SyncOpDelete = "delete"
+)
+
+const (
+	SyncStrategyReport         = "report"
+	SyncStrategyLastWriterWins = "last_writer_wins"
+)
+
+const (
+	SyncApplied  = "applied"
+	SyncConflict = "conflict"
+	SyncFailed   = "error"
+)
+
+const (
+	SyncResolutionClient = "client"
+	SyncResolutionServer = "server"
+)
 
 type TodoItem struct {
 	Id          string `json:"id" validate:"required" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
 	Item        TodoItemDto `json:"item" validate:"required"`
+	UserId      string `json:"user_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
 	IsCompleted bool   `json:"is_completed" validate:"required"`
+	Status      string `json:"status" example:"in_progress"`
 	IsDeleted   bool   `json:"is_deleted" validate:"required"`
 	CreatedAt   time.Time `json:"created_at" validate:"required"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+	Version     int        `json:"version" example:"1"`
+	Labels      []string   `json:"labels,omitempty" example:"finance"`
+	CommentCount int       `json:"comment_count" example:"2"`
+	AssigneeId  string     `json:"assignee_id,omitempty" example:"8c3e5f7a-9b1d-4e2f-a4c6-d8e0f2a4b6c8"`
+	Watchers    []string   `json:"watchers,omitempty" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	WorkspaceId string     `json:"workspace_id,omitempty" example:"5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"`
+	BlockedBy   []string   `json:"blocked_by,omitempty" example:"7c1f2e3d-4b5a-4c6d-8e7f-9a0b1c2d3e4f"`
+	Blocked     bool       `json:"blocked" example:"false"`
+	Rank        string     `json:"rank,omitempty" example:"0017a2b3c4d5e6f1"`
+	Recurrence  string     `json:"recurrence,omitempty" example:"FREQ=MONTHLY"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }
 
 type TodoItemDto struct {
@@ -24,4 +110,471 @@ type TodoItemDetailsDto struct {
 
 type GetListDto struct {
 	Limit int `json:"limit" validate:"required" example:"5"`
-}
\ No newline at end of file
+	AssignedToMe bool `json:"assigned_to_me" example:"false"`
+	CreatedByMe  bool `json:"created_by_me" example:"true"`
+}
+
+type ItemStatusDto struct {
+	Status string `json:"status" validate:"required,oneof=todo in_progress blocked done cancelled" example:"in_progress"`
+}
+
+type BulkOperationDto struct {
+	Op      string              `json:"op" validate:"required,oneof=create update complete delete move label" example:"complete"`
+	Id      string              `json:"id,omitempty" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
+	Version int                 `json:"version,omitempty" example:"2"`
+	Item    *TodoItemDto        `json:"item,omitempty"`
+	Details *TodoItemDetailsDto `json:"details,omitempty"`
+	Status  string              `json:"status,omitempty" example:"in_progress"`
+	Add     []string            `json:"add,omitempty" validate:"dive,min=1,max=50,excludesall=0x2C" example:"finance"`
+	Remove  []string            `json:"remove,omitempty" validate:"dive,min=1,max=50,excludesall=0x2C" example:"home"`
+}
+
+type BulkRequestDto struct {
+	Atomic     bool               `json:"atomic" example:"true"`
+	Operations []BulkOperationDto `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+type BulkResultDto struct {
+	Index  int       `json:"index" example:"0"`
+	Op     string    `json:"op" example:"complete"`
+	Id     string    `json:"id,omitempty" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
+	Status int       `json:"status" example:"200"`
+	Item   *TodoItem `json:"item,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+type BulkResponseDto struct {
+	Committed bool            `json:"committed" example:"true"`
+	Results   []BulkResultDto `json:"results"`
+}
+
+type Attachment struct {
+	Id          string    `json:"id" example:"7e5a1b2c-3d4e-4f5a-8b9c-0d1e2f3a4b5c"`
+	ItemId      string    `json:"item_id" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
+	UserId      string    `json:"user_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	FileName    string    `json:"file_name" example:"receipt.pdf"`
+	ContentType string    `json:"content_type" example:"application/pdf"`
+	Size        int64     `json:"size" example:"48213"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Comment struct {
+	Id        string     `json:"id" example:"9b2f1c8e-4d7a-4f43-b0b5-2d6a7c1e5f10"`
+	ItemId    string     `json:"item_id" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
+	ParentId  string     `json:"parent_id,omitempty" example:"5c1d2e3f-6a7b-4c8d-9e0f-1a2b3c4d5e6f"`
+	UserId    string     `json:"user_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	Body      string     `json:"body" example:"Waiting for the **invoice**"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	IsDeleted bool       `json:"is_deleted"`
+	Replies   []Comment  `json:"replies,omitempty"`
+}
+
+type CommentDto struct {
+	ParentId string `json:"parent_id,omitempty" example:"5c1d2e3f-6a7b-4c8d-9e0f-1a2b3c4d5e6f"`
+	Body     string `json:"body" validate:"required,max=10000" example:"Waiting for the **invoice**"`
+}
+
+type CommentBodyDto struct {
+	Body string `json:"body" validate:"required,max=10000" example:"Paid, see the attached receipt"`
+}
+
+type FieldChange struct {
+	Field string      `json:"field" example:"priority"`
+	From  interface{} `json:"from" swaggertype:"string" example:"LOW"`
+	To    interface{} `json:"to" swaggertype:"string" example:"HIGH"`
+}
+
+type ItemRevision struct {
+	Id        int64         `json:"id" example:"3"`
+	ItemId    string        `json:"item_id" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
+	UserId    string        `json:"user_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	Action    string        `json:"action" example:"updated"`
+	Changes   []FieldChange `json:"changes"`
+	Item      TodoItem      `json:"item"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type Share struct {
+	Id          string     `json:"id" example:"1d9c7a4e-2b3f-4c5d-8e6f-7a8b9c0d1e2f"`
+	ItemId      string     `json:"item_id" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
+	UserId      string     `json:"user_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	Email       string     `json:"email" example:"colleague@gmail.com"`
+	Role        string     `json:"role" example:"editor"`
+	Status      string     `json:"status" example:"pending"`
+	InvitedBy   string     `json:"invited_by" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+type ShareDto struct {
+	Email string `json:"email" validate:"required,email" example:"colleague@gmail.com"`
+	Role  string `json:"role" validate:"required,oneof=viewer editor" example:"editor"`
+}
+
+type AssigneeDto struct {
+	Email string `json:"email" validate:"required,email" example:"colleague@gmail.com"`
+}
+
+type SharedItem struct {
+	TodoItem
+	Role       string `json:"role" example:"viewer"`
+	OwnerEmail string `json:"owner_email" example:"example@gmail.com"`
+}
+
+type DependencyDto struct {
+	BlockerId string `json:"blocker_id" validate:"required" example:"7c1f2e3d-4b5a-4c6d-8e7f-9a0b1c2d3e4f"`
+}
+
+type ItemDependencies struct {
+	BlockedBy []TodoItem `json:"blocked_by"`
+	Blocking  []TodoItem `json:"blocking"`
+}
+
+type BoardColumn struct {
+	Name  string     `json:"name" example:"in_progress"`
+	Total int        `json:"total" example:"12"`
+	Items []TodoItem `json:"items"`
+}
+
+type Board struct {
+	GroupBy string        `json:"group_by" example:"status"`
+	Columns []BoardColumn `json:"columns"`
+}
+
+type MoveItemDto struct {
+	GroupBy    string `json:"group_by,omitempty" validate:"omitempty,oneof=status label" example:"status"`
+	Column     string `json:"column,omitempty" validate:"omitempty,max=50,excludesall=0x2C" example:"in_progress"`
+	FromColumn string `json:"from_column,omitempty" validate:"omitempty,max=50,excludesall=0x2C" example:"todo"`
+	AfterId    string `json:"after_id,omitempty" example:"5c1d2e3f-6a7b-4c8d-9e0f-1a2b3c4d5e6f"`
+	BeforeId   string `json:"before_id,omitempty" example:"7c1f2e3d-4b5a-4c6d-8e7f-9a0b1c2d3e4f"`
+}
+
+type QuickAddDto struct {
+	Text     string `json:"text" validate:"required,max=500" example:"Pay rent tomorrow 9am !high #finance every month"`
+	Timezone string `json:"timezone,omitempty" example:"Europe/Berlin"`
+	Preview  bool   `json:"preview" example:"false"`
+}
+
+type QuickAddParsed struct {
+	Name       string    `json:"name" example:"Pay rent"`
+	DueDate    time.Time `json:"due_date" example:"2023-05-23T09:00:00+02:00"`
+	Priority   string    `json:"priority" example:"HIGH"`
+	Labels     []string  `json:"labels" example:"finance"`
+	Recurrence string    `json:"recurrence,omitempty" example:"FREQ=MONTHLY"`
+}
+
+type QuickAddToken struct {
+	Text  string `json:"text" example:"every month"`
+	Kind  string `json:"kind" example:"recurrence"`
+	Value string `json:"value" example:"FREQ=MONTHLY"`
+	Start int    `json:"start" example:"37"`
+	End   int    `json:"end" example:"48"`
+}
+
+type QuickAddResponse struct {
+	Item   *TodoItem       `json:"item,omitempty"`
+	Parsed QuickAddParsed  `json:"parsed"`
+	Tokens []QuickAddToken `json:"tokens"`
+}
+
+type SavedFilter struct {
+	Id         string    `json:"id" example:"2e7d4c1b-9a8f-4b6e-a5d3-c1b2a3f4e5d6"`
+	UserId     string    `json:"user_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	Name       string    `json:"name" example:"Due this week"`
+	Expression string    `json:"expression" example:"priority=HIGH due<=today+7d status!=done"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type SavedFilterDto struct {
+	Name       string `json:"name" validate:"required,max=100" example:"Due this week"`
+	Expression string `json:"expression" validate:"required,max=1000" example:"priority=HIGH due<=today+7d status!=done"`
+}
+
+type Template struct {
+	Id        string         `json:"id" example:"9b2e4c6a-1d3f-4a5b-8c7d-0e1f2a3b4c5d"`
+	UserId    string         `json:"user_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	Name      string         `json:"name" example:"Monthly close"`
+	Items     []TemplateItem `json:"items"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// An item of a template, its due date is the base date of the instantiation moved by DueInDays.
+// BlockedBy holds the positions of the items of the template it waits on
+type TemplateItem struct {
+	Name        string   `json:"name" validate:"required,min=3" example:"Reconcile accounts"`
+	Description string   `json:"description" validate:"max=5000" example:"Match the bank statements with the ledger."`
+	Priority    string   `json:"priority" validate:"required,oneof=HIGH LOW MEDIUM" example:"HIGH"`
+	Labels      []string `json:"labels,omitempty" validate:"max=20,dive,required,max=50,excludesall=0x2C" example:"finance"`
+	Recurrence  string   `json:"recurrence,omitempty" validate:"max=200" example:"FREQ=MONTHLY"`
+	DueInDays   int      `json:"due_in_days" validate:"min=-3650,max=3650" example:"2"`
+	DueTime     string   `json:"due_time,omitempty" example:"17:00"`
+	BlockedBy   []int    `json:"blocked_by,omitempty" example:"0"`
+}
+
+type TemplateDto struct {
+	Name  string         `json:"name" validate:"required,max=100" example:"Monthly close"`
+	Items []TemplateItem `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+type TemplateFromItemsDto struct {
+	Name     string   `json:"name" validate:"required,max=100" example:"Monthly close"`
+	ItemIds  []string `json:"item_ids" validate:"required,min=1,max=100,dive,required" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
+	Timezone string   `json:"timezone,omitempty" example:"Europe/Berlin"`
+}
+
+type InstantiateTemplateDto struct {
+	BaseDate string `json:"base_date" validate:"required" example:"2023-11-01"`
+	Timezone string `json:"timezone,omitempty" example:"Europe/Berlin"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row" example:"3"`
+	Error string `json:"error" example:"due_date must be a date like 2023-10-15 or 2023-10-15T09:38:24Z"`
+}
+
+type ImportResultDto struct {
+	DryRun   bool             `json:"dry_run" example:"false"`
+	Rows     int              `json:"rows" example:"120"`
+	Imported int              `json:"imported" example:"120"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+type AppImportField struct {
+	Field string `json:"field" example:"due_date"`
+	From  string `json:"from,omitempty" example:"2023-10-15T23:59:59Z"`
+	To    string `json:"to" example:"2023-10-20T23:59:59Z"`
+}
+
+type AppImportChange struct {
+	SourceId string           `json:"source_id" example:"6Jf8VQXxpwv56VQ7"`
+	Action   string           `json:"action" example:"update"`
+	ItemId   string           `json:"item_id,omitempty" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
+	Name     string           `json:"name" example:"Pay rent"`
+	Fields   []AppImportField `json:"fields,omitempty"`
+	Error    string           `json:"error,omitempty" example:"the name must be at least 3 characters"`
+}
+
+type AppImportResultDto struct {
+	Source    string            `json:"source" example:"todoist"`
+	DryRun    bool              `json:"dry_run" example:"false"`
+	Created   int               `json:"created" example:"12"`
+	Updated   int               `json:"updated" example:"3"`
+	Unchanged int               `json:"unchanged" example:"40"`
+	Skipped   int               `json:"skipped" example:"1"`
+	Invalid   int               `json:"invalid" example:"0"`
+	Changes   []AppImportChange `json:"changes"`
+}
+
+type CalendarFeed struct {
+	Id          string    `json:"id" example:"4d8e2f1a-6b3c-4a5d-9e7f-0a1b2c3d4e5f"`
+	UserId      string    `json:"user_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	WorkspaceId string    `json:"workspace_id,omitempty" example:"5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CalendarFeedResponse struct {
+	Url         string    `json:"url" example:"https://todo.example.com/calendar/Xq3vJ8kP2mN5rT7wY1zA4cE6gH9jL0oS.ics"`
+	Token       string    `json:"token" example:"Xq3vJ8kP2mN5rT7wY1zA4cE6gH9jL0oS"`
+	WorkspaceId string    `json:"workspace_id,omitempty" example:"5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Workspace struct {
+	Id        string    `json:"id" example:"5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"`
+	Name      string    `json:"name" example:"Finance team"`
+	OwnerId   string    `json:"owner_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	Role      string    `json:"role" example:"owner"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WorkspaceDto struct {
+	Name string `json:"name" validate:"required,min=3,max=100" example:"Finance team"`
+}
+
+type WorkspaceMember struct {
+	Id          string     `json:"id" example:"2a7d9c4e-1b3f-4e5d-8c6f-9a0b1c2d3e4f"`
+	WorkspaceId string     `json:"workspace_id" example:"5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"`
+	UserId      string     `json:"user_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	Email       string     `json:"email" example:"colleague@gmail.com"`
+	Role        string     `json:"role" example:"editor"`
+	Status      string     `json:"status" example:"pending"`
+	InvitedBy   string     `json:"invited_by" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+type WorkspaceMemberDto struct {
+	Email string `json:"email" validate:"required,email" example:"colleague@gmail.com"`
+	Role  string `json:"role" validate:"required,oneof=viewer editor owner" example:"editor"`
+}
+
+type TrashPurgeResponseDto struct {
+	Response string `json:"response" example:"The trash is emptied successfully"`
+	Purged   int64  `json:"purged" example:"3"`
+}
+
+type Webhook struct {
+	Id          string    `json:"id" example:"9b2e4c6a-8d1f-4a3b-9c5e-7f0a2b4c6d8e"`
+	UserId      string    `json:"user_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	WorkspaceId string    `json:"workspace_id,omitempty" example:"5f0c6a1e-8d2b-4c3a-9e7f-1b2c3d4e5f60"`
+	Url         string    `json:"url" example:"https://ci.example.com/hooks/todo"`
+	Events      []string  `json:"events" example:"item.completed"`
+	Secret      string    `json:"secret,omitempty" example:"4f9d2c7a1e8b3f6d0a5c9e2b7d4f1a8c"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type WebhookDto struct {
+	Url    string   `json:"url" validate:"required,url,max=2000" example:"https://ci.example.com/hooks/todo"`
+	Secret string   `json:"secret,omitempty" validate:"omitempty,min=16,max=200" example:"4f9d2c7a1e8b3f6d0a5c9e2b7d4f1a8c"`
+	Events []string `json:"events" validate:"required,min=1,max=20,dive,required" example:"item.completed"`
+}
+
+// Body of a delivery, the item is its state after the change and is left out of a test event
+type WebhookPayload struct {
+	Id        string    `json:"id" example:"1c3e5a7b-9d2f-4b6a-8c0e-2d4f6a8b0c1e"`
+	Event     string    `json:"event" example:"item.completed"`
+	CreatedAt time.Time `json:"created_at"`
+	ActorId   string    `json:"actor_id,omitempty" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	Item      *TodoItem `json:"item,omitempty"`
+}
+
+type WebhookAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code" example:"502"`
+	Error       string    `json:"error,omitempty" example:"Webhook was rejected with status 502"`
+	DurationMs  int64     `json:"duration_ms" example:"120"`
+}
+
+type WebhookDelivery struct {
+	Id             string           `json:"id" example:"1c3e5a7b-9d2f-4b6a-8c0e-2d4f6a8b0c1e"`
+	WebhookId      string           `json:"webhook_id" example:"9b2e4c6a-8d1f-4a3b-9c5e-7f0a2b4c6d8e"`
+	Event          string           `json:"event" example:"item.completed"`
+	Status         string           `json:"status" example:"pending"`
+	Attempts       int              `json:"attempts" example:"2"`
+	NextAttemptAt  *time.Time       `json:"next_attempt_at,omitempty"`
+	LastStatusCode int              `json:"last_status_code" example:"502"`
+	LastError      string           `json:"last_error,omitempty" example:"Webhook was rejected with status 502"`
+	CreatedAt      time.Time        `json:"created_at"`
+	DeliveredAt    *time.Time       `json:"delivered_at,omitempty"`
+	Payload        json.RawMessage  `json:"payload,omitempty" swaggertype:"object"`
+	Log            []WebhookAttempt `json:"log,omitempty"`
+}
+
+// A delivery claimed to be sent, with the URL and the secret of its webhook
+type WebhookJob struct {
+	Delivery WebhookDelivery
+	Url      string
+	Secret   string
+}
+
+// Change of an item pushed to the streams of the users who can see it, the item is its state after the change
+type ItemEvent struct {
+	Id        int64     `json:"id" example:"42"`
+	Event     string    `json:"event" example:"item.completed"`
+	ItemId    string    `json:"item_id,omitempty" example:"3a35452e-957c-4588-8d40-c88f370067d2"`
+	ActorId   string    `json:"actor_id,omitempty" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	CreatedAt time.Time `json:"created_at"`
+	Item      *TodoItem `json:"item,omitempty"`
+}
+
+// Notification of a logged event sent to every instance, the streams of the recipients and of the members of
+// the workspace read the log
+type ItemEventNotice struct {
+	Id          int64    `json:"id"`
+	Recipients  []string `json:"recipients"`
+	WorkspaceId string   `json:"workspace_id,omitempty"`
+}
+
+// Fields of an item changed by a client while it was offline, a field that is left out is unchanged. Labels
+// replace the labels of the item, an empty list removes them all
+type SyncFieldsDto struct {
+	Name        *string    `json:"name,omitempty" validate:"omitempty,min=3" example:"Todo list 1"`
+	Description *string    `json:"description,omitempty" example:"This is description for the todo list item."`
+	DueDate     *time.Time `json:"due_date,omitempty" example:"2023-05-22T09:38:24.405027Z"`
+	Priority    *string    `json:"priority,omitempty" validate:"omitempty,oneof=HIGH LOW MEDIUM" example:"HIGH"`
+	Status      *string    `json:"status,omitempty" example:"done"`
+	Labels      []string   `json:"labels,omitempty" validate:"max=20,dive,min=1,max=50,excludesall=0x2C" example:"finance"`
+}
+
+// A change a client made to an item, base_version is the version of the item the change was made to and
+// changed_at when it was made
+type SyncChangeDto struct {
+	Id          string         `json:"id" validate:"required,max=100" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
+	Op          string         `json:"op" validate:"required,oneof=create update delete" example:"update"`
+	BaseVersion int            `json:"base_version,omitempty" validate:"min=0" example:"3"`
+	ChangedAt   time.Time      `json:"changed_at"`
+	Fields      *SyncFieldsDto `json:"fields,omitempty"`
+}
+
+type SyncRequestDto struct {
+	Token    string          `json:"token,omitempty" example:"1042"`
+	Strategy string          `json:"strategy,omitempty" validate:"omitempty,oneof=report last_writer_wins" example:"last_writer_wins"`
+	Changes  []SyncChangeDto `json:"changes" validate:"max=500,dive"`
+}
+
+// A field both the client and the server changed, resolved to the value of one of them
+type SyncConflictDto struct {
+	Field      string      `json:"field" example:"due_date"`
+	Client     interface{} `json:"client" swaggertype:"string" example:"2023-05-23T09:00:00Z"`
+	Server     interface{} `json:"server" swaggertype:"string" example:"2023-05-24T09:00:00Z"`
+	Resolution string      `json:"resolution" example:"server"`
+}
+
+type SyncResultDto struct {
+	Id        string            `json:"id" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
+	Op        string            `json:"op" example:"update"`
+	Status    string            `json:"status" example:"conflict"`
+	Item      *TodoItem         `json:"item,omitempty"`
+	Conflicts []SyncConflictDto `json:"conflicts,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// An item changed on the server, with the number of the change in the change sequence
+type ItemChange struct {
+	ChangeSeq int64    `json:"change_seq" example:"1043"`
+	Item      TodoItem `json:"item"`
+}
+
+// An item deleted on the server, purged when it was permanently deleted rather than moved to trash
+type ItemTombstone struct {
+	Id        string    `json:"id" example:"3604fa26-5ee8-428f-a6dd-c742455e8148"`
+	ChangeSeq int64     `json:"change_seq" example:"1044"`
+	DeletedAt time.Time `json:"deleted_at"`
+	Purged    bool      `json:"purged" example:"false"`
+}
+
+// Changes after the token of the request, in the order of the change sequence. The token of the response is
+// sent with the next sync, with has_more the client syncs again right away
+type SyncResponseDto struct {
+	Token      string          `json:"token" example:"1044"`
+	HasMore    bool            `json:"has_more" example:"false"`
+	Results    []SyncResultDto `json:"results"`
+	Changes    []ItemChange    `json:"changes"`
+	Tombstones []ItemTombstone `json:"tombstones"`
+}
+
+// A registered user as other users see them, without the password
+type User struct {
+	Id    string `json:"id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	Email string `json:"email" example:"johndoe@gmail.com"`
+}
+
+type GraphQLRequestDto struct {
+	Query         string                 `json:"query" validate:"required" example:"{ items(first: 5) { nodes { id name owner { email } } } }"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type GraphQLErrorDto struct {
+	Message string        `json:"message" example:"Item does not belong to the current user"`
+	Path    []interface{} `json:"path,omitempty" swaggertype:"array,string" example:"item"`
+}
+
+// Result of a GraphQL query, the fields that failed are null in data and have an error
+type GraphQLResponseDto struct {
+	Data   interface{}       `json:"data,omitempty" swaggertype:"object"`
+	Errors []GraphQLErrorDto `json:"errors,omitempty"`
+}
@@ -2,18 +2,26 @@ package main
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
+	_ "time/tzdata"
 
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/lib/pq"
 	echoSwagger "github.com/swaggo/echo-swagger"
 
 	"todo-project/api"
 	"todo-project/authentication"
+	"todo-project/constants"
 	"todo-project/database"
 	_ "todo-project/docs"
+	"todo-project/notification"
+	"todo-project/storage"
 )
 
 func controller(db *sql.DB, e *echo.Echo) {
@@ -33,12 +41,42 @@ func controller(db *sql.DB, e *echo.Echo) {
 		DB: db,
 	}
 
+	transitions, err := api.ParseStatusTransitions(os.Getenv("STATUS_TRANSITIONS"))
+	if err != nil {
+		log.Fatalf(constants.INVALID_STATUS_TRANSITIONS, err)
+	}
+
+	requireIfMatch, _ := strconv.ParseBool(os.Getenv("REQUIRE_IF_MATCH"))
+
+	attachmentLimits, err := api.ParseAttachmentLimits(os.Getenv("MAX_ATTACHMENT_SIZE"), os.Getenv("ALLOWED_ATTACHMENT_TYPES"), os.Getenv("ATTACHMENT_QUOTA"))
+	if err != nil {
+		log.Fatalf(constants.INVALID_ATTACHMENT_LIMITS, err)
+	}
+
 	apiService := api.ApiService{
-		R: apiRepo,
+		R:              apiRepo,
+		Transitions:    transitions,
+		RequireIfMatch: requireIfMatch,
+		Blobs:          blobStore(),
+		Attachments:    attachmentLimits,
+		Notifier:       notifier(),
+		Webhooks:       webhookSettings(),
+		Events:         eventHub(),
+	}
+
+	if apiService.Events != nil {
+		listenItemEvents(apiService.Events)
 	}
 
 	e.POST("/register", authService.UserRegister)
 	e.POST("/login", authService.UserLogin)
+	e.GET("/calendar/:token", apiService.GetCalendarFeed)
+
+	// Shared by the item endpoints and the GraphQL endpoint over the same items
+	itemAuth := echojwt.WithConfig(echojwt.Config{
+		SigningMethod: "HS512",
+		SigningKey:    []byte(jwtSecretKey),
+	})
 
 	g := e.Group("/item")
 
@@ -46,20 +84,257 @@ func controller(db *sql.DB, e *echo.Echo) {
 		Format: `[${time_rfc3339}] ${status} ${method} ${host}${path} ${latency_human}` + "\n",
 	}))
 
-	g.Use(echojwt.WithConfig(echojwt.Config{
-		SigningMethod: "HS512",
-		SigningKey:    []byte(jwtSecretKey),
-	}))
+	g.Use(itemAuth)
+
+	g.Use(apiService.WorkspaceScope)
 
 	g.GET("/main", func(c echo.Context) error {
 		return c.String(http.StatusOK, "Welcome to main page")
 	})
+	g.GET("/board", apiService.GetBoard)
+	g.GET("/export", apiService.ExportItems)
+	g.POST("/import", apiService.ImportItems)
+	g.POST("/import/:source", apiService.ImportAppItems)
+	g.POST("/calendar", apiService.CreateCalendarFeed)
+	g.DELETE("/calendar", apiService.DeleteCalendarFeed)
+	g.GET("/filters", apiService.GetSavedFilters)
+	g.POST("/filters", apiService.CreateSavedFilter)
+	g.PUT("/filters/:id", apiService.UpdateSavedFilterById)
+	g.DELETE("/filters/:id", apiService.DeleteSavedFilterById)
+	g.GET("/filters/:id/items", apiService.GetFilteredItems)
 	g.GET("/:id", apiService.FindById)
+	g.PATCH("/:id", apiService.PatchItemById)
+	g.GET("/:id/history", apiService.GetItemHistory)
+	g.POST("/:id/history/:revision/revert", apiService.RevertItemById)
+	g.GET("/:id/comments", apiService.GetComments)
+	g.POST("/:id/comments", apiService.CreateComment)
+	g.PATCH("/:id/comments/:commentId", apiService.UpdateCommentById)
+	g.DELETE("/:id/comments/:commentId", apiService.DeleteCommentById)
+	g.GET("/:id/attachments", apiService.GetAttachments)
+	g.POST("/:id/attachments", apiService.UploadAttachment)
+	g.GET("/:id/attachments/:attachmentId", apiService.DownloadAttachment)
+	g.DELETE("/:id/attachments/:attachmentId", apiService.DeleteAttachmentById)
+	g.GET("/:id/shares", apiService.GetItemShares)
+	g.POST("/:id/shares", apiService.ShareItemById)
+	g.DELETE("/:id/shares/:shareId", apiService.RevokeShareById)
+	g.PUT("/:id/assignee", apiService.AssignItemById)
+	g.DELETE("/:id/assignee", apiService.UnassignItemById)
+	g.POST("/:id/watchers", apiService.WatchItemById)
+	g.DELETE("/:id/watchers", apiService.UnwatchItemById)
+	g.GET("/:id/dependencies", apiService.GetItemDependencies)
+	g.POST("/:id/dependencies", apiService.AddItemDependency)
+	g.DELETE("/:id/dependencies/:blockerId", apiService.RemoveItemDependency)
+	g.POST("/:id/move", apiService.MoveItemById)
+	g.GET("/shared", apiService.GetSharedItems)
+	g.GET("/invitations", apiService.GetInvitations)
+	g.POST("/invitations/:shareId/accept", apiService.AcceptInvitation)
+	g.POST("/invitations/:shareId/decline", apiService.DeclineInvitation)
 	g.POST("/list", apiService.GetAllItems)
 	g.POST("/create", apiService.CreateTodoItem)
+	g.POST("/quick", apiService.QuickAddItem)
+	g.POST("/bulk", apiService.BulkItems)
 	g.PUT("/update/:id", apiService.UpdateItemById)
 	g.DELETE("/delete/:id", apiService.DeleteById)
 	g.PATCH("/complete/:id", apiService.UpdateStatustoCompleted)
+	g.GET("/trash", apiService.GetTrashItems)
+	g.DELETE("/trash", apiService.EmptyTrash)
+	g.PATCH("/restore/:id", apiService.RestoreById)
+	g.DELETE("/purge/:id", apiService.PurgeById)
+	g.PATCH("/status/:id", apiService.ChangeStatusById)
+	g.PATCH("/reopen/:id", apiService.ReopenById)
+
+	gq := e.Group("/graphql")
+
+	gq.Use(itemAuth)
+
+	gq.Use(apiService.WorkspaceScope)
+
+	gq.POST("", apiService.GraphQL)
+
+	t := e.Group("/templates")
+
+	t.Use(echojwt.WithConfig(echojwt.Config{
+		SigningMethod: "HS512",
+		SigningKey:    []byte(jwtSecretKey),
+	}))
+
+	t.Use(apiService.WorkspaceScope)
+
+	t.GET("", apiService.GetTemplates)
+	t.POST("", apiService.CreateTemplate)
+	t.POST("/from-items", apiService.CreateTemplateFromItems)
+	t.GET("/:id", apiService.GetTemplateById)
+	t.DELETE("/:id", apiService.DeleteTemplateById)
+	t.POST("/:id/instantiate", apiService.InstantiateTemplate)
+
+	e.Any("/.well-known/caldav", apiService.CalDAVWellKnown)
+
+	d := e.Group("/caldav")
+
+	d.Use(middleware.BasicAuthWithConfig(middleware.BasicAuthConfig{
+		Validator: authService.BasicAuthValidator,
+		Realm:     "Todo",
+	}))
+
+	d.Match(api.CalDAVHomeMethods, "", apiService.CalDAVHome)
+	d.Match(api.CalDAVHomeMethods, "/", apiService.CalDAVHome)
+	d.Match(api.CalDAVCalendarMethods, "/:calendar", apiService.CalDAVCalendar)
+	d.Match(api.CalDAVCalendarMethods, "/:calendar/", apiService.CalDAVCalendar)
+	d.Match(api.CalDAVObjectMethods, "/:calendar/:name", apiService.CalDAVObject)
+
+	h := e.Group("/webhooks")
+
+	h.Use(echojwt.WithConfig(echojwt.Config{
+		SigningMethod: "HS512",
+		SigningKey:    []byte(jwtSecretKey),
+	}))
+
+	h.Use(apiService.WorkspaceScope)
+
+	h.GET("", apiService.GetWebhooks)
+	h.POST("", apiService.CreateWebhook)
+	h.GET("/dead-letters", apiService.GetDeadWebhookDeliveries)
+	h.DELETE("/:id", apiService.DeleteWebhookById)
+	h.POST("/:id/test", apiService.SendTestWebhook)
+	h.GET("/:id/deliveries", apiService.GetWebhookDeliveries)
+	h.GET("/:id/deliveries/:deliveryId", apiService.GetWebhookDeliveryById)
+	h.POST("/:id/deliveries/:deliveryId/redeliver", apiService.RedeliverWebhookById)
+
+	sy := e.Group("/sync")
+
+	sy.Use(echojwt.WithConfig(echojwt.Config{
+		SigningMethod: "HS512",
+		SigningKey:    []byte(jwtSecretKey),
+	}))
+
+	sy.Use(apiService.WorkspaceScope)
+
+	sy.POST("", apiService.SyncItems)
+
+	ev := e.Group("/events")
+
+	ev.Use(echojwt.WithConfig(echojwt.Config{
+		SigningMethod: "HS512",
+		SigningKey:    []byte(jwtSecretKey),
+		TokenLookup:   "header:Authorization:Bearer ,query:access_token",
+	}))
+
+	ev.GET("", apiService.StreamItemEvents)
+	ev.GET("/ws", apiService.StreamItemEventsWebSocket)
+
+	w := e.Group("/workspaces")
+
+	w.Use(echojwt.WithConfig(echojwt.Config{
+		SigningMethod: "HS512",
+		SigningKey:    []byte(jwtSecretKey),
+	}))
+
+	w.POST("", apiService.CreateWorkspace)
+	w.GET("", apiService.GetWorkspaces)
+	w.GET("/invitations", apiService.GetWorkspaceInvitations)
+	w.POST("/invitations/:memberId/accept", apiService.AcceptWorkspaceInvitation)
+	w.POST("/invitations/:memberId/decline", apiService.DeclineWorkspaceInvitation)
+	w.POST("/:id/token", apiService.CreateWorkspaceToken)
+	w.GET("/:id/members", apiService.GetWorkspaceMembers)
+	w.POST("/:id/members", apiService.InviteWorkspaceMember)
+	w.DELETE("/:id/members/:userId", apiService.RemoveWorkspaceMember)
+}
+
+// Number of days a deleted item is kept in trash before it is purged
+func trashRetentionDays() int {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		return 30
+	}
+
+	return days
+}
+
+// Blob store holding the attachments, either a local directory or an S3 compatible bucket
+func blobStore() storage.BlobStore {
+	if os.Getenv("ATTACHMENT_STORAGE") == "s3" {
+		return storage.S3Store{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		}
+	}
+
+	root := os.Getenv("ATTACHMENT_DIR")
+	if root == "" {
+		root = "./attachments"
+	}
+
+	return storage.LocalStore{Root: root}
+}
+
+// Notifier of assignees and watchers, notifications are logged unless they are posted to a URL or disabled
+func notifier() notification.Notifier {
+	switch os.Getenv("NOTIFIER") {
+	case "none":
+		return nil
+	case "http":
+		return notification.HTTPNotifier{URL: os.Getenv("NOTIFIER_URL")}
+	}
+
+	return notification.LogNotifier{}
+}
+
+// Settings of the webhook deliveries, nil when webhooks are disabled so no events are queued
+func webhookSettings() *api.WebhookSettings {
+	if enabled, _ := strconv.ParseBool(os.Getenv("WEBHOOKS")); !enabled {
+		return nil
+	}
+
+	settings, err := api.ParseWebhookSettings(os.Getenv("WEBHOOK_MAX_ATTEMPTS"), os.Getenv("WEBHOOK_BACKOFF"), os.Getenv("WEBHOOK_ALLOWED_NETWORKS"))
+	if err != nil {
+		log.Fatalf(constants.INVALID_WEBHOOK_SETTINGS, err)
+	}
+
+	return &settings
+}
+
+// Streams of the changes of items, nil when real-time updates are disabled so no events are logged
+func eventHub() *api.EventHub {
+	if enabled, _ := strconv.ParseBool(os.Getenv("EVENTS")); !enabled {
+		return nil
+	}
+
+	return api.NewEventHub()
+}
+
+// Hours an event is kept in the log the streams resume from
+func eventRetentionHours() int {
+	hours, err := strconv.Atoi(os.Getenv("EVENT_RETENTION_HOURS"))
+	if err != nil || hours <= 0 {
+		return 24
+	}
+
+	return hours
+}
+
+// Listens to the events logged by every instance, so the streams of this instance get the changes made on the others
+func listenItemEvents(hub *api.EventHub) {
+	listener := pq.NewListener(database.ConnectionString(), 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf(constants.LISTEN_ITEM_EVENTS_ERROR, err)
+		}
+	})
+
+	if err := listener.Listen(api.ItemEventsChannel); err != nil {
+		log.Fatalf(constants.LISTEN_ITEM_EVENTS_ERROR, err)
+	}
+
+	go hub.Listen(listener.Notify)
+
+	// A ping notices a dropped connection while no notifications come
+	go func() {
+		for range time.Tick(90 * time.Second) {
+			listener.Ping()
+		}
+	}()
 }
 
 // @title Todo API
@@ -70,9 +345,31 @@ func controller(db *sql.DB, e *echo.Echo) {
 func main() {
 	e := echo.New();
 	db := database.ConnectToDb()
+
+	if len(os.Args) > 1 {
+		code := runCommand(api.ApiRepository{DB: db}, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+		db.Close()
+		os.Exit(code)
+	}
 	
 	controller(db,e)
 	defer db.Close()
 
+	apiRepo := api.ApiRepository{
+		DB: db,
+	}
+	retentionJob := apiRepo.StartTrashRetentionJob(trashRetentionDays(), time.Hour, blobStore())
+	defer retentionJob.Stop()
+
+	if enabled, _ := strconv.ParseBool(os.Getenv("EVENTS")); enabled {
+		eventJob := apiRepo.StartItemEventRetentionJob(time.Duration(eventRetentionHours())*time.Hour, time.Hour)
+		defer eventJob.Stop()
+	}
+
+	if settings := webhookSettings(); settings != nil {
+		webhookJob := apiRepo.StartWebhookDeliveryJob(*settings, 10*time.Second)
+		defer webhookJob.Stop()
+	}
+
 	e.Logger.Fatal(e.Start(":5000"))
 }
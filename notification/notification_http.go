@@ -0,0 +1,44 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"todo-project/constants"
+)
+
+// Posts each notification as JSON to a URL, e.g. a mail or chat relay
+type HTTPNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (h HTTPNotifier) client() *http.Client {
+	if h.Client != nil {
+		return h.Client
+	}
+
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+func (h HTTPNotifier) Notify(n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.client().Post(h.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf(constants.NOTIFICATION_REJECTED, res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package notification
+
+import (
+	"log"
+
+	"todo-project/entity"
+)
+
+const (
+	EventAssigned   = "item.assigned"
+	EventUnassigned = "item.unassigned"
+)
+
+// A change of an item sent to one of the users following it
+type Notification struct {
+	Event     string          `json:"event" example:"item.assigned"`
+	Recipient string          `json:"recipient" example:"8c3e5f7a-9b1d-4e2f-a4c6-d8e0f2a4b6c8"`
+	ActorId   string          `json:"actor_id" example:"ed6caeda-1fa9-442e-a41d-dd2b135cea67"`
+	Item      entity.TodoItem `json:"item"`
+}
+
+// Delivers notifications, implementations decide how the recipient is reached
+type Notifier interface {
+	//Deliver a notification to its recipient
+	Notify(n Notification) error
+}
+
+// Adapts a function to the Notifier interface
+type NotifierFunc func(n Notification) error
+
+func (f NotifierFunc) Notify(n Notification) error {
+	return f(n)
+}
+
+// Writes the notifications to the log
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(n Notification) error {
+	log.Printf("notify %s of %s on item %s by %s", n.Recipient, n.Event, n.Item.Id, n.ActorId)
+	return nil
+}
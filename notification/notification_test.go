@@ -0,0 +1,59 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"todo-project/entity"
+)
+
+func TestNotifiers(t *testing.T) {
+	n := Notification{
+		Event:     EventAssigned,
+		Recipient: "8c3e5f7a-9b1d-4e2f-a4c6-d8e0f2a4b6c8",
+		ActorId:   "ed6caeda-1fa9-442e-a41d-dd2b135cea67",
+		Item:      entity.TodoItem{Id: "3a35452e-957c-4588-8d40-c88f370067d2"},
+	}
+
+	t.Run("Test Notifier func", func(t *testing.T) {
+		var got []Notification
+		notifier := NotifierFunc(func(n Notification) error {
+			got = append(got, n)
+			return nil
+		})
+
+		assert.NoError(t, notifier.Notify(n))
+		assert.Equal(t, []Notification{n}, got)
+	})
+
+	t.Run("Test HTTP notifier", func(t *testing.T) {
+		var got Notification
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		err := HTTPNotifier{URL: server.URL}.Notify(n)
+
+		assert.NoError(t, err)
+		assert.Equal(t, n.Recipient, got.Recipient)
+		assert.Equal(t, n.Item.Id, got.Item.Id)
+	})
+
+	t.Run("Test HTTP notifier rejected", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		err := HTTPNotifier{URL: server.URL}.Notify(n)
+
+		assert.EqualError(t, err, "Notification was rejected with status 502")
+	})
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"errors"
+	"io"
+
+	"todo-project/constants"
+)
+
+var ErrBlobNotFound = errors.New(constants.BLOB_NOT_FOUND)
+var ErrInvalidBlobKey = errors.New(constants.INVALID_BLOB_KEY)
+
+// Stores the content of attachments, keys are slash separated paths like items/<item id>/<attachment id>
+type BlobStore interface {
+	//Store a blob of the given size, replacing any blob with the same key
+	Put(key string, body io.Reader, size int64, contentType string) error
+
+	//Open a blob for reading, seeking allows serving byte ranges
+	Open(key string) (io.ReadSeekCloser, error)
+
+	//Delete a blob, deleting a missing blob is not an error
+	Delete(key string) error
+}
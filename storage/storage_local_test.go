@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStore(t *testing.T) {
+	store := LocalStore{Root: t.TempDir()}
+	key := "items/3a35452e-957c-4588-8d40-c88f370067d2/9b2f1c8e-4d7a-4f43-b0b5-2d6a7c1e5f10"
+
+	t.Run("Test Put and open", func(t *testing.T) {
+		err := store.Put(key, strings.NewReader("hello world"), 11, "text/plain")
+		assert.NoError(t, err)
+
+		blob, err := store.Open(key)
+		assert.NoError(t, err)
+		defer blob.Close()
+
+		_, err = blob.Seek(6, io.SeekStart)
+		assert.NoError(t, err)
+
+		content, err := io.ReadAll(blob)
+		assert.NoError(t, err)
+		assert.Equal(t, "world", string(content))
+	})
+
+	t.Run("Test Short upload", func(t *testing.T) {
+		err := store.Put("items/short", strings.NewReader("hello"), 11, "text/plain")
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+		_, err = store.Open("items/short")
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+	})
+
+	t.Run("Test Delete", func(t *testing.T) {
+		assert.NoError(t, store.Delete(key))
+		assert.NoError(t, store.Delete(key))
+
+		_, err := store.Open(key)
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+	})
+
+	t.Run("Test Invalid keys", func(t *testing.T) {
+		for _, key := range []string{"", "/etc/passwd", "items/../../secret", "items//a", `items\a`} {
+			_, err := store.Open(key)
+			assert.ErrorIs(t, err, ErrInvalidBlobKey, key)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// In memory stand-in for an S3 compatible server, supports the requests made by S3Store
+type objectServer struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *objectServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") || r.Header.Get("X-Amz-Date") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	object, ok := s.objects[r.URL.Path]
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		s.objects[r.URL.Path] = body
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodHead, http.MethodGet:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		start := 0
+		if r.Header.Get("Range") != "" {
+			start, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.Header.Get("Range"), "bytes="), "-"))
+			object = object[start:]
+			w.WriteHeader(http.StatusPartialContent)
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(object)))
+		w.Write(object)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	server := &objectServer{objects: map[string][]byte{}}
+	stub := httptest.NewServer(server)
+	defer stub.Close()
+
+	store := S3Store{
+		Endpoint:  stub.URL,
+		Bucket:    "attachments",
+		Region:    "us-east-1",
+		AccessKey: "access",
+		SecretKey: "secret",
+	}
+
+	key := "items/3a35452e-957c-4588-8d40-c88f370067d2/9b2f1c8e-4d7a-4f43-b0b5-2d6a7c1e5f10"
+
+	t.Run("Test Put", func(t *testing.T) {
+		err := store.Put(key, bytes.NewReader([]byte("hello world")), 11, "text/plain")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "hello world", string(server.objects["/attachments/"+key]))
+	})
+
+	t.Run("Test Open with range", func(t *testing.T) {
+		blob, err := store.Open(key)
+		assert.NoError(t, err)
+		defer blob.Close()
+
+		size, err := blob.Seek(0, io.SeekEnd)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), size)
+
+		_, err = blob.Seek(6, io.SeekStart)
+		assert.NoError(t, err)
+
+		content, err := io.ReadAll(blob)
+		assert.NoError(t, err)
+		assert.Equal(t, "world", string(content))
+	})
+
+	t.Run("Test Open missing blob", func(t *testing.T) {
+		_, err := store.Open("items/missing")
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+	})
+
+	t.Run("Test Delete", func(t *testing.T) {
+		assert.NoError(t, store.Delete(key))
+		assert.NoError(t, store.Delete(key))
+		assert.Empty(t, server.objects)
+	})
+
+	t.Run("Test Rejected credentials", func(t *testing.T) {
+		rejected := store
+		rejected.AccessKey = "other"
+
+		err := rejected.Put(key, bytes.NewReader([]byte("hello")), 5, "text/plain")
+		assert.ErrorContains(t, err, "403")
+	})
+}
+
+func TestSignature(t *testing.T) {
+	t.Run("Test Signing key", func(t *testing.T) {
+		// Example from the AWS Signature Version 4 documentation
+		key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+
+		assert.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+	})
+
+	t.Run("Test Authorization header", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost:9000/attachments/items/a", nil)
+		now := time.Date(2023, 10, 15, 9, 38, 24, 0, time.UTC)
+
+		signRequest(req, "access", "secret", "us-east-1", "s3", now)
+
+		assert.Equal(t, "20231015T093824Z", req.Header.Get("X-Amz-Date"))
+		assert.Regexp(t, `^AWS4-HMAC-SHA256 Credential=access/20231015/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`, req.Header.Get("Authorization"))
+	})
+}